@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// configFlag names the flag that points at the config file, and
+// profileFlag the flag that picks a profile inside it.
+const (
+	configFlag  = "config"
+	profileFlag = "profile"
+)
+
+// defaultConfigPath is where the config file is looked for when -config is
+// not given: ~/.config/ytdl-go/config.yaml on Linux.
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "ytdl-go", "config.yaml")
+}
+
+// flagFromArgs finds the value of the flag name in args ahead of flag
+// parsing, since the config file must be applied before the command line
+// overrides it. It walks args the way fs.Parse will, stopping at the first
+// non-flag.
+func flagFromArgs(fs *flag.FlagSet, args []string, name string) (value string, found bool) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || len(arg) < 2 || arg[0] != '-' {
+			break
+		}
+		argName, argValue, hasValue := strings.Cut(strings.TrimPrefix(arg[1:], "-"), "=")
+		if argName == name {
+			if hasValue {
+				return argValue, true
+			}
+			if i+1 < len(args) {
+				return args[i+1], true
+			}
+			break
+		}
+		if hasValue {
+			continue
+		}
+		if f := fs.Lookup(argName); f != nil && !isBoolFlag(f) {
+			i++ // skip the flag's value
+		}
+	}
+	return "", false
+}
+
+// configPathFromArgs returns the -config path in args, or the default path
+// when there is none.
+func configPathFromArgs(fs *flag.FlagSet, args []string) (path string, explicit bool) {
+	if path, ok := flagFromArgs(fs, args, configFlag); ok {
+		return path, true
+	}
+	return defaultConfigPath(), false
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// applyConfigFile sets each key in the config file at path as the value of
+// the flag of the same name, so the file replaces built-in defaults and the
+// command line, parsed afterwards, overrides both. The keys of profile, when
+// set, are applied after the file's top-level keys. A missing file is only an
+// error when it was named with -config.
+func applyConfigFile(fs *flag.FlagSet, path string, explicit bool, profile string) error {
+	if path == "" {
+		return missingProfile(profile)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		if !explicit && errors.Is(err, os.ErrNotExist) {
+			return missingProfile(profile)
+		}
+		return fmt.Errorf("reading config file: %w", err)
+	}
+	defer file.Close()
+
+	config, err := parseConfig(file)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	entries := config.entries
+	if profile != "" {
+		profileEntries, ok := config.profiles[profile]
+		if !ok {
+			return fmt.Errorf("%s: unknown profile %q", path, profile)
+		}
+		entries = append(entries, profileEntries...)
+	}
+	for _, entry := range entries {
+		if entry.key == configFlag || entry.key == profileFlag || fs.Lookup(entry.key) == nil {
+			return fmt.Errorf("%s:%d: unknown option %q", path, entry.line, entry.key)
+		}
+		if err := fs.Set(entry.key, entry.value); err != nil {
+			return fmt.Errorf("%s:%d: invalid value %q for %s: %w", path, entry.line, entry.value, entry.key, err)
+		}
+	}
+	return nil
+}
+
+func missingProfile(profile string) error {
+	if profile == "" {
+		return nil
+	}
+	return fmt.Errorf("profile %q requested but no config file was found", profile)
+}
+
+type configEntry struct {
+	line  int
+	key   string
+	value string
+}
+
+// configFile is a parsed config file: top-level entries plus the entries of
+// each named profile.
+type configFile struct {
+	entries  []configEntry
+	profiles map[string][]configEntry
+}
+
+// parseConfig reads the subset of YAML the config file uses: flat
+// "key: value" lines, and a top-level "profiles:" block holding one indented
+// block of "key: value" lines per profile name. Values may be quoted;
+// unquoted values end at a " #" comment.
+func parseConfig(r io.Reader) (configFile, error) {
+	config := configFile{profiles: map[string][]configEntry{}}
+	inProfiles := false
+	profile, profileIndent := "", -1
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		raw := scanner.Text()
+		line := strings.TrimSpace(raw)
+		if line == "" || strings.HasPrefix(line, "#") || line == "---" {
+			continue
+		}
+		indent := len(raw) - len(strings.TrimLeft(raw, " \t"))
+		key, value, ok := strings.Cut(line, ":")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return configFile{}, fmt.Errorf("line %d: expected \"key: value\"", lineNo)
+		}
+		value, err := parseConfigValue(strings.TrimSpace(value))
+		if err != nil {
+			return configFile{}, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		entry := configEntry{line: lineNo, key: key, value: value}
+
+		switch {
+		case indent == 0:
+			inProfiles = key == "profiles" && value == ""
+			profile, profileIndent = "", -1
+			if !inProfiles {
+				config.entries = append(config.entries, entry)
+			}
+		case !inProfiles:
+			return configFile{}, fmt.Errorf("line %d: unexpected indentation", lineNo)
+		case profileIndent < 0 || indent <= profileIndent:
+			if value != "" || (profileIndent >= 0 && indent != profileIndent) {
+				return configFile{}, fmt.Errorf("line %d: expected a profile name", lineNo)
+			}
+			if _, dup := config.profiles[key]; dup {
+				return configFile{}, fmt.Errorf("line %d: duplicate profile %q", lineNo, key)
+			}
+			profile, profileIndent = key, indent
+			config.profiles[profile] = nil
+		default:
+			config.profiles[profile] = append(config.profiles[profile], entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return configFile{}, fmt.Errorf("reading config file: %w", err)
+	}
+	return config, nil
+}
+
+func parseConfigValue(raw string) (string, error) {
+	switch {
+	case strings.HasPrefix(raw, `"`):
+		end := strings.LastIndex(raw, `"`)
+		if end == 0 || !isConfigComment(raw[end+1:]) {
+			return "", fmt.Errorf("unterminated quoted value %s", raw)
+		}
+		return strconv.Unquote(raw[:end+1])
+	case strings.HasPrefix(raw, "'"):
+		end := strings.LastIndex(raw, "'")
+		if end == 0 || !isConfigComment(raw[end+1:]) {
+			return "", fmt.Errorf("unterminated quoted value %s", raw)
+		}
+		return strings.ReplaceAll(raw[1:end], "''", "'"), nil
+	}
+	if i := strings.Index(raw, " #"); i >= 0 {
+		raw = raw[:i]
+	}
+	return strings.TrimSpace(raw), nil
+}
+
+// isConfigComment reports whether rest, the text after a closing quote, is
+// empty or a comment.
+func isConfigComment(rest string) bool {
+	rest = strings.TrimSpace(rest)
+	return rest == "" || strings.HasPrefix(rest, "#")
+}
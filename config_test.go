@@ -0,0 +1,176 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newConfigTestFlags() (*flag.FlagSet, *string, *string, *int, *bool) {
+	fs := flag.NewFlagSet("ytdl-go", flag.ContinueOnError)
+	output := fs.String("o", "{title}.{ext}", "")
+	quality := fs.String("quality", "best", "")
+	concurrency := fs.Int("segment-concurrency", 0, "")
+	audio := fs.Bool("audio", false, "")
+	fs.String(configFlag, "", "")
+	fs.String(profileFlag, "", "")
+	return fs, output, quality, concurrency, audio
+}
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	return path
+}
+
+func TestConfigFileSetsDefaultsAndFlagsOverride(t *testing.T) {
+	path := writeConfig(t, `# ytdl-go defaults
+o: "{artist}/{title}.{ext}"
+quality: 720p   # cap the resolution
+segment-concurrency: 4
+audio: true
+`)
+	fs, output, quality, concurrency, audio := newConfigTestFlags()
+	args := []string{"-config", path, "-quality", "1080p", "https://youtu.be/aaa"}
+
+	configPath, explicit := configPathFromArgs(fs, args)
+	if configPath != path || !explicit {
+		t.Fatalf("configPathFromArgs = %q, %v; want %q, true", configPath, explicit, path)
+	}
+	if err := applyConfigFile(fs, configPath, explicit, ""); err != nil {
+		t.Fatalf("applyConfigFile: %v", err)
+	}
+	urls, err := parseArgs(fs, args)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	if *output != "{artist}/{title}.{ext}" {
+		t.Errorf("-o = %q, want the config value", *output)
+	}
+	if *quality != "1080p" {
+		t.Errorf("-quality = %q, want the command line to override the config", *quality)
+	}
+	if *concurrency != 4 || !*audio {
+		t.Errorf("segment-concurrency = %d, audio = %v; want 4, true from the config", *concurrency, *audio)
+	}
+	if len(urls) != 1 || urls[0] != "https://youtu.be/aaa" {
+		t.Errorf("urls = %q", urls)
+	}
+}
+
+func TestConfigPathFromArgs(t *testing.T) {
+	fs, _, _, _, _ := newConfigTestFlags()
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--config=/tmp/a.yaml"}, "/tmp/a.yaml"},
+		{[]string{"-audio", "-o", "out.mp4", "-config", "/tmp/b.yaml"}, "/tmp/b.yaml"},
+	}
+	for _, tt := range tests {
+		if got, explicit := configPathFromArgs(fs, tt.args); got != tt.want || !explicit {
+			t.Errorf("configPathFromArgs(%q) = %q, %v; want %q, true", tt.args, got, explicit, tt.want)
+		}
+	}
+	// A -config after the first URL is not a flag, as fs.Parse would see it.
+	for _, args := range [][]string{
+		{"https://youtu.be/aaa", "-config", "/tmp/c.yaml"},
+		{"-o", "-config", "https://youtu.be/aaa"},
+	} {
+		if _, explicit := configPathFromArgs(fs, args); explicit {
+			t.Errorf("configPathFromArgs(%q) found a -config flag", args)
+		}
+	}
+}
+
+func TestConfigFileMissingAndInvalid(t *testing.T) {
+	fs, _, _, _, _ := newConfigTestFlags()
+	missing := filepath.Join(t.TempDir(), "missing.yaml")
+	if err := applyConfigFile(fs, missing, false, ""); err != nil {
+		t.Fatalf("expected a missing default config to be ignored, got %v", err)
+	}
+	if err := applyConfigFile(fs, missing, true, ""); err == nil {
+		t.Fatal("expected a missing -config file to be an error")
+	}
+
+	for content, want := range map[string]string{
+		"no-such-flag: 1\n":           `unknown option "no-such-flag"`,
+		"segment-concurrency: lots\n": "invalid value",
+		"just a line\n":               "expected",
+		"o: \"unterminated\n":         "unterminated",
+		"  audio: true\n":             "unexpected indentation",
+		"profiles:\n  music: x\n":     "expected a profile name",
+	} {
+		err := applyConfigFile(fs, writeConfig(t, content), true, "")
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("config %q: error %v, want it to mention %q", content, err, want)
+		}
+	}
+}
+
+const profileConfig = `quality: 1080p
+o: "{title}.{ext}"
+profiles:
+  music:
+    audio: true
+    o: "{artist}/{title}.{ext}"   # per-artist folders
+  video:
+    quality: 2160p
+segment-concurrency: 4
+`
+
+func TestConfigProfileOverridesBaseConfig(t *testing.T) {
+	path := writeConfig(t, profileConfig)
+	fs, output, quality, concurrency, audio := newConfigTestFlags()
+	args := []string{"-config", path, "--profile=music", "https://youtu.be/aaa"}
+
+	profile, _ := flagFromArgs(fs, args, profileFlag)
+	if profile != "music" {
+		t.Fatalf("flagFromArgs(profile) = %q, want music", profile)
+	}
+	configPath, explicit := configPathFromArgs(fs, args)
+	if err := applyConfigFile(fs, configPath, explicit, profile); err != nil {
+		t.Fatalf("applyConfigFile: %v", err)
+	}
+	if _, err := parseArgs(fs, args); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	if !*audio || *output != "{artist}/{title}.{ext}" {
+		t.Errorf("audio = %v, -o = %q; want the music profile's values", *audio, *output)
+	}
+	if *quality != "1080p" {
+		t.Errorf("-quality = %q, want the base value the profile leaves alone", *quality)
+	}
+	if *concurrency != 4 {
+		t.Errorf("segment-concurrency = %d, want the top-level key after the profiles block", *concurrency)
+	}
+}
+
+func TestConfigWithoutProfileIgnoresProfiles(t *testing.T) {
+	path := writeConfig(t, profileConfig)
+	fs, output, quality, _, audio := newConfigTestFlags()
+	if err := applyConfigFile(fs, path, true, ""); err != nil {
+		t.Fatalf("applyConfigFile: %v", err)
+	}
+	if *audio || *output != "{title}.{ext}" || *quality != "1080p" {
+		t.Errorf("audio = %v, -o = %q, -quality = %q; want only top-level values", *audio, *output, *quality)
+	}
+}
+
+func TestConfigUnknownProfile(t *testing.T) {
+	fs, _, _, _, _ := newConfigTestFlags()
+	if err := applyConfigFile(fs, writeConfig(t, profileConfig), true, "podcast"); err == nil || !strings.Contains(err.Error(), `unknown profile "podcast"`) {
+		t.Fatalf("expected an unknown profile error, got %v", err)
+	}
+	missing := filepath.Join(t.TempDir(), "missing.yaml")
+	if err := applyConfigFile(fs, missing, false, "music"); err == nil {
+		t.Fatal("expected -profile without a config file to be an error")
+	}
+}
@@ -53,9 +53,11 @@ export default function ActiveDownloads() {
                                     </span>
                                     <span class={`font-mono ${
                                         task.status === 'error' ? 'text-red-400' : 
+                                        task.status === 'cancelled' ? 'text-slate-400' :
                                         task.done ? 'text-emerald-400' : 'text-accent-primary'
                                     }`}>
                                         {task.status === 'error' ? 'Error' : 
+                                         task.status === 'cancelled' ? 'Cancelled' : 
                                          task.percent !== undefined ? `${task.percent.toFixed(1)}%` : '0.0%'}
                                     </span>
                                 </div>
@@ -28,7 +28,7 @@ const statusTone = (status) => {
       bar: 'bg-green-500',
     };
   }
-  if (status === 'reconnecting') {
+  if (status === 'reconnecting' || status === 'cancelled') {
     return {
       card: 'bg-amber-500/5 border-amber-500/20',
       icon: 'bg-amber-500/10 text-amber-400',
@@ -67,6 +67,7 @@ const statusIconName = (status) => {
   const s = normalizeStatus(status);
   if (s === 'error') return 'alert-circle';
   if (s === 'complete') return 'check-circle-2';
+  if (s === 'cancelled') return 'x';
   if (s === 'reconnecting') return 'loader';
   if (s === 'running') return 'loader';
   if (s === 'queued') return 'loader';
@@ -77,6 +78,7 @@ const statusTitle = (status) => {
   const s = normalizeStatus(status);
   if (s === 'error') return 'Download Failed';
   if (s === 'complete') return 'Download Complete';
+  if (s === 'cancelled') return 'Download Cancelled';
   if (s === 'reconnecting') return 'Reconnecting...';
   if (s === 'running') return 'Downloading...';
   if (s === 'queued') return 'Queued';
@@ -87,6 +89,7 @@ const statusDefaultMessage = (status) => {
   const s = normalizeStatus(status);
   if (s === 'error') return 'An unexpected error occurred.';
   if (s === 'complete') return 'All tasks finished successfully.';
+  if (s === 'cancelled') return 'The download was cancelled.';
   if (s === 'reconnecting') return 'Connection interrupted, attempting to resume...';
   if (s === 'running') return 'Processing download tasks...';
   if (s === 'queued') return 'Waiting for available worker slot...';
@@ -360,7 +363,7 @@ export default function DownloadView(props = {}) {
                 </div>
               </Show>
 
-              <Show when={currentStatus() === 'complete' || currentStatus() === 'error'}>
+              <Show when={currentStatus() === 'complete' || currentStatus() === 'error' || currentStatus() === 'cancelled'}>
                 <div class="flex justify-center pt-2">
                   <button
                     onClick={openLibrary}
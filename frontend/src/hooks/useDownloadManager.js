@@ -3,6 +3,7 @@ import { useAppStore } from '../store/appStore';
 import { downloadStore, setDownloadStore } from '../store/downloadStore';
 import wsService from '../services/websocket';
 import {
+    isTerminalDownloadStatus,
     normalizeDownloadStatus,
 } from '../utils/downloadStatus';
 
@@ -19,6 +20,7 @@ const statusDefaultMessage = (status) => {
         case 'reconnecting': return 'Reconnecting to the progress stream...';
         case 'complete': return 'All downloads in this job are finished.';
         case 'error': return 'Download failed.';
+        case 'cancelled': return 'Download cancelled.';
         default: return '';
     }
 };
@@ -68,7 +70,8 @@ const normalizeStatus = normalizeDownloadStatus;
 
 const notifyJobOutcome = (job) => {
 
-    const title = job.status === 'complete' ? 'Download Complete' : 'Download Failed';
+    const titles = { complete: 'Download Complete', cancelled: 'Download Cancelled' };
+    const title = titles[job.status] || 'Download Failed';
     const body = job.status === 'complete' 
         ? `Successfully downloaded ${job.stats?.succeeded || 0} items.`
         : job.error || job.message || 'An error occurred during download.';
@@ -135,16 +138,16 @@ export function useDownloadManager() {
                         };
                         
                         // Notify on terminal state change
-                        if (prev?.status !== nextStatus && (nextStatus === 'complete' || nextStatus === 'error')) {
+                        if (prev?.status !== nextStatus && isTerminalDownloadStatus(nextStatus)) {
                             notifyJobOutcome(next);
 
-                            // Clear only tasks belonging to this job 300ms after completion for UI transition
+                            // Clear only tasks belonging to this job (pool tasks are keyed by job ID) 300ms after completion for UI transition
                             const completedJobId = data.jobId;
                             setTimeout(() => {
                                 try {
                                     const current = downloadStore.activeDownloads;
                                     const keysToRemove = Object.keys(current).filter(
-                                        (key) => current[key]?.jobId === completedJobId || current[key]?.done
+                                        (key) => key === completedJobId || current[key]?.jobId === completedJobId || current[key]?.done
                                     );
                                     for (const key of keysToRemove) {
                                         setDownloadStore('activeDownloads', key, undefined);
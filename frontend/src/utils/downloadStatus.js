@@ -1,6 +1,6 @@
-const acceptedDownloadStatuses = new Set(['queued', 'running', 'reconnecting', 'complete', 'error']);
+const acceptedDownloadStatuses = new Set(['queued', 'running', 'reconnecting', 'complete', 'error', 'cancelled']);
 const activeDownloadStreamStatuses = new Set(['queued', 'running', 'reconnecting']);
-const terminalDownloadStatuses = new Set(['complete', 'error']);
+const terminalDownloadStatuses = new Set(['complete', 'error', 'cancelled']);
 
 export const normalizeDownloadStatus = (value) => {
   if (typeof value !== 'string') return '';
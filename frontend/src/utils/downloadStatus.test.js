@@ -14,6 +14,7 @@ describe('normalizeDownloadStatus', () => {
         expect(normalizeDownloadStatus('reconnecting')).toBe('reconnecting');
         expect(normalizeDownloadStatus('complete')).toBe('complete');
         expect(normalizeDownloadStatus('error')).toBe('error');
+        expect(normalizeDownloadStatus('cancelled')).toBe('cancelled');
     });
 
     it('maps "done" to "complete"', () => {
@@ -12,6 +12,7 @@ require (
 	github.com/dop251/goja v0.0.0-20260106131823-651366fbe6e3
 	github.com/gorilla/websocket v1.5.3
 	github.com/lvcoi/ytdl-lib/v2 v2.10.5-fork.3
+	github.com/muesli/termenv v0.16.0
 	github.com/u2takey/ffmpeg-go v0.5.0
 	modernc.org/sqlite v1.46.1
 )
@@ -42,7 +43,6 @@ require (
 	github.com/mattn/go-runewidth v0.0.19 // indirect
 	github.com/muesli/ansi v0.0.0-20230316100256-276c6243b2f6 // indirect
 	github.com/muesli/cancelreader v0.2.2 // indirect
-	github.com/muesli/termenv v0.16.0 // indirect
 	github.com/ncruces/go-strftime v1.0.0 // indirect
 	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
 	github.com/rivo/uniseg v0.4.7 // indirect
@@ -7,9 +7,11 @@ import (
 )
 
 type Result struct {
-	URL   string `json:"url"`
-	Error string `json:"error,omitempty"`
-	Err   error  `json:"-"`
+	URL       string `json:"url"`
+	Error     string `json:"error,omitempty"`
+	Category  string `json:"category,omitempty"`
+	Retryable *bool  `json:"retryable,omitempty"`
+	Err       error  `json:"-"`
 }
 
 func Run(ctx context.Context, urls []string, opts downloader.Options, jobs int) ([]Result, int) {
@@ -19,6 +21,12 @@ func Run(ctx context.Context, urls []string, opts downloader.Options, jobs int)
 	if opts.DuplicateSession == nil {
 		opts.DuplicateSession = downloader.NewDuplicateSession()
 	}
+	if opts.AutoNumber == nil {
+		opts.AutoNumber = downloader.NewAutoNumber(opts.AutonumberStart, opts.AutonumberWidth)
+	}
+	// opts.Stats is the caller's run-wide tally; each task records into its
+	// own collector so failures can be attributed per URL before merging.
+	stats := opts.Stats
 
 	type task struct {
 		url string
@@ -45,15 +53,28 @@ func Run(ctx context.Context, urls []string, opts downloader.Options, jobs int)
 					if !ok {
 						return
 					}
+					taskOpts := opts
+					if stats != nil {
+						taskOpts.Stats = downloader.NewRunStats()
+					}
 					var err error
 					if jobs > 1 && sharedManager != nil {
-						err = downloader.ProcessWithManager(ctx, t.url, opts, sharedManager)
+						err = downloader.ProcessWithManager(ctx, t.url, taskOpts, sharedManager)
 					} else {
-						err = downloader.Process(ctx, t.url, opts)
+						err = downloader.Process(ctx, t.url, taskOpts)
+					}
+					// Failures before any item was reported (bad URL, metadata
+					// fetch) still count toward the summary.
+					if err != nil && taskOpts.Stats.Total() == 0 {
+						taskOpts.Stats.RecordFailure()
 					}
+					stats.Merge(taskOpts.Stats)
 					res := Result{URL: t.url, Err: err}
 					if err != nil {
+						retryable := downloader.IsRetryable(err)
 						res.Error = err.Error()
+						res.Category = string(downloader.CategoryOf(err))
+						res.Retryable = &retryable
 					}
 					select {
 					case results <- res:
@@ -0,0 +1,144 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lvcoi/ytdl-go/internal/downloader"
+)
+
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe: %v", err)
+	}
+	orig := os.Stdout
+	os.Stdout = w
+	done := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- string(data)
+	}()
+	fn()
+	os.Stdout = orig
+	w.Close()
+	return <-done
+}
+
+func TestRunEmitsJSONSummary(t *testing.T) {
+	payload := "\x00\x00\x00\x18ftypisom" + strings.Repeat("\x00", 12) + "moov" + strings.Repeat("x", 2048)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "video/mp4")
+		_, _ = io.WriteString(w, payload)
+	}))
+	defer srv.Close()
+
+	opts := downloader.Options{
+		OutputTemplate: "{title}.{ext}",
+		OutputDir:      t.TempDir(),
+		JSON:           true,
+		Quiet:          true,
+		LogLevel:       "error",
+		Stats:          downloader.NewRunStats(),
+	}
+	urls := []string{srv.URL + "/clip.mp4", "not-a-url"}
+
+	var exitCode int
+	out := captureStdout(t, func() {
+		_, exitCode = Run(context.Background(), urls, opts, 1)
+		if err := downloader.EmitJSONSummary(os.Stdout, opts.Stats.Summary()); err != nil {
+			t.Errorf("emit summary: %v", err)
+		}
+	})
+	if exitCode == 0 {
+		t.Fatalf("expected non-zero exit code for the invalid URL")
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		t.Fatalf("expected JSON output, got none")
+	}
+
+	var summary downloader.RunSummary
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &summary); err != nil {
+		t.Fatalf("last line is not JSON: %v\n%s", err, out)
+	}
+	if summary.Type != "summary" {
+		t.Fatalf("expected summary as the last line, got %q", lines[len(lines)-1])
+	}
+	if summary.Total != 2 || summary.OK != 1 || summary.Failed != 1 || summary.Skipped != 0 {
+		t.Fatalf("unexpected counts: %+v\n%s", summary, out)
+	}
+	if summary.Bytes != int64(len(payload)) {
+		t.Fatalf("expected %d bytes, got %d", len(payload), summary.Bytes)
+	}
+	if summary.Duration < 0 {
+		t.Fatalf("expected non-negative duration, got %v", summary.Duration)
+	}
+}
+
+func TestRunAutonumbersAcrossURLs(t *testing.T) {
+	payload := "\x00\x00\x00\x18ftypisom" + strings.Repeat("\x00", 12) + "moov" + strings.Repeat("x", 2048)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "video/mp4")
+		_, _ = io.WriteString(w, payload)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	opts := downloader.Options{
+		OutputTemplate:  "{autonumber}-{title}.{ext}",
+		OutputDir:       dir,
+		Quiet:           true,
+		LogLevel:        "error",
+		AutonumberStart: 8,
+		AutonumberWidth: 3,
+	}
+	urls := []string{srv.URL + "/first.mp4", srv.URL + "/second.mp4", srv.URL + "/third.mp4"}
+
+	if _, exitCode := Run(context.Background(), urls, opts, 1); exitCode != 0 {
+		t.Fatalf("expected success, got exit code %d", exitCode)
+	}
+	for _, name := range []string{"008-first.mp4", "009-second.mp4", "010-third.mp4"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Fatalf("expected %s: %v", name, err)
+		}
+	}
+}
+
+func TestRunResultCarriesCategoryAndRetryable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	opts := downloader.Options{OutputTemplate: "{title}.{ext}", OutputDir: t.TempDir(), Quiet: true, LogLevel: "error"}
+	results, exitCode := Run(context.Background(), []string{srv.URL + "/locked.mp4"}, opts, 1)
+	if exitCode == 0 || len(results) != 1 {
+		t.Fatalf("expected one failed result, got %+v (exit %d)", results, exitCode)
+	}
+	data, err := json.Marshal(results[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"category":"restricted"`, `"retryable":false`} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("expected %s in %s", want, data)
+		}
+	}
+}
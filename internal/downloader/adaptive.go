@@ -1,6 +1,7 @@
 package downloader
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -20,9 +21,30 @@ const (
 	maxSegmentRetries = 3
 	resumeSuffix      = ".resume.json"
 	partSuffix        = ".part"
+
+	// liveMaxIdlePolls is how many consecutive live playlist refreshes may
+	// bring no new segments before the stream is treated as ended.
+	liveMaxIdlePolls = 12
+	// liveMaxFetchFailures is how many consecutive live playlist fetches may
+	// fail before the stream is treated as ended.
+	liveMaxFetchFailures = 3
 )
 
+// livePollInterval is how long to wait between live playlist refreshes. HLS
+// asks clients to reload no sooner than the target duration.
+var livePollInterval = func(manifest HLSManifest) time.Duration {
+	if manifest.TargetDuration > 0 {
+		return time.Duration(manifest.TargetDuration * float64(time.Second))
+	}
+	return 5 * time.Second
+}
+
 func downloadAdaptive(ctx context.Context, client YouTubeClient, video *youtube.Video, opts Options, ctxInfo outputContext, printer *Printer, prefix string, formatErr error) (downloadResult, error) {
+	if opts.AudioFormat != "" {
+		// The HLS and DASH paths mux video; there is no audio stream to
+		// encode, so refuse rather than quietly ignore the codec.
+		return downloadResult{}, wrapCategory(CategoryUnsupported, fmt.Errorf("-audio-format %s is not supported for adaptive (HLS/DASH) downloads: %w", opts.AudioFormat, formatErr))
+	}
 	if opts.AudioOnly {
 		return downloadResult{}, wrapCategory(CategoryUnsupported, fmt.Errorf("audio-only adaptive downloads are not supported yet (use --list-formats): %w", formatErr))
 	}
@@ -87,6 +109,11 @@ func downloadHLS(ctx context.Context, client YouTubeClient, video *youtube.Video
 	}
 
 	format := hlsFormatFromSegments(manifest.Segments, opts.Quality, selectedVariant)
+	baseDir, err := routedOutputDir(opts, format)
+	if err != nil {
+		return downloadResult{}, wrapCategory(CategoryFilesystem, err)
+	}
+	opts.OutputDir = baseDir
 	outputPath, err := resolveOutputPath(opts.OutputTemplate, video, format, ctxInfo, opts.OutputDir)
 	if err != nil {
 		return downloadResult{}, wrapCategory(CategoryFilesystem, err)
@@ -99,7 +126,20 @@ func downloadHLS(ctx context.Context, client YouTubeClient, video *youtube.Video
 		return downloadResult{skipped: true, outputPath: outputPath}, nil
 	}
 
-	result, err := downloadHLSSegments(ctx, client, playlistURL, manifest.Segments, outputPath, opts.OutputDir, opts, printer, prefix)
+	useFFmpeg := opts.HLSUseFFmpeg && ffmpegAvailable()
+	if opts.HLSUseFFmpeg && !useFFmpeg && printer != nil {
+		printer.Log(LogWarn, "warning: --hls-use-ffmpeg ignored: ffmpeg not found")
+	}
+
+	var result downloadResult
+	switch {
+	case opts.LiveFromStart && !manifest.EndList:
+		result, err = downloadHLSLive(ctx, client, playlistURL, manifest, outputPath, opts.OutputDir, opts, printer, prefix)
+	case useFFmpeg:
+		result, err = downloadHLSWithFFmpeg(ctx, playlistURL, outputPath, opts.OutputDir, opts, printer, prefix)
+	default:
+		result, err = downloadHLSSegments(ctx, client, playlistURL, manifest.Segments, outputPath, opts.OutputDir, opts, printer, prefix)
+	}
 	result.format = format
 	if err == nil {
 		result.outputPath = outputPath
@@ -235,7 +275,7 @@ type hlsResumeState struct {
 	BytesWritten int64  `json:"bytes_written"`
 }
 
-func downloadHLSSegments(ctx context.Context, client YouTubeClient, playlistURL string, segments []HLSSegment, outputPath, baseDir string, opts Options, printer *Printer, prefix string) (downloadResult, error) {
+func downloadHLSSegments(ctx context.Context, client YouTubeClient, playlistURL string, segments []HLSSegment, outputPath, baseDir string, opts Options, printer *Printer, prefix string) (result downloadResult, err error) {
 	partPath, err := artifactPath(outputPath, partSuffix, baseDir)
 	if err != nil {
 		return downloadResult{}, err
@@ -244,6 +284,11 @@ func downloadHLSSegments(ctx context.Context, client YouTubeClient, playlistURL
 	if err != nil {
 		return downloadResult{}, err
 	}
+	defer func() {
+		if err != nil {
+			cleanupFailedArtifacts(ctx, opts, partPath, resumePath)
+		}
+	}()
 	segmentDir, err := artifactPath(outputPath, ".segments", baseDir)
 	if err != nil {
 		return downloadResult{}, err
@@ -255,6 +300,9 @@ func downloadHLSSegments(ctx context.Context, client YouTubeClient, playlistURL
 		NextIndex:    0,
 		BytesWritten: 0,
 	}
+	if err := discardResumeArtifacts(opts, partPath, resumePath); err != nil {
+		return downloadResult{}, err
+	}
 	if loaded, err := loadHLSResume(resumePath); err == nil && loaded.ManifestURL == playlistURL && loaded.SegmentCount == len(segments) {
 		state = loaded
 	}
@@ -276,12 +324,15 @@ func downloadHLSSegments(ctx context.Context, client YouTubeClient, playlistURL
 			urls[i] = resolveManifestURL(playlistURL, seg.URI)
 		}
 		plan := segmentDownloadPlan{
-			URLs:        urls,
-			TempDir:     tempDir,
-			Prefix:      prefix,
-			Concurrency: opts.SegmentConcurrency,
+			URLs:            urls,
+			TempDir:         tempDir,
+			Prefix:          prefix,
+			OutputPath:      outputPath,
+			Concurrency:     opts.SegmentConcurrency,
+			AutoConcurrency: opts.AutoConcurrency,
+			SkipUnavailable: opts.SkipUnavailableFragments,
 		}
-		total, err := downloadSegmentsParallel(ctx, client, plan, file, printer)
+		total, skipped, err := downloadSegmentsParallel(ctx, client, plan, file, printer)
 		if err != nil {
 			return downloadResult{}, err
 		}
@@ -294,8 +345,7 @@ func downloadHLSSegments(ctx context.Context, client YouTubeClient, playlistURL
 		if err := validateOutputFile(outputPath, nil); err != nil {
 			return downloadResult{}, err
 		}
-		_ = os.RemoveAll(segmentDir)
-		return downloadResult{bytes: total, outputPath: outputPath}, nil
+		return downloadResult{bytes: total, outputPath: outputPath, skippedFragments: skipped}, nil
 	}
 
 	file, err := os.OpenFile(partPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
@@ -306,24 +356,30 @@ func downloadHLSSegments(ctx context.Context, client YouTubeClient, playlistURL
 
 	var writer io.Writer = file
 	var progress *progressWriter
-	if !opts.Quiet {
-		progress = newProgressWriter(0, printer, prefix)
+	if !opts.Quiet || opts.Renderer != nil {
+		progress = newProgressWriter(0, printer, prefix, outputPath)
 		progress.total.Store(state.BytesWritten)
+		progress.EstimateFromSegments(state.NextIndex, len(segments))
 		writer = io.MultiWriter(file, progress)
 	}
 
+	skipped := 0
 	for idx := state.NextIndex; idx < len(segments); idx++ {
 		segmentURL := resolveManifestURL(playlistURL, segments[idx].URI)
-		if err := downloadSegmentWithRetry(ctx, client, segmentURL, writer); err != nil {
-			if progress != nil {
-				progress.NewLine()
+		if err := fetchSegment(ctx, client, segmentURL, writer, opts.SkipUnavailableFragments); err != nil {
+			if !skipUnavailableSegment(ctx, opts.SkipUnavailableFragments, err, idx, printer, prefix) {
+				if progress != nil {
+					progress.NewLine()
+				}
+				return downloadResult{}, wrapCategory(CategoryNetwork, fmt.Errorf("segment %d failed: %w", idx+1, err))
 			}
-			return downloadResult{}, wrapCategory(CategoryNetwork, fmt.Errorf("segment %d failed: %w", idx+1, err))
+			skipped++
 		}
 
 		state.NextIndex = idx + 1
 		if progress != nil {
 			state.BytesWritten = progress.total.Load()
+			progress.EstimateFromSegments(state.NextIndex, len(segments))
 		}
 		if err := saveHLSResume(resumePath, state); err != nil {
 			return downloadResult{}, err
@@ -345,7 +401,154 @@ func downloadHLSSegments(ctx context.Context, client YouTubeClient, playlistURL
 	}
 	_ = os.Remove(resumePath)
 
-	return downloadResult{bytes: state.BytesWritten, outputPath: outputPath}, nil
+	return downloadResult{bytes: state.BytesWritten, outputPath: outputPath, skippedFragments: skipped}, nil
+}
+
+// downloadHLSLive records a live HLS playlist for --live-from-start. It
+// starts from the earliest segment the playlist still lists, then re-fetches
+// the playlist and appends each newly published segment until #EXT-X-ENDLIST
+// appears, the playlist stops changing or can no longer be fetched, or ctx is
+// cancelled. Whatever was recorded is kept when the user cancels.
+func downloadHLSLive(ctx context.Context, client YouTubeClient, playlistURL string, manifest HLSManifest, outputPath, baseDir string, opts Options, printer *Printer, prefix string) (result downloadResult, err error) {
+	partPath, err := artifactPath(outputPath, partSuffix, baseDir)
+	if err != nil {
+		return downloadResult{}, err
+	}
+	defer func() {
+		if err != nil {
+			cleanupFailedArtifacts(ctx, opts, partPath)
+		}
+	}()
+
+	file, err := os.OpenFile(partPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return downloadResult{}, wrapCategory(CategoryFilesystem, fmt.Errorf("opening temp file: %w", err))
+	}
+	defer file.Close()
+
+	var writer io.Writer = file
+	var progress *progressWriter
+	if !opts.Quiet || opts.Renderer != nil {
+		progress = newProgressWriter(0, printer, prefix, outputPath)
+		writer = io.MultiWriter(file, progress)
+	}
+	if printer != nil {
+		printer.Log(LogInfo, fmt.Sprintf("%s recording live stream until it ends (Ctrl+C to stop)", prefix))
+	}
+
+	var written int64
+	nextSeq := manifest.MediaSequence
+	idlePolls, fetchFailures := 0, 0
+	for {
+		if manifest.MediaSequence > nextSeq && printer != nil {
+			printer.Log(LogWarn, fmt.Sprintf("%s live playlist moved past %d segment(s) before they were fetched", prefix, manifest.MediaSequence-nextSeq))
+		}
+		fresh := 0
+		for i, segment := range manifest.Segments {
+			seq := manifest.MediaSequence + i
+			if seq < nextSeq {
+				continue
+			}
+			counter := &countingWriter{w: writer}
+			if err := downloadSegmentWithRetry(ctx, client, resolveManifestURL(playlistURL, segment.URI), counter); err != nil {
+				if ctx.Err() != nil {
+					break
+				}
+				if progress != nil {
+					progress.NewLine()
+				}
+				return downloadResult{}, wrapCategory(CategoryNetwork, fmt.Errorf("live segment %d failed: %w", seq, err))
+			}
+			written += counter.n
+			nextSeq = seq + 1
+			fresh++
+		}
+		if manifest.EndList || ctx.Err() != nil {
+			break
+		}
+		if fresh == 0 {
+			idlePolls++
+		} else {
+			idlePolls = 0
+		}
+		if idlePolls >= liveMaxIdlePolls {
+			if printer != nil {
+				printer.Log(LogWarn, fmt.Sprintf("%s live playlist stopped updating; finishing recording", prefix))
+			}
+			break
+		}
+
+		if sleepWithContext(ctx, livePollInterval(manifest)) != nil {
+			break
+		}
+		refreshed, fetchErr := fetchLivePlaylist(ctx, client, playlistURL)
+		if fetchErr != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			fetchFailures++
+			if fetchFailures >= liveMaxFetchFailures {
+				if written == 0 {
+					return downloadResult{}, wrapCategory(CategoryNetwork, fmt.Errorf("refreshing live playlist: %w", fetchErr))
+				}
+				if printer != nil {
+					printer.Log(LogWarn, fmt.Sprintf("%s live playlist unavailable (%v); finishing recording", prefix, fetchErr))
+				}
+				break
+			}
+			continue
+		}
+		fetchFailures = 0
+		manifest = refreshed
+	}
+
+	if progress != nil {
+		progress.Finish()
+	}
+	if written == 0 {
+		return downloadResult{}, wrapCategory(CategoryNetwork, fmt.Errorf("no live segments recorded"))
+	}
+	// Drop any partial segment left behind by a cancelled fetch.
+	if err := file.Truncate(written); err != nil {
+		return downloadResult{}, wrapCategory(CategoryFilesystem, fmt.Errorf("truncating temp file: %w", err))
+	}
+	if err := file.Close(); err != nil {
+		return downloadResult{}, wrapCategory(CategoryFilesystem, fmt.Errorf("closing temp file: %w", err))
+	}
+	if err := os.Rename(partPath, outputPath); err != nil {
+		return downloadResult{}, wrapCategory(CategoryFilesystem, fmt.Errorf("renaming output: %w", err))
+	}
+	if err := validateOutputFile(outputPath, nil); err != nil {
+		return downloadResult{}, err
+	}
+	return downloadResult{bytes: written, outputPath: outputPath}, nil
+}
+
+func fetchLivePlaylist(ctx context.Context, client YouTubeClient, playlistURL string) (HLSManifest, error) {
+	data, err := fetchManifest(ctx, client, playlistURL)
+	if err != nil {
+		return HLSManifest{}, err
+	}
+	return ParseHLSManifest(data)
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// segmentStatusError is the non-2xx status a segment request ended with.
+type segmentStatusError int
+
+func (e segmentStatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d", int(e))
 }
 
 func downloadSegmentWithRetry(ctx context.Context, client YouTubeClient, segmentURL string, writer io.Writer) error {
@@ -357,7 +560,7 @@ func downloadSegmentWithRetry(ctx context.Context, client YouTubeClient, segment
 		}
 		resp, err := client.HTTP().Do(req)
 		if err == nil && resp != nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
-			_, copyErr := io.Copy(writer, resp.Body)
+			_, copyErr := copyBuffer(writer, resp.Body)
 			resp.Body.Close()
 			if copyErr == nil {
 				return nil
@@ -366,16 +569,50 @@ func downloadSegmentWithRetry(ctx context.Context, client YouTubeClient, segment
 		} else {
 			if resp != nil {
 				resp.Body.Close()
-				lastErr = fmt.Errorf("unexpected status %d", resp.StatusCode)
+				lastErr = segmentStatusError(resp.StatusCode)
 			} else {
 				lastErr = err
 			}
 		}
-		time.Sleep(time.Duration(attempt) * 300 * time.Millisecond)
+		if attempt < maxSegmentRetries {
+			if err := sleepWithContext(ctx, time.Duration(attempt)*300*time.Millisecond); err != nil {
+				return lastErr
+			}
+		}
 	}
 	return lastErr
 }
 
+// fetchSegment downloads one segment into writer. When buffered is set the
+// segment is held in memory until it is complete, so one that fails after
+// all retries leaves nothing behind in writer and can be skipped.
+func fetchSegment(ctx context.Context, client YouTubeClient, segmentURL string, writer io.Writer, buffered bool) error {
+	if !buffered {
+		return downloadSegmentWithRetry(ctx, client, segmentURL, writer)
+	}
+	var buf bytes.Buffer
+	if err := downloadSegmentWithRetry(ctx, client, segmentURL, &buf); err != nil {
+		return err
+	}
+	if _, err := writer.Write(buf.Bytes()); err != nil {
+		return wrapCategory(CategoryFilesystem, fmt.Errorf("writing segment: %w", err))
+	}
+	return nil
+}
+
+// skipUnavailableSegment reports whether segment index (0-based), which
+// failed with err, is skipped under -skip-unavailable-fragments. Write
+// failures and cancellation are never skipped.
+func skipUnavailableSegment(ctx context.Context, skip bool, err error, index int, printer *Printer, prefix string) bool {
+	if !skip || ctx.Err() != nil || errorCategory(err) == CategoryFilesystem {
+		return false
+	}
+	if printer != nil {
+		printer.Log(LogWarn, fmt.Sprintf("%s skipping unavailable fragment %d: %v", prefix, index+1, err))
+	}
+	return true
+}
+
 func loadHLSResume(path string) (hlsResumeState, error) {
 	file, err := os.Open(path)
 	if err != nil {
@@ -0,0 +1,259 @@
+package downloader
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lvcoi/ytdl-lib/v2"
+)
+
+// livePlaylist renders a media playlist listing segments first..last.
+func livePlaylist(first, last int, ended bool) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "#EXTM3U\n#EXT-X-TARGETDURATION:2\n#EXT-X-MEDIA-SEQUENCE:%d\n", first)
+	for seq := first; seq <= last; seq++ {
+		fmt.Fprintf(&b, "#EXTINF:2.0,\nseg%d.ts\n", seq)
+	}
+	if ended {
+		b.WriteString("#EXT-X-ENDLIST\n")
+	}
+	return b.String()
+}
+
+func useFastLivePolling(t *testing.T) {
+	t.Helper()
+	original := livePollInterval
+	livePollInterval = func(HLSManifest) time.Duration { return time.Millisecond }
+	t.Cleanup(func() { livePollInterval = original })
+}
+
+func TestParseHLSManifestLiveTags(t *testing.T) {
+	manifest, err := ParseHLSManifest([]byte(livePlaylist(7, 9, false)))
+	if err != nil {
+		t.Fatalf("ParseHLSManifest: %v", err)
+	}
+	if manifest.MediaSequence != 7 || manifest.TargetDuration != 2 || manifest.EndList || len(manifest.Segments) != 3 {
+		t.Fatalf("unexpected live manifest: %+v", manifest)
+	}
+
+	manifest, err = ParseHLSManifest([]byte(livePlaylist(7, 9, true)))
+	if err != nil {
+		t.Fatalf("ParseHLSManifest: %v", err)
+	}
+	if !manifest.EndList {
+		t.Fatal("expected EXT-X-ENDLIST to be detected")
+	}
+}
+
+func TestDownloadHLSLiveFollowsGrowingPlaylist(t *testing.T) {
+	useFastLivePolling(t)
+
+	// The first refresh brings nothing new, the next two slide the window
+	// forward by one segment each, and the last one ends the stream.
+	playlists := []string{
+		livePlaylist(1, 3, false),
+		livePlaylist(1, 3, false),
+		livePlaylist(2, 4, false),
+		livePlaylist(3, 5, true),
+	}
+	var refreshes atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ".m3u8") {
+			n := int(refreshes.Add(1))
+			if n > len(playlists) {
+				n = len(playlists)
+			}
+			_, _ = w.Write([]byte(playlists[n-1]))
+			return
+		}
+		_, _ = w.Write([]byte(strings.TrimSuffix(filepath.Base(r.URL.Path), ".ts") + ";"))
+	}))
+	defer srv.Close()
+
+	client := &mockYouTubeClient{httpDoer: srv.Client()}
+	playlistURL := srv.URL + "/live.m3u8"
+	initial, err := fetchLivePlaylist(context.Background(), client, playlistURL)
+	if err != nil {
+		t.Fatalf("fetchLivePlaylist: %v", err)
+	}
+
+	dir := t.TempDir()
+	outputPath := filepath.Join(dir, "live.bin")
+	result, err := downloadHLSLive(context.Background(), client, playlistURL, initial, outputPath, dir, Options{Quiet: true}, nil, "")
+	if err != nil {
+		t.Fatalf("downloadHLSLive: %v", err)
+	}
+
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("reading output: %v", err)
+	}
+	want := "seg1;seg2;seg3;seg4;seg5;"
+	if string(data) != want {
+		t.Fatalf("expected %q, got %q", want, data)
+	}
+	if result.bytes != int64(len(want)) {
+		t.Fatalf("expected %d bytes, got %d", len(want), result.bytes)
+	}
+	if _, err := os.Stat(outputPath + partSuffix); !os.IsNotExist(err) {
+		t.Fatalf("expected part file to be renamed, stat err = %v", err)
+	}
+}
+
+func TestDownloadHLSLiveKeepsRecordingOnCancel(t *testing.T) {
+	useFastLivePolling(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var segments atomic.Int32
+	var refreshes atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ".m3u8") {
+			n := int(refreshes.Add(1))
+			_, _ = w.Write([]byte(livePlaylist(n, n+1, false)))
+			return
+		}
+		if segments.Add(1) == 3 {
+			cancel()
+		}
+		_, _ = w.Write([]byte("x"))
+	}))
+	defer srv.Close()
+
+	client := &mockYouTubeClient{httpDoer: srv.Client()}
+	playlistURL := srv.URL + "/live.m3u8"
+	initial, err := fetchLivePlaylist(context.Background(), client, playlistURL)
+	if err != nil {
+		t.Fatalf("fetchLivePlaylist: %v", err)
+	}
+
+	dir := t.TempDir()
+	outputPath := filepath.Join(dir, "live.bin")
+	result, err := downloadHLSLive(ctx, client, playlistURL, initial, outputPath, dir, Options{Quiet: true}, nil, "")
+	if err != nil {
+		t.Fatalf("downloadHLSLive: %v", err)
+	}
+	if result.bytes < 2 {
+		t.Fatalf("expected the segments fetched before cancelling to be kept, got %d bytes", result.bytes)
+	}
+	if info, err := os.Stat(outputPath); err != nil || info.Size() != result.bytes {
+		t.Fatalf("expected output of %d bytes, stat = %v, %v", result.bytes, info, err)
+	}
+}
+
+// manifestOnlyDoer serves playlist requests and records any other request,
+// which only the native segment downloader would make.
+type manifestOnlyDoer struct {
+	doer     HTTPDoer
+	segments atomic.Int32
+}
+
+func (d *manifestOnlyDoer) Do(req *http.Request) (*http.Response, error) {
+	if !strings.HasSuffix(req.URL.Path, ".m3u8") {
+		d.segments.Add(1)
+	}
+	return d.doer.Do(req)
+}
+
+func TestDownloadHLSUseFFmpegSelectsFFmpeg(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake ffmpeg is a shell script")
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("#EXTM3U\n#EXT-X-TARGETDURATION:2\n#EXTINF:2.0,\nseg0.bin\n#EXT-X-ENDLIST\n"))
+	}))
+	defer srv.Close()
+
+	// The fake ffmpeg records its arguments and writes its last one, the
+	// output path.
+	binDir := t.TempDir()
+	logPath := filepath.Join(binDir, "invocations.log")
+	script := "#!/bin/sh\necho \"$@\" >> '" + logPath + "'\nfor last; do :; done\nprintf ffmpeg > \"$last\"\n"
+	if err := os.WriteFile(filepath.Join(binDir, "ffmpeg"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	prevFFmpeg := ffmpegPath
+	ffmpegPath = filepath.Join(binDir, "ffmpeg")
+	t.Cleanup(func() { ffmpegPath = prevFFmpeg })
+
+	dir := t.TempDir()
+	opts := Options{Quiet: true, HLSUseFFmpeg: true, OutputDir: dir, OutputTemplate: "{title}.{ext}"}
+	doer := &manifestOnlyDoer{doer: srv.Client()}
+	client := &mockYouTubeClient{httpDoer: doer}
+	video := &youtube.Video{ID: "abc123", Title: "stream", HLSManifestURL: srv.URL + "/index.m3u8"}
+	result, err := downloadHLS(context.Background(), client, video, opts, outputContext{}, newPrinter(opts, nil), "")
+	if err != nil {
+		t.Fatalf("downloadHLS: %v", err)
+	}
+
+	if n := doer.segments.Load(); n != 0 {
+		t.Fatalf("native downloader fetched %d segments, want the ffmpeg path", n)
+	}
+	logged, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("expected ffmpeg to run: %v", err)
+	}
+	if !strings.Contains(string(logged), srv.URL+"/index.m3u8") {
+		t.Fatalf("expected ffmpeg to be given the playlist URL, got %q", logged)
+	}
+	data, err := os.ReadFile(result.outputPath)
+	if err != nil || string(data) != "ffmpeg" {
+		t.Fatalf("expected ffmpeg output at %q, got %q, %v", result.outputPath, data, err)
+	}
+	if result.bytes != int64(len("ffmpeg")) {
+		t.Fatalf("expected %d bytes, got %d", len("ffmpeg"), result.bytes)
+	}
+}
+
+func TestDownloadHLSWithFFmpegRemuxesStream(t *testing.T) {
+	if !ffmpegAvailable() {
+		t.Skip("ffmpeg not available")
+	}
+
+	streamDir := t.TempDir()
+	cmd := exec.Command(ffmpegPath, "-hide_banner", "-loglevel", "error",
+		"-f", "lavfi", "-i", "testsrc=duration=3:size=64x64:rate=10",
+		"-c:v", "mpeg2video", "-f", "hls", "-hls_time", "1", "-hls_list_size", "0",
+		filepath.Join(streamDir, "index.m3u8"))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Skipf("ffmpeg cannot write an HLS stream: %v: %s", err, out)
+	}
+	srv := httptest.NewServer(http.FileServer(http.Dir(streamDir)))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	opts := Options{Quiet: true, HLSUseFFmpeg: true, OutputDir: dir, OutputTemplate: "{title}.{ext}"}
+	doer := &manifestOnlyDoer{doer: srv.Client()}
+	client := &mockYouTubeClient{httpDoer: doer}
+	video := &youtube.Video{ID: "abc123", Title: "stream", HLSManifestURL: srv.URL + "/index.m3u8"}
+	result, err := downloadHLS(context.Background(), client, video, opts, outputContext{}, newPrinter(opts, nil), "")
+	if err != nil {
+		t.Fatalf("downloadHLS: %v", err)
+	}
+	if n := doer.segments.Load(); n != 0 {
+		t.Fatalf("native downloader fetched %d segments, want the ffmpeg path", n)
+	}
+	if filepath.Ext(result.outputPath) != ".ts" || result.bytes == 0 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+}
+
+func TestDownloadAdaptiveRejectsAudioFormat(t *testing.T) {
+	video := &youtube.Video{ID: "abc123", Title: "Live", HLSManifestURL: "https://example.invalid/master.m3u8"}
+	opts := Options{AudioFormat: "mp3", OutputDir: t.TempDir(), Quiet: true}
+	formatErr := wrapCategory(CategoryUnsupported, fmt.Errorf("no progressive formats"))
+	_, err := downloadAdaptive(context.Background(), &mockYouTubeClient{}, video, opts, outputContext{}, newPrinter(opts, nil), "[1/1]", formatErr)
+	if errorCategory(err) != CategoryUnsupported || !strings.Contains(err.Error(), "-audio-format mp3") {
+		t.Fatalf("expected -audio-format to be rejected for an HLS download, got %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+package downloader
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/lvcoi/ytdl-lib/v2"
+)
+
+// archiveExtractor prefixes every archive line, as in yt-dlp's archive
+// format, so plain "youtube <id>" archives from either tool load.
+const archiveExtractor = "youtube"
+
+// DownloadArchive is the --download-archive file: one line per downloaded
+// video, "youtube <id>" optionally followed by the itag, height and bitrate
+// of the format saved, e.g. "youtube abc123 itag=22 height=720
+// bitrate=1200000". An upgraded download appends a new line; the last line
+// for an ID wins.
+type DownloadArchive struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]archiveEntry
+}
+
+type archiveEntry struct {
+	itag    int
+	height  int
+	bitrate int
+}
+
+// known reports whether the entry records which format was saved; plain
+// yt-dlp style lines do not.
+func (e archiveEntry) known() bool {
+	return e.itag > 0 || e.height > 0 || e.bitrate > 0
+}
+
+// OpenDownloadArchive loads the archive at path. A missing file is an empty
+// archive; it is created on the first download.
+func OpenDownloadArchive(path string) (*DownloadArchive, error) {
+	archive := &DownloadArchive{path: path, entries: map[string]archiveEntry{}}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return archive, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading download archive: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("%s:%d: expected \"<extractor> <id>\"", path, lineNo)
+		}
+		if fields[0] != archiveExtractor {
+			continue
+		}
+		var entry archiveEntry
+		for _, field := range fields[2:] {
+			key, value, _ := strings.Cut(field, "=")
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: invalid %s value %q", path, lineNo, key, value)
+			}
+			switch key {
+			case "itag":
+				entry.itag = n
+			case "height":
+				entry.height = n
+			case "bitrate":
+				entry.bitrate = n
+			}
+		}
+		archive.entries[fields[1]] = entry
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading download archive: %w", err)
+	}
+	return archive, nil
+}
+
+// skipReason returns why video id should not be downloaded again with
+// format, or "" when it should. Without --redownload-on-better-quality any
+// archived ID is skipped. With it, the archived copy is replaced only when
+// format is known to be better: taller, or the same height at a higher
+// bitrate. A nil format (an adaptive stream, picked later) or an archive line
+// without format details never counts as an upgrade.
+func (a *DownloadArchive) skipReason(id string, format *youtube.Format, opts Options) string {
+	if a == nil {
+		return ""
+	}
+	a.mu.Lock()
+	entry, ok := a.entries[id]
+	a.mu.Unlock()
+	if !ok {
+		return ""
+	}
+	if !opts.RedownloadOnBetterQuality {
+		return "in download archive"
+	}
+	if format == nil || !entry.known() || format.ItagNo == entry.itag {
+		return "in download archive"
+	}
+	if format.Height != entry.height {
+		if format.Height > entry.height {
+			return ""
+		}
+		return "in download archive at better quality"
+	}
+	if bitrateForFormat(format) > entry.bitrate {
+		return ""
+	}
+	return "in download archive at better quality"
+}
+
+// Record appends the downloaded format of video id to the archive.
+func (a *DownloadArchive) Record(id string, format *youtube.Format) error {
+	if a == nil || id == "" {
+		return nil
+	}
+	var entry archiveEntry
+	line := fmt.Sprintf("%s %s", archiveExtractor, id)
+	if format != nil {
+		entry = archiveEntry{itag: format.ItagNo, height: format.Height, bitrate: bitrateForFormat(format)}
+		line += fmt.Sprintf(" itag=%d height=%d bitrate=%d", entry.itag, entry.height, entry.bitrate)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	file, err := os.OpenFile(a.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return wrapCategory(CategoryFilesystem, fmt.Errorf("opening download archive: %w", err))
+	}
+	defer file.Close()
+	if _, err := fmt.Fprintln(file, line); err != nil {
+		return wrapCategory(CategoryFilesystem, fmt.Errorf("writing download archive: %w", err))
+	}
+	a.entries[id] = entry
+	return nil
+}
@@ -0,0 +1,168 @@
+package downloader
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	youtube "github.com/lvcoi/ytdl-lib/v2"
+)
+
+func writeArchive(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "archive.txt")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestOpenDownloadArchive(t *testing.T) {
+	archive, err := OpenDownloadArchive(writeArchive(t, `# comment
+youtube plain01
+youtube up0001 itag=18 height=360 bitrate=500000
+vimeo 12345
+youtube up0001 itag=22 height=720 bitrate=1500000
+`))
+	if err != nil {
+		t.Fatalf("OpenDownloadArchive: %v", err)
+	}
+	if entry, ok := archive.entries["plain01"]; !ok || entry.known() {
+		t.Errorf("plain01 = %+v, %v; want a plain entry", entry, ok)
+	}
+	if entry := archive.entries["up0001"]; entry != (archiveEntry{itag: 22, height: 720, bitrate: 1500000}) {
+		t.Errorf("up0001 = %+v, want the last line's format", entry)
+	}
+	if _, ok := archive.entries["12345"]; ok {
+		t.Error("expected other extractors' lines to be ignored")
+	}
+
+	missing, err := OpenDownloadArchive(filepath.Join(t.TempDir(), "none.txt"))
+	if err != nil || len(missing.entries) != 0 {
+		t.Fatalf("expected a missing archive to load empty, got %v, %v", missing, err)
+	}
+	if _, err := OpenDownloadArchive(writeArchive(t, "youtube abc itag=x\n")); err == nil || !strings.Contains(err.Error(), "invalid itag") {
+		t.Fatalf("expected an invalid itag error, got %v", err)
+	}
+}
+
+func TestDownloadArchiveSkipReason(t *testing.T) {
+	archive, err := OpenDownloadArchive(writeArchive(t, "youtube vid001 itag=22 height=720 bitrate=1500000\nyoutube plain01\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	upgrade := Options{RedownloadOnBetterQuality: true}
+	tests := []struct {
+		name   string
+		id     string
+		format *youtube.Format
+		opts   Options
+		want   string
+	}{
+		{"not archived", "other01", &youtube.Format{ItagNo: 18, Height: 360}, upgrade, ""},
+		{"archived", "vid001", &youtube.Format{ItagNo: 137, Height: 1080}, Options{}, "in download archive"},
+		{"identical", "vid001", &youtube.Format{ItagNo: 22, Height: 720, Bitrate: 1500000}, upgrade, "in download archive"},
+		{"taller", "vid001", &youtube.Format{ItagNo: 137, Height: 1080, Bitrate: 1000000}, upgrade, ""},
+		{"same height, higher bitrate", "vid001", &youtube.Format{ItagNo: 136, Height: 720, Bitrate: 2500000}, upgrade, ""},
+		{"same height, lower bitrate", "vid001", &youtube.Format{ItagNo: 136, Height: 720, Bitrate: 900000}, upgrade, "in download archive at better quality"},
+		{"shorter", "vid001", &youtube.Format{ItagNo: 18, Height: 360}, upgrade, "in download archive at better quality"},
+		{"adaptive", "vid001", nil, upgrade, "in download archive"},
+		{"plain entry", "plain01", &youtube.Format{ItagNo: 137, Height: 1080}, upgrade, "in download archive"},
+	}
+	for _, tt := range tests {
+		if got := archive.skipReason(tt.id, tt.format, tt.opts); got != tt.want {
+			t.Errorf("%s: skipReason = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDownloadVideoArchiveSkipsIdenticalAndRedownloadsUpgrade(t *testing.T) {
+	payload := "\x00\x00\x00\x18ftypisom" + strings.Repeat("\x00", 12) + "moov" + strings.Repeat("x", 256)
+	progressive := func(itag, height int) youtube.Format {
+		return youtube.Format{ItagNo: itag, URL: "https://example.com/stream", MimeType: `video/mp4; codecs="avc1.42001E, mp4a.40.2"`, AudioChannels: 2, Width: height * 16 / 9, Height: height}
+	}
+	client := &mockYouTubeClient{
+		getStreamFn: func(ctx context.Context, video *youtube.Video, format *youtube.Format) (io.ReadCloser, int64, error) {
+			return io.NopCloser(strings.NewReader(payload)), int64(len(payload)), nil
+		},
+	}
+	archivePath := writeArchive(t, "youtube vid001 itag=18 height=360 bitrate=0\n")
+	download := func(formats youtube.FormatList, redownload bool) downloadResult {
+		t.Helper()
+		archive, err := OpenDownloadArchive(archivePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts := Options{
+			OutputTemplate:            "{title}-{quality}.{ext}",
+			OutputDir:                 t.TempDir(),
+			Quiet:                     true,
+			Archive:                   archive,
+			RedownloadOnBetterQuality: redownload,
+		}
+		video := &youtube.Video{ID: "vid001", Title: "Clip", Formats: formats}
+		result, err := downloadVideo(context.Background(), client, video, opts, outputContext{}, newPrinter(opts, nil), "[1/1]")
+		if err != nil {
+			t.Fatalf("downloadVideo: %v", err)
+		}
+		return result
+	}
+
+	if result := download(youtube.FormatList{progressive(18, 360)}, true); !result.skipped || result.skipReason != "in download archive" {
+		t.Fatalf("expected the identical format to be skipped, got %+v", result)
+	}
+	upgraded := youtube.FormatList{progressive(18, 360), progressive(22, 720)}
+	if result := download(upgraded, false); !result.skipped {
+		t.Fatalf("expected an archived video to be skipped without -redownload-on-better-quality, got %+v", result)
+	}
+	if result := download(upgraded, true); result.skipped || result.outputPath == "" {
+		t.Fatalf("expected the 720p format to be downloaded as an upgrade, got %+v", result)
+	}
+	data, err := os.ReadFile(archivePath)
+	if err != nil || !strings.HasSuffix(string(data), "youtube vid001 itag=22 height=720 bitrate=0\n") {
+		t.Fatalf("expected the upgrade to be appended to the archive, got %q (err %v)", data, err)
+	}
+	if result := download(upgraded, true); !result.skipped {
+		t.Fatalf("expected the upgraded copy to be skipped on the next run, got %+v", result)
+	}
+}
+
+func TestArchivedPlaylistEntriesAreNotResolved(t *testing.T) {
+	archive, err := OpenDownloadArchive(writeArchive(t, "youtube done01 itag=18 height=360 bitrate=500000\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	archived := &youtube.PlaylistEntry{ID: "done01", Title: "Done"}
+	if got := entrySkipReason(archived, Options{Archive: archive}); got != "in download archive" {
+		t.Fatalf("entrySkipReason = %q, want the archive reason", got)
+	}
+	if got := entrySkipReason(archived, Options{Archive: archive, RedownloadOnBetterQuality: true}); got != "" {
+		t.Fatalf("expected -redownload-on-better-quality to resolve archived entries, got %q", got)
+	}
+
+	client := &mockYouTubeClient{
+		videoFromFn: func(ctx context.Context, entry *youtube.PlaylistEntry) (*youtube.Video, error) {
+			if entry.ID == "done01" {
+				t.Errorf("unexpected metadata fetch for archived entry %s", entry.ID)
+			}
+			return &youtube.Video{ID: entry.ID, Title: entry.Title, Formats: youtube.FormatList{{
+				ItagNo: 18, MimeType: `video/mp4; codecs="avc1.42001E, mp4a.40.2"`, AudioChannels: 2, Width: 640, Height: 360,
+			}}}, nil
+		},
+	}
+	playlist := &youtube.Playlist{ID: "PL1", Title: "Mix", Videos: []*youtube.PlaylistEntry{archived, {ID: "new001", Title: "New"}}}
+	opts := Options{Archive: archive, OutputTemplate: "{title}.{ext}", OutputDir: t.TempDir(), Quiet: true}
+	plan, err := planPlaylist(context.Background(), client, playlist, opts, false)
+	if err != nil {
+		t.Fatalf("planPlaylist: %v", err)
+	}
+	if entry := plan.Entries[0]; entry.Action != planSkip || entry.Reason != "in download archive" {
+		t.Errorf("entry 1: action %q, reason %q; want an archive skip", entry.Action, entry.Reason)
+	}
+	if entry := plan.Entries[1]; entry.Action != planDownload {
+		t.Errorf("entry 2: action %q, want %q", entry.Action, planDownload)
+	}
+}
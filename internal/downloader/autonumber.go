@@ -0,0 +1,52 @@
+package downloader
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// defaultAutonumberWidth is the zero-padding applied to {autonumber} when
+// no width is configured.
+const defaultAutonumberWidth = 3
+
+// AutoNumber hands out the run-wide sequence behind the {autonumber} output
+// template placeholder, so items are numbered in download order regardless of
+// which URL or playlist they came from. It is safe for concurrent use; a nil
+// *AutoNumber yields empty numbers.
+type AutoNumber struct {
+	mu    sync.Mutex
+	next  int
+	width int
+}
+
+// NewAutoNumber returns a counter whose first number is start, zero-padded to
+// width digits. A width below 1 uses the default of 3.
+func NewAutoNumber(start, width int) *AutoNumber {
+	if width < 1 {
+		width = defaultAutonumberWidth
+	}
+	return &AutoNumber{next: start, width: width}
+}
+
+// Next returns the next number formatted for the output template.
+func (a *AutoNumber) Next() string {
+	if a == nil {
+		return ""
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	n := a.next
+	a.next++
+	return fmt.Sprintf("%0*d", a.width, n)
+}
+
+// nextAutoNumber draws the number for the item about to be resolved. Nothing
+// is consumed unless the output template uses {autonumber}, so a run without
+// it keeps its counter untouched.
+func nextAutoNumber(opts Options) string {
+	if !strings.Contains(opts.OutputTemplate, "{autonumber}") {
+		return ""
+	}
+	return opts.AutoNumber.Next()
+}
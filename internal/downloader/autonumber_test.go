@@ -0,0 +1,61 @@
+package downloader
+
+import (
+	"sync"
+	"testing"
+
+	youtube "github.com/lvcoi/ytdl-lib/v2"
+)
+
+func TestAutoNumberSequenceAndPadding(t *testing.T) {
+	counter := NewAutoNumber(9, 3)
+	for _, want := range []string{"009", "010", "011"} {
+		if got := counter.Next(); got != want {
+			t.Fatalf("Next() = %q, want %q", got, want)
+		}
+	}
+	if got := NewAutoNumber(1, 0).Next(); got != "001" {
+		t.Fatalf("expected default width of 3, got %q", got)
+	}
+	var nilCounter *AutoNumber
+	if got := nilCounter.Next(); got != "" {
+		t.Fatalf("expected nil counter to yield an empty number, got %q", got)
+	}
+}
+
+func TestAutoNumberConcurrentUseIsUnique(t *testing.T) {
+	counter := NewAutoNumber(1, 4)
+	seen := make(map[string]bool)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n := counter.Next()
+			mu.Lock()
+			seen[n] = true
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if len(seen) != 50 || !seen["0001"] || !seen["0050"] {
+		t.Fatalf("expected 0001..0050 exactly once each, got %d distinct numbers", len(seen))
+	}
+}
+
+func TestNextAutoNumberOnlyConsumesWhenTemplateUsesIt(t *testing.T) {
+	counter := NewAutoNumber(1, 2)
+	if got := nextAutoNumber(Options{OutputTemplate: "{title}.{ext}", AutoNumber: counter}); got != "" {
+		t.Fatalf("expected no number without {autonumber}, got %q", got)
+	}
+	opts := Options{OutputTemplate: "{autonumber} - {title}.{ext}", AutoNumber: counter}
+	ctxInfo := outputContext{AutoNumber: nextAutoNumber(opts)}
+	path, err := resolveOutputPath(opts.OutputTemplate, &youtube.Video{Title: "Song"}, &youtube.Format{MimeType: "audio/webm"}, ctxInfo, "out")
+	if err != nil {
+		t.Fatalf("resolveOutputPath: %v", err)
+	}
+	if path != "out/01 - Song.webm" {
+		t.Fatalf("expected the first number to be used, got %q", path)
+	}
+}
@@ -0,0 +1,165 @@
+package downloader
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// autoConcurrencyStart is where --auto-concurrency begins before any
+	// throughput has been measured.
+	autoConcurrencyStart = 2
+	// autoConcurrencyMax caps the ramp when -segment-concurrency is unset.
+	autoConcurrencyMax = 16
+	// autoConcurrencyWindow is how long throughput is sampled before each
+	// adjustment.
+	autoConcurrencyWindow = 2 * time.Second
+	// autoConcurrencyGain is the relative throughput improvement that justifies
+	// keeping an extra worker.
+	autoConcurrencyGain = 0.05
+	// autoConcurrencyPoll is how often a parked worker rechecks the limit.
+	autoConcurrencyPoll = 100 * time.Millisecond
+)
+
+// concurrencyTuner hill-climbs a worker count against measured throughput.
+// It adds a worker while each step still raises throughput, steps back and
+// settles once an extra worker stops paying for itself, and halves the count
+// whenever the server throttles (HTTP 429/403). After a throttle it starts
+// probing upward again from the reduced value.
+type concurrencyTuner struct {
+	mu        sync.Mutex
+	now       func() time.Time
+	window    time.Duration
+	min       int
+	max       int
+	current   int
+	settled   bool
+	lastRate  float64
+	start     time.Time
+	bytes     int64
+	throttled int
+}
+
+func newConcurrencyTuner(max int, now func() time.Time) *concurrencyTuner {
+	if max < 1 {
+		max = autoConcurrencyMax
+	}
+	if now == nil {
+		now = time.Now
+	}
+	start := autoConcurrencyStart
+	if start > max {
+		start = max
+	}
+	return &concurrencyTuner{
+		now:     now,
+		window:  autoConcurrencyWindow,
+		min:     1,
+		max:     max,
+		current: start,
+		start:   now(),
+	}
+}
+
+// Limit reports how many workers may run right now.
+func (t *concurrencyTuner) Limit() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.current
+}
+
+// Max reports the most workers the tuner will ever allow.
+func (t *concurrencyTuner) Max() int {
+	return t.max
+}
+
+// RecordBytes adds transferred bytes to the current sample window.
+func (t *concurrencyTuner) RecordBytes(n int64) {
+	t.mu.Lock()
+	t.bytes += n
+	t.mu.Unlock()
+	t.maybeAdjust()
+}
+
+// Write lets the tuner sit in a segment's writer chain and count bytes.
+func (t *concurrencyTuner) Write(p []byte) (int, error) {
+	t.RecordBytes(int64(len(p)))
+	return len(p), nil
+}
+
+// RecordStatus notes an HTTP response; 429 and 403 count as throttling.
+func (t *concurrencyTuner) RecordStatus(code int) {
+	if code != http.StatusTooManyRequests && code != http.StatusForbidden {
+		return
+	}
+	t.mu.Lock()
+	t.throttled++
+	t.mu.Unlock()
+	t.maybeAdjust()
+}
+
+func (t *concurrencyTuner) maybeAdjust() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	elapsed := t.now().Sub(t.start)
+	if t.throttled == 0 && elapsed < t.window {
+		return
+	}
+
+	switch {
+	case t.throttled > 0:
+		t.current /= 2
+		if t.current < t.min {
+			t.current = t.min
+		}
+		t.settled = false
+		t.lastRate = 0
+	case elapsed > 0:
+		rate := float64(t.bytes) / elapsed.Seconds()
+		switch {
+		case t.settled:
+			// Hold steady; only throttling moves a settled tuner.
+		case t.lastRate == 0 || rate > t.lastRate*(1+autoConcurrencyGain):
+			t.lastRate = rate
+			if t.current < t.max {
+				t.current++
+			} else {
+				t.settled = true
+			}
+		default:
+			// The last extra worker did not help; give it back and stop.
+			if t.current > t.min {
+				t.current--
+			}
+			t.settled = true
+		}
+	}
+
+	t.start = t.now()
+	t.bytes = 0
+	t.throttled = 0
+}
+
+// tunedClient reports every segment response status to a tuner.
+type tunedClient struct {
+	YouTubeClient
+	tuner *concurrencyTuner
+}
+
+func (c tunedClient) HTTP() HTTPDoer {
+	return tunedDoer{doer: c.YouTubeClient.HTTP(), tuner: c.tuner}
+}
+
+type tunedDoer struct {
+	doer  HTTPDoer
+	tuner *concurrencyTuner
+}
+
+func (d tunedDoer) Do(req *http.Request) (*http.Response, error) {
+	resp, err := d.doer.Do(req)
+	if resp != nil {
+		d.tuner.RecordStatus(resp.StatusCode)
+	}
+	return resp, err
+}
@@ -0,0 +1,77 @@
+package downloader
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced time source for the tuner.
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.t }
+
+func TestConcurrencyTunerFollowsThroughput(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	tuner := newConcurrencyTuner(0, clock.Now)
+
+	// The simulated link scales linearly up to 6 workers, then saturates.
+	const perWorker = 1 << 20
+	runWindow := func() {
+		workers := tuner.Limit()
+		if workers > 6 {
+			workers = 6
+		}
+		clock.t = clock.t.Add(autoConcurrencyWindow)
+		tuner.RecordBytes(int64(workers) * perWorker * int64(autoConcurrencyWindow/time.Second))
+	}
+
+	if got := tuner.Limit(); got != autoConcurrencyStart {
+		t.Fatalf("expected to start at %d workers, got %d", autoConcurrencyStart, got)
+	}
+	for i := 0; i < 20; i++ {
+		runWindow()
+	}
+	if got := tuner.Limit(); got != 6 {
+		t.Fatalf("expected tuner to settle at the saturation point of 6, got %d", got)
+	}
+
+	// A throttled response halves the worker count, then it climbs back.
+	tuner.RecordStatus(http.StatusTooManyRequests)
+	if got := tuner.Limit(); got != 3 {
+		t.Fatalf("expected 429 to halve concurrency to 3, got %d", got)
+	}
+	tuner.RecordStatus(http.StatusOK)
+	if got := tuner.Limit(); got != 3 {
+		t.Fatalf("expected 200 to leave concurrency alone, got %d", got)
+	}
+	for i := 0; i < 20; i++ {
+		runWindow()
+	}
+	if got := tuner.Limit(); got != 6 {
+		t.Fatalf("expected tuner to recover to 6 after throttling, got %d", got)
+	}
+}
+
+func TestConcurrencyTunerRespectsCeilingAndFloor(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	tuner := newConcurrencyTuner(4, clock.Now)
+
+	// Throughput keeps scaling, so only the ceiling stops the ramp.
+	for i := 0; i < 10; i++ {
+		clock.t = clock.t.Add(autoConcurrencyWindow)
+		tuner.RecordBytes(int64(tuner.Limit()) << 30)
+	}
+	if got := tuner.Limit(); got != 4 {
+		t.Fatalf("expected ceiling of 4, got %d", got)
+	}
+
+	for i := 0; i < 5; i++ {
+		tuner.RecordStatus(http.StatusForbidden)
+	}
+	if got := tuner.Limit(); got != 1 {
+		t.Fatalf("expected repeated 403s to bottom out at 1 worker, got %d", got)
+	}
+}
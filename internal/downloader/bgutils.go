@@ -77,7 +77,7 @@ func NewBgUtils() (*BgUtils, error) {
 					req.Header.Set(k, fmt.Sprint(v))
 				}
 			}
-			client := &http.Client{Transport: sharedTransport, Timeout: 30 * time.Second}
+			client := &http.Client{Transport: newLimitedTransport(sharedTransport, connLimiter), Timeout: 30 * time.Second}
 			resp, err := client.Do(req)
 			if err != nil {
 				reject(vm.ToValue(err.Error()))
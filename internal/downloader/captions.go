@@ -0,0 +1,286 @@
+package downloader
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// captionCue is a single timed caption, independent of the source format.
+type captionCue struct {
+	Start time.Duration
+	End   time.Duration
+	Text  string
+}
+
+var (
+	captionTagRegex      = regexp.MustCompile(`<[^>]*>`)
+	captionAssTagRegex   = regexp.MustCompile(`\{\\[^}]*\}`)
+	captionSpaceRegex    = regexp.MustCompile(`[ \t]+`)
+	vttTimingRegex       = regexp.MustCompile(`^((?:\d+:)?\d{2}:\d{2}[.,]\d{3})\s+-->\s+((?:\d+:)?\d{2}:\d{2}[.,]\d{3})`)
+	errUnknownCaptionFmt = errors.New("unrecognized caption format")
+)
+
+// convertCaptionsToSRT parses captions in any format YouTube serves (JSON3,
+// WebVTT, or timedtext XML) and renders them as SRT.
+func convertCaptionsToSRT(data []byte) (string, error) {
+	cues, err := parseCaptions(data)
+	if err != nil {
+		return "", err
+	}
+	return formatSRT(normalizeCues(cues)), nil
+}
+
+// convertCaptionsToVTT is the WebVTT counterpart of convertCaptionsToSRT.
+func convertCaptionsToVTT(data []byte) (string, error) {
+	cues, err := parseCaptions(data)
+	if err != nil {
+		return "", err
+	}
+	return formatVTT(normalizeCues(cues)), nil
+}
+
+// parseCaptions sniffs the caption format from the payload itself, since the
+// same track can come back as any of them depending on the endpoint.
+func parseCaptions(data []byte) ([]captionCue, error) {
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		return parseJSON3Captions(trimmed)
+	case bytes.HasPrefix(trimmed, []byte("WEBVTT")):
+		return parseVTTCaptions(trimmed)
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		return parseTimedTextCaptions(trimmed)
+	default:
+		return nil, errUnknownCaptionFmt
+	}
+}
+
+type json3Captions struct {
+	Events []struct {
+		StartMs    int64 `json:"tStartMs"`
+		DurationMs int64 `json:"dDurationMs"`
+		Segs       []struct {
+			UTF8 string `json:"utf8"`
+		} `json:"segs"`
+	} `json:"events"`
+}
+
+func parseJSON3Captions(data []byte) ([]captionCue, error) {
+	var payload json3Captions
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("parsing json3 captions: %w", err)
+	}
+	cues := make([]captionCue, 0, len(payload.Events))
+	for _, event := range payload.Events {
+		// Events without segments only define windows and pens.
+		if len(event.Segs) == 0 {
+			continue
+		}
+		var text strings.Builder
+		for _, seg := range event.Segs {
+			text.WriteString(seg.UTF8)
+		}
+		start := time.Duration(event.StartMs) * time.Millisecond
+		cues = append(cues, captionCue{
+			Start: start,
+			End:   start + time.Duration(event.DurationMs)*time.Millisecond,
+			Text:  text.String(),
+		})
+	}
+	return cues, nil
+}
+
+func parseVTTCaptions(data []byte) ([]captionCue, error) {
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	var cues []captionCue
+	for i := 0; i < len(lines); i++ {
+		match := vttTimingRegex.FindStringSubmatch(strings.TrimSpace(lines[i]))
+		if match == nil {
+			// Header, NOTE/STYLE blocks and cue identifiers carry no text.
+			continue
+		}
+		start, err := parseCaptionTimestamp(match[1])
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseCaptionTimestamp(match[2])
+		if err != nil {
+			return nil, err
+		}
+		var text []string
+		for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" {
+			i++
+			text = append(text, lines[i])
+		}
+		cues = append(cues, captionCue{Start: start, End: end, Text: strings.Join(text, "\n")})
+	}
+	if len(cues) == 0 && !strings.HasPrefix(strings.TrimSpace(string(data)), "WEBVTT") {
+		return nil, errUnknownCaptionFmt
+	}
+	return cues, nil
+}
+
+// timedTextCaptions covers both the legacy transcript XML (seconds as floats
+// on <text>) and srv3 (milliseconds on <p>).
+type timedTextCaptions struct {
+	Texts []struct {
+		Start string `xml:"start,attr"`
+		Dur   string `xml:"dur,attr"`
+		Inner string `xml:",innerxml"`
+	} `xml:"text"`
+	Paragraphs []struct {
+		T     int64  `xml:"t,attr"`
+		D     int64  `xml:"d,attr"`
+		Inner string `xml:",innerxml"`
+	} `xml:"body>p"`
+}
+
+func parseTimedTextCaptions(data []byte) ([]captionCue, error) {
+	var payload timedTextCaptions
+	if err := xml.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("parsing timedtext captions: %w", err)
+	}
+	var cues []captionCue
+	for _, text := range payload.Texts {
+		start, err := strconv.ParseFloat(text.Start, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parsing timedtext start %q: %w", text.Start, err)
+		}
+		dur, _ := strconv.ParseFloat(text.Dur, 64)
+		startDur := time.Duration(start * float64(time.Second))
+		cues = append(cues, captionCue{
+			Start: startDur,
+			End:   startDur + time.Duration(dur*float64(time.Second)),
+			Text:  text.Inner,
+		})
+	}
+	for _, p := range payload.Paragraphs {
+		start := time.Duration(p.T) * time.Millisecond
+		cues = append(cues, captionCue{
+			Start: start,
+			End:   start + time.Duration(p.D)*time.Millisecond,
+			Text:  p.Inner,
+		})
+	}
+	return cues, nil
+}
+
+// parseCaptionTimestamp parses "HH:MM:SS.mmm", "MM:SS.mmm", or the SRT comma form.
+func parseCaptionTimestamp(value string) (time.Duration, error) {
+	parts := strings.Split(strings.Replace(value, ",", ".", 1), ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid caption timestamp %q", value)
+	}
+	seconds, err := strconv.ParseFloat(parts[len(parts)-1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid caption timestamp %q", value)
+	}
+	total := time.Duration(seconds*1000+0.5) * time.Millisecond
+	units := []time.Duration{time.Minute, time.Hour}
+	for i := len(parts) - 2; i >= 0; i-- {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil {
+			return 0, fmt.Errorf("invalid caption timestamp %q", value)
+		}
+		total += time.Duration(n) * units[len(parts)-2-i]
+	}
+	return total, nil
+}
+
+// cleanCaptionText strips markup (VTT voice/class/timestamp tags, ASS-style
+// positioning overrides, XML spans), decodes entities and collapses blanks.
+func cleanCaptionText(text string) string {
+	text = captionTagRegex.ReplaceAllString(text, "")
+	text = captionAssTagRegex.ReplaceAllString(text, "")
+	// timedtext XML is frequently double-escaped (&amp;#39;).
+	text = html.UnescapeString(html.UnescapeString(text))
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		line = strings.TrimSpace(captionSpaceRegex.ReplaceAllString(line, " "))
+		if line != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// normalizeCues cleans cue text, drops empty cues, orders by start time and
+// resolves overlaps. Overlapping cues with the same text (or where one
+// extends the other, as rolling auto-captions do) are merged into one; other
+// overlaps are resolved by ending the earlier cue when the next one starts.
+func normalizeCues(cues []captionCue) []captionCue {
+	cleaned := make([]captionCue, 0, len(cues))
+	for _, cue := range cues {
+		cue.Text = cleanCaptionText(cue.Text)
+		if cue.Text == "" || cue.End <= cue.Start {
+			continue
+		}
+		cleaned = append(cleaned, cue)
+	}
+	sort.SliceStable(cleaned, func(i, j int) bool { return cleaned[i].Start < cleaned[j].Start })
+
+	merged := make([]captionCue, 0, len(cleaned))
+	for _, cue := range cleaned {
+		if len(merged) == 0 {
+			merged = append(merged, cue)
+			continue
+		}
+		prev := &merged[len(merged)-1]
+		if cue.Start >= prev.End {
+			merged = append(merged, cue)
+			continue
+		}
+		switch {
+		case cue.Text == prev.Text || strings.HasPrefix(cue.Text, prev.Text) || strings.HasPrefix(prev.Text, cue.Text):
+			if len(cue.Text) > len(prev.Text) {
+				prev.Text = cue.Text
+			}
+			if cue.End > prev.End {
+				prev.End = cue.End
+			}
+		default:
+			prev.End = cue.Start
+			if prev.End <= prev.Start {
+				merged[len(merged)-1] = cue
+				continue
+			}
+			merged = append(merged, cue)
+		}
+	}
+	return merged
+}
+
+func formatSRT(cues []captionCue) string {
+	var b strings.Builder
+	for i, cue := range cues {
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", i+1, formatCaptionTimestamp(cue.Start, ","), formatCaptionTimestamp(cue.End, ","), cue.Text)
+	}
+	return b.String()
+}
+
+func formatVTT(cues []captionCue) string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n\n")
+	for _, cue := range cues {
+		fmt.Fprintf(&b, "%s --> %s\n%s\n\n", formatCaptionTimestamp(cue.Start, "."), formatCaptionTimestamp(cue.End, "."), cue.Text)
+	}
+	return b.String()
+}
+
+func formatCaptionTimestamp(d time.Duration, sep string) string {
+	if d < 0 {
+		d = 0
+	}
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", ms/3_600_000, ms/60_000%60, ms/1000%60, sep, ms%1000)
+}
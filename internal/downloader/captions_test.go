@@ -0,0 +1,78 @@
+package downloader
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConvertJSON3CaptionsToSRT(t *testing.T) {
+	data := []byte(`{
+  "wireMagic": "pb3",
+  "events": [
+    {"tStartMs": 0, "dDurationMs": 3723000, "id": 1, "wpWinPosId": 1},
+    {"tStartMs": 1200, "dDurationMs": 2300, "segs": [{"utf8": "Hello"}, {"utf8": " world"}]},
+    {"tStartMs": 3500, "dDurationMs": 10, "aAppend": 1, "segs": [{"utf8": "\n"}]},
+    {"tStartMs": 3723004, "dDurationMs": 1500, "segs": [{"utf8": "Tom &amp; Jerry"}]}
+  ]
+}`)
+	got, err := convertCaptionsToSRT(data)
+	if err != nil {
+		t.Fatalf("convertCaptionsToSRT: %v", err)
+	}
+	want := "1\n00:00:01,200 --> 00:00:03,500\nHello world\n\n" +
+		"2\n01:02:03,004 --> 01:02:04,504\nTom & Jerry\n\n"
+	if got != want {
+		t.Fatalf("unexpected SRT:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestConvertVTTCaptionsStripsPositioning(t *testing.T) {
+	data := []byte("WEBVTT\nKind: captions\nLanguage: en\n\n" +
+		"00:00:01.000 --> 00:00:04.000 align:start position:0%\n" +
+		"<c.colorE5E5E5>so</c><00:00:01.500><c> today</c>\n\n" +
+		"00:00:02.000 --> 00:00:05.000 align:start position:0%\n" +
+		"so today we\n\n" +
+		"00:00:06.000 --> 00:00:07.000\n{\\an8}<i>Music</i>\n")
+	got, err := convertCaptionsToSRT(data)
+	if err != nil {
+		t.Fatalf("convertCaptionsToSRT: %v", err)
+	}
+	want := "1\n00:00:01,000 --> 00:00:05,000\nso today we\n\n" +
+		"2\n00:00:06,000 --> 00:00:07,000\nMusic\n\n"
+	if got != want {
+		t.Fatalf("unexpected SRT:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestConvertTimedTextXMLToVTT(t *testing.T) {
+	data := []byte(`<?xml version="1.0" encoding="utf-8" ?><transcript>` +
+		`<text start="0.5" dur="2">it&amp;#39;s here</text>` +
+		`<text start="2" dur="1.5">next line</text></transcript>`)
+	got, err := convertCaptionsToVTT(data)
+	if err != nil {
+		t.Fatalf("convertCaptionsToVTT: %v", err)
+	}
+	// The first cue overlaps the second with different text, so it is cut
+	// short where the second begins.
+	want := "WEBVTT\n\n00:00:00.500 --> 00:00:02.000\nit's here\n\n00:00:02.000 --> 00:00:03.500\nnext line\n\n"
+	if got != want {
+		t.Fatalf("unexpected VTT:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestParseCaptionTimestamp(t *testing.T) {
+	tests := map[string]time.Duration{
+		"00:00:01.200": 1200 * time.Millisecond,
+		"01:02:03,004": time.Hour + 2*time.Minute + 3*time.Second + 4*time.Millisecond,
+		"02:03.500":    2*time.Minute + 3500*time.Millisecond,
+	}
+	for in, want := range tests {
+		got, err := parseCaptionTimestamp(in)
+		if err != nil || got != want {
+			t.Fatalf("parseCaptionTimestamp(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := convertCaptionsToSRT([]byte("not captions")); err == nil {
+		t.Fatalf("expected error for unrecognized input")
+	}
+}
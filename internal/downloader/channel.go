@@ -0,0 +1,112 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	youtube "github.com/lvcoi/ytdl-lib/v2"
+)
+
+var (
+	channelIDRegex = regexp.MustCompile(`^UC[A-Za-z0-9_-]{22}$`)
+	// channelPageIDRegexes find the channel's own ID in its HTML page. The
+	// page mentions other channels too, so only fields naming the page owner
+	// are trusted.
+	channelPageIDRegexes = []*regexp.Regexp{
+		regexp.MustCompile(`"externalId":"(UC[A-Za-z0-9_-]{22})"`),
+		regexp.MustCompile(`<link rel="canonical" href="https://www\.youtube\.com/channel/(UC[A-Za-z0-9_-]{22})"`),
+		regexp.MustCompile(`<meta itemprop="identifier" content="(UC[A-Za-z0-9_-]{22})"`),
+	}
+)
+
+// channelRef identifies a channel from its URL. ID is set when the URL
+// carries it (/channel/UC...); otherwise PageURL is the channel page that
+// must be fetched to learn it (/@handle, /c/name, /user/name).
+type channelRef struct {
+	ID      string
+	PageURL string
+}
+
+// parseChannelURL recognizes YouTube channel URLs, including tab suffixes
+// such as /@handle/videos.
+func parseChannelURL(raw string) (channelRef, bool) {
+	parsed, err := url.Parse(raw)
+	if err != nil || normalizeHostname(parsed) != "youtube.com" {
+		return channelRef{}, false
+	}
+	parts := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	switch {
+	case strings.HasPrefix(parts[0], "@") && len(parts[0]) > 1:
+		return channelRef{PageURL: "https://www.youtube.com/" + parts[0]}, true
+	case parts[0] == "channel" && len(parts) >= 2 && channelIDRegex.MatchString(parts[1]):
+		return channelRef{ID: parts[1]}, true
+	case (parts[0] == "c" || parts[0] == "user") && len(parts) >= 2 && parts[1] != "":
+		return channelRef{PageURL: "https://www.youtube.com/" + parts[0] + "/" + url.PathEscape(parts[1])}, true
+	}
+	return channelRef{}, false
+}
+
+// uploadsPlaylistID maps a channel ID to its uploads playlist, which YouTube
+// derives by swapping the UC prefix for UU.
+func uploadsPlaylistID(channelID string) string {
+	return "UU" + strings.TrimPrefix(channelID, "UC")
+}
+
+// fetchChannelPage downloads a channel page's HTML. Tests replace it.
+var fetchChannelPage = func(ctx context.Context, pageURL string, timeout time.Duration) ([]byte, error) {
+	client := newMetadataHTTPClient(timeout)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", musicUserAgent)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, youtube.ErrUnexpectedStatusCode(resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// channelIDFromPage extracts the owning channel's ID from its HTML page.
+func channelIDFromPage(body []byte) (string, bool) {
+	for _, re := range channelPageIDRegexes {
+		if match := re.FindSubmatch(body); match != nil {
+			return string(match[1]), true
+		}
+	}
+	return "", false
+}
+
+// resolveChannelURL rewrites a channel URL to its uploads playlist URL so the
+// channel is processed like any other playlist. URLs that are not channel
+// URLs are returned unchanged.
+func resolveChannelURL(ctx context.Context, raw string, opts Options) (string, error) {
+	ref, ok := parseChannelURL(raw)
+	if !ok {
+		return raw, nil
+	}
+	channelID := ref.ID
+	if channelID == "" {
+		body, err := fetchChannelPage(ctx, ref.PageURL, opts.Timeout)
+		if err != nil {
+			return "", wrapAccessError(fmt.Errorf("fetching channel page: %w", err))
+		}
+		id, found := channelIDFromPage(body)
+		if !found {
+			return "", wrapCategory(CategoryUnsupported, errors.New("channel ID not found in channel page"))
+		}
+		channelID = id
+	}
+	return "https://www.youtube.com/playlist?list=" + uploadsPlaylistID(channelID), nil
+}
@@ -0,0 +1,107 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+const testChannelID = "UCuAXFkgsw1L7xaCfnd5JJOw"
+
+func stubChannelPage(t *testing.T, body string, err error) *[]string {
+	t.Helper()
+	var fetched []string
+	prev := fetchChannelPage
+	fetchChannelPage = func(_ context.Context, pageURL string, _ time.Duration) ([]byte, error) {
+		fetched = append(fetched, pageURL)
+		return []byte(body), err
+	}
+	t.Cleanup(func() { fetchChannelPage = prev })
+	return &fetched
+}
+
+func TestParseChannelURL(t *testing.T) {
+	cases := []struct {
+		raw  string
+		want channelRef
+		ok   bool
+	}{
+		{raw: "https://www.youtube.com/@SomeHandle", want: channelRef{PageURL: "https://www.youtube.com/@SomeHandle"}, ok: true},
+		{raw: "https://youtube.com/@SomeHandle/videos", want: channelRef{PageURL: "https://www.youtube.com/@SomeHandle"}, ok: true},
+		{raw: "https://www.youtube.com/channel/" + testChannelID, want: channelRef{ID: testChannelID}, ok: true},
+		{raw: "https://www.youtube.com/channel/" + testChannelID + "/videos", want: channelRef{ID: testChannelID}, ok: true},
+		{raw: "https://www.youtube.com/c/SomeName", want: channelRef{PageURL: "https://www.youtube.com/c/SomeName"}, ok: true},
+		{raw: "https://www.youtube.com/user/SomeName", want: channelRef{PageURL: "https://www.youtube.com/user/SomeName"}, ok: true},
+		{raw: "https://www.youtube.com/channel/not-an-id"},
+		{raw: "https://www.youtube.com/@"},
+		{raw: "https://www.youtube.com/watch?v=dQw4w9WgXcQ"},
+		{raw: "https://www.youtube.com/playlist?list=PLxA687tYuMWhkqYjvAGtW_heiEL4Hk_Lx"},
+		{raw: "https://example.com/@SomeHandle"},
+	}
+	for _, tc := range cases {
+		got, ok := parseChannelURL(tc.raw)
+		if ok != tc.ok || got != tc.want {
+			t.Errorf("parseChannelURL(%q) = %+v, %v; want %+v, %v", tc.raw, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestResolveChannelURLChannelIDSkipsFetch(t *testing.T) {
+	fetched := stubChannelPage(t, "", errors.New("should not fetch"))
+
+	got, err := resolveChannelURL(context.Background(), "https://www.youtube.com/channel/"+testChannelID, Options{})
+	if err != nil {
+		t.Fatalf("resolveChannelURL: %v", err)
+	}
+	if want := "https://www.youtube.com/playlist?list=UUuAXFkgsw1L7xaCfnd5JJOw"; got != want {
+		t.Fatalf("resolved URL = %q, want %q", got, want)
+	}
+	if len(*fetched) != 0 {
+		t.Fatalf("channel page fetched for a /channel/ URL: %v", *fetched)
+	}
+	if !shouldProcessAsPlaylist(got, Options{NoPlaylist: true}) {
+		t.Fatalf("expected resolved channel URL to be processed as a playlist")
+	}
+}
+
+func TestResolveChannelURLHandleReadsChannelPage(t *testing.T) {
+	page := `<html><head><link rel="canonical" href="https://www.youtube.com/channel/` + testChannelID + `">` +
+		`</head><body><script>var ytInitialData = {"channelId":"UCxxxxxxxxxxxxxxxxxxxxxx","externalId":"` + testChannelID + `"};</script></body></html>`
+	fetched := stubChannelPage(t, page, nil)
+
+	got, err := resolveChannelURL(context.Background(), "https://www.youtube.com/@SomeHandle/videos", Options{})
+	if err != nil {
+		t.Fatalf("resolveChannelURL: %v", err)
+	}
+	if want := "https://www.youtube.com/playlist?list=UUuAXFkgsw1L7xaCfnd5JJOw"; got != want {
+		t.Fatalf("resolved URL = %q, want %q", got, want)
+	}
+	if len(*fetched) != 1 || (*fetched)[0] != "https://www.youtube.com/@SomeHandle" {
+		t.Fatalf("fetched pages = %v, want the handle page", *fetched)
+	}
+}
+
+func TestResolveChannelURLErrors(t *testing.T) {
+	stubChannelPage(t, "<html>no id here</html>", nil)
+	_, err := resolveChannelURL(context.Background(), "https://www.youtube.com/@SomeHandle", Options{})
+	if errorCategory(err) != CategoryUnsupported {
+		t.Fatalf("expected unsupported error for page without channel ID, got %v", err)
+	}
+
+	fetchErr := errors.New("connection refused")
+	stubChannelPage(t, "", fetchErr)
+	_, err = resolveChannelURL(context.Background(), "https://www.youtube.com/user/SomeName", Options{})
+	if !errors.Is(err, fetchErr) {
+		t.Fatalf("expected fetch error, got %v", err)
+	}
+}
+
+func TestResolveChannelURLLeavesOtherURLs(t *testing.T) {
+	stubChannelPage(t, "", errors.New("should not fetch"))
+	const watch = "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+	got, err := resolveChannelURL(context.Background(), watch, Options{})
+	if err != nil || got != watch {
+		t.Fatalf("resolveChannelURL(%q) = %q, %v; want unchanged", watch, got, err)
+	}
+}
@@ -0,0 +1,113 @@
+package downloader
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// checksumSuffix names the sidecar written by -write-checksum.
+const checksumSuffix = ".sha256"
+
+// outputChecksum computes the SHA-256 of a download for -write-checksum. The
+// copy loop feeds it through Writer so the usual case needs no second read of
+// the output; when the file is rewritten afterwards (transcoding, tagging,
+// trimming) or was never streamed through it, Write hashes the file instead.
+// A nil *outputChecksum, returned when the flag is off, does nothing.
+type outputChecksum struct {
+	hash    hash.Hash
+	sum     string
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+func newOutputChecksum(opts Options) *outputChecksum {
+	if !opts.WriteChecksum {
+		return nil
+	}
+	return &outputChecksum{hash: sha256.New()}
+}
+
+// Writer returns w with the hash added as a second destination.
+func (c *outputChecksum) Writer(w io.Writer) io.Writer {
+	if c == nil {
+		return w
+	}
+	return io.MultiWriter(w, c.hash)
+}
+
+// Reset discards what was hashed so far, for a copy that starts over.
+func (c *outputChecksum) Reset() {
+	if c == nil {
+		return
+	}
+	c.hash.Reset()
+	c.path = ""
+}
+
+// Seal records that everything written to path went through Writer. Its size
+// and modification time are kept to tell later whether it was rewritten.
+func (c *outputChecksum) Seal(path string) {
+	if c == nil {
+		return
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	c.path, c.size, c.modTime = path, info.Size(), info.ModTime()
+}
+
+// Sum returns the hex digest from the last Write, or "" when there is none.
+func (c *outputChecksum) Sum() string {
+	if c == nil {
+		return ""
+	}
+	return c.sum
+}
+
+// Write computes the checksum of the finished file at outputPath and writes
+// it to <output>.sha256 in the format sha256sum -c reads.
+func (c *outputChecksum) Write(outputPath, baseDir string) error {
+	if c == nil || outputPath == "" {
+		return nil
+	}
+	sum, err := c.digest(outputPath)
+	if err != nil {
+		return wrapCategory(CategoryFilesystem, fmt.Errorf("computing checksum: %w", err))
+	}
+	path, err := artifactPath(outputPath, checksumSuffix, baseDir)
+	if err != nil {
+		return err
+	}
+	line := fmt.Sprintf("%s  %s\n", sum, filepath.Base(outputPath))
+	if err := os.WriteFile(path, []byte(line), 0o644); err != nil {
+		return wrapCategory(CategoryFilesystem, fmt.Errorf("writing checksum: %w", err))
+	}
+	c.sum = sum
+	return nil
+}
+
+func (c *outputChecksum) digest(outputPath string) (string, error) {
+	if c.path == outputPath {
+		if info, err := os.Stat(outputPath); err == nil && info.Size() == c.size && info.ModTime().Equal(c.modTime) {
+			return hex.EncodeToString(c.hash.Sum(nil)), nil
+		}
+	}
+	file, err := os.Open(outputPath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	h := sha256.New()
+	if _, err := copyBuffer(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,92 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestDownloadDirectFileWritesChecksum(t *testing.T) {
+	content := bytes.Repeat([]byte{0x00, 0x00, 0x00, 0x18, 'f', 't', 'y', 'p'}, 2048)
+	srv := httptest.NewServer(&directResumeServer{content: content, ranged: true})
+	defer srv.Close()
+
+	dir := t.TempDir()
+	opts := Options{OutputTemplate: "{title}.{ext}", OutputDir: dir, Quiet: true, WriteChecksum: true}
+	info := directInfo{URL: srv.URL + "/clip.bin", Kind: "file", Title: "clip", ID: "clip", Ext: "bin"}
+	result, err := downloadDirectFile(context.Background(), info, opts, newPrinter(opts, nil))
+	if err != nil {
+		t.Fatalf("download failed: %v", err)
+	}
+
+	data, err := os.ReadFile(result.outputPath)
+	if err != nil {
+		t.Fatalf("read output: %v", err)
+	}
+	want := sha256Hex(data)
+	if result.sha256 != want {
+		t.Fatalf("result checksum = %q, want %q", result.sha256, want)
+	}
+	line, err := os.ReadFile(result.outputPath + checksumSuffix)
+	if err != nil {
+		t.Fatalf("read checksum sidecar: %v", err)
+	}
+	if got := string(line); got != want+"  clip.bin\n" {
+		t.Fatalf("checksum sidecar = %q, want the sha256sum line for clip.bin", got)
+	}
+
+	raw, err := os.ReadFile(filepath.Join(dir, "clip.bin.json"))
+	if err != nil {
+		t.Fatalf("read metadata sidecar: %v", err)
+	}
+	var metadata ItemMetadata
+	if err := json.Unmarshal(raw, &metadata); err != nil {
+		t.Fatalf("parse metadata sidecar: %v", err)
+	}
+	if metadata.SHA256 != want {
+		t.Fatalf("metadata sidecar sha256 = %q, want %q", metadata.SHA256, want)
+	}
+}
+
+func TestOutputChecksumRehashesRewrittenFile(t *testing.T) {
+	dir := t.TempDir()
+	outputPath := filepath.Join(dir, "song.m4a")
+	file, err := os.Create(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checksum := newOutputChecksum(Options{WriteChecksum: true})
+	if _, err := checksum.Writer(file).Write([]byte("streamed audio")); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	checksum.Seal(outputPath)
+
+	// Embedding tags after the copy rewrites the file.
+	rewritten := []byte("streamed audio with embedded tags")
+	if err := os.WriteFile(outputPath, rewritten, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := checksum.Write(outputPath, dir); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if got, want := checksum.Sum(), sha256Hex(rewritten); got != want {
+		t.Fatalf("checksum = %q, want the hash of the rewritten file %q", got, want)
+	}
+
+	disabled := newOutputChecksum(Options{})
+	if err := disabled.Write(outputPath, dir); err != nil || disabled.Sum() != "" {
+		t.Fatalf("expected a disabled checksum to do nothing, got %q, %v", disabled.Sum(), err)
+	}
+}
@@ -0,0 +1,298 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	youtube "github.com/lvcoi/ytdl-lib/v2"
+)
+
+const (
+	// adaptiveChunkMax caps how far sustained success grows a chunk. It is
+	// above maxChunkSize because only long, healthy downloads get there.
+	adaptiveChunkMax int64 = 8 * 1024 * 1024
+	// adaptiveChunkGrowAfter is how many chunks in a row must succeed before
+	// the chunk size doubles.
+	adaptiveChunkGrowAfter = 4
+	// adaptiveChunkWorkers is how many chunks are fetched at once.
+	adaptiveChunkWorkers = 4
+	// adaptiveChunkRetries is how many times one chunk is attempted.
+	adaptiveChunkRetries = 3
+)
+
+// chunkSizer adapts the chunk size of a progressive download to how the
+// server copes with it. It starts small so progress shows up early, doubles
+// after a run of successful chunks to cut per-request overhead on long
+// downloads, and halves whenever a chunk is refused (HTTP 403) or times out.
+type chunkSizer struct {
+	mu        sync.Mutex
+	min       int64
+	max       int64
+	current   int64
+	growAfter int
+	streak    int
+}
+
+func newChunkSizer(initial, min, max int64) *chunkSizer {
+	if initial < min {
+		initial = min
+	} else if initial > max {
+		initial = max
+	}
+	return &chunkSizer{
+		min:       min,
+		max:       max,
+		current:   initial,
+		growAfter: adaptiveChunkGrowAfter,
+	}
+}
+
+// Size reports the chunk size to request next.
+func (s *chunkSizer) Size() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.current
+}
+
+// Success records a chunk that arrived intact.
+func (s *chunkSizer) Success() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.streak++
+	if s.streak < s.growAfter {
+		return
+	}
+	s.streak = 0
+	s.current *= 2
+	if s.current > s.max {
+		s.current = s.max
+	}
+}
+
+// Shrink records a chunk the server refused or that timed out.
+func (s *chunkSizer) Shrink() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.streak = 0
+	s.current /= 2
+	if s.current < s.min {
+		s.current = s.min
+	}
+}
+
+// chunkSizeFor picks the starting chunk size for a stream of contentLength
+// bytes: small enough to keep progress updates frequent without spawning
+// thousands of requests.
+func chunkSizeFor(contentLength int64) int64 {
+	chunk := contentLength / targetChunkCount
+	if chunk < minChunkSize {
+		chunk = minChunkSize
+	} else if chunk > maxChunkSize {
+		chunk = maxChunkSize
+	}
+	return chunk
+}
+
+// openProgressiveStream starts downloading format. Streams of known length
+// are fetched in adaptively sized chunks from offset; the rest go through the
+// client's single-request download, which always starts at the first byte.
+func openProgressiveStream(ctx context.Context, client YouTubeClient, video *youtube.Video, format *youtube.Format, offset int64) (io.ReadCloser, int64, error) {
+	if format.ContentLength <= 0 {
+		return client.GetStreamContext(ctx, video, format)
+	}
+	streamURL, err := client.GetStreamURLContext(ctx, video, format)
+	if err != nil {
+		return nil, 0, err
+	}
+	sizer := newChunkSizer(chunkSizeFor(format.ContentLength), minChunkSize, adaptiveChunkMax)
+	return openAdaptiveStream(ctx, client.HTTP(), streamURL, offset, format.ContentLength, sizer), format.ContentLength, nil
+}
+
+type adaptiveChunk struct {
+	start int64
+	end   int64
+	done  chan adaptiveChunkResult
+}
+
+type adaptiveChunkResult struct {
+	data []byte
+	err  error
+}
+
+// adaptiveStream is the read side of an adaptive chunked download. Closing it
+// stops the download.
+type adaptiveStream struct {
+	*io.PipeReader
+	cancel context.CancelFunc
+}
+
+func (s adaptiveStream) Close() error {
+	s.cancel()
+	return s.PipeReader.Close()
+}
+
+// openAdaptiveStream downloads bytes offset..contentLength-1 of streamURL as
+// ranged chunks sized by sizer. Chunks are fetched by a few workers and delivered
+// in order; each chunk's size is taken from sizer when it is handed out, so
+// feedback from finished chunks shapes the ones that follow.
+func openAdaptiveStream(ctx context.Context, doer HTTPDoer, streamURL string, offset, contentLength int64, sizer *chunkSizer) io.ReadCloser {
+	ctx, cancel := context.WithCancel(ctx)
+	r, w := io.Pipe()
+	jobs := make(chan *adaptiveChunk)
+	// pending holds dispatched chunks in stream order and bounds how far the
+	// workers run ahead of the reader.
+	pending := make(chan *adaptiveChunk, adaptiveChunkWorkers)
+
+	go func() {
+		defer close(jobs)
+		defer close(pending)
+		for start := offset; start < contentLength; {
+			end := start + sizer.Size() - 1
+			if end >= contentLength {
+				end = contentLength - 1
+			}
+			chunk := &adaptiveChunk{start: start, end: end, done: make(chan adaptiveChunkResult, 1)}
+			select {
+			case pending <- chunk:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- chunk:
+			case <-ctx.Done():
+				return
+			}
+			start = end + 1
+		}
+	}()
+
+	for i := 0; i < adaptiveChunkWorkers; i++ {
+		go func() {
+			for chunk := range jobs {
+				data, err := fetchAdaptiveChunk(ctx, doer, streamURL, chunk.start, chunk.end, sizer)
+				chunk.done <- adaptiveChunkResult{data: data, err: err}
+			}
+		}()
+	}
+
+	go func() {
+		defer cancel()
+		for chunk := range pending {
+			var result adaptiveChunkResult
+			select {
+			case result = <-chunk.done:
+			case <-ctx.Done():
+				w.CloseWithError(ctx.Err())
+				return
+			}
+			if result.err != nil {
+				w.CloseWithError(result.err)
+				return
+			}
+			if _, err := w.Write(result.data); err != nil {
+				return
+			}
+		}
+		// The dispatcher also stops early on cancellation; don't let that
+		// pass for a complete stream.
+		if err := ctx.Err(); err != nil {
+			w.CloseWithError(err)
+			return
+		}
+		w.Close()
+	}()
+
+	return adaptiveStream{PipeReader: r, cancel: cancel}
+}
+
+// fetchAdaptiveChunk downloads bytes start..end (inclusive). A refused or
+// timed-out request shrinks the chunk size and the remainder is fetched in
+// smaller pieces, so a range the server will not serve at the current size is
+// split rather than retried as is.
+func fetchAdaptiveChunk(ctx context.Context, doer HTTPDoer, streamURL string, start, end int64, sizer *chunkSizer) ([]byte, error) {
+	data := make([]byte, 0, end-start+1)
+	attempt := 0
+	for offset := start; offset <= end; {
+		pieceEnd := offset + sizer.Size() - 1
+		if pieceEnd > end {
+			pieceEnd = end
+		}
+		piece, err := fetchChunkRange(ctx, doer, streamURL, offset, pieceEnd)
+		if err == nil {
+			sizer.Success()
+			data = append(data, piece...)
+			offset = pieceEnd + 1
+			attempt = 0
+			continue
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		attempt++
+		if attempt >= adaptiveChunkRetries {
+			return nil, fmt.Errorf("chunk at offset %d failed after %d attempts: %w", offset, attempt, err)
+		}
+		if isChunkThrottle(ctx, err) {
+			sizer.Shrink()
+		}
+		if err := sleepWithContext(ctx, time.Duration(attempt)*300*time.Millisecond); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// fetchChunkRange requests one byte range using the range query parameter
+// googlevideo URLs accept.
+func fetchChunkRange(ctx context.Context, doer HTTPDoer, streamURL string, start, end int64) ([]byte, error) {
+	parsed, err := url.Parse(streamURL)
+	if err != nil {
+		return nil, err
+	}
+	query := parsed.Query()
+	query.Set("range", fmt.Sprintf("%d-%d", start, end))
+	parsed.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, parsed.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := doer.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		return nil, youtube.ErrUnexpectedStatusCode(resp.StatusCode)
+	}
+
+	expected := end - start + 1
+	if resp.ContentLength >= 0 && resp.ContentLength != expected {
+		return nil, fmt.Errorf("chunk at offset %d has invalid size: expected=%d actual=%d", start, expected, resp.ContentLength)
+	}
+	data := make([]byte, expected)
+	if _, err := io.ReadFull(resp.Body, data); err != nil {
+		return nil, fmt.Errorf("chunk at offset %d: %w", start, err)
+	}
+	return data, nil
+}
+
+// isChunkThrottle reports whether a chunk failure suggests the chunk was too
+// large: a 403 refusal or a request timeout.
+func isChunkThrottle(ctx context.Context, err error) bool {
+	if isUnexpectedStatus(err, http.StatusForbidden) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil
+}
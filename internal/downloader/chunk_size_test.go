@@ -0,0 +1,144 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestChunkSizerAdaptsToFeedback(t *testing.T) {
+	sizer := newChunkSizer(64, 16, 256)
+
+	// Sustained success doubles the size after every run of successes.
+	for i := 0; i < adaptiveChunkGrowAfter-1; i++ {
+		sizer.Success()
+	}
+	if got := sizer.Size(); got != 64 {
+		t.Fatalf("expected size to hold at 64 before a full run of successes, got %d", got)
+	}
+	sizer.Success()
+	if got := sizer.Size(); got != 128 {
+		t.Fatalf("expected size to double to 128, got %d", got)
+	}
+	for i := 0; i < 4*adaptiveChunkGrowAfter; i++ {
+		sizer.Success()
+	}
+	if got := sizer.Size(); got != 256 {
+		t.Fatalf("expected size to stop at the 256 ceiling, got %d", got)
+	}
+
+	// A refusal halves it and restarts the run.
+	sizer.Shrink()
+	if got := sizer.Size(); got != 128 {
+		t.Fatalf("expected 403 to halve size to 128, got %d", got)
+	}
+	for i := 0; i < adaptiveChunkGrowAfter-1; i++ {
+		sizer.Success()
+	}
+	sizer.Shrink()
+	if got := sizer.Size(); got != 64 {
+		t.Fatalf("expected a shrink to discard the partial run, got %d", got)
+	}
+	for i := 0; i < 10; i++ {
+		sizer.Shrink()
+	}
+	if got := sizer.Size(); got != 16 {
+		t.Fatalf("expected size to stop at the 16 floor, got %d", got)
+	}
+}
+
+func TestChunkSizeForBounds(t *testing.T) {
+	cases := []struct {
+		length int64
+		want   int64
+	}{
+		{length: 1024, want: minChunkSize},
+		{length: targetChunkCount * 512 * 1024, want: 512 * 1024},
+		{length: 1 << 40, want: maxChunkSize},
+	}
+	for _, tc := range cases {
+		if got := chunkSizeFor(tc.length); got != tc.want {
+			t.Errorf("chunkSizeFor(%d) = %d, want %d", tc.length, got, tc.want)
+		}
+	}
+}
+
+func TestAdaptiveStreamGrowsChunksAndRecoversFrom403(t *testing.T) {
+	content := make([]byte, 8192)
+	for i := range content {
+		content[i] = byte(i % 251)
+	}
+
+	var mu sync.Mutex
+	var sizes []int64
+	refused := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var start, end int64
+		if _, err := fmt.Sscanf(r.URL.Query().Get("range"), "%d-%d", &start, &end); err != nil {
+			http.Error(w, "missing range", http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		first := !refused
+		refused = true
+		sizes = append(sizes, end-start+1)
+		mu.Unlock()
+		if first {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write(content[start : end+1])
+	}))
+	defer srv.Close()
+
+	sizer := newChunkSizer(64, 16, 512)
+	stream := openAdaptiveStream(context.Background(), srv.Client(), srv.URL+"/videoplayback?id=1", 0, int64(len(content)), sizer)
+	defer stream.Close()
+
+	got, err := io.ReadAll(stream)
+	if err != nil {
+		t.Fatalf("reading adaptive stream: %v", err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Fatalf("stream content mismatch: got %d bytes, want %d", len(got), len(content))
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	var largest int64
+	for _, size := range sizes {
+		if size > largest {
+			largest = size
+		}
+	}
+	if largest <= 64 {
+		t.Fatalf("expected chunk size to grow past the initial 64 bytes, requested sizes %v", sizes)
+	}
+	if largest > 512 {
+		t.Fatalf("chunk size exceeded the 512 byte ceiling: %v", sizes)
+	}
+}
+
+func TestAdaptiveStreamReportsPersistent403(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	sizer := newChunkSizer(64, 16, 512)
+	stream := openAdaptiveStream(context.Background(), srv.Client(), srv.URL, 0, 256, sizer)
+	defer stream.Close()
+
+	_, err := io.ReadAll(stream)
+	if !isUnexpectedStatus(err, http.StatusForbidden) {
+		t.Fatalf("expected a 403 error for the single-request fallback, got %v", err)
+	}
+	if got := sizer.Size(); got >= 64 {
+		t.Fatalf("expected repeated 403s to shrink the chunk size, got %d", got)
+	}
+}
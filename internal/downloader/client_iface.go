@@ -21,6 +21,7 @@ type YouTubeClient interface {
 	GetVideoContext(ctx context.Context, url string) (*youtube.Video, error)
 	GetPlaylistContext(ctx context.Context, url string) (*youtube.Playlist, error)
 	GetStreamContext(ctx context.Context, video *youtube.Video, format *youtube.Format) (io.ReadCloser, int64, error)
+	GetStreamURLContext(ctx context.Context, video *youtube.Video, format *youtube.Format) (string, error)
 	VideoFromPlaylistEntryContext(ctx context.Context, entry *youtube.PlaylistEntry) (*youtube.Video, error)
 	// HTTP returns the underlying HTTP client for raw requests (manifests, segments).
 	HTTP() HTTPDoer
@@ -35,11 +36,37 @@ type YouTubeClient interface {
 // accessor methods for HTTPClient and ChunkSize fields.
 type youtubeClientAdapter struct {
 	*youtube.Client
+	// media, when set, fetches streams, manifests and segments while the
+	// embedded client makes the metadata calls (--metadata-proxy).
+	media *youtube.Client
 }
 
-func (a *youtubeClientAdapter) HTTP() HTTPDoer                      { return a.Client.HTTPClient }
-func (a *youtubeClientAdapter) SetChunkSize(s int64)                { a.Client.ChunkSize = s }
-func (a *youtubeClientAdapter) SetClientInfo(ci youtube.ClientInfo) { a.Client.ClientType = &ci }
+func (a *youtubeClientAdapter) mediaClient() *youtube.Client {
+	if a.media != nil {
+		return a.media
+	}
+	return a.Client
+}
+
+func (a *youtubeClientAdapter) HTTP() HTTPDoer { return a.mediaClient().HTTPClient }
+
+func (a *youtubeClientAdapter) SetChunkSize(s int64) {
+	a.Client.ChunkSize = s
+	a.mediaClient().ChunkSize = s
+}
+
+func (a *youtubeClientAdapter) SetClientInfo(ci youtube.ClientInfo) {
+	a.Client.ClientType = &ci
+	a.mediaClient().ClientType = &ci
+}
+
+func (a *youtubeClientAdapter) GetStreamContext(ctx context.Context, video *youtube.Video, format *youtube.Format) (io.ReadCloser, int64, error) {
+	return a.mediaClient().GetStreamContext(ctx, video, format)
+}
+
+func (a *youtubeClientAdapter) GetStreamURLContext(ctx context.Context, video *youtube.Video, format *youtube.Format) (string, error) {
+	return a.mediaClient().GetStreamURLContext(ctx, video, format)
+}
 
 // Compile-time check: *youtubeClientAdapter must implement YouTubeClient.
 var _ YouTubeClient = (*youtubeClientAdapter)(nil)
@@ -15,6 +15,7 @@ type mockYouTubeClient struct {
 	getVideoFn    func(ctx context.Context, url string) (*youtube.Video, error)
 	getPlaylistFn func(ctx context.Context, url string) (*youtube.Playlist, error)
 	getStreamFn   func(ctx context.Context, video *youtube.Video, format *youtube.Format) (io.ReadCloser, int64, error)
+	streamURLFn   func(ctx context.Context, video *youtube.Video, format *youtube.Format) (string, error)
 	videoFromFn   func(ctx context.Context, entry *youtube.PlaylistEntry) (*youtube.Video, error)
 	httpDoer      HTTPDoer
 	chunkSize     int64
@@ -41,6 +42,13 @@ func (m *mockYouTubeClient) GetStreamContext(ctx context.Context, video *youtube
 	return io.NopCloser(&io.LimitedReader{}), 0, nil
 }
 
+func (m *mockYouTubeClient) GetStreamURLContext(ctx context.Context, video *youtube.Video, format *youtube.Format) (string, error) {
+	if m.streamURLFn != nil {
+		return m.streamURLFn(ctx, video, format)
+	}
+	return format.URL, nil
+}
+
 func (m *mockYouTubeClient) VideoFromPlaylistEntryContext(ctx context.Context, entry *youtube.PlaylistEntry) (*youtube.Video, error) {
 	if m.videoFromFn != nil {
 		return m.videoFromFn(ctx, entry)
@@ -58,7 +66,7 @@ var _ YouTubeClient = (*mockYouTubeClient)(nil)
 
 func TestYouTubeClientAdapter_HTTP(t *testing.T) {
 	httpClient := &http.Client{Timeout: 5 * time.Second}
-	adapter := &youtubeClientAdapter{&youtube.Client{HTTPClient: httpClient}}
+	adapter := &youtubeClientAdapter{Client: &youtube.Client{HTTPClient: httpClient}}
 
 	doer := adapter.HTTP()
 	if doer == nil {
@@ -70,7 +78,7 @@ func TestYouTubeClientAdapter_HTTP(t *testing.T) {
 }
 
 func TestYouTubeClientAdapter_SetChunkSize(t *testing.T) {
-	adapter := &youtubeClientAdapter{&youtube.Client{}}
+	adapter := &youtubeClientAdapter{Client: &youtube.Client{}}
 
 	adapter.SetChunkSize(1024 * 1024)
 	if adapter.Client.ChunkSize != 1024*1024 {
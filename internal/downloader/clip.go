@@ -0,0 +1,59 @@
+package downloader
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// trimToStartOffset cuts everything before offset from outputPath in place.
+// Streams are copied rather than re-encoded, so the cut lands on the nearest
+// keyframe. Without ffmpeg the full download is kept and a warning is logged;
+// the returned bool reports whether the file was actually trimmed.
+func trimToStartOffset(outputPath, baseDir string, offset time.Duration, printer *Printer) (bool, error) {
+	if offset <= 0 || outputPath == "" {
+		return false, nil
+	}
+	if !ffmpegAvailable() {
+		printer.Log(LogWarn, "warning: --respect-timestamps skipped: ffmpeg not found")
+		return false, nil
+	}
+
+	ext := filepath.Ext(outputPath)
+	tmpPath, err := artifactPath(outputPath, ".trim"+ext, baseDir)
+	if err != nil {
+		return false, err
+	}
+	printer.Log(LogInfo, fmt.Sprintf("trimming to start at %s", offset))
+	cmd := exec.Command(ffmpegPath, trimArgs(outputPath, tmpPath, offset)...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		os.Remove(tmpPath)
+		stderr := strings.TrimSpace(string(output))
+		if stderr != "" {
+			return false, wrapCategory(CategoryFilesystem, fmt.Errorf("trimming to start timestamp: %s: %w", stderr, err))
+		}
+		return false, wrapCategory(CategoryFilesystem, fmt.Errorf("trimming to start timestamp: %w", err))
+	}
+	if err := os.Rename(tmpPath, outputPath); err != nil {
+		os.Remove(tmpPath)
+		return false, wrapCategory(CategoryFilesystem, fmt.Errorf("replacing trimmed output: %w", err))
+	}
+	return true, nil
+}
+
+func trimArgs(inputPath, outputPath string, offset time.Duration) []string {
+	return []string{
+		"-hide_banner", "-loglevel", "error",
+		"-ss", strconv.FormatFloat(offset.Seconds(), 'f', 3, 64),
+		"-i", inputPath,
+		"-map", "0",
+		"-c", "copy",
+		"-avoid_negative_ts", "make_zero",
+		"-y", outputPath,
+	}
+}
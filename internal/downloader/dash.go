@@ -6,6 +6,7 @@ import (
 	"encoding/xml"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
@@ -91,6 +92,11 @@ func downloadDASH(ctx context.Context, client YouTubeClient, video *youtube.Vide
 	}
 
 	format := dashFormatFromRepresentation(selected, opts.Quality)
+	baseDir, err := routedOutputDir(opts, format)
+	if err != nil {
+		return downloadResult{}, wrapCategory(CategoryFilesystem, err)
+	}
+	opts.OutputDir = baseDir
 	outputPath, err := resolveOutputPath(opts.OutputTemplate, video, format, ctxInfo, opts.OutputDir)
 	if err != nil {
 		return downloadResult{}, wrapCategory(CategoryFilesystem, err)
@@ -103,7 +109,7 @@ func downloadDASH(ctx context.Context, client YouTubeClient, video *youtube.Vide
 		return downloadResult{skipped: true, outputPath: outputPath}, nil
 	}
 
-	result, err := downloadDASHSegments(ctx, client, selected, outputPath, opts.OutputDir, opts, printer, prefix)
+	result, err := downloadDASHSegments(ctx, client, video.ID, selected, outputPath, opts.OutputDir, opts, printer, prefix)
 	result.format = format
 	if err == nil {
 		result.outputPath = outputPath
@@ -321,7 +327,11 @@ type dashResumeState struct {
 	InitDone     bool   `json:"init_done"`
 }
 
-func downloadDASHSegments(ctx context.Context, client YouTubeClient, rep dashRepresentation, outputPath, baseDir string, opts Options, printer *Printer, prefix string) (downloadResult, error) {
+// downloadDASHSegments fetches rep's segments into outputPath, resuming from
+// a previous attempt's state when one matches. If a segment is refused with
+// 403 on the sequential path, the representation is re-fetched for videoID
+// once per segment in case its URLs expired.
+func downloadDASHSegments(ctx context.Context, client YouTubeClient, videoID string, rep dashRepresentation, outputPath, baseDir string, opts Options, printer *Printer, prefix string) (result downloadResult, err error) {
 	partPath, err := artifactPath(outputPath, partSuffix, baseDir)
 	if err != nil {
 		return downloadResult{}, err
@@ -330,6 +340,11 @@ func downloadDASHSegments(ctx context.Context, client YouTubeClient, rep dashRep
 	if err != nil {
 		return downloadResult{}, err
 	}
+	defer func() {
+		if err != nil {
+			cleanupFailedArtifacts(ctx, opts, partPath, resumePath)
+		}
+	}()
 	segmentDir, err := artifactPath(outputPath, ".segments", baseDir)
 	if err != nil {
 		return downloadResult{}, err
@@ -342,6 +357,9 @@ func downloadDASHSegments(ctx context.Context, client YouTubeClient, rep dashRep
 		BytesWritten: 0,
 		InitDone:     false,
 	}
+	if err := discardResumeArtifacts(opts, partPath, resumePath); err != nil {
+		return downloadResult{}, err
+	}
 	if loaded, err := loadDASHResume(resumePath); err == nil && loaded.SegmentCount == len(rep.Segments) {
 		state = loaded
 	}
@@ -364,12 +382,15 @@ func downloadDASHSegments(ctx context.Context, client YouTubeClient, rep dashRep
 			}
 		}
 		plan := segmentDownloadPlan{
-			URLs:        rep.Segments,
-			TempDir:     tempDir,
-			Prefix:      prefix,
-			Concurrency: opts.SegmentConcurrency,
+			URLs:            rep.Segments,
+			TempDir:         tempDir,
+			Prefix:          prefix,
+			OutputPath:      outputPath,
+			Concurrency:     opts.SegmentConcurrency,
+			AutoConcurrency: opts.AutoConcurrency,
+			SkipUnavailable: opts.SkipUnavailableFragments,
 		}
-		total, err := downloadSegmentsParallel(ctx, client, plan, file, printer)
+		total, skipped, err := downloadSegmentsParallel(ctx, client, plan, file, printer)
 		if err != nil {
 			return downloadResult{}, err
 		}
@@ -382,8 +403,7 @@ func downloadDASHSegments(ctx context.Context, client YouTubeClient, rep dashRep
 		if err := validateOutputFile(outputPath, nil); err != nil {
 			return downloadResult{}, err
 		}
-		_ = os.RemoveAll(segmentDir)
-		return downloadResult{bytes: total, outputPath: outputPath}, nil
+		return downloadResult{bytes: total, outputPath: outputPath, skippedFragments: skipped}, nil
 	}
 
 	file, err := os.OpenFile(partPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
@@ -394,9 +414,10 @@ func downloadDASHSegments(ctx context.Context, client YouTubeClient, rep dashRep
 
 	var writer io.Writer = file
 	var progress *progressWriter
-	if !opts.Quiet {
-		progress = newProgressWriter(0, printer, prefix)
+	if !opts.Quiet || opts.Renderer != nil {
+		progress = newProgressWriter(0, printer, prefix, outputPath)
 		progress.total.Store(state.BytesWritten)
+		progress.EstimateFromSegments(state.NextIndex, len(rep.Segments))
 		writer = io.MultiWriter(file, progress)
 	}
 
@@ -413,16 +434,36 @@ func downloadDASHSegments(ctx context.Context, client YouTubeClient, rep dashRep
 		}
 	}
 
+	refreshedAt := -1
+	skipped := 0
 	for idx := state.NextIndex; idx < len(rep.Segments); idx++ {
-		if err := downloadSegmentWithRetry(ctx, client, rep.Segments[idx], writer); err != nil {
-			if progress != nil {
-				progress.NewLine()
+		err := fetchSegment(ctx, client, rep.Segments[idx], writer, opts.SkipUnavailableFragments)
+		if err != nil && isUnexpectedStatus(err, http.StatusForbidden) && videoID != "" && refreshedAt != idx {
+			refreshedAt = idx
+			fresh, refreshErr := refreshDASHRepresentation(ctx, client, videoID, rep)
+			if refreshErr == nil {
+				if printer != nil {
+					printer.Log(LogWarn, fmt.Sprintf("%s segment %d refused (403); continuing with refreshed segment URLs", prefix, idx+1))
+				}
+				rep = fresh
+				err = fetchSegment(ctx, client, rep.Segments[idx], writer, opts.SkipUnavailableFragments)
+			} else if printer != nil {
+				printer.Log(LogDebug, fmt.Sprintf("refreshing DASH segment URLs failed: %v", refreshErr))
+			}
+		}
+		if err != nil {
+			if !skipUnavailableSegment(ctx, opts.SkipUnavailableFragments, err, idx, printer, prefix) {
+				if progress != nil {
+					progress.NewLine()
+				}
+				return downloadResult{}, wrapCategory(CategoryNetwork, fmt.Errorf("segment %d failed: %w", idx+1, err))
 			}
-			return downloadResult{}, wrapCategory(CategoryNetwork, fmt.Errorf("segment %d failed: %w", idx+1, err))
+			skipped++
 		}
 		state.NextIndex = idx + 1
 		if progress != nil {
 			state.BytesWritten = progress.total.Load()
+			progress.EstimateFromSegments(state.NextIndex, len(rep.Segments))
 		}
 		if err := saveDASHResume(resumePath, state); err != nil {
 			return downloadResult{}, err
@@ -444,7 +485,7 @@ func downloadDASHSegments(ctx context.Context, client YouTubeClient, rep dashRep
 	}
 	_ = os.Remove(resumePath)
 
-	return downloadResult{bytes: state.BytesWritten, outputPath: outputPath}, nil
+	return downloadResult{bytes: state.BytesWritten, outputPath: outputPath, skippedFragments: skipped}, nil
 }
 
 func loadDASHResume(path string) (dashResumeState, error) {
@@ -0,0 +1,41 @@
+package downloader
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// dateFilterLayout is the YYYYMMDD form taken by -dateafter and -datebefore.
+const dateFilterLayout = "20060102"
+
+// ParseDate parses a -dateafter or -datebefore value such as "20240131".
+func ParseDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, errors.New("date is empty")
+	}
+	date, err := time.Parse(dateFilterLayout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected YYYYMMDD, got %q", value)
+	}
+	return date, nil
+}
+
+// dateWindowSkipReason returns why a video published on published falls
+// outside the -dateafter/-datebefore window, or "" when it is inside it. Both
+// bounds are inclusive. A video with no known publish date is kept.
+func dateWindowSkipReason(published time.Time, opts Options) string {
+	if published.IsZero() || (opts.DateAfter.IsZero() && opts.DateBefore.IsZero()) {
+		return ""
+	}
+	day := time.Date(published.Year(), published.Month(), published.Day(), 0, 0, 0, 0, time.UTC)
+	switch {
+	case !opts.DateAfter.IsZero() && day.Before(opts.DateAfter):
+		return fmt.Sprintf("published %s, before -dateafter", day.Format(dateFilterLayout))
+	case !opts.DateBefore.IsZero() && day.After(opts.DateBefore):
+		return fmt.Sprintf("published %s, after -datebefore", day.Format(dateFilterLayout))
+	}
+	return ""
+}
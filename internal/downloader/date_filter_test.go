@@ -0,0 +1,48 @@
+package downloader
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	got, err := ParseDate("20240131")
+	if err != nil || !got.Equal(time.Date(2024, time.January, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("ParseDate(20240131) = %v, %v", got, err)
+	}
+	for _, bad := range []string{"", "2024-01-31", "20241301", "yesterday"} {
+		if _, err := ParseDate(bad); err == nil {
+			t.Errorf("ParseDate(%q) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestDateWindowSkipReason(t *testing.T) {
+	after, _ := ParseDate("20240110")
+	before, _ := ParseDate("20240120")
+	opts := Options{DateAfter: after, DateBefore: before}
+	est := time.FixedZone("EST", -5*60*60)
+
+	tests := []struct {
+		name      string
+		published time.Time
+		opts      Options
+		want      string
+	}{
+		{"inside", time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC), opts, ""},
+		{"on dateafter", time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC), opts, ""},
+		{"on datebefore late in the day", time.Date(2024, 1, 20, 23, 0, 0, 0, est), opts, ""},
+		{"before the window", time.Date(2024, 1, 9, 23, 59, 0, 0, time.UTC), opts, "before -dateafter"},
+		{"after the window", time.Date(2024, 1, 21, 0, 0, 0, 0, time.UTC), opts, "after -datebefore"},
+		{"unknown date", time.Time{}, opts, ""},
+		{"only dateafter", time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), Options{DateAfter: after}, ""},
+		{"only datebefore", time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), Options{DateBefore: before}, "after -datebefore"},
+	}
+	for _, tt := range tests {
+		got := dateWindowSkipReason(tt.published, tt.opts)
+		if (tt.want == "") != (got == "") || !strings.Contains(got, tt.want) {
+			t.Errorf("%s: skip reason %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package downloader
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -42,11 +43,11 @@ func processDirect(ctx context.Context, rawURL string, opts Options, printer *Pr
 	switch info.Kind {
 	case "hls":
 		video.HLSManifestURL = info.URL
-		ctxInfo := outputContext{SourceURL: info.URL, MetaOverrides: opts.MetaOverrides}
+		ctxInfo := outputContext{SourceURL: info.URL, MetaOverrides: opts.MetaOverrides, TrimFilenames: opts.TrimFilenames, AutoNumber: nextAutoNumber(opts)}
 		return downloadHLS(ctx, newClient(opts), video, opts, ctxInfo, printer, printer.Prefix(1, 1, info.Title))
 	case "dash":
 		video.DASHManifestURL = info.URL
-		ctxInfo := outputContext{SourceURL: info.URL, MetaOverrides: opts.MetaOverrides}
+		ctxInfo := outputContext{SourceURL: info.URL, MetaOverrides: opts.MetaOverrides, TrimFilenames: opts.TrimFilenames, AutoNumber: nextAutoNumber(opts)}
 		return downloadDASH(ctx, newClient(opts), video, opts, ctxInfo, printer, printer.Prefix(1, 1, info.Title))
 	default:
 		return downloadDirectFile(ctx, info, opts, printer)
@@ -117,7 +118,7 @@ func probeDirectURL(ctx context.Context, rawURL string, timeout time.Duration) (
 }
 
 func headOrGet(ctx context.Context, rawURL string, timeout time.Duration) (*http.Response, error) {
-	client := newHTTPClient(timeout)
+	client := newMediaHTTPClient(timeout)
 	req, err := http.NewRequestWithContext(ctx, http.MethodHead, rawURL, nil)
 	if err != nil {
 		return nil, err
@@ -150,7 +151,7 @@ func kindFromExt(ext string) string {
 }
 
 func guessKindFromContentType(contentType string) string {
-	ctype := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	ctype := mediaTypeOf(contentType)
 	switch ctype {
 	case "application/vnd.apple.mpegurl", "application/x-mpegurl":
 		return "hls"
@@ -177,7 +178,7 @@ func titleFromURL(parsed *url.URL) (string, string) {
 	return safe, safe
 }
 
-func downloadDirectFile(ctx context.Context, info directInfo, opts Options, printer *Printer) (downloadResult, error) {
+func downloadDirectFile(ctx context.Context, info directInfo, opts Options, printer *Printer) (result downloadResult, err error) {
 	format := &youtube.Format{
 		MimeType: fmt.Sprintf("video/%s", info.Ext),
 	}
@@ -186,7 +187,12 @@ func downloadDirectFile(ctx context.Context, info directInfo, opts Options, prin
 		Title:  info.Title,
 		Author: info.Author,
 	}
-	outputPath, err := resolveOutputPath(opts.OutputTemplate, video, format, outputContext{SourceURL: info.URL, MetaOverrides: opts.MetaOverrides}, opts.OutputDir)
+	baseDir, err := routedOutputDir(opts, format)
+	if err != nil {
+		return downloadResult{}, wrapCategory(CategoryFilesystem, err)
+	}
+	opts.OutputDir = baseDir
+	outputPath, err := resolveOutputPath(opts.OutputTemplate, video, format, outputContext{SourceURL: info.URL, MetaOverrides: opts.MetaOverrides, TrimFilenames: opts.TrimFilenames, AutoNumber: nextAutoNumber(opts)}, opts.OutputDir)
 	if err != nil {
 		return downloadResult{}, wrapCategory(CategoryFilesystem, err)
 	}
@@ -208,10 +214,24 @@ func downloadDirectFile(ctx context.Context, info directInfo, opts Options, prin
 	if err != nil {
 		return downloadResult{}, err
 	}
+	defer func() {
+		if err != nil {
+			cleanupFailedArtifacts(ctx, opts, partPath, resumePath)
+		}
+	}()
 
 	state := fileResumeState{URL: info.URL, BytesWritten: 0}
+	if err := discardResumeArtifacts(opts, partPath, resumePath); err != nil {
+		return downloadResult{}, err
+	}
+	adopted := false
 	if loaded, err := loadFileResume(resumePath); err == nil && loaded.URL == info.URL {
 		state = loaded
+	} else if opts.AdoptPartial {
+		// A part file left by another tool has no resume state; take its
+		// size as the offset and let the Range response confirm it.
+		state.BytesWritten = adoptablePartSize(partPath, info.Size, printer)
+		adopted = state.BytesWritten > 0
 	}
 
 	file, err := os.OpenFile(partPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
@@ -219,17 +239,32 @@ func downloadDirectFile(ctx context.Context, info directInfo, opts Options, prin
 		return downloadResult{}, wrapCategory(CategoryFilesystem, fmt.Errorf("opening temp file: %w", err))
 	}
 	defer file.Close()
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, info.URL, nil)
-	if err != nil {
-		return downloadResult{}, wrapCategory(CategoryNetwork, err)
+	// Resume from the last recorded offset. Bytes past it (written just
+	// before a crash, say) are dropped so the Range request lines up; a part
+	// file shorter than recorded cannot be trusted and starts over.
+	if partInfo, err := file.Stat(); err != nil || partInfo.Size() < state.BytesWritten {
+		state.BytesWritten = 0
 	}
-	if state.BytesWritten > 0 {
-		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", state.BytesWritten))
+	if err := file.Truncate(state.BytesWritten); err != nil {
+		return downloadResult{}, wrapCategory(CategoryFilesystem, fmt.Errorf("truncating temp file: %w", err))
 	}
-	resp, err := doWithRetry(req, opts.Timeout, maxSegmentRetries)
+
+	resp, err := requestDirectFile(ctx, info.URL, state.BytesWritten, opts)
 	if err != nil {
-		return downloadResult{}, wrapCategory(CategoryNetwork, err)
+		return downloadResult{}, err
+	}
+	if adopted && resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		// The adopted part is at least as long as the file, so it is not a
+		// prefix of it; start over.
+		resp.Body.Close()
+		printer.Log(LogWarn, fmt.Sprintf("warning: %s does not match the remote file; downloading from the start", filepath.Base(partPath)))
+		if err := file.Truncate(0); err != nil {
+			return downloadResult{}, wrapCategory(CategoryFilesystem, fmt.Errorf("truncating temp file: %w", err))
+		}
+		state.BytesWritten = 0
+		if resp, err = requestDirectFile(ctx, info.URL, 0, opts); err != nil {
+			return downloadResult{}, err
+		}
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
@@ -239,16 +274,46 @@ func downloadDirectFile(ctx context.Context, info directInfo, opts Options, prin
 		return downloadResult{}, wrapCategory(CategoryNetwork, fmt.Errorf("unexpected status %d", resp.StatusCode))
 	}
 
+	if state.BytesWritten > 0 && resp.StatusCode != http.StatusPartialContent {
+		// The server ignored the Range request and is sending the whole file.
+		if err := file.Truncate(0); err != nil {
+			return downloadResult{}, wrapCategory(CategoryFilesystem, fmt.Errorf("truncating temp file: %w", err))
+		}
+		state.BytesWritten = 0
+	}
+
+	var body io.Reader = resp.Body
+	if !opts.NoContentCheck {
+		body, err = checkDirectContent(resp, state.BytesWritten == 0)
+		if err != nil {
+			return downloadResult{}, err
+		}
+	}
+
+	// A resumed file is only partly copied here, so its checksum is taken
+	// from the finished file instead.
+	checksum := newOutputChecksum(opts)
+	streamed := state.BytesWritten == 0
 	var writer io.Writer = file
+	if streamed {
+		writer = checksum.Writer(file)
+	}
 	var progress *progressWriter
-	if !opts.Quiet {
-		progress = newProgressWriter(resp.ContentLength, printer, printer.Prefix(1, 1, info.Title))
+	if !opts.Quiet || opts.Renderer != nil {
+		total := resp.ContentLength
+		if total > 0 {
+			total += state.BytesWritten
+		}
+		progress = newProgressWriter(total, printer, printer.Prefix(1, 1, info.Title), outputPath)
 		progress.SetCurrent(state.BytesWritten)
-		writer = io.MultiWriter(file, progress)
+		writer = io.MultiWriter(writer, progress)
 	}
 
-	written, err := io.Copy(writer, resp.Body)
+	written, err := copyBuffer(writer, body)
 	if err != nil {
+		// Record what reached disk so a rerun resumes with a Range request.
+		state.BytesWritten += written
+		_ = saveFileResume(resumePath, state)
 		return downloadResult{}, wrapCategory(CategoryNetwork, fmt.Errorf("download failed: %w", err))
 	}
 	state.BytesWritten += written
@@ -264,15 +329,67 @@ func downloadDirectFile(ctx context.Context, info directInfo, opts Options, prin
 	if err := os.Rename(partPath, outputPath); err != nil {
 		return downloadResult{}, wrapCategory(CategoryFilesystem, fmt.Errorf("renaming output: %w", err))
 	}
+	if streamed {
+		checksum.Seal(outputPath)
+	}
 	if err := validateOutputFile(outputPath, format); err != nil {
 		return downloadResult{}, err
 	}
 	_ = os.Remove(resumePath)
 	metadata := buildItemMetadata(video, format, outputContext{SourceURL: info.URL, MetaOverrides: opts.MetaOverrides}, outputPath, "ok", nil)
-	if err := finalizeDownloadMetadata(outputPath, opts.OutputDir, metadata, opts.AudioOnly, printer); err != nil {
+	if err := finalizeDownloadMetadata(outputPath, opts.OutputDir, metadata, checksum, opts, printer); err != nil {
 		return downloadResult{}, err
 	}
-	return downloadResult{bytes: state.BytesWritten, outputPath: outputPath}, nil
+	metadata.SHA256 = checksum.Sum()
+	runPostDownloadExec(ctx, opts, metadata, printer)
+	runPrintToFile(opts, metadata, printer)
+	if opts.OnItemComplete != nil {
+		opts.OnItemComplete(metadata, state.BytesWritten)
+	}
+	return downloadResult{bytes: state.BytesWritten, outputPath: outputPath, sha256: checksum.Sum()}, nil
+}
+
+// sniffLength is how much of a fresh direct download is inspected before any
+// of it is written; http.DetectContentType looks at no more than this.
+const sniffLength = 512
+
+// checkDirectContent rejects a direct download response that is plainly not
+// media, such as an HTML error or login page served with a 200 status. The
+// Content-Type header is always checked; when sniff is set the start of the
+// body is also inspected for markup. The returned reader yields the whole
+// body, including any bytes read for sniffing.
+func checkDirectContent(resp *http.Response, sniff bool) (io.Reader, error) {
+	if ctype := mediaTypeOf(resp.Header.Get("Content-Type")); isTextContentType(ctype) {
+		return nil, wrapCategory(CategoryUnsupported, fmt.Errorf("server returned %s instead of media (use -no-content-check to download anyway)", ctype))
+	}
+	if !sniff {
+		return resp.Body, nil
+	}
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(resp.Body, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, wrapCategory(CategoryNetwork, fmt.Errorf("download failed: %w", err))
+	}
+	head = head[:n]
+	switch sniffed := mediaTypeOf(http.DetectContentType(head)); sniffed {
+	case "text/html", "text/xml":
+		return nil, wrapCategory(CategoryUnsupported, fmt.Errorf("response body looks like %s, not media (use -no-content-check to download anyway)", sniffed))
+	}
+	return io.MultiReader(bytes.NewReader(head), resp.Body), nil
+}
+
+// mediaTypeOf returns the lower-cased media type of a Content-Type value,
+// without parameters.
+func mediaTypeOf(contentType string) string {
+	return strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+}
+
+func isTextContentType(ctype string) bool {
+	switch ctype {
+	case "application/json", "application/xhtml+xml":
+		return true
+	}
+	return strings.HasPrefix(ctype, "text/")
 }
 
 type fileResumeState struct {
@@ -307,8 +424,25 @@ func saveFileResume(path string, state fileResumeState) error {
 	return nil
 }
 
+// requestDirectFile starts a GET for url, asking for the bytes from offset on
+// when it is past the start.
+func requestDirectFile(ctx context.Context, url string, offset int64, opts Options) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, wrapCategory(CategoryNetwork, err)
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := doWithRetry(req, opts.Timeout, maxSegmentRetries)
+	if err != nil {
+		return nil, wrapCategory(CategoryNetwork, err)
+	}
+	return resp, nil
+}
+
 func doWithRetry(req *http.Request, timeout time.Duration, maxAttempts int) (*http.Response, error) {
-	client := newHTTPClient(timeout)
+	client := newMediaHTTPClient(timeout)
 	var lastErr error
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
 		resp, err := client.Do(req)
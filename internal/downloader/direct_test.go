@@ -0,0 +1,280 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDownloadDirectFileFailureArtifacts(t *testing.T) {
+	// The server promises more bytes than it sends, so the copy fails mid-way.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "video/mp4")
+		w.Header().Set("Content-Length", "4096")
+		_, _ = w.Write(bytes.Repeat([]byte("x"), 1024))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name     string
+		cleanup  bool
+		wantKept bool
+	}{
+		{"default keeps artifacts for resume", false, true},
+		{"cleanup-on-failure removes artifacts", true, false},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		opts := Options{
+			OutputTemplate:   "{title}.{ext}",
+			OutputDir:        dir,
+			Quiet:            true,
+			CleanupOnFailure: tt.cleanup,
+		}
+		info := directInfo{URL: srv.URL + "/clip.mp4", Kind: "file", Title: "clip", ID: "clip", Ext: "mp4"}
+		_, err := downloadDirectFile(context.Background(), info, opts, newPrinter(opts, nil))
+		if err == nil {
+			t.Fatalf("%s: expected the truncated download to fail", tt.name)
+		}
+
+		for _, name := range []string{"clip.mp4" + partSuffix, "clip.mp4" + resumeSuffix} {
+			_, statErr := os.Stat(filepath.Join(dir, name))
+			kept := statErr == nil
+			if kept != tt.wantKept {
+				t.Fatalf("%s: %s kept=%v, want %v (stat err %v)", tt.name, name, kept, tt.wantKept, statErr)
+			}
+		}
+		if _, err := os.Stat(filepath.Join(dir, "clip.mp4")); !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("%s: expected no final output file, stat err = %v", tt.name, err)
+		}
+	}
+}
+
+func TestCleanupFailedArtifactsKeepsFilesOnCancel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clip.mp4"+partSuffix)
+	if err := os.WriteFile(path, []byte("partial"), 0o644); err != nil {
+		t.Fatalf("seed part file: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cleanupFailedArtifacts(ctx, Options{CleanupOnFailure: true}, path)
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("expected part file to survive an interrupted run: %v", err)
+	}
+}
+
+func TestDownloadDirectFileRejectsHTML(t *testing.T) {
+	const page = "<!DOCTYPE html><html><body>Access denied</body></html>"
+	tests := []struct {
+		name        string
+		contentType string
+	}{
+		{"html content type", "text/html; charset=utf-8"},
+		{"html body behind a media content type", "video/mp4"},
+	}
+	for _, tt := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", tt.contentType)
+			_, _ = w.Write([]byte(page))
+		}))
+
+		dir := t.TempDir()
+		opts := Options{OutputTemplate: "{title}.{ext}", OutputDir: dir, Quiet: true, CleanupOnFailure: true}
+		info := directInfo{URL: srv.URL + "/clip.mp4", Kind: "file", Title: "clip", ID: "clip", Ext: "mp4"}
+		_, err := downloadDirectFile(context.Background(), info, opts, newPrinter(opts, nil))
+		srv.Close()
+		if errorCategory(err) != CategoryUnsupported {
+			t.Fatalf("%s: expected unsupported error, got %v", tt.name, err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "clip.mp4")); !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("%s: expected no output file, stat err = %v", tt.name, err)
+		}
+	}
+}
+
+func TestDownloadDirectFileNoContentCheck(t *testing.T) {
+	const page = "<html><body>not really a video</body></html>"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(page))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	opts := Options{OutputTemplate: "{title}.{ext}", OutputDir: dir, Quiet: true, NoContentCheck: true}
+	info := directInfo{URL: srv.URL + "/page.bin", Kind: "file", Title: "page", ID: "page", Ext: "bin"}
+	result, err := downloadDirectFile(context.Background(), info, opts, newPrinter(opts, nil))
+	if err != nil {
+		t.Fatalf("expected -no-content-check to save the response, got %v", err)
+	}
+	data, err := os.ReadFile(result.outputPath)
+	if err != nil || string(data) != page {
+		t.Fatalf("expected the full body to be saved, got %q, %v", data, err)
+	}
+}
+
+func TestCheckDirectContentKeepsSniffedBytes(t *testing.T) {
+	body := append([]byte("\x00\x00\x00\x18ftypmp42"), bytes.Repeat([]byte{0xAB}, 2*sniffLength)...)
+	resp := &http.Response{
+		Header: http.Header{"Content-Type": []string{"application/octet-stream"}},
+		Body:   io.NopCloser(bytes.NewReader(body)),
+	}
+	reader, err := checkDirectContent(resp, true)
+	if err != nil {
+		t.Fatalf("checkDirectContent: %v", err)
+	}
+	got, err := io.ReadAll(reader)
+	if err != nil || !bytes.Equal(got, body) {
+		t.Fatalf("expected the body to pass through unchanged (%d bytes), got %d bytes, %v", len(body), len(got), err)
+	}
+}
+
+// directResumeServer serves content with Range support. While truncate is
+// set, full-body responses stop halfway to simulate a dropped connection.
+// Unless ranged is set, Range headers are ignored.
+type directResumeServer struct {
+	content  []byte
+	truncate atomic.Bool
+	ranged   bool
+	ranges   []string
+	mu       sync.Mutex
+}
+
+func (s *directResumeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.ranges = append(s.ranges, r.Header.Get("Range"))
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/octet-stream")
+	if s.truncate.Load() {
+		w.Header().Set("Content-Length", strconv.Itoa(len(s.content)))
+		_, _ = w.Write(s.content[:len(s.content)/2])
+		return
+	}
+	if !s.ranged {
+		r.Header.Del("Range")
+	}
+	http.ServeContent(w, r, "clip.bin", time.Time{}, bytes.NewReader(s.content))
+}
+
+func TestDownloadDirectFileResumes(t *testing.T) {
+	content := bytes.Repeat([]byte{0xAB, 0xCD, 0xEF, 0x01}, 4096)
+	for _, ranged := range []bool{true, false} {
+		server := &directResumeServer{content: content, ranged: ranged}
+		server.truncate.Store(true)
+		srv := httptest.NewServer(server)
+
+		dir := t.TempDir()
+		opts := Options{OutputTemplate: "{title}.{ext}", OutputDir: dir, Quiet: true}
+		info := directInfo{URL: srv.URL + "/clip.bin", Kind: "file", Title: "clip", ID: "clip", Ext: "bin"}
+		if _, err := downloadDirectFile(context.Background(), info, opts, newPrinter(opts, nil)); err == nil {
+			t.Fatalf("ranged=%v: expected the interrupted download to fail", ranged)
+		}
+		partPath := filepath.Join(dir, "clip.bin"+partSuffix)
+		if partInfo, err := os.Stat(partPath); err != nil || partInfo.Size() != int64(len(content)/2) {
+			t.Fatalf("ranged=%v: expected half the file in %s, got %v, %v", ranged, partPath, partInfo, err)
+		}
+
+		server.truncate.Store(false)
+		result, err := downloadDirectFile(context.Background(), info, opts, newPrinter(opts, nil))
+		srv.Close()
+		if err != nil {
+			t.Fatalf("ranged=%v: resumed download failed: %v", ranged, err)
+		}
+		data, err := os.ReadFile(result.outputPath)
+		if err != nil || !bytes.Equal(data, content) {
+			t.Fatalf("ranged=%v: resumed output differs from the source (%d of %d bytes), err %v", ranged, len(data), len(content), err)
+		}
+		if result.bytes != int64(len(content)) {
+			t.Fatalf("ranged=%v: expected %d bytes, got %d", ranged, len(content), result.bytes)
+		}
+		if want := fmt.Sprintf("bytes=%d-", len(content)/2); server.ranges[len(server.ranges)-1] != want {
+			t.Fatalf("ranged=%v: expected resume request with Range %q, got %q", ranged, want, server.ranges)
+		}
+		for _, name := range []string{"clip.bin" + partSuffix, "clip.bin" + resumeSuffix} {
+			if _, err := os.Stat(filepath.Join(dir, name)); !errors.Is(err, os.ErrNotExist) {
+				t.Fatalf("ranged=%v: expected %s to be removed, stat err = %v", ranged, name, err)
+			}
+		}
+	}
+}
+
+func TestDownloadDirectFileNoResumeStartsFresh(t *testing.T) {
+	content := bytes.Repeat([]byte{0x10, 0x20, 0x30, 0x40}, 4096)
+	server := &directResumeServer{content: content, ranged: true}
+	srv := httptest.NewServer(server)
+	defer srv.Close()
+
+	dir := t.TempDir()
+	opts := Options{OutputTemplate: "{title}.{ext}", OutputDir: dir, Quiet: true, NoResume: true}
+	info := directInfo{URL: srv.URL + "/clip.bin", Kind: "file", Title: "clip", ID: "clip", Ext: "bin"}
+
+	// A stale part file and a resume state that claims it is half done.
+	partPath := filepath.Join(dir, "clip.bin"+partSuffix)
+	if err := os.WriteFile(partPath, bytes.Repeat([]byte{0xFF}, len(content)/2), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveFileResume(filepath.Join(dir, "clip.bin"+resumeSuffix), fileResumeState{URL: info.URL, BytesWritten: int64(len(content) / 2)}); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := downloadDirectFile(context.Background(), info, opts, newPrinter(opts, nil))
+	if err != nil {
+		t.Fatalf("download failed: %v", err)
+	}
+	if len(server.ranges) != 1 || server.ranges[0] != "" {
+		t.Fatalf("expected one request without a Range header, got %q", server.ranges)
+	}
+	data, err := os.ReadFile(result.outputPath)
+	if err != nil || !bytes.Equal(data, content) {
+		t.Fatalf("expected a fresh copy of the source (%d of %d bytes), err %v", len(data), len(content), err)
+	}
+}
+
+func TestDownloadDirectFileAdoptsExistingPart(t *testing.T) {
+	content := bytes.Repeat([]byte{0x0A, 0x0B, 0x0C, 0x0D}, 4096)
+	tests := []struct {
+		name       string
+		part       []byte
+		size       int64
+		wantRanges []string
+	}{
+		{"prefix", content[:len(content)/4], int64(len(content)), []string{fmt.Sprintf("bytes=%d-", len(content)/4)}},
+		{"too long", append(append([]byte{}, content...), 0xFF), 0, []string{fmt.Sprintf("bytes=%d-", len(content)+1), ""}},
+		{"not shorter than Content-Length", content, int64(len(content)), []string{""}},
+	}
+	for _, tt := range tests {
+		server := &directResumeServer{content: content, ranged: true}
+		srv := httptest.NewServer(server)
+
+		dir := t.TempDir()
+		// Written by another tool, so there is no resume state beside it.
+		if err := os.WriteFile(filepath.Join(dir, "clip.bin"+partSuffix), tt.part, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		opts := Options{OutputTemplate: "{title}.{ext}", OutputDir: dir, Quiet: true, AdoptPartial: true}
+		info := directInfo{URL: srv.URL + "/clip.bin", Kind: "file", Size: tt.size, Title: "clip", ID: "clip", Ext: "bin"}
+		result, err := downloadDirectFile(context.Background(), info, opts, newPrinter(opts, nil))
+		srv.Close()
+		if err != nil {
+			t.Fatalf("%s: download failed: %v", tt.name, err)
+		}
+		if fmt.Sprint(server.ranges) != fmt.Sprint(tt.wantRanges) {
+			t.Fatalf("%s: requested ranges %q, want %q", tt.name, server.ranges, tt.wantRanges)
+		}
+		data, err := os.ReadFile(result.outputPath)
+		if err != nil || !bytes.Equal(data, content) {
+			t.Fatalf("%s: output differs from the source (%d of %d bytes), err %v", tt.name, len(data), len(content), err)
+		}
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	stderrors "errors"
 	"fmt"
+	"os"
+	"regexp"
 	"time"
 
 	youtube "github.com/lvcoi/ytdl-lib/v2"
@@ -11,28 +13,98 @@ import (
 
 // Options describes CLI behavior for a download run.
 type Options struct {
-	OutputTemplate      string
-	OutputDir           string
-	AudioOnly           bool
-	InfoOnly            bool
-	ListFormats         bool
-	Quiet               bool
-	JSON                bool
-	Quality             string
-	Format              string
-	Itag                int
-	MetaOverrides       map[string]string
-	SegmentConcurrency  int
-	PlaylistConcurrency int
-	Timeout             time.Duration
-	ProgressLayout      string
-	LogLevel            string
-	Renderer            ProgressRenderer  `json:"-"`
-	OnDuplicate         DuplicatePolicy   `json:"on-duplicate,omitempty"`
-	DuplicatePrompter   DuplicatePrompter `json:"-"`
-	DuplicateSession    *DuplicateSession `json:"-"`
-	UseCookies          bool
-	PoToken             string
+	OutputTemplate       string
+	OutputDir            string
+	AudioOnly            bool
+	InfoOnly             bool
+	FlatPlaylist         bool
+	ListFormats          bool
+	ListSubtitles        bool
+	SortFormats          bool
+	FormatsArrayJSON     bool
+	Quiet                bool
+	JSON                 bool
+	Quality              string
+	Format               string
+	Itag                 int
+	MetaOverrides        map[string]string
+	SegmentConcurrency   int
+	AutoConcurrency      bool
+	CleanupOnFailure     bool
+	NoResume             bool
+	AdoptPartial         bool
+	PlaylistConcurrency  int
+	Timeout              time.Duration
+	ProgressLayout       string
+	LogLevel             string
+	Renderer             ProgressRenderer  `json:"-"`
+	OnDuplicate          DuplicatePolicy   `json:"on-duplicate,omitempty"`
+	DuplicatePrompter    DuplicatePrompter `json:"-"`
+	DuplicateSession     *DuplicateSession `json:"-"`
+	AutoNumber           *AutoNumber       `json:"-"`
+	UseCookies           bool
+	PoToken              string
+	PlaylistEntryRetries int
+	RequireAll           bool
+	MetadataConcurrency  int
+	CleanArtist          string
+	AddReplayGain        bool
+	Paths                map[string]string
+	NoPlaylist           bool
+	YesPlaylist          bool
+	Color                string
+	Silent               bool
+	Verify               bool
+	WriteChecksum        bool
+	Exec                 string
+	PreferFreeFormats    bool
+	AudioFormat          string
+	AudioQuality         string
+	EmbedMetadata        bool
+	ReportFailed         string
+	RespectTimestamps    bool
+	Subtitles            string
+	EmbedSubs            bool
+	MinFileSize          int64
+	TrimFilenames        int
+	MaxDescriptionLength int
+	AutonumberStart      int
+	AutonumberWidth      int
+	FlatPlaylistLayout   bool
+	DateAfter            time.Time
+	DateBefore           time.Time
+	MatchTitle           *regexp.Regexp `json:"-"`
+	RejectTitle          *regexp.Regexp `json:"-"`
+	MinDuration          time.Duration
+	MaxDuration          time.Duration
+	PrintToFile          []PrintSpec
+	WritePlaylistM3U     bool
+	M3UIncludeSkipped    bool
+	GetFilename          bool
+	GetURL               bool
+	DumpPlan             bool
+	Archive              *DownloadArchive `json:"-"`
+	PreferLang           string
+	LiveFromStart        bool
+	WaitForVideo         time.Duration
+	WaitForVideoMax      time.Duration
+	JSONProgress         bool
+	NoMtime              bool
+	NoContentCheck       bool
+	MetadataIndex        string
+	Stats                *RunStats `json:"-"`
+	// OnItemComplete, when set, is called after each item downloads
+	// successfully with its metadata and the number of bytes transferred.
+	OnItemComplete func(metadata ItemMetadata, bytes int64) `json:"-"`
+	// SkipUnavailableFragments skips HLS/DASH segments that still fail after
+	// retries instead of failing the whole download.
+	SkipUnavailableFragments bool
+	// HLSUseFFmpeg downloads finished HLS streams through ffmpeg instead of
+	// stitching segments natively, when ffmpeg is available.
+	HLSUseFFmpeg bool
+	// RedownloadOnBetterQuality downloads a video in the download archive
+	// again when the selected format beats the archived one.
+	RedownloadOnBetterQuality bool
 }
 
 type outputContext struct {
@@ -45,6 +117,11 @@ type outputContext struct {
 	SourceURL     string
 	PlaylistURL   string
 	MetaOverrides map[string]string
+	CleanArtist   bool
+	StartOffset   time.Duration
+	TrimFilenames int
+	AutoNumber    string
+	FlatPlaylist  bool
 }
 
 type downloadResult struct {
@@ -54,6 +131,12 @@ type downloadResult struct {
 	retried     bool
 	hadProgress bool
 	skipped     bool
+	skipReason  string
+	// skippedFragments counts segments left out under
+	// -skip-unavailable-fragments.
+	skippedFragments int
+	// sha256 is the output's checksum under -write-checksum.
+	sha256 string
 }
 
 type reportedError struct {
@@ -102,6 +185,9 @@ func categoryForYouTubeError(err error) ErrorCategory {
 }
 
 func wrapFetchError(err error, context string) error {
+	if message, ok := describePlayability(err, time.Now()); ok {
+		return wrapCategory(CategoryRestricted, fmt.Errorf("%s: %s: %w", context, message, err))
+	}
 	category := categoryForYouTubeError(err)
 	wrapped := fmt.Errorf("%s: %w", context, err)
 	switch category {
@@ -121,6 +207,9 @@ func Process(ctx context.Context, url string, opts Options) error {
 // ProcessWithManager is like Process but allows sharing a progress manager across
 // multiple concurrent downloads. If manager is nil, a new one is created.
 func ProcessWithManager(ctx context.Context, url string, opts Options, manager *ProgressManager) error {
+	if opts.JSONProgress && opts.Renderer == nil {
+		opts.Renderer = newJSONProgressRenderer(os.Stdout, url)
+	}
 	var ownedManager *ProgressManager
 	if manager == nil && opts.Renderer == nil {
 		ownedManager = NewProgressManager(opts)
@@ -143,52 +232,83 @@ func ProcessWithManager(ctx context.Context, url string, opts Options, manager *
 	// Detect YouTube Music URLs by parsing and normalizing the hostname
 	isMusicURL := isMusicYouTubeURL(originalURL)
 
-	if looksLikePlaylist(url) {
+	// Channels are downloaded through their uploads playlist.
+	url, err = resolveChannelURL(ctx, url, opts)
+	if err != nil {
+		return err
+	}
+
+	if shouldProcessAsPlaylist(url, opts) {
 		return processPlaylist(ctx, url, opts, printer, isMusicURL)
 	}
+	if opts.NoPlaylist && looksLikePlaylist(url) {
+		url = singleVideoURL(url)
+	}
 
 	if !isYouTubeURL(url) {
+		if opts.GetFilename || opts.GetURL {
+			return wrapCategory(CategoryUnsupported, stderrors.New("-get-filename and -get-url only support YouTube URLs"))
+		}
+		if opts.DumpPlan {
+			return wrapCategory(CategoryUnsupported, stderrors.New("-dump-plan only supports YouTube URLs"))
+		}
+		if opts.FlatPlaylist {
+			return wrapCategory(CategoryUnsupported, stderrors.New("-flat-playlist only supports YouTube URLs"))
+		}
 		result, err := processDirect(ctx, url, opts, printer)
 		if opts.JSON {
 			status := "ok"
-			errMsg := ""
 			if err != nil {
 				status = "error"
-				errMsg = err.Error()
 			}
-			emitJSONResult(jsonResult{
+			emitJSONResult(opts.Stats, jsonResult{
 				Type:    "item",
 				Status:  status,
 				URL:     url,
 				Output:  result.outputPath,
 				Bytes:   result.bytes,
 				Retries: result.retried,
-				Error:   errMsg,
-			})
+			}.withError(err))
 		}
 		return err
 	}
 
 	client := newClientForType("android", opts)
-	video, err := client.GetVideoContext(ctx, url)
+	video, err := fetchVideoWaiting(ctx, client, url, opts, printer)
 	if err != nil {
 		return wrapFetchError(err, "fetching video metadata")
 	}
+	applyPreferredLanguage(ctx, client, video, opts.PreferLang, printer)
 
 	if opts.InfoOnly {
-		return printVideoInfo(video)
+		return printVideoInfo(video, opts.MaxDescriptionLength)
+	}
+	if opts.FlatPlaylist {
+		return printFlatEntries(os.Stdout, []flatEntry{{Index: 1, ID: video.ID, Title: video.Title, Author: video.Author, Duration: int(video.Duration.Seconds()), URL: watchURLForID(video.ID)}})
 	}
 	if opts.ListFormats {
 		return renderFormats(video, opts, "", "", 0, 0)
 	}
+	if opts.ListSubtitles {
+		return renderSubtitles(os.Stdout, video, opts, "", "", 0, 0)
+	}
 
-	ctxInfo := outputContext{}
+	ctxInfo := outputContext{CleanArtist: shouldCleanArtist(opts.CleanArtist, isMusicURL), TrimFilenames: opts.TrimFilenames, AutoNumber: nextAutoNumber(opts)}
+	if opts.GetFilename || opts.GetURL {
+		return printQuickQuery(ctx, os.Stdout, client, video, opts, ctxInfo)
+	}
+	if opts.DumpPlan {
+		return writePlan(os.Stdout, downloadPlan{Type: "plan", Entries: []planEntry{planVideo(video, opts, ctxInfo)}})
+	}
+	if opts.RespectTimestamps {
+		ctxInfo.StartOffset = urlStartOffset(url)
+	}
 	prefix := printer.Prefix(1, 1, video.Title)
 	result, err := downloadVideo(ctx, client, video, opts, ctxInfo, printer, prefix)
 	if err != nil {
 		printer.ItemResult(prefix, result, err)
 		if opts.JSON {
-			emitJSONResult(jsonResult{
+			emitJSONResult(opts.Stats, jsonResult{
 				Type:    "item",
 				Status:  "error",
 				URL:     url,
@@ -197,8 +317,7 @@ func ProcessWithManager(ctx context.Context, url string, opts Options, manager *
 				Output:  result.outputPath,
 				Bytes:   result.bytes,
 				Retries: result.retried,
-				Error:   err.Error(),
-			})
+			}.withError(err))
 		}
 		return markReported(err)
 	}
@@ -208,22 +327,28 @@ func ProcessWithManager(ctx context.Context, url string, opts Options, manager *
 		okCount = 0
 		skipped = 1
 	}
-	printer.ItemResult(prefix, result, nil)
+	if result.skipReason != "" {
+		printer.ItemSkipped(prefix, result.skipReason)
+	} else {
+		printer.ItemResult(prefix, result, nil)
+	}
 	if opts.JSON {
 		status := "ok"
 		if result.skipped {
 			status = "skip"
 		}
-		emitJSONResult(jsonResult{
-			Type:    "item",
-			Status:  status,
-			URL:     url,
-			ID:      video.ID,
-			Title:   video.Title,
-			Output:  result.outputPath,
-			Bytes:   result.bytes,
-			Retries: result.retried,
-			Skipped: result.skipped,
+		emitJSONResult(opts.Stats, jsonResult{
+			Type:             "item",
+			Status:           status,
+			URL:              url,
+			ID:               video.ID,
+			Title:            video.Title,
+			Output:           result.outputPath,
+			Bytes:            result.bytes,
+			Retries:          result.retried,
+			Skipped:          result.skipped,
+			SkippedFragments: result.skippedFragments,
+			SHA256:           result.sha256,
 		})
 	}
 	printer.Summary(1, okCount, 0, skipped, result.bytes)
@@ -232,12 +357,21 @@ func ProcessWithManager(ctx context.Context, url string, opts Options, manager *
 
 func renderFormats(video *youtube.Video, opts Options, playlistID, playlistTitle string, index, total int) error {
 	if opts.JSON {
+		if opts.SortFormats {
+			// JSON keeps YouTube's order unless sorting was asked for.
+			sorted := *video
+			sorted.Formats = sortFormatsByQuality(video.Formats)
+			video = &sorted
+		}
+		if opts.FormatsArrayJSON {
+			return renderFormatsArrayJSON(video)
+		}
 		return renderFormatsJSON(video, playlistID, playlistTitle, index, total)
 	}
 	title := fmt.Sprintf(" Formats: %s ", video.Title)
 
 	ctx := context.Background()
-	selectedItag, tui, err := RunSeamlessFormatSelector(ctx, video, title, playlistID, playlistTitle, index, total)
+	selectedItag, tui, err := RunSeamlessFormatSelector(ctx, video, title, playlistID, playlistTitle, index, total, opts.SortFormats)
 	if err != nil {
 		return err
 	}
@@ -256,7 +390,7 @@ func renderFormats(video *youtube.Video, opts Options, playlistID, playlistTitle
 	client := newClientForType("android", opts)
 	printer := NewSeamlessPrinter(opts, tui)
 
-	ctxInfo := outputContext{}
+	ctxInfo := outputContext{TrimFilenames: opts.TrimFilenames, AutoNumber: nextAutoNumber(opts)}
 	if playlistID != "" {
 		ctxInfo.Index = index
 		ctxInfo.Total = total
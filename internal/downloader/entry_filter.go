@@ -0,0 +1,54 @@
+package downloader
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lvcoi/ytdl-lib/v2"
+)
+
+// entrySkipReason returns why the download archive or the title and duration
+// filters leave a playlist entry out, or "" when it should be downloaded. It
+// only needs the playlist listing, so skipped entries are never resolved.
+// With -redownload-on-better-quality an archived entry is kept, since the
+// comparison needs the resolved formats.
+func entrySkipReason(entry *youtube.PlaylistEntry, opts Options) string {
+	if !opts.RedownloadOnBetterQuality {
+		if reason := opts.Archive.skipReason(entry.ID, nil, opts); reason != "" {
+			return reason
+		}
+	}
+	if reason := titleSkipReason(entry.Title, opts); reason != "" {
+		return reason
+	}
+	return durationSkipReason(entry.Duration, opts)
+}
+
+// titleSkipReason returns why a playlist entry titled title is left out by
+// -match-title or -reject-title, or "" when it should be downloaded.
+func titleSkipReason(title string, opts Options) string {
+	if opts.MatchTitle != nil && !opts.MatchTitle.MatchString(title) {
+		return "title does not match -match-title"
+	}
+	if opts.RejectTitle != nil && opts.RejectTitle.MatchString(title) {
+		return "title matches -reject-title"
+	}
+	return ""
+}
+
+// durationSkipReason returns why a playlist entry of the given duration is
+// left out by -min-duration or -max-duration, or "" when it should be
+// downloaded. Both bounds are inclusive, and an entry whose playlist listing
+// has no duration is kept.
+func durationSkipReason(duration time.Duration, opts Options) string {
+	if duration <= 0 {
+		return ""
+	}
+	if opts.MinDuration > 0 && duration < opts.MinDuration {
+		return fmt.Sprintf("duration %s, below -min-duration", duration)
+	}
+	if opts.MaxDuration > 0 && duration > opts.MaxDuration {
+		return fmt.Sprintf("duration %s, above -max-duration", duration)
+	}
+	return ""
+}
@@ -0,0 +1,59 @@
+package downloader
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTitleSkipReason(t *testing.T) {
+	tests := []struct {
+		name   string
+		title  string
+		opts   Options
+		reason string
+	}{
+		{"no filters", "Anything", Options{}, ""},
+		{"match", "Episode 12: Interview", Options{MatchTitle: regexp.MustCompile(`(?i)episode \d+`)}, ""},
+		{"no match", "Trailer", Options{MatchTitle: regexp.MustCompile(`(?i)episode \d+`)}, "title does not match -match-title"},
+		{"rejected", "Episode 3 (Live)", Options{RejectTitle: regexp.MustCompile(`\(Live\)`)}, "title matches -reject-title"},
+		{"not rejected", "Episode 3", Options{RejectTitle: regexp.MustCompile(`\(Live\)`)}, ""},
+		{
+			"reject wins over match",
+			"Episode 4 (Live)",
+			Options{MatchTitle: regexp.MustCompile(`^Episode`), RejectTitle: regexp.MustCompile(`\(Live\)`)},
+			"title matches -reject-title",
+		},
+	}
+	for _, tt := range tests {
+		if got := titleSkipReason(tt.title, tt.opts); got != tt.reason {
+			t.Errorf("%s: titleSkipReason(%q) = %q, want %q", tt.name, tt.title, got, tt.reason)
+		}
+	}
+}
+
+func TestDurationSkipReason(t *testing.T) {
+	opts := Options{MinDuration: time.Minute, MaxDuration: time.Hour}
+	tests := []struct {
+		name     string
+		duration time.Duration
+		opts     Options
+		want     string
+	}{
+		{"inside", 10 * time.Minute, opts, ""},
+		{"on the lower bound", time.Minute, opts, ""},
+		{"on the upper bound", time.Hour, opts, ""},
+		{"short", 45 * time.Second, opts, "below -min-duration"},
+		{"long", time.Hour + time.Second, opts, "above -max-duration"},
+		{"unknown duration", 0, opts, ""},
+		{"no bounds", 20 * time.Second, Options{}, ""},
+		{"only max", 20 * time.Second, Options{MaxDuration: time.Hour}, ""},
+	}
+	for _, tt := range tests {
+		got := durationSkipReason(tt.duration, tt.opts)
+		if (tt.want == "") != (got == "") || !strings.Contains(got, tt.want) {
+			t.Errorf("%s: durationSkipReason(%s) = %q, want %q", tt.name, tt.duration, got, tt.want)
+		}
+	}
+}
@@ -1,6 +1,10 @@
 package downloader
 
-import "errors"
+import (
+	"errors"
+
+	youtube "github.com/lvcoi/ytdl-lib/v2"
+)
 
 // Error categories are used to map failures to stable exit codes and messages.
 type ErrorCategory string
@@ -28,6 +32,20 @@ func (e CategorizedError) Unwrap() error {
 	return e.Err
 }
 
+// Retryable reports whether the same request may succeed if tried again later.
+// Network failures are transient; restricted content and invalid URLs never
+// are. Other categories are retryable only when caused by a rate limit or
+// server error status.
+func (e CategorizedError) Retryable() bool {
+	switch e.Category {
+	case CategoryNetwork:
+		return true
+	case CategoryRestricted, CategoryInvalidURL:
+		return false
+	}
+	return hasRetryableStatus(e.Err)
+}
+
 // wrapCategory ensures an error carries the desired category.
 func wrapCategory(category ErrorCategory, err error) error {
 	if err == nil {
@@ -54,6 +72,22 @@ func CategoryOf(err error) ErrorCategory {
 	return errorCategory(err)
 }
 
+// IsRetryable reports whether err is worth re-enqueueing, using
+// CategorizedError.Retryable when err carries a category.
+func IsRetryable(err error) bool {
+	var ce CategorizedError
+	if errors.As(err, &ce) {
+		return ce.Retryable()
+	}
+	return hasRetryableStatus(err)
+}
+
+// hasRetryableStatus reports whether err stems from a 429 or 5xx response.
+func hasRetryableStatus(err error) bool {
+	var statusErr youtube.ErrUnexpectedStatusCode
+	return errors.As(err, &statusErr) && isRetryableStatus(int(statusErr))
+}
+
 // ExitCode maps a categorized error to a stable non-zero exit code.
 func ExitCode(err error) int {
 	switch errorCategory(err) {
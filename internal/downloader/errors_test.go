@@ -0,0 +1,67 @@
+package downloader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	youtube "github.com/lvcoi/ytdl-lib/v2"
+)
+
+func TestCategorizedErrorRetryable(t *testing.T) {
+	rateLimited := youtube.ErrUnexpectedStatusCode(http.StatusTooManyRequests)
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "network", err: wrapCategory(CategoryNetwork, errors.New("connection reset")), want: true},
+		{name: "network 403", err: wrapCategory(CategoryNetwork, youtube.ErrUnexpectedStatusCode(http.StatusForbidden)), want: true},
+		{name: "unknown 429", err: wrapCategory(CategoryUnknown, rateLimited), want: true},
+		{name: "unsupported 503", err: wrapCategory(CategoryUnsupported, fmt.Errorf("manifest: %w", youtube.ErrUnexpectedStatusCode(http.StatusServiceUnavailable))), want: true},
+		{name: "restricted", err: wrapCategory(CategoryRestricted, errors.New("private video")), want: false},
+		{name: "restricted 429", err: wrapCategory(CategoryRestricted, rateLimited), want: false},
+		{name: "invalid url", err: wrapCategory(CategoryInvalidURL, errors.New("bad url")), want: false},
+		{name: "unsupported", err: wrapCategory(CategoryUnsupported, errors.New("no formats")), want: false},
+		{name: "filesystem", err: wrapCategory(CategoryFilesystem, errors.New("disk full")), want: false},
+		{name: "bare 429", err: fmt.Errorf("fetch: %w", rateLimited), want: true},
+		{name: "bare error", err: errors.New("boom"), want: false},
+		{name: "nil", err: nil, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Fatalf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+			var ce CategorizedError
+			if errors.As(tt.err, &ce) && ce.Retryable() != tt.want {
+				t.Fatalf("Retryable() = %v, want %v", ce.Retryable(), tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONResultWithErrorIncludesRetryable(t *testing.T) {
+	ok := jsonResult{Type: "item", Status: "ok"}.withError(nil)
+	data, err := json.Marshal(ok)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "retryable") || strings.Contains(string(data), "category") {
+		t.Fatalf("expected no error fields on success, got %s", data)
+	}
+
+	failed := jsonResult{Type: "item", Status: "error"}.withError(wrapCategory(CategoryRestricted, errors.New("private video")))
+	data, err = json.Marshal(failed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"error":"private video"`, `"category":"restricted"`, `"retryable":false`} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("expected %s in %s", want, data)
+		}
+	}
+}
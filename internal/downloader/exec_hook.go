@@ -0,0 +1,163 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// execPlaceholders are the substitutions available to --exec templates. Any
+// other braces (${VAR}, awk '{print}') are passed to the shell untouched.
+var execPlaceholders = []string{"output", "title", "id"}
+
+var (
+	// execNameRegex matches a brace-wrapped name that reads as a placeholder.
+	execNameRegex = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+	// execSingleQuotedRegex matches single-quoted shell text, where braces
+	// usually belong to an awk or jq program.
+	execSingleQuotedRegex = regexp.MustCompile(`'[^']*'`)
+)
+
+// ValidateExecTemplate checks that a --exec command template is usable. A
+// {name} that is not a supported placeholder is rejected unless it is part of
+// a ${VAR} expansion or sits inside single quotes.
+func ValidateExecTemplate(template string) error {
+	if strings.TrimSpace(template) == "" {
+		return errors.New("exec command is empty")
+	}
+	unquoted := execSingleQuotedRegex.ReplaceAllString(template, "''")
+	for _, loc := range execNameRegex.FindAllStringSubmatchIndex(unquoted, -1) {
+		if loc[0] > 0 && unquoted[loc[0]-1] == '$' {
+			continue
+		}
+		if _, ok := execPlaceholderAt(unquoted[loc[0]:]); !ok {
+			return fmt.Errorf("unknown exec placeholder {%s} (supported: {output}, {title}, {id})", unquoted[loc[2]:loc[3]])
+		}
+	}
+	return nil
+}
+
+// execEnvName returns the environment variable that carries a placeholder's
+// value, e.g. YTDL_OUTPUT for {output}.
+func execEnvName(placeholder string) string {
+	return "YTDL_" + strings.ToUpper(placeholder)
+}
+
+// renderExecCommand replaces placeholders with references to the YTDL_*
+// environment variables rather than the values themselves, so the shell
+// expands them after parsing and a title such as $(rm -rf ~) stays data. The
+// reference is quoted to suit the surrounding quote context of the template.
+func renderExecCommand(template string, windows bool) string {
+	var b strings.Builder
+	var inSingle, inDouble bool
+	for i := 0; i < len(template); {
+		if name, ok := execPlaceholderAt(template[i:]); ok {
+			b.WriteString(execVariableRef(execEnvName(name), inSingle, inDouble, windows))
+			i += len(name) + 2
+			continue
+		}
+		c := template[i]
+		switch {
+		case windows:
+			if c == '"' {
+				inDouble = !inDouble
+			}
+		case c == '\\' && !inSingle && i+1 < len(template):
+			b.WriteString(template[i : i+2])
+			i += 2
+			continue
+		case c == '\'' && !inDouble:
+			inSingle = !inSingle
+		case c == '"' && !inSingle:
+			inDouble = !inDouble
+		}
+		b.WriteByte(c)
+		i++
+	}
+	return b.String()
+}
+
+func execPlaceholderAt(s string) (string, bool) {
+	for _, name := range execPlaceholders {
+		if strings.HasPrefix(s, "{"+name+"}") {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func execVariableRef(env string, inSingle, inDouble, windows bool) string {
+	if windows {
+		// cmd expands %VAR% before parsing, so use delayed !VAR! expansion,
+		// which happens after metacharacters have been handled.
+		if inDouble {
+			return "!" + env + "!"
+		}
+		return `"!` + env + `!"`
+	}
+	switch {
+	case inSingle:
+		return `'"$` + env + `"'`
+	case inDouble:
+		return "${" + env + "}"
+	default:
+		return `"$` + env + `"`
+	}
+}
+
+// runExecHook runs the --exec command through the system shell with the
+// placeholder values exported as YTDL_* environment variables. Its stdout is
+// forwarded to stdout; stderr is captured and returned on a non-zero exit.
+func runExecHook(ctx context.Context, template string, values map[string]string, stdout io.Writer) error {
+	windows := runtime.GOOS == "windows"
+	command := renderExecCommand(template, windows)
+	var cmd *exec.Cmd
+	if windows {
+		cmd = exec.CommandContext(ctx, "cmd", "/V:ON", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Env = os.Environ()
+	for _, name := range execPlaceholders {
+		cmd.Env = append(cmd.Env, execEnvName(name)+"="+values[name])
+	}
+	var stderr bytes.Buffer
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		detail := strings.TrimSpace(stderr.String())
+		if detail != "" {
+			return fmt.Errorf("exec command failed: %s: %w", detail, err)
+		}
+		return fmt.Errorf("exec command failed: %w", err)
+	}
+	return nil
+}
+
+// runPostDownloadExec runs --exec for a finished download and downgrades any
+// failure to a warning; the download itself has already succeeded.
+func runPostDownloadExec(ctx context.Context, opts Options, metadata ItemMetadata, printer *Printer) {
+	if opts.Exec == "" || metadata.Status != "ok" || metadata.Output == "" {
+		return
+	}
+	var stdout io.Writer = os.Stdout
+	if opts.JSON {
+		// Keep stdout reserved for JSON results.
+		stdout = io.Discard
+	}
+	values := map[string]string{
+		"output": metadata.Output,
+		"title":  metadata.Title,
+		"id":     metadata.ID,
+	}
+	if err := runExecHook(ctx, opts.Exec, values, stdout); err != nil && printer != nil {
+		printer.Log(LogWarn, fmt.Sprintf("warning: %v", err))
+	}
+}
@@ -0,0 +1,127 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestValidateExecTemplate(t *testing.T) {
+	tests := []struct {
+		template string
+		wantErr  bool
+	}{
+		{"mv {output} /nas/{id}", false},
+		{`echo "{title}"`, false},
+		{"echo ${HOME}", false},
+		{"awk '{print}' {output}", false},
+		{"find . -exec rm {} +", false},
+		{"", true},
+		{"  ", true},
+		{"mv {outptu} /nas/", true},
+		{"echo {artist}", true},
+		{`echo "{titel}"`, true},
+		{"echo {id}{ext}", true},
+	}
+	for _, tt := range tests {
+		err := ValidateExecTemplate(tt.template)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateExecTemplate(%q) error = %v, wantErr %v", tt.template, err, tt.wantErr)
+		}
+	}
+}
+
+func TestRunExecHookEcho(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell quoting test targets POSIX sh")
+	}
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	var stdout bytes.Buffer
+	values := map[string]string{
+		"output": "/tmp/My Song.m4a",
+		"title":  "It's $(dangerous)",
+		"id":     "vid123",
+	}
+	if err := runExecHook(context.Background(), "echo {id} {title} {output}", values, &stdout); err != nil {
+		t.Fatalf("runExecHook: %v", err)
+	}
+	want := "vid123 It's $(dangerous) /tmp/My Song.m4a"
+	if got := strings.TrimSpace(stdout.String()); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestRunExecHookDoesNotExpandValues(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell quoting test targets POSIX sh")
+	}
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	marker := filepath.Join(t.TempDir(), "pwned")
+	title := `$(touch ` + marker + `) "; touch ` + marker + `; echo " '; touch ` + marker + `; echo '`
+	values := map[string]string{"title": title}
+	for _, template := range []string{
+		`printf '%s\n' {title}`,
+		`printf '%s\n' "{title}"`,
+		`printf '%s\n' '{title}'`,
+		`printf '%s\n' "$YTDL_TITLE"`,
+	} {
+		var stdout bytes.Buffer
+		if err := runExecHook(context.Background(), template, values, &stdout); err != nil {
+			t.Fatalf("%s: runExecHook: %v", template, err)
+		}
+		if got := strings.TrimSuffix(stdout.String(), "\n"); got != title {
+			t.Fatalf("%s: expected %q, got %q", template, title, got)
+		}
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Fatal("expected the title to be passed as data, but it ran as a command")
+	}
+}
+
+func TestRenderExecCommandLeavesOtherBracesAlone(t *testing.T) {
+	tests := []struct {
+		template string
+		windows  bool
+		want     string
+	}{
+		{`mv {output} /nas/`, false, `mv "$YTDL_OUTPUT" /nas/`},
+		{`mv "{output}" /nas/`, false, `mv "${YTDL_OUTPUT}" /nas/`},
+		{`echo '{id}: {title}'`, false, `echo ''"$YTDL_ID"': '"$YTDL_TITLE"''`},
+		{`awk '{print}' {output} ${HOME}`, false, `awk '{print}' "$YTDL_OUTPUT" ${HOME}`},
+		{`copy {output} D:\media`, true, `copy "!YTDL_OUTPUT!" D:\media`},
+		{`copy "{output}" D:\media`, true, `copy "!YTDL_OUTPUT!" D:\media`},
+	}
+	for _, tt := range tests {
+		if got := renderExecCommand(tt.template, tt.windows); got != tt.want {
+			t.Errorf("renderExecCommand(%q, %v) = %q, want %q", tt.template, tt.windows, got, tt.want)
+		}
+	}
+}
+
+func TestRunExecHookReportsStderrOnFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell test targets POSIX sh")
+	}
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	err := runExecHook(context.Background(), "echo boom >&2; exit 3", nil, &bytes.Buffer{})
+	if err == nil {
+		t.Fatalf("expected non-zero exit to return an error")
+	}
+	if !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("expected stderr in error, got %v", err)
+	}
+}
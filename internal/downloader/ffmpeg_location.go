@@ -0,0 +1,62 @@
+package downloader
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// ffmpegPath and ffprobePath name the binaries every ffmpeg/ffprobe call runs.
+// They default to a PATH lookup and are replaced by --ffmpeg-location.
+var (
+	ffmpegPath  = "ffmpeg"
+	ffprobePath = "ffprobe"
+)
+
+// executableName appends the platform's executable suffix to name.
+func executableName(name string) string {
+	if runtime.GOOS == "windows" {
+		return name + ".exe"
+	}
+	return name
+}
+
+// SetFFmpegLocation points ffmpeg and ffprobe at location, for
+// --ffmpeg-location. location is either the ffmpeg binary itself or the
+// directory holding it; ffprobe is taken from the same directory when present
+// there and otherwise still looked up on PATH. It must be called before any
+// downloads start.
+func SetFFmpegLocation(location string) error {
+	info, err := os.Stat(location)
+	if err != nil {
+		return fmt.Errorf("invalid ffmpeg location %q: %w", location, err)
+	}
+	binary := location
+	if info.IsDir() {
+		binary = filepath.Join(location, executableName("ffmpeg"))
+	}
+	// LookPath on a path with a separator checks the file itself, including
+	// its executable bit, without searching PATH.
+	resolved, err := exec.LookPath(absOrSelf(binary))
+	if err != nil {
+		return fmt.Errorf("invalid ffmpeg location %q: %w", location, err)
+	}
+	probe := ffprobePath
+	if candidate, err := exec.LookPath(filepath.Join(filepath.Dir(resolved), executableName("ffprobe"))); err == nil {
+		probe = candidate
+	}
+	ffmpegPath = resolved
+	ffprobePath = probe
+	return nil
+}
+
+// absOrSelf makes path absolute so exec.LookPath treats it as a file rather
+// than a name to search for; on failure path is returned unchanged.
+func absOrSelf(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
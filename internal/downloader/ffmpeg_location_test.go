@@ -0,0 +1,84 @@
+package downloader
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// useFakeFFmpeg installs a shell script as ffmpeg that records its arguments
+// to a log file, and restores the defaults when the test ends.
+func useFakeFFmpeg(t *testing.T) (dir, logPath string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake ffmpeg is a shell script")
+	}
+	dir = t.TempDir()
+	logPath = filepath.Join(dir, "invocations.log")
+	script := "#!/bin/sh\necho \"$@\" >> '" + logPath + "'\n"
+	if err := os.WriteFile(filepath.Join(dir, "ffmpeg"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	prevFFmpeg, prevFFprobe := ffmpegPath, ffprobePath
+	t.Cleanup(func() { ffmpegPath, ffprobePath = prevFFmpeg, prevFFprobe })
+	return dir, logPath
+}
+
+func TestSetFFmpegLocationUsedByExtractAudio(t *testing.T) {
+	dir, logPath := useFakeFFmpeg(t)
+	if err := SetFFmpegLocation(filepath.Join(dir, "ffmpeg")); err != nil {
+		t.Fatalf("SetFFmpegLocation: %v", err)
+	}
+	if !ffmpegAvailable() {
+		t.Fatal("expected the configured ffmpeg to be reported as available")
+	}
+
+	input := filepath.Join(dir, "in.mp4")
+	output := filepath.Join(dir, "out.mp3")
+	if err := extractAudio(input, output, "", "", ""); err != nil {
+		t.Fatalf("extractAudio: %v", err)
+	}
+	logged, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("expected the fake ffmpeg to run: %v", err)
+	}
+	if !strings.Contains(string(logged), input) || !strings.Contains(string(logged), output) {
+		t.Fatalf("expected ffmpeg arguments to name input and output, got %q", logged)
+	}
+}
+
+func TestSetFFmpegLocationAcceptsDirectory(t *testing.T) {
+	dir, _ := useFakeFFmpeg(t)
+	probe := filepath.Join(dir, "ffprobe")
+	if err := os.WriteFile(probe, []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetFFmpegLocation(dir); err != nil {
+		t.Fatalf("SetFFmpegLocation: %v", err)
+	}
+	if ffmpegPath != filepath.Join(dir, "ffmpeg") {
+		t.Fatalf("expected ffmpeg inside %s, got %s", dir, ffmpegPath)
+	}
+	if ffprobePath != probe {
+		t.Fatalf("expected ffprobe next to ffmpeg, got %s", ffprobePath)
+	}
+}
+
+func TestSetFFmpegLocationRejectsNonExecutable(t *testing.T) {
+	dir, _ := useFakeFFmpeg(t)
+	plain := filepath.Join(dir, "not-ffmpeg")
+	if err := os.WriteFile(plain, []byte("data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	before := ffmpegPath
+	for _, location := range []string{plain, filepath.Join(dir, "missing"), t.TempDir()} {
+		if err := SetFFmpegLocation(location); err == nil {
+			t.Errorf("expected %s to be rejected", location)
+		}
+	}
+	if ffmpegPath != before {
+		t.Fatalf("expected a rejected location to leave ffmpeg unchanged, got %s", ffmpegPath)
+	}
+}
@@ -9,6 +9,74 @@ import (
 	"github.com/lvcoi/ytdl-lib/v2"
 )
 
+// errBelowMinFileSize is returned by selectFormat when every otherwise
+// acceptable format is smaller than --min-filesize; the item is skipped.
+var errBelowMinFileSize = errors.New("below min-filesize")
+
+// ParseByteSize parses sizes like "500K", "1M", "1.5GiB" or plain bytes using
+// 1024-based units, matching how sizes are displayed.
+func ParseByteSize(value string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(value))
+	if s == "" {
+		return 0, errors.New("size is empty")
+	}
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "B"), "I")
+	multiplier := int64(1)
+	if n := len(s); n > 0 {
+		switch s[n-1] {
+		case 'K':
+			multiplier = 1 << 10
+		case 'M':
+			multiplier = 1 << 20
+		case 'G':
+			multiplier = 1 << 30
+		case 'T':
+			multiplier = 1 << 40
+		}
+		if multiplier > 1 {
+			s = s[:n-1]
+		}
+	}
+	number, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("invalid size %q (expected like 500K, 1M, 2G)", value)
+	}
+	return int64(number * float64(multiplier)), nil
+}
+
+// hdrItags are YouTube's HDR video itags (VP9.2 and AV1), for formats whose
+// quality label does not say HDR.
+var hdrItags = map[int]bool{
+	330: true, 331: true, 332: true, 333: true, 334: true, 335: true, 336: true, 337: true,
+	694: true, 695: true, 696: true, 697: true, 698: true, 699: true, 700: true, 701: true, 702: true,
+}
+
+// formatIsHDR reports whether a format carries HDR video. The library does
+// not expose the stream's colorInfo, so the quality label ("1080p60 HDR") and
+// the known HDR itags are used instead.
+func formatIsHDR(f *youtube.Format) bool {
+	return strings.Contains(strings.ToUpper(f.QualityLabel), "HDR") || hdrItags[f.ItagNo]
+}
+
+// formatIsSpatial reports whether a format is 360°/VR video, which YouTube
+// marks with a non-rectangular projection.
+func formatIsSpatial(f *youtube.Format) bool {
+	projection := strings.ToUpper(f.ProjectionType)
+	return projection != "" && projection != "RECTANGULAR"
+}
+
+// formatFlags lists a format's HDR and 360° markers for display.
+func formatFlags(f *youtube.Format) string {
+	var flags []string
+	if formatIsHDR(f) {
+		flags = append(flags, "HDR")
+	}
+	if formatIsSpatial(f) {
+		flags = append(flags, "360")
+	}
+	return strings.Join(flags, " ")
+}
+
 func selectFormat(video *youtube.Video, opts Options) (*youtube.Format, error) {
 	// If itag is specified, search for it directly.
 	// Itag takes precedence over quality, format, and audio-only options.
@@ -23,6 +91,7 @@ func selectFormat(video *youtube.Video, opts Options) (*youtube.Format, error) {
 	}
 
 	candidates := make([]*youtube.Format, 0, len(video.Formats))
+	belowMinSize := 0
 	for i := range video.Formats {
 		format := &video.Formats[i]
 
@@ -42,10 +111,18 @@ func selectFormat(video *youtube.Video, opts Options) (*youtube.Format, error) {
 		if opts.Format != "" && !formatMatches(format, opts.Format) {
 			continue
 		}
+		// Unknown sizes (ContentLength 0) are never filtered.
+		if opts.MinFileSize > 0 && format.ContentLength > 0 && format.ContentLength < opts.MinFileSize {
+			belowMinSize++
+			continue
+		}
 
 		candidates = append(candidates, format)
 	}
 
+	if len(candidates) == 0 && belowMinSize > 0 {
+		return nil, errBelowMinFileSize
+	}
 	if len(candidates) == 0 {
 		// If format was specified but not found, try again without format filter (fallback)
 		if opts.Format != "" {
@@ -86,7 +163,7 @@ func pickVideoFormat(candidates []*youtube.Format, opts Options) (*youtube.Forma
 	if targetHeight == 0 && !preferLowest {
 		var best *youtube.Format
 		for _, f := range candidates {
-			if best == nil || betterVideoFormat(f, best) {
+			if best == nil || betterVideoFormat(f, best, opts.PreferFreeFormats) {
 				best = f
 			}
 		}
@@ -99,7 +176,7 @@ func pickVideoFormat(candidates []*youtube.Format, opts Options) (*youtube.Forma
 			if f.Height == 0 || f.Height > targetHeight {
 				continue
 			}
-			if best == nil || f.Height > best.Height || (f.Height == best.Height && bitrateForFormat(f) > bitrateForFormat(best)) {
+			if best == nil || f.Height > best.Height || (f.Height == best.Height && betterAtSameHeight(f, best, opts.PreferFreeFormats)) {
 				best = f
 			}
 		}
@@ -108,7 +185,7 @@ func pickVideoFormat(candidates []*youtube.Format, opts Options) (*youtube.Forma
 	if best == nil && targetHeight > 0 {
 		// No option under target: pick the closest above target.
 		for _, f := range candidates {
-			if best == nil || f.Height < best.Height || (f.Height == best.Height && bitrateForFormat(f) > bitrateForFormat(best)) {
+			if best == nil || f.Height < best.Height || (f.Height == best.Height && betterAtSameHeight(f, best, opts.PreferFreeFormats)) {
 				best = f
 			}
 		}
@@ -116,7 +193,7 @@ func pickVideoFormat(candidates []*youtube.Format, opts Options) (*youtube.Forma
 
 	if best == nil && preferLowest {
 		for _, f := range candidates {
-			if best == nil || f.Height < best.Height || (f.Height == best.Height && bitrateForFormat(f) > bitrateForFormat(best)) {
+			if best == nil || f.Height < best.Height || (f.Height == best.Height && betterAtSameHeight(f, best, opts.PreferFreeFormats)) {
 				best = f
 			}
 		}
@@ -142,7 +219,7 @@ func pickAudioFormat(candidates []*youtube.Format, opts Options) (*youtube.Forma
 			if br == 0 || br > targetBitrate {
 				continue
 			}
-			if best == nil || br > bitrateForFormat(best) {
+			if best == nil || br > bitrateForFormat(best) || (br == bitrateForFormat(best) && preferFreeTiebreak(f, best, opts.PreferFreeFormats)) {
 				best = f
 			}
 		}
@@ -153,7 +230,7 @@ func pickAudioFormat(candidates []*youtube.Format, opts Options) (*youtube.Forma
 				if br == 0 {
 					continue
 				}
-				if best == nil || br < bitrateForFormat(best) {
+				if best == nil || br < bitrateForFormat(best) || (br == bitrateForFormat(best) && preferFreeTiebreak(f, best, opts.PreferFreeFormats)) {
 					best = f
 				}
 			}
@@ -166,7 +243,7 @@ func pickAudioFormat(candidates []*youtube.Format, opts Options) (*youtube.Forma
 			if br == 0 {
 				continue
 			}
-			if best == nil || br < bitrateForFormat(best) {
+			if best == nil || br < bitrateForFormat(best) || (br == bitrateForFormat(best) && preferFreeTiebreak(f, best, opts.PreferFreeFormats)) {
 				best = f
 			}
 		}
@@ -174,7 +251,7 @@ func pickAudioFormat(candidates []*youtube.Format, opts Options) (*youtube.Forma
 
 	if best == nil {
 		for _, f := range candidates {
-			if best == nil || bitrateForFormat(f) > bitrateForFormat(best) {
+			if best == nil || bitrateForFormat(f) > bitrateForFormat(best) || (bitrateForFormat(f) == bitrateForFormat(best) && preferFreeTiebreak(f, best, opts.PreferFreeFormats)) {
 				best = f
 			}
 		}
@@ -229,9 +306,45 @@ func formatMatches(format *youtube.Format, desired string) bool {
 	return strings.EqualFold(mimeToExt(format.MimeType), strings.TrimSpace(strings.ToLower(desired)))
 }
 
-func betterVideoFormat(candidate, current *youtube.Format) bool {
+func betterVideoFormat(candidate, current *youtube.Format, preferFree bool) bool {
 	if candidate.Height != current.Height {
 		return candidate.Height > current.Height
 	}
+	return betterAtSameHeight(candidate, current, preferFree)
+}
+
+// betterAtSameHeight breaks ties between two formats of equal resolution.
+// Bitrates across codecs are not comparable (vp9 is more efficient than
+// avc1), so with preferFree the codec decides before bitrate does.
+func betterAtSameHeight(candidate, current *youtube.Format, preferFree bool) bool {
+	if preferFree {
+		if candidateFree, currentFree := isFreeFormat(candidate), isFreeFormat(current); candidateFree != currentFree {
+			return candidateFree
+		}
+	}
 	return bitrateForFormat(candidate) > bitrateForFormat(current)
 }
+
+// preferFreeTiebreak reports whether candidate should replace an otherwise
+// equal current format under --prefer-free-formats.
+func preferFreeTiebreak(candidate, current *youtube.Format, preferFree bool) bool {
+	return preferFree && isFreeFormat(candidate) && !isFreeFormat(current)
+}
+
+// freeCodecs are royalty-free codecs favored by --prefer-free-formats.
+var freeCodecs = []string{"vp9", "vp09", "vp8", "av01", "opus", "vorbis", "flac"}
+
+// isFreeFormat reports whether a format uses a webm container or a
+// royalty-free codec.
+func isFreeFormat(format *youtube.Format) bool {
+	mime := strings.ToLower(format.MimeType)
+	if strings.Contains(mime, "/webm") {
+		return true
+	}
+	for _, codec := range freeCodecs {
+		if strings.Contains(mime, codec) {
+			return true
+		}
+	}
+	return false
+}
@@ -104,11 +104,37 @@ func newFormatSelectorModel(video *youtube.Video, title string, playlistID, play
 	}
 }
 
+// sortFormatsByQuality returns formats ordered best first for --sort-formats:
+// tallest video first, HDR ahead of SDR at the same height, then highest
+// bitrate. Audio-only formats have no height and so follow the video formats,
+// ordered by bitrate.
+func sortFormatsByQuality(formats []youtube.Format) []youtube.Format {
+	sorted := make([]youtube.Format, len(formats))
+	copy(sorted, formats)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Height != sorted[j].Height {
+			return sorted[i].Height > sorted[j].Height
+		}
+		if hdrI, hdrJ := formatIsHDR(&sorted[i]), formatIsHDR(&sorted[j]); hdrI != hdrJ {
+			return hdrI
+		}
+		return sorted[i].Bitrate > sorted[j].Bitrate
+	})
+	return sorted
+}
+
+// sortByQuality reorders the listed formats with sortFormatsByQuality in
+// place of the default itag order.
+func (m *formatSelectorModel) sortByQuality() {
+	m.formats = sortFormatsByQuality(m.formats)
+	m.updateContent()
+}
+
 func buildFormatContent(formats []youtube.Format, selected int) string {
 	var b strings.Builder
 
 	// Header
-	b.WriteString(selectorHeaderStyle.Render("itag   ext    quality      size       audio   video"))
+	b.WriteString(selectorHeaderStyle.Render("itag   ext    quality      size       audio   video       flags"))
 	b.WriteString("\n")
 
 	for i, f := range formats {
@@ -129,13 +155,14 @@ func buildFormatContent(formats []youtube.Format, selected int) string {
 			qual = f.Quality
 		}
 
-		line := fmt.Sprintf("%5d   %-5s  %-12s %-10s %-7s %s",
+		line := fmt.Sprintf("%5d   %-5s  %-12s %-10s %-7s %-11s %s",
 			f.ItagNo,
 			mimeToExt(f.MimeType),
 			qual,
 			size,
 			audio,
 			videoRes,
+			formatFlags(&f),
 		)
 
 		if i == selected {
@@ -0,0 +1,149 @@
+package downloader
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/lvcoi/ytdl-lib/v2"
+)
+
+func TestSortFormatsByQuality(t *testing.T) {
+	formats := []youtube.Format{
+		{ItagNo: 140, MimeType: "audio/mp4", Bitrate: 130000},
+		{ItagNo: 18, MimeType: "video/mp4", Height: 360, Bitrate: 500000},
+		{ItagNo: 251, MimeType: "audio/webm", Bitrate: 160000},
+		{ItagNo: 137, MimeType: "video/mp4", Height: 1080, Bitrate: 4000000},
+		{ItagNo: 248, MimeType: "video/webm", Height: 1080, Bitrate: 2500000},
+		{ItagNo: 22, MimeType: "video/mp4", Height: 720, Bitrate: 1200000},
+	}
+	sorted := sortFormatsByQuality(formats)
+
+	want := []int{137, 248, 22, 18, 251, 140}
+	for i, f := range sorted {
+		if f.ItagNo != want[i] {
+			t.Fatalf("sorted itags = %v, want %v", itagsOf(sorted), want)
+		}
+	}
+	if formats[0].ItagNo != 140 {
+		t.Fatalf("expected the input order to be left alone, got %v", itagsOf(formats))
+	}
+
+	model := newFormatSelectorModel(&youtube.Video{Formats: formats}, "", "", "", 0, 0)
+	if model.formats[0].ItagNo != 18 {
+		t.Fatalf("expected the selector to list by itag by default, got %v", itagsOf(model.formats))
+	}
+	model.sortByQuality()
+	if got := itagsOf(model.formats); got[0] != 137 || got[len(got)-1] != 140 {
+		t.Fatalf("expected the selector to list best formats first, got %v", got)
+	}
+}
+
+func itagsOf(formats []youtube.Format) []int {
+	itags := make([]int, len(formats))
+	for i, f := range formats {
+		itags[i] = f.ItagNo
+	}
+	return itags
+}
+
+// hdrFixtureFormats mirrors a 360° upload with HDR renditions: one labelled
+// HDR, one HDR only by itag, an SDR 360° stream and plain audio.
+func hdrFixtureFormats() []youtube.Format {
+	return []youtube.Format{
+		{ItagNo: 337, MimeType: `video/webm; codecs="vp09.02.51.10.01.09.16.09.00"`, QualityLabel: "2160p60 HDR", Height: 2160, Width: 3840, Bitrate: 30000000, ProjectionType: "EQUIRECTANGULAR"},
+		{ItagNo: 701, MimeType: `video/mp4; codecs="av01.0.13M.10.0.110.09.16.09.0"`, QualityLabel: "2160p60", Height: 2160, Width: 3840, Bitrate: 25000000, ProjectionType: "RECTANGULAR"},
+		{ItagNo: 315, MimeType: `video/webm; codecs="vp9"`, QualityLabel: "2160p60", Height: 2160, Width: 3840, Bitrate: 40000000, ProjectionType: "EQUIRECTANGULAR"},
+		{ItagNo: 140, MimeType: `audio/mp4; codecs="mp4a.40.2"`, AudioChannels: 2, Bitrate: 130000},
+	}
+}
+
+func TestFormatFlagsSurfaceHDRAndSpatial(t *testing.T) {
+	formats := hdrFixtureFormats()
+	wantFlags := map[int]string{337: "HDR 360", 701: "HDR", 315: "360", 140: ""}
+	for i := range formats {
+		if got := formatFlags(&formats[i]); got != wantFlags[formats[i].ItagNo] {
+			t.Errorf("formatFlags(itag %d) = %q, want %q", formats[i].ItagNo, got, wantFlags[formats[i].ItagNo])
+		}
+	}
+
+	content := buildFormatContent(formats, -1)
+	for _, line := range strings.Split(content, "\n") {
+		if strings.Contains(line, "  337 ") && !strings.Contains(line, "HDR 360") {
+			t.Fatalf("expected the HDR row to show its flags, got %q", line)
+		}
+	}
+
+	// HDR outranks a higher-bitrate SDR stream of the same height.
+	if got := itagsOf(sortFormatsByQuality(formats)); got[0] != 337 || got[1] != 701 || got[2] != 315 {
+		t.Fatalf("expected HDR formats first at equal height, got %v", got)
+	}
+}
+
+func TestRenderFormatsJSONReportsHDR(t *testing.T) {
+	out, err := os.CreateTemp(t.TempDir(), "formats-*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	stdout := os.Stdout
+	os.Stdout = out
+	err = renderFormatsJSON(&youtube.Video{ID: "abc123", Formats: hdrFixtureFormats()}, "", "", 0, 0)
+	os.Stdout = stdout
+	if err != nil {
+		t.Fatalf("renderFormatsJSON: %v", err)
+	}
+
+	data, err := os.ReadFile(out.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payload struct {
+		Formats []formatInfo `json:"formats"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		t.Fatalf("decoding %s: %v", data, err)
+	}
+	got := make(map[int]formatInfo)
+	for _, f := range payload.Formats {
+		got[f.Itag] = f
+	}
+	if !got[337].HDR || !got[337].Spatial || !got[701].HDR || got[701].Spatial || got[315].HDR || got[140].HDR {
+		t.Fatalf("unexpected hdr/spatial flags: %+v", payload.Formats)
+	}
+}
+
+func TestRenderFormatsPrintsBareArray(t *testing.T) {
+	out, err := os.CreateTemp(t.TempDir(), "formats-*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	stdout := os.Stdout
+	os.Stdout = out
+	video := &youtube.Video{ID: "abc123", Title: "Fixture", Formats: hdrFixtureFormats()}
+	err = renderFormats(video, Options{JSON: true, FormatsArrayJSON: true, SortFormats: true}, "PL1", "Playlist", 1, 2)
+	os.Stdout = stdout
+	if err != nil {
+		t.Fatalf("renderFormats: %v", err)
+	}
+
+	data, err := os.ReadFile(out.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trimmed := strings.TrimSpace(string(data)); !strings.HasPrefix(trimmed, "[") || !strings.HasSuffix(trimmed, "]") {
+		t.Fatalf("expected a bare JSON array, got %s", data)
+	}
+	var formats []formatInfo
+	if err := json.Unmarshal(data, &formats); err != nil {
+		t.Fatalf("decoding %s: %v", data, err)
+	}
+	if len(formats) != len(video.Formats) {
+		t.Fatalf("expected %d formats, got %d", len(video.Formats), len(formats))
+	}
+	if formats[0].Itag != 337 {
+		t.Fatalf("expected -sort-formats to still apply, first itag %d", formats[0].Itag)
+	}
+}
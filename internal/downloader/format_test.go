@@ -1,6 +1,7 @@
 package downloader
 
 import (
+	"errors"
 	"testing"
 
 	youtube "github.com/lvcoi/ytdl-lib/v2"
@@ -80,3 +81,103 @@ func TestSelectFormat(t *testing.T) {
 		})
 	}
 }
+
+func TestPreferFreeFormatsBreaksTies(t *testing.T) {
+	video := &youtube.Video{
+		Formats: youtube.FormatList{
+			{ItagNo: 100, MimeType: `video/mp4; codecs="avc1.64001F, mp4a.40.2"`, Width: 1280, Height: 720, AudioChannels: 2, Bitrate: 2_000_000},
+			{ItagNo: 101, MimeType: `video/webm; codecs="vp9, opus"`, Width: 1280, Height: 720, AudioChannels: 2, Bitrate: 2_000_000},
+			{ItagNo: 140, MimeType: `audio/mp4; codecs="mp4a.40.2"`, AudioChannels: 2, Bitrate: 128_000},
+			{ItagNo: 250, MimeType: `audio/webm; codecs="opus"`, AudioChannels: 2, Bitrate: 128_000},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		opts     Options
+		wantItag int
+	}{
+		{name: "video keeps first without flag", opts: Options{}, wantItag: 100},
+		{name: "video prefers vp9 with flag", opts: Options{PreferFreeFormats: true}, wantItag: 101},
+		{name: "video prefers vp9 at target height", opts: Options{PreferFreeFormats: true, Quality: "720p"}, wantItag: 101},
+		{name: "audio keeps first without flag", opts: Options{AudioOnly: true}, wantItag: 140},
+		{name: "audio prefers opus with flag", opts: Options{AudioOnly: true, PreferFreeFormats: true}, wantItag: 250},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := selectFormat(video, tt.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.ItagNo != tt.wantItag {
+				t.Errorf("got itag %d, want %d", got.ItagNo, tt.wantItag)
+			}
+		})
+	}
+}
+
+func TestPreferFreeFormatsDoesNotTradeResolution(t *testing.T) {
+	video := &youtube.Video{
+		Formats: youtube.FormatList{
+			{ItagNo: 37, MimeType: "video/mp4", Width: 1920, Height: 1080, AudioChannels: 2, Bitrate: 4_000_000},
+			{ItagNo: 43, MimeType: "video/webm", Width: 1280, Height: 720, AudioChannels: 2, Bitrate: 2_000_000},
+		},
+	}
+	got, err := selectFormat(video, Options{PreferFreeFormats: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.ItagNo != 37 {
+		t.Fatalf("got itag %d, want 37", got.ItagNo)
+	}
+}
+
+func TestSelectFormatMinFileSize(t *testing.T) {
+	video := &youtube.Video{
+		Formats: youtube.FormatList{
+			{ItagNo: 18, MimeType: "video/mp4", Width: 640, Height: 360, AudioChannels: 2, Bitrate: 500_000, ContentLength: 4 << 20},
+			{ItagNo: 22, MimeType: "video/mp4", Width: 1280, Height: 720, AudioChannels: 2, Bitrate: 2_000_000, ContentLength: 512},
+			{ItagNo: 140, MimeType: "audio/mp4", AudioChannels: 2, Bitrate: 128_000, ContentLength: 100},
+			{ItagNo: 251, MimeType: "audio/webm", AudioChannels: 2, Bitrate: 160_000, ContentLength: 200},
+		},
+	}
+
+	got, err := selectFormat(video, Options{MinFileSize: 1 << 20})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.ItagNo != 18 {
+		t.Fatalf("expected tiny 720p placeholder to be filtered, got itag %d", got.ItagNo)
+	}
+
+	if _, err := selectFormat(video, Options{AudioOnly: true, MinFileSize: 1 << 20}); !errors.Is(err, errBelowMinFileSize) {
+		t.Fatalf("expected errBelowMinFileSize when every format is too small, got %v", err)
+	}
+
+	video.Formats[3].ContentLength = 0
+	got, err = selectFormat(video, Options{AudioOnly: true, MinFileSize: 1 << 20})
+	if err != nil || got.ItagNo != 251 {
+		t.Fatalf("expected unknown-size format to bypass the check, got %v, %v", got, err)
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := map[string]int64{
+		"512":    512,
+		"500K":   500 << 10,
+		"1M":     1 << 20,
+		"1mb":    1 << 20,
+		"1.5GiB": 3 << 29,
+	}
+	for in, want := range tests {
+		got, err := ParseByteSize(in)
+		if err != nil || got != want {
+			t.Fatalf("ParseByteSize(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "abc", "-1M", "M"} {
+		if _, err := ParseByteSize(in); err == nil {
+			t.Fatalf("expected error for %q", in)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package downloader
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// downloadHLSWithFFmpeg hands the media playlist to ffmpeg for
+// --hls-use-ffmpeg. ffmpeg fetches the segments itself and remuxes them
+// without re-encoding, which copes with discontinuities and timestamp resets
+// that a native byte-level stitch passes through unchanged.
+func downloadHLSWithFFmpeg(ctx context.Context, playlistURL, outputPath, baseDir string, opts Options, printer *Printer, prefix string) (result downloadResult, err error) {
+	// The temp file keeps the output extension so ffmpeg picks the muxer.
+	tmpPath, err := artifactPath(outputPath, partSuffix+filepath.Ext(outputPath), baseDir)
+	if err != nil {
+		return downloadResult{}, err
+	}
+	defer func() {
+		if err != nil {
+			cleanupFailedArtifacts(ctx, opts, tmpPath)
+		}
+	}()
+
+	if printer != nil {
+		printer.Log(LogInfo, fmt.Sprintf("%s downloading HLS stream with ffmpeg", prefix))
+	}
+	output, err := exec.CommandContext(ctx, ffmpegPath, hlsFFmpegArgs(playlistURL, tmpPath)...).CombinedOutput()
+	if err != nil {
+		if ctx.Err() != nil {
+			return downloadResult{}, ctx.Err()
+		}
+		stderr := strings.TrimSpace(string(output))
+		if stderr != "" {
+			return downloadResult{}, wrapCategory(CategoryNetwork, fmt.Errorf("ffmpeg HLS download: %s: %w", stderr, err))
+		}
+		return downloadResult{}, wrapCategory(CategoryNetwork, fmt.Errorf("ffmpeg HLS download: %w", err))
+	}
+	if err := os.Rename(tmpPath, outputPath); err != nil {
+		return downloadResult{}, wrapCategory(CategoryFilesystem, fmt.Errorf("renaming output: %w", err))
+	}
+	if err := validateOutputFile(outputPath, nil); err != nil {
+		return downloadResult{}, err
+	}
+	info, err := os.Stat(outputPath)
+	if err != nil {
+		return downloadResult{}, wrapCategory(CategoryFilesystem, fmt.Errorf("stat output: %w", err))
+	}
+	return downloadResult{bytes: info.Size(), outputPath: outputPath}, nil
+}
+
+func hlsFFmpegArgs(playlistURL, outputPath string) []string {
+	return []string{
+		"-hide_banner", "-loglevel", "error",
+		"-i", playlistURL,
+		"-map", "0",
+		"-c", "copy",
+		"-y", outputPath,
+	}
+}
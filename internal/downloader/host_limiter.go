@@ -0,0 +1,107 @@
+package downloader
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// hostLimiter caps how many requests may be in flight to the same host at
+// once. A slot is held from the moment a request is sent until its response
+// body is closed, so it bounds open connections rather than request starts.
+// It is shared by every client in the process; a limit of 0 disables it.
+type hostLimiter struct {
+	mu    sync.Mutex
+	limit int
+	hosts map[string]chan struct{}
+}
+
+// connLimiter is the process-wide limiter behind --max-connections-per-host.
+var connLimiter = &hostLimiter{}
+
+// SetMaxConnectionsPerHost caps concurrent connections to any one host across
+// all jobs and segment workers, for --max-connections-per-host. A limit of 0
+// removes the cap. It must be called before any downloads start.
+func SetMaxConnectionsPerHost(limit int) {
+	connLimiter.setLimit(limit)
+}
+
+func (l *hostLimiter) setLimit(limit int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit = limit
+	l.hosts = make(map[string]chan struct{})
+}
+
+// acquire blocks until a slot for host is free or ctx is done. The returned
+// release must be called exactly once; it is a no-op without a limit.
+func (l *hostLimiter) acquire(ctx context.Context, host string) (func(), error) {
+	l.mu.Lock()
+	if l.limit <= 0 {
+		l.mu.Unlock()
+		return func() {}, nil
+	}
+	host = strings.ToLower(host)
+	slots, ok := l.hosts[host]
+	if !ok {
+		slots = make(chan struct{}, l.limit)
+		l.hosts[host] = slots
+	}
+	l.mu.Unlock()
+
+	select {
+	case slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	var once sync.Once
+	return func() { once.Do(func() { <-slots }) }, nil
+}
+
+// limitedTransport routes requests through a hostLimiter before handing them
+// to base.
+type limitedTransport struct {
+	base    http.RoundTripper
+	limiter *hostLimiter
+}
+
+func newLimitedTransport(base http.RoundTripper, limiter *hostLimiter) http.RoundTripper {
+	return &limitedTransport{base: base, limiter: limiter}
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	release, err := t.limiter.acquire(req.Context(), req.URL.Host)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp.Body = &releaseOnClose{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// releaseOnClose frees a limiter slot once the response body is drained or
+// closed, whichever comes first.
+type releaseOnClose struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releaseOnClose) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.release()
+	}
+	return n, err
+}
+
+func (b *releaseOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
@@ -0,0 +1,127 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHostLimiterCapsSegmentFetchesAcrossDownloads(t *testing.T) {
+	const limit = 2
+	var inFlight, peak atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			old := peak.Load()
+			if n <= old || peak.CompareAndSwap(old, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		_, _ = w.Write(bytes.Repeat([]byte("x"), 128))
+	}))
+	defer srv.Close()
+
+	SetMaxConnectionsPerHost(limit)
+	t.Cleanup(func() { SetMaxConnectionsPerHost(0) })
+
+	client := &mockYouTubeClient{httpDoer: &http.Client{Transport: newLimitedTransport(srv.Client().Transport, connLimiter)}}
+	urls := make([]string, 6)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("%s/seg%d.ts", srv.URL, i)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		tempDir, err := validateSegmentTempDir(fmt.Sprintf("limit-test-%d-%d.segments", time.Now().UnixNano(), i))
+		if err != nil {
+			t.Fatalf("validateSegmentTempDir: %v", err)
+		}
+		t.Cleanup(func() { os.RemoveAll(tempDir) })
+		plan := segmentDownloadPlan{URLs: urls, TempDir: tempDir, Concurrency: 4}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, err := downloadSegmentsParallel(context.Background(), client, plan, &bytes.Buffer{}, nil)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("downloadSegmentsParallel: %v", err)
+		}
+	}
+	if got := peak.Load(); got > limit {
+		t.Fatalf("expected at most %d concurrent fetches, saw %d", limit, got)
+	}
+}
+
+func TestHostLimiterUnlimitedByDefault(t *testing.T) {
+	limiter := &hostLimiter{}
+	for i := 0; i < 10; i++ {
+		if _, err := limiter.acquire(context.Background(), "example.com"); err != nil {
+			t.Fatalf("acquire: %v", err)
+		}
+	}
+}
+
+func TestHostLimiterAcquireHonorsContext(t *testing.T) {
+	limiter := &hostLimiter{}
+	limiter.setLimit(1)
+	release, err := limiter.acquire(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	if _, err := limiter.acquire(context.Background(), "other.example.com"); err != nil {
+		t.Fatalf("expected another host to have its own slot: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := limiter.acquire(ctx, "EXAMPLE.com"); err == nil {
+		t.Fatal("expected a second acquire for the same host to wait for the context")
+	}
+	release()
+	release()
+	if _, err := limiter.acquire(context.Background(), "example.com"); err != nil {
+		t.Fatalf("expected the released slot to be free: %v", err)
+	}
+}
+
+func TestRetryReleasesHostSlotBeforeRetrying(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte("busy"))
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	limiter := &hostLimiter{}
+	limiter.setLimit(1)
+	client := &http.Client{
+		Timeout:   2 * time.Second,
+		Transport: newRetryTransport(newLimitedTransport(srv.Client().Transport, limiter), retryConfig{MaxRetries: 2, InitialDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}),
+	}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("expected the retry to get a slot, got %v (server hits %d)", err, hits.Load())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || hits.Load() != 2 {
+		t.Fatalf("status %d after %d hits, want 200 after 2", resp.StatusCode, hits.Load())
+	}
+}
@@ -2,12 +2,16 @@ package downloader
 
 import (
 	"bytes"
+	"crypto/tls"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net"
 	"net/http"
 	"net/http/cookiejar"
+	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	youtube "github.com/lvcoi/ytdl-lib/v2"
@@ -17,19 +21,186 @@ const defaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/
 const musicUserAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/122.0.0.0 Safari/537.36"
 
 var sharedTransport = &http.Transport{
-	MaxIdleConns:        100,
-	MaxIdleConnsPerHost: 10,
-	DialContext: (&net.Dialer{
-		Timeout:   30 * time.Second,
-		KeepAlive: 30 * time.Second,
-	}).DialContext,
+	MaxIdleConns:          100,
+	MaxIdleConnsPerHost:   10,
+	DialContext:           newDialer(nil).DialContext,
 	TLSHandshakeTimeout:   10 * time.Second,
 	ResponseHeaderTimeout: 15 * time.Second,
 	IdleConnTimeout:       90 * time.Second,
+	// A custom DialContext disables h2 unless it is requested explicitly.
+	ForceAttemptHTTP2: true,
 }
 
+// interfaceAddrs lists the host's interface addresses; tests replace it.
+var interfaceAddrs = net.InterfaceAddrs
+
 func CloseIdleConnections() {
 	sharedTransport.CloseIdleConnections()
+	metadataProxyMu.Lock()
+	defer metadataProxyMu.Unlock()
+	if metadataTransport != nil {
+		metadataTransport.CloseIdleConnections()
+	}
+	insecureMediaMu.Lock()
+	defer insecureMediaMu.Unlock()
+	if insecureMediaTransport != nil {
+		insecureMediaTransport.CloseIdleConnections()
+	}
+}
+
+// newDialer returns the dialer used for outgoing connections, bound to
+// localIP when it is non-nil.
+func newDialer(localIP net.IP) *net.Dialer {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	if localIP != nil {
+		dialer.LocalAddr = &net.TCPAddr{IP: localIP}
+	}
+	return dialer
+}
+
+// parseSourceAddress validates a --source-address value: it must be an IP
+// literal assigned to one of this host's interfaces.
+func parseSourceAddress(value string) (net.IP, error) {
+	ip := net.ParseIP(strings.TrimSpace(value))
+	if ip == nil {
+		return nil, fmt.Errorf("invalid source address %q: not an IP address", value)
+	}
+	addrs, err := interfaceAddrs()
+	if err != nil {
+		return nil, fmt.Errorf("listing local addresses: %w", err)
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.Equal(ip) {
+			return ip, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid source address %q: not assigned to a local interface", value)
+}
+
+// SetSourceAddress binds every outgoing connection to the local IP value, for
+// --source-address. It must be called before any requests are made.
+func SetSourceAddress(value string) error {
+	ip, err := parseSourceAddress(value)
+	if err != nil {
+		return err
+	}
+	sharedTransport.DialContext = newDialer(ip).DialContext
+	return nil
+}
+
+// insecureMedia is set by --no-check-certificate. insecureMediaTransport is
+// built from the shared transport on first use, like metadataTransport, and
+// carries only streams, segments and direct downloads.
+var (
+	insecureMediaMu        sync.Mutex
+	insecureMedia          bool
+	insecureMediaTransport *http.Transport
+)
+
+// DisableCertificateCheck turns off TLS certificate verification for media
+// downloads, for --no-check-certificate. Metadata requests and everything
+// else built on the shared transport still verify certificates. It must be
+// called before any requests are made.
+func DisableCertificateCheck() {
+	insecureMediaMu.Lock()
+	defer insecureMediaMu.Unlock()
+	insecureMedia = true
+	insecureMediaTransport = nil
+}
+
+// mediaBaseTransport returns the transport for streams, segments and direct
+// downloads: the shared transport, or an unverified clone of it under
+// --no-check-certificate.
+func mediaBaseTransport() *http.Transport {
+	insecureMediaMu.Lock()
+	defer insecureMediaMu.Unlock()
+	if !insecureMedia {
+		return sharedTransport
+	}
+	if insecureMediaTransport == nil {
+		insecureMediaTransport = sharedTransport.Clone()
+		insecureMediaTransport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec // explicitly requested by the user
+	}
+	return insecureMediaTransport
+}
+
+// ForceHTTP1 limits the shared transport to HTTP/1.1, for --force-http1.
+// Some CDN edges throttle or reset HTTP/2 streams that behave fine over
+// separate HTTP/1.1 connections. It must be called before any requests are
+// made.
+func ForceHTTP1() {
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	sharedTransport.Protocols = protocols
+	sharedTransport.ForceAttemptHTTP2 = false
+}
+
+// SetIdleConnTimeout sets how long an idle connection stays in the shared
+// pool, for --idle-conn-timeout. Zero keeps idle connections until the
+// server closes them.
+func SetIdleConnTimeout(timeout time.Duration) {
+	sharedTransport.IdleConnTimeout = timeout
+}
+
+// DisableKeepAlives makes the shared transport open a new connection for
+// every request, for --no-keep-alive.
+func DisableKeepAlives() {
+	sharedTransport.DisableKeepAlives = true
+}
+
+// metadataProxy is the --metadata-proxy URL. metadataTransport is built from
+// the shared transport on first use, so the other transport flags apply to it
+// too.
+var (
+	metadataProxyMu   sync.Mutex
+	metadataProxy     *url.URL
+	metadataTransport *http.Transport
+)
+
+// SetMetadataProxy sends YouTube metadata requests (the innertube video and
+// playlist calls and the YouTube Music API) through the proxy at rawURL, for
+// --metadata-proxy. Streams, manifests and segments keep the direct
+// connection. It must be called before any requests are made.
+func SetMetadataProxy(rawURL string) error {
+	proxyURL, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || proxyURL.Host == "" {
+		return fmt.Errorf("invalid proxy URL %q (want scheme://host:port)", rawURL)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return fmt.Errorf("unsupported proxy scheme %q (want http, https, socks5 or socks5h)", proxyURL.Scheme)
+	}
+	metadataProxyMu.Lock()
+	defer metadataProxyMu.Unlock()
+	metadataProxy = proxyURL
+	metadataTransport = nil
+	return nil
+}
+
+// metadataProxyEnabled reports whether --metadata-proxy is set.
+func metadataProxyEnabled() bool {
+	metadataProxyMu.Lock()
+	defer metadataProxyMu.Unlock()
+	return metadataProxy != nil
+}
+
+// metadataBaseTransport returns the transport for metadata requests, or nil
+// when --metadata-proxy is not set and they share the direct transport.
+func metadataBaseTransport() *http.Transport {
+	metadataProxyMu.Lock()
+	defer metadataProxyMu.Unlock()
+	if metadataProxy == nil {
+		return nil
+	}
+	if metadataTransport == nil {
+		metadataTransport = sharedTransport.Clone()
+		metadataTransport.Proxy = http.ProxyURL(metadataProxy)
+	}
+	return metadataTransport
 }
 
 type consistentTransport struct {
@@ -85,8 +256,27 @@ func (t *bgTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 }
 
 func newHTTPClient(timeout time.Duration) *http.Client {
+	return newHTTPClientOver(sharedTransport, timeout)
+}
+
+// newMetadataHTTPClient is newHTTPClient for YouTube metadata endpoints; it
+// goes through --metadata-proxy when one is set.
+func newMetadataHTTPClient(timeout time.Duration) *http.Client {
+	if proxied := metadataBaseTransport(); proxied != nil {
+		return newHTTPClientOver(proxied, timeout)
+	}
+	return newHTTPClient(timeout)
+}
+
+// newMediaHTTPClient is newHTTPClient for direct media downloads; it skips
+// certificate verification under --no-check-certificate.
+func newMediaHTTPClient(timeout time.Duration) *http.Client {
+	return newHTTPClientOver(mediaBaseTransport(), timeout)
+}
+
+func newHTTPClientOver(base http.RoundTripper, timeout time.Duration) *http.Client {
 	var transport http.RoundTripper = &consistentTransport{
-		base:      sharedTransport,
+		base:      newRateLimitedTransport(newLimitedTransport(base, connLimiter), downloadLimiter),
 		userAgent: defaultUserAgent,
 	}
 	transport = newRetryTransport(transport, defaultRetryConfig)
@@ -98,25 +288,46 @@ func newHTTPClient(timeout time.Duration) *http.Client {
 
 func newClient(opts Options) YouTubeClient {
 	jar, _ := cookiejar.New(nil)
+	var poToken string
+	if bg, err := NewBgUtils(); err == nil {
+		if token, err := bg.GeneratePlaceholder("WEB"); err == nil {
+			poToken = token
+		}
+	}
+	mediaBase := mediaBaseTransport()
+	media := &youtube.Client{HTTPClient: newYouTubeHTTPClient(mediaBase, jar, poToken, opts)}
+	metadataBase := metadataBaseTransport()
+	if metadataBase == nil {
+		if mediaBase == sharedTransport {
+			return &youtubeClientAdapter{Client: media}
+		}
+		metadataBase = sharedTransport
+	}
+	// With --metadata-proxy or --no-check-certificate the innertube calls
+	// use their own client, and streams and segments stay on the media one.
+	return &youtubeClientAdapter{
+		Client: &youtube.Client{HTTPClient: newYouTubeHTTPClient(metadataBase, jar, poToken, opts)},
+		media:  media,
+	}
+}
+
+func newYouTubeHTTPClient(base http.RoundTripper, jar http.CookieJar, poToken string, opts Options) *http.Client {
 	var transport http.RoundTripper = &consistentTransport{
-		base:      sharedTransport,
+		base:      newRateLimitedTransport(newLimitedTransport(base, connLimiter), downloadLimiter),
 		userAgent: defaultUserAgent,
 	}
-	if bg, err := NewBgUtils(); err == nil {
-		if token, err := bg.GeneratePlaceholder("WEB"); err == nil {
-			transport = &bgTransport{
-				base:    transport,
-				poToken: token,
-			}
+	if poToken != "" {
+		transport = &bgTransport{
+			base:    transport,
+			poToken: poToken,
 		}
 	}
 	transport = newRetryTransport(transport, defaultRetryConfig)
-	httpClient := &http.Client{
+	return &http.Client{
 		Timeout:   opts.Timeout,
 		Jar:       jar,
 		Transport: transport,
 	}
-	return &youtubeClientAdapter{&youtube.Client{HTTPClient: httpClient}}
 }
 
 // newClientForType creates a YouTubeClient configured for the given
@@ -1,12 +1,18 @@
 package downloader
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"sync"
 	"testing"
 	"time"
+
+	youtube "github.com/lvcoi/ytdl-lib/v2"
 )
 
 func TestConsistentTransportDoesNotMutateOriginalRequest(t *testing.T) {
@@ -316,3 +322,256 @@ func TestNewClientForType_ConcurrentSafety(t *testing.T) {
 		seen[adapter.Client.HTTPClient] = true
 	}
 }
+
+func TestNewDialerBindsSourceAddress(t *testing.T) {
+	if dialer := newDialer(nil); dialer.LocalAddr != nil {
+		t.Fatalf("expected no LocalAddr by default, got %v", dialer.LocalAddr)
+	}
+
+	ip := net.ParseIP("192.0.2.10")
+	dialer := newDialer(ip)
+	addr, ok := dialer.LocalAddr.(*net.TCPAddr)
+	if !ok || !addr.IP.Equal(ip) || addr.Port != 0 {
+		t.Fatalf("expected LocalAddr 192.0.2.10:0, got %v", dialer.LocalAddr)
+	}
+}
+
+func TestParseSourceAddress(t *testing.T) {
+	original := interfaceAddrs
+	t.Cleanup(func() { interfaceAddrs = original })
+	interfaceAddrs = func() ([]net.Addr, error) {
+		return []net.Addr{
+			&net.IPNet{IP: net.ParseIP("127.0.0.1"), Mask: net.CIDRMask(8, 32)},
+			&net.IPNet{IP: net.ParseIP("2001:db8::1"), Mask: net.CIDRMask(64, 128)},
+		}, nil
+	}
+
+	for _, value := range []string{"127.0.0.1", "2001:db8::1", " 127.0.0.1 "} {
+		if _, err := parseSourceAddress(value); err != nil {
+			t.Fatalf("parseSourceAddress(%q) returned error: %v", value, err)
+		}
+	}
+	for _, value := range []string{"", "eth0", "example.com", "127.0.0.2", "203.0.113.5"} {
+		if _, err := parseSourceAddress(value); err == nil {
+			t.Fatalf("parseSourceAddress(%q) expected error", value)
+		}
+	}
+}
+
+func TestDisableCertificateCheck(t *testing.T) {
+	t.Cleanup(func() {
+		insecureMediaMu.Lock()
+		insecureMedia, insecureMediaTransport = false, nil
+		insecureMediaMu.Unlock()
+	})
+	if mediaBaseTransport() != sharedTransport {
+		t.Fatal("expected media downloads to use the verifying shared transport by default")
+	}
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	verifies := func(doer HTTPDoer) bool {
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := doer.Do(req)
+		if err != nil {
+			return true
+		}
+		resp.Body.Close()
+		return false
+	}
+
+	if !verifies(newMediaHTTPClient(5 * time.Second)) {
+		t.Fatal("expected a self-signed certificate to be rejected by default")
+	}
+
+	DisableCertificateCheck()
+	if sharedTransport.TLSClientConfig != nil && sharedTransport.TLSClientConfig.InsecureSkipVerify {
+		t.Fatal("expected the shared transport to keep verifying certificates")
+	}
+	if verifies(newMediaHTTPClient(5 * time.Second)) {
+		t.Fatal("expected direct media downloads to accept the self-signed certificate")
+	}
+	if !verifies(newHTTPClient(5 * time.Second)) {
+		t.Fatal("expected other requests to keep verifying certificates")
+	}
+	adapter := newClient(Options{Timeout: 5 * time.Second}).(*youtubeClientAdapter)
+	if verifies(adapter.HTTP()) {
+		t.Fatal("expected the stream client to accept the self-signed certificate")
+	}
+	if !verifies(adapter.Client.HTTPClient) {
+		t.Fatal("expected the metadata client to keep verifying certificates")
+	}
+}
+
+func TestForceHTTP1(t *testing.T) {
+	prevProtocols, prevForce := sharedTransport.Protocols, sharedTransport.ForceAttemptHTTP2
+	t.Cleanup(func() {
+		sharedTransport.Protocols = prevProtocols
+		sharedTransport.ForceAttemptHTTP2 = prevForce
+		sharedTransport.CloseIdleConnections()
+	})
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Proto)
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+	tlsConfig := server.Client().Transport.(*http.Transport).TLSClientConfig
+
+	get := func(transport *http.Transport) string {
+		transport = transport.Clone()
+		transport.TLSClientConfig = tlsConfig.Clone()
+		defer transport.CloseIdleConnections()
+		resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+		if err != nil {
+			t.Fatalf("GET: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	// The shared transport negotiates h2 out of the box.
+	if got := get(sharedTransport); got != "HTTP/2.0" {
+		t.Fatalf("expected the default transport to use HTTP/2, got %s", got)
+	}
+
+	ForceHTTP1()
+	if sharedTransport.Protocols == nil || !sharedTransport.Protocols.HTTP1() || sharedTransport.Protocols.HTTP2() {
+		t.Fatalf("expected the shared transport to allow HTTP/1.1 only, got %v", sharedTransport.Protocols)
+	}
+	if got := get(sharedTransport); got != "HTTP/1.1" {
+		t.Fatalf("expected HTTP/1.1 after ForceHTTP1, got %s", got)
+	}
+}
+
+func TestKeepAliveTuning(t *testing.T) {
+	prevIdle, prevKeepAlive := sharedTransport.IdleConnTimeout, sharedTransport.DisableKeepAlives
+	t.Cleanup(func() {
+		sharedTransport.IdleConnTimeout = prevIdle
+		sharedTransport.DisableKeepAlives = prevKeepAlive
+	})
+
+	SetIdleConnTimeout(15 * time.Second)
+	if sharedTransport.IdleConnTimeout != 15*time.Second {
+		t.Fatalf("IdleConnTimeout = %s, want 15s", sharedTransport.IdleConnTimeout)
+	}
+	if sharedTransport.DisableKeepAlives {
+		t.Fatal("expected keep-alives on by default")
+	}
+	DisableKeepAlives()
+	if !sharedTransport.DisableKeepAlives {
+		t.Fatal("expected DisableKeepAlives to turn keep-alives off")
+	}
+}
+
+func TestMetadataProxyCarriesOnlyMetadataRequests(t *testing.T) {
+	t.Cleanup(func() {
+		metadataProxyMu.Lock()
+		metadataProxy, metadataTransport = nil, nil
+		metadataProxyMu.Unlock()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var mu sync.Mutex
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		proxied = append(proxied, r.Method+" "+r.Host)
+		mu.Unlock()
+		if r.Method == http.MethodConnect {
+			// Refuse the tunnel and stop the client retrying it.
+			cancel()
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = io.WriteString(w, "proxied")
+	}))
+	defer proxy.Close()
+
+	payload := strings.Repeat("s", 4096)
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, payload)
+	}))
+	defer origin.Close()
+	originHost := strings.TrimPrefix(origin.URL, "http://")
+
+	if err := SetMetadataProxy("ftp://" + originHost); err == nil {
+		t.Fatal("expected an unsupported proxy scheme to be rejected")
+	}
+	if err := SetMetadataProxy(proxy.URL); err != nil {
+		t.Fatalf("SetMetadataProxy: %v", err)
+	}
+	client := newClient(Options{Timeout: 5 * time.Second})
+	// A client with an Android version uses format URLs as they are, without
+	// fetching the player to unthrottle them.
+	info := youtube.AndroidClient
+	info.AndroidVersion = 30
+	client.SetClientInfo(info)
+
+	// Streams, and raw requests for manifests and segments, go direct.
+	format := &youtube.Format{ItagNo: 18, URL: origin.URL + "/videoplayback", ContentLength: int64(len(payload))}
+	stream, _, err := client.GetStreamContext(context.Background(), &youtube.Video{ID: "abc123"}, format)
+	if err != nil {
+		t.Fatalf("GetStreamContext: %v", err)
+	}
+	data, err := io.ReadAll(stream)
+	stream.Close()
+	if err != nil || string(data) != payload {
+		t.Fatalf("expected the stream from the origin, got %d bytes (err %v)", len(data), err)
+	}
+	resp, err := client.HTTP().Do(mustRequest(t, origin.URL+"/segment"))
+	if err != nil {
+		t.Fatalf("segment request: %v", err)
+	}
+	resp.Body.Close()
+
+	// YouTube Music API calls go through the proxy.
+	resp, err = newMetadataHTTPClient(5 * time.Second).Get(origin.URL + "/youtubei/v1/browse")
+	if err != nil {
+		t.Fatalf("metadata request: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "proxied" {
+		t.Fatalf("expected the music API request to be proxied, got %q", body)
+	}
+
+	// So do channel page lookups.
+	page, err := fetchChannelPage(context.Background(), origin.URL+"/@creator", 5*time.Second)
+	if err != nil || string(page) != "proxied" {
+		t.Fatalf("expected the channel page request to be proxied, got %q (err %v)", page, err)
+	}
+
+	// And innertube video calls; the proxy refuses to tunnel them.
+	if _, err := client.GetVideoContext(ctx, "https://www.youtube.com/watch?v=dQw4w9WgXcQ"); err == nil {
+		t.Fatal("expected the video lookup to fail at the refusing proxy")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(proxied) < 3 || proxied[0] != "GET "+originHost || proxied[1] != "GET "+originHost || proxied[2] != "CONNECT www.youtube.com:443" {
+		t.Fatalf("expected only the music, channel and innertube requests at the proxy, got %v", proxied)
+	}
+	for _, request := range proxied[3:] {
+		if request != "CONNECT www.youtube.com:443" {
+			t.Fatalf("unexpected request at the proxy: %s", request)
+		}
+	}
+}
+
+func mustRequest(t *testing.T, rawURL string) *http.Request {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
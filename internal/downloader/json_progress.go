@@ -0,0 +1,120 @@
+package downloader
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// jsonProgressInterval is the minimum gap between progress lines for one
+// download under --json-progress.
+const jsonProgressInterval = time.Second
+
+// jsonProgressLine is one --json-progress record.
+type jsonProgressLine struct {
+	Type    string   `json:"type"`
+	URL     string   `json:"url"`
+	ID      string   `json:"id,omitempty"`
+	Current int64    `json:"current"`
+	Total   int64    `json:"total"`
+	Percent *float64 `json:"percent,omitempty"`
+}
+
+type jsonProgressTask struct {
+	id      string
+	current int64
+	total   int64
+	last    time.Time
+}
+
+// jsonProgressRenderer is a ProgressRenderer that writes periodic progress
+// as JSON lines for --json-progress. One renderer serves one input URL;
+// playlist entries download sequentially and are told apart by video ID.
+type jsonProgressRenderer struct {
+	mu       sync.Mutex
+	w        io.Writer
+	url      string
+	itemID   string
+	interval time.Duration
+	now      func() time.Time
+	nextTask int
+	tasks    map[string]*jsonProgressTask
+}
+
+func newJSONProgressRenderer(w io.Writer, url string) *jsonProgressRenderer {
+	return &jsonProgressRenderer{
+		w:        w,
+		url:      url,
+		interval: jsonProgressInterval,
+		now:      time.Now,
+		tasks:    map[string]*jsonProgressTask{},
+	}
+}
+
+// SetItem records the video ID reported by downloads registered from now on.
+func (r *jsonProgressRenderer) SetItem(id string) {
+	r.mu.Lock()
+	r.itemID = id
+	r.mu.Unlock()
+}
+
+func (r *jsonProgressRenderer) Register(prefix string, size int64) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextTask++
+	taskID := strconv.Itoa(r.nextTask)
+	task := &jsonProgressTask{id: r.itemID, total: size, last: r.now()}
+	r.tasks[taskID] = task
+	r.emitLocked(task)
+	return taskID
+}
+
+func (r *jsonProgressRenderer) Update(id string, current, total int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	task, ok := r.tasks[id]
+	if !ok {
+		return
+	}
+	task.current, task.total = current, total
+	now := r.now()
+	if now.Sub(task.last) < r.interval {
+		return
+	}
+	task.last = now
+	r.emitLocked(task)
+}
+
+func (r *jsonProgressRenderer) Finish(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	task, ok := r.tasks[id]
+	if !ok {
+		return
+	}
+	delete(r.tasks, id)
+	r.emitLocked(task)
+}
+
+// Log is a no-op: stdout carries only JSON records under --json.
+func (r *jsonProgressRenderer) Log(level LogLevel, msg string) {}
+
+func (r *jsonProgressRenderer) emitLocked(task *jsonProgressTask) {
+	line := jsonProgressLine{
+		Type:    "progress",
+		URL:     r.url,
+		ID:      task.id,
+		Current: task.current,
+		Total:   task.total,
+	}
+	if task.total > 0 {
+		percent := math.Round(float64(task.current)*1000/float64(task.total)) / 10
+		line.Percent = &percent
+	}
+	enc := json.NewEncoder(r.w)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(line)
+}
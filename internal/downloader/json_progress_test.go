@@ -0,0 +1,107 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func decodeProgressLines(t *testing.T, out string) []jsonProgressLine {
+	t.Helper()
+	var lines []jsonProgressLine
+	for _, raw := range strings.Split(strings.TrimSpace(out), "\n") {
+		if raw == "" {
+			continue
+		}
+		var line jsonProgressLine
+		if err := json.Unmarshal([]byte(raw), &line); err != nil {
+			t.Fatalf("invalid progress line %q: %v", raw, err)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestJSONProgressRendererThrottlesAndFinishes(t *testing.T) {
+	var buf bytes.Buffer
+	r := newJSONProgressRenderer(&buf, "https://youtu.be/abc123")
+	clock := time.Unix(0, 0)
+	r.now = func() time.Time { return clock }
+	r.SetItem("abc123")
+
+	id := r.Register("[1/1] Song", 1000)
+	r.Update(id, 100, 1000) // within the interval: dropped
+	clock = clock.Add(time.Second)
+	r.Update(id, 500, 1000)
+	clock = clock.Add(100 * time.Millisecond)
+	r.Update(id, 1000, 1000) // throttled, but reported by Finish
+	r.Finish(id)
+	r.Update(id, 1000, 1000) // after Finish: ignored
+
+	lines := decodeProgressLines(t, buf.String())
+	if len(lines) != 3 {
+		t.Fatalf("expected register, one update and finish lines, got %d:\n%s", len(lines), buf.String())
+	}
+	wantCurrent := []int64{0, 500, 1000}
+	wantPercent := []float64{0, 50, 100}
+	for i, line := range lines {
+		if line.Type != "progress" || line.URL != "https://youtu.be/abc123" || line.ID != "abc123" || line.Total != 1000 {
+			t.Fatalf("line %d has unexpected fields: %+v", i, line)
+		}
+		if line.Current != wantCurrent[i] || line.Percent == nil || *line.Percent != wantPercent[i] {
+			t.Fatalf("line %d: expected current %d (%.0f%%), got %+v", i, wantCurrent[i], wantPercent[i], line)
+		}
+	}
+}
+
+func TestJSONProgressOmitsPercentWithoutTotal(t *testing.T) {
+	var buf bytes.Buffer
+	r := newJSONProgressRenderer(&buf, "https://example.com/live.m3u8")
+	id := r.Register("live", 0)
+	r.Finish(id)
+	if strings.Contains(buf.String(), "percent") {
+		t.Fatalf("expected no percent without a known total, got %s", buf.String())
+	}
+}
+
+func TestJSONProgressDuringSegmentDownload(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		_, _ = w.Write(bytes.Repeat([]byte("x"), 256))
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	renderer := newJSONProgressRenderer(&buf, srv.URL+"/index.m3u8")
+	renderer.interval = 0
+	opts := Options{Quiet: true, JSON: true, Renderer: renderer, SegmentConcurrency: 1}
+	printer := newPrinter(opts, nil)
+
+	segments := make([]HLSSegment, 4)
+	for i := range segments {
+		segments[i] = HLSSegment{URI: fmt.Sprintf("seg%d.bin", i)}
+	}
+	dir := t.TempDir()
+	outputPath := filepath.Join(dir, "video.bin")
+	client := &mockYouTubeClient{httpDoer: srv.Client()}
+	result, err := downloadHLSSegments(context.Background(), client, srv.URL+"/index.m3u8", segments, outputPath, dir, opts, printer, "[1/1] video")
+	if err != nil {
+		t.Fatalf("downloadHLSSegments: %v", err)
+	}
+
+	lines := decodeProgressLines(t, buf.String())
+	if len(lines) < 3 {
+		t.Fatalf("expected periodic progress lines, got %d:\n%s", len(lines), buf.String())
+	}
+	last := lines[len(lines)-1]
+	if last.Current != result.bytes || last.URL != srv.URL+"/index.m3u8" {
+		t.Fatalf("expected final line to report %d bytes, got %+v", result.bytes, last)
+	}
+}
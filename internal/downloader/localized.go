@@ -0,0 +1,135 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	youtube "github.com/lvcoi/ytdl-lib/v2"
+)
+
+// LocalizedDetails is a video's title and description in one language.
+type LocalizedDetails struct {
+	Title       string
+	Description string
+}
+
+// localizedMetadataClient is implemented by clients that can fetch a video's
+// title and description in a given language.
+type localizedMetadataClient interface {
+	LocalizedMetadataContext(ctx context.Context, video *youtube.Video, lang string) (LocalizedDetails, error)
+}
+
+// innertubePlayerURL is the player endpoint localized metadata is read from;
+// tests point it at a local server.
+var innertubePlayerURL = "https://www.youtube.com/youtubei/v1/player"
+
+var _ localizedMetadataClient = (*youtubeClientAdapter)(nil)
+
+// LocalizedMetadataContext asks the innertube player endpoint for video with
+// the interface language (hl) set to lang. YouTube answers with the
+// uploader's translation for that language when there is one and with the
+// default title otherwise.
+func (a *youtubeClientAdapter) LocalizedMetadataContext(ctx context.Context, video *youtube.Video, lang string) (LocalizedDetails, error) {
+	payload, err := json.Marshal(map[string]any{
+		"videoId": video.ID,
+		"context": map[string]any{
+			"client": map[string]any{
+				"hl":            lang,
+				"gl":            "US",
+				"clientName":    youtube.WebClient.Name,
+				"clientVersion": youtube.WebClient.Version,
+			},
+		},
+		"contentCheckOk": true,
+		"racyCheckOk":    true,
+	})
+	if err != nil {
+		return LocalizedDetails{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, innertubePlayerURL+"?key="+youtube.WebClient.Key, bytes.NewReader(payload))
+	if err != nil {
+		return LocalizedDetails{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	httpClient := a.Client.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return LocalizedDetails{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return LocalizedDetails{}, youtube.ErrUnexpectedStatusCode(resp.StatusCode)
+	}
+
+	var player struct {
+		VideoDetails struct {
+			Title            string `json:"title"`
+			ShortDescription string `json:"shortDescription"`
+		} `json:"videoDetails"`
+		Microformat struct {
+			Renderer struct {
+				Title struct {
+					SimpleText string `json:"simpleText"`
+				} `json:"title"`
+				Description struct {
+					SimpleText string `json:"simpleText"`
+				} `json:"description"`
+			} `json:"playerMicroformatRenderer"`
+		} `json:"microformat"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&player); err != nil {
+		return LocalizedDetails{}, fmt.Errorf("decoding player response: %w", err)
+	}
+	// The microformat carries the translated strings; videoDetails keeps
+	// the original on some clients.
+	details := LocalizedDetails{
+		Title:       player.Microformat.Renderer.Title.SimpleText,
+		Description: player.Microformat.Renderer.Description.SimpleText,
+	}
+	if details.Title == "" {
+		details.Title = player.VideoDetails.Title
+	}
+	if details.Description == "" {
+		details.Description = player.VideoDetails.ShortDescription
+	}
+	return details, nil
+}
+
+// applyPreferredLanguage swaps video's title and description for the lang
+// variant when the client can fetch one. It reports whether the title
+// changed. Missing or unavailable localizations leave the video untouched.
+func applyPreferredLanguage(ctx context.Context, client YouTubeClient, video *youtube.Video, lang string, printer *Printer) bool {
+	lang = strings.TrimSpace(lang)
+	if lang == "" || video == nil {
+		return false
+	}
+	provider, ok := client.(localizedMetadataClient)
+	if !ok {
+		if printer != nil {
+			printer.Log(LogDebug, fmt.Sprintf("prefer-lang: client exposes no localized metadata for %s; using default title", video.ID))
+		}
+		return false
+	}
+	details, err := provider.LocalizedMetadataContext(ctx, video, lang)
+	if err != nil {
+		if printer != nil {
+			printer.Log(LogWarn, fmt.Sprintf("warning: localized metadata unavailable for %s: %v", video.ID, err))
+		}
+		return false
+	}
+	if details.Description != "" {
+		video.Description = details.Description
+	}
+	if details.Title == "" || details.Title == video.Title {
+		return false
+	}
+	video.Title = details.Title
+	return true
+}
@@ -0,0 +1,119 @@
+package downloader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	youtube "github.com/lvcoi/ytdl-lib/v2"
+)
+
+// localizedMockClient exposes a fixed set of localized titles.
+type localizedMockClient struct {
+	mockYouTubeClient
+	localized map[string]LocalizedDetails
+}
+
+func (m *localizedMockClient) LocalizedMetadataContext(ctx context.Context, video *youtube.Video, lang string) (LocalizedDetails, error) {
+	return m.localized[lang], nil
+}
+
+func TestApplyPreferredLanguage(t *testing.T) {
+	localized := map[string]LocalizedDetails{
+		"en":    {Title: "Morning Song", Description: "An English description"},
+		"es":    {Title: "Canción de la mañana"},
+		"pt-PT": {Title: "Canção da manhã"},
+	}
+
+	tests := []struct {
+		name      string
+		client    YouTubeClient
+		lang      string
+		wantTitle string
+		wantDesc  string
+		changed   bool
+	}{
+		{name: "translated title", client: &localizedMockClient{localized: localized}, lang: "es", wantTitle: "Canción de la mañana", wantDesc: "Original description", changed: true},
+		{name: "with description", client: &localizedMockClient{localized: localized}, lang: "en", wantTitle: "Morning Song", wantDesc: "An English description", changed: true},
+		{name: "trimmed", client: &localizedMockClient{localized: localized}, lang: " pt-PT ", wantTitle: "Canção da manhã", wantDesc: "Original description", changed: true},
+		{name: "missing language", client: &localizedMockClient{localized: localized}, lang: "de", wantTitle: "Original", wantDesc: "Original description"},
+		{name: "unset", client: &localizedMockClient{localized: localized}, lang: "", wantTitle: "Original", wantDesc: "Original description"},
+		{name: "client without localizations", client: &mockYouTubeClient{}, lang: "es", wantTitle: "Original", wantDesc: "Original description"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			video := &youtube.Video{ID: "abc123", Title: "Original", Description: "Original description"}
+			changed := applyPreferredLanguage(context.Background(), tt.client, video, tt.lang, nil)
+			if changed != tt.changed {
+				t.Fatalf("changed = %v, want %v", changed, tt.changed)
+			}
+			if video.Title != tt.wantTitle || video.Description != tt.wantDesc {
+				t.Fatalf("got title %q description %q, want %q / %q", video.Title, video.Description, tt.wantTitle, tt.wantDesc)
+			}
+		})
+	}
+}
+
+func TestPreferredLanguageFlowsIntoOutputPathAndSidecar(t *testing.T) {
+	client := &localizedMockClient{localized: map[string]LocalizedDetails{
+		"fr": {Title: "Chanson du matin"},
+	}}
+	video := &youtube.Video{ID: "abc123", Title: "Morning Song", Author: "Artist"}
+	format := &youtube.Format{MimeType: "audio/mp4"}
+
+	applyPreferredLanguage(context.Background(), client, video, "fr", nil)
+
+	path, err := resolveOutputPath("{title}.{ext}", video, format, outputContext{}, "out")
+	if err != nil {
+		t.Fatalf("resolveOutputPath: %v", err)
+	}
+	if filepath.Base(path) != "Chanson du matin.mp4" {
+		t.Fatalf("expected localized filename, got %q", path)
+	}
+	metadata := buildItemMetadata(video, format, outputContext{}, path, "ok", nil)
+	if metadata.Title != "Chanson du matin" {
+		t.Fatalf("expected localized sidecar title, got %q", metadata.Title)
+	}
+}
+
+func TestYouTubeClientAdapterLocalizedMetadata(t *testing.T) {
+	var gotHL string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			VideoID string `json:"videoId"`
+			Context struct {
+				Client struct {
+					HL string `json:"hl"`
+				} `json:"client"`
+			} `json:"context"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.VideoID != "abc123" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		gotHL = body.Context.Client.HL
+		fmt.Fprint(w, `{"videoDetails":{"title":"Morning Song","shortDescription":"Original description"},
+"microformat":{"playerMicroformatRenderer":{"title":{"simpleText":"Chanson du matin"}}}}`)
+	}))
+	defer srv.Close()
+	prev := innertubePlayerURL
+	innertubePlayerURL = srv.URL + "/youtubei/v1/player"
+	defer func() { innertubePlayerURL = prev }()
+
+	client := &youtubeClientAdapter{Client: &youtube.Client{HTTPClient: srv.Client()}}
+	video := &youtube.Video{ID: "abc123", Title: "Morning Song", Description: "Original description"}
+	if !applyPreferredLanguage(context.Background(), client, video, "fr", nil) {
+		t.Fatal("expected the adapter's localized title to be applied")
+	}
+	if gotHL != "fr" {
+		t.Fatalf("expected the player request to set hl=fr, got %q", gotHL)
+	}
+	if video.Title != "Chanson du matin" || video.Description != "Original description" {
+		t.Fatalf("got title %q description %q", video.Title, video.Description)
+	}
+}
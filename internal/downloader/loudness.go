@@ -0,0 +1,89 @@
+package downloader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// replayGainReferenceLUFS is the ReplayGain 2.0 reference loudness.
+const replayGainReferenceLUFS = -18.0
+
+// Loudness holds the EBU R128 measurement for a file and the ReplayGain
+// values derived from it.
+type Loudness struct {
+	IntegratedLUFS float64 `json:"integrated_lufs"`
+	TruePeakDBTP   float64 `json:"true_peak_dbtp"`
+	TrackGainDB    float64 `json:"replaygain_track_gain_db"`
+	TrackPeak      float64 `json:"replaygain_track_peak"`
+}
+
+// TrackGainTag formats the track gain as a REPLAYGAIN_TRACK_GAIN value.
+func (l Loudness) TrackGainTag() string {
+	return fmt.Sprintf("%.2f dB", l.TrackGainDB)
+}
+
+// TrackPeakTag formats the track peak as a REPLAYGAIN_TRACK_PEAK value.
+func (l Loudness) TrackPeakTag() string {
+	return fmt.Sprintf("%.6f", l.TrackPeak)
+}
+
+// measureLoudness runs ffmpeg's loudnorm filter in analysis mode and returns
+// the integrated loudness and true peak of the file.
+func measureLoudness(path string) (*Loudness, error) {
+	if _, err := exec.LookPath(ffmpegPath); err != nil {
+		return nil, fmt.Errorf("ffmpeg not found: %w", err)
+	}
+	cmd := exec.Command(ffmpegPath,
+		"-hide_banner",
+		"-nostats",
+		"-i", path,
+		"-af", "loudnorm=print_format=json",
+		"-f", "null",
+		"-",
+	)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		stderr := strings.TrimSpace(string(output))
+		if stderr != "" {
+			return nil, fmt.Errorf("loudness analysis failed: %s: %w", stderr, err)
+		}
+		return nil, fmt.Errorf("loudness analysis failed: %w", err)
+	}
+	return parseLoudnormOutput(string(output))
+}
+
+// parseLoudnormOutput extracts the JSON summary printed by the loudnorm
+// filter at the end of ffmpeg's stderr.
+func parseLoudnormOutput(output string) (*Loudness, error) {
+	start := strings.LastIndex(output, "{")
+	end := strings.LastIndex(output, "}")
+	if start < 0 || end < start {
+		return nil, errors.New("loudness analysis produced no summary")
+	}
+	var summary struct {
+		InputI  string `json:"input_i"`
+		InputTP string `json:"input_tp"`
+	}
+	if err := json.Unmarshal([]byte(output[start:end+1]), &summary); err != nil {
+		return nil, fmt.Errorf("parsing loudness summary: %w", err)
+	}
+	integrated, err := strconv.ParseFloat(strings.TrimSpace(summary.InputI), 64)
+	if err != nil || math.IsInf(integrated, 0) || math.IsNaN(integrated) {
+		return nil, fmt.Errorf("invalid integrated loudness %q", summary.InputI)
+	}
+	truePeak, err := strconv.ParseFloat(strings.TrimSpace(summary.InputTP), 64)
+	if err != nil || math.IsInf(truePeak, 0) || math.IsNaN(truePeak) {
+		return nil, fmt.Errorf("invalid true peak %q", summary.InputTP)
+	}
+	return &Loudness{
+		IntegratedLUFS: integrated,
+		TruePeakDBTP:   truePeak,
+		TrackGainDB:    replayGainReferenceLUFS - integrated,
+		TrackPeak:      math.Pow(10, truePeak/20),
+	}, nil
+}
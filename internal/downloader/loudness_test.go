@@ -0,0 +1,106 @@
+package downloader
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	id3v2 "github.com/bogem/id3v2/v2"
+)
+
+func TestParseLoudnormOutput(t *testing.T) {
+	output := `[Parsed_loudnorm_0 @ 0x1]
+{
+	"input_i" : "-23.00",
+	"input_tp" : "-6.02",
+	"input_lra" : "0.00",
+	"input_thresh" : "-33.00",
+	"output_i" : "-24.00",
+	"output_tp" : "-2.00",
+	"output_lra" : "0.00",
+	"output_thresh" : "-34.00",
+	"normalization_type" : "dynamic",
+	"target_offset" : "0.00"
+}
+`
+	loudness, err := parseLoudnormOutput(output)
+	if err != nil {
+		t.Fatalf("parseLoudnormOutput: %v", err)
+	}
+	if loudness.IntegratedLUFS != -23 {
+		t.Fatalf("expected -23 LUFS, got %v", loudness.IntegratedLUFS)
+	}
+	if loudness.TrackGainDB != 5 {
+		t.Fatalf("expected +5 dB track gain, got %v", loudness.TrackGainDB)
+	}
+	if math.Abs(loudness.TrackPeak-0.5) > 0.001 {
+		t.Fatalf("expected track peak ~0.5, got %v", loudness.TrackPeak)
+	}
+	if got := loudness.TrackGainTag(); got != "5.00 dB" {
+		t.Fatalf("unexpected gain tag %q", got)
+	}
+}
+
+func TestParseLoudnormOutputRejectsSilence(t *testing.T) {
+	if _, err := parseLoudnormOutput(`{"input_i" : "-inf", "input_tp" : "-inf"}`); err == nil {
+		t.Fatalf("expected error for silent input")
+	}
+	if _, err := parseLoudnormOutput("no summary here"); err == nil {
+		t.Fatalf("expected error when summary is missing")
+	}
+}
+
+func TestReplayGainTagsEmbeddedInMP3(t *testing.T) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		t.Skip("ffmpeg not available")
+	}
+
+	baseDir := t.TempDir()
+	outputPath := filepath.Join(baseDir, "tone.mp3")
+	cmd := exec.Command("ffmpeg", "-hide_banner", "-loglevel", "error",
+		"-f", "lavfi", "-i", "sine=frequency=440:duration=2",
+		"-c:a", "libmp3lame", "-y", outputPath)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Skipf("ffmpeg cannot encode mp3: %v: %s", err, out)
+	}
+
+	metadata := ItemMetadata{ID: "vid123", Title: "Tone", Status: "ok", Output: outputPath}
+	if err := finalizeDownloadMetadata(outputPath, baseDir, metadata, nil, Options{AudioOnly: true, AddReplayGain: true}, nil); err != nil {
+		t.Fatalf("finalizeDownloadMetadata: %v", err)
+	}
+
+	tag, err := id3v2.Open(outputPath, id3v2.Options{Parse: true})
+	if err != nil {
+		t.Fatalf("open tags: %v", err)
+	}
+	defer tag.Close()
+
+	found := map[string]string{}
+	for _, frame := range tag.GetFrames(tag.CommonID("User defined text information frame")) {
+		if udtf, ok := frame.(id3v2.UserDefinedTextFrame); ok {
+			found[udtf.Description] = udtf.Value
+		}
+	}
+	if found["REPLAYGAIN_TRACK_GAIN"] == "" || found["REPLAYGAIN_TRACK_PEAK"] == "" {
+		t.Fatalf("expected ReplayGain tags, got %v", found)
+	}
+
+	sidecar, err := sidecarPath(outputPath, baseDir)
+	if err != nil {
+		t.Fatalf("sidecarPath: %v", err)
+	}
+	data, err := os.ReadFile(sidecar)
+	if err != nil {
+		t.Fatalf("read sidecar: %v", err)
+	}
+	var written ItemMetadata
+	if err := json.Unmarshal(data, &written); err != nil {
+		t.Fatalf("decode sidecar: %v", err)
+	}
+	if written.Loudness == nil || written.Loudness.IntegratedLUFS == 0 {
+		t.Fatalf("expected measured LUFS in sidecar, got %+v", written.Loudness)
+	}
+}
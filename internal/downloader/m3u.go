@@ -0,0 +1,75 @@
+package downloader
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lvcoi/ytdl-lib/v2"
+)
+
+// writePlaylistM3U writes an extended M3U index of a playlist's downloaded
+// files, in playlist order, for --write-playlist-m3u. The file is named after
+// the playlist and placed in the deepest directory shared by every listed
+// file, with entries relative to it. Entries skipped because the file already
+// exists are listed only when includeSkipped is set. It returns the written
+// path, or "" when there was nothing to list.
+func writePlaylistM3U(playlist *youtube.Playlist, outcomes []playlistOutcome, includeSkipped bool) (string, error) {
+	var listed []playlistOutcome
+	for _, outcome := range outcomes {
+		if outcome.output == "" {
+			continue
+		}
+		if outcome.ok || (outcome.skipped && includeSkipped) {
+			listed = append(listed, outcome)
+		}
+	}
+	if len(listed) == 0 {
+		return "", nil
+	}
+
+	dir := filepath.Dir(listed[0].output)
+	for _, outcome := range listed[1:] {
+		dir = commonDir(dir, filepath.Dir(outcome.output))
+	}
+
+	title := "Playlist"
+	if playlist != nil && playlist.Title != "" {
+		title = playlist.Title
+	}
+	path := filepath.Join(dir, sanitize(title)+".m3u8")
+
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n")
+	for _, outcome := range listed {
+		rel, err := filepath.Rel(dir, outcome.output)
+		if err != nil {
+			rel = outcome.output
+		}
+		name := outcome.title
+		if name == "" {
+			name = strings.TrimSuffix(filepath.Base(outcome.output), filepath.Ext(outcome.output))
+		}
+		fmt.Fprintf(&b, "#EXTINF:-1,%s\n%s\n", name, filepath.ToSlash(rel))
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		return "", fmt.Errorf("writing playlist m3u: %w", err)
+	}
+	return path, nil
+}
+
+// commonDir returns the deepest directory containing both a and b.
+func commonDir(a, b string) string {
+	for {
+		rel, err := filepath.Rel(a, b)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return a
+		}
+		parent := filepath.Dir(a)
+		if parent == a {
+			return a
+		}
+		a = parent
+	}
+}
@@ -0,0 +1,63 @@
+package downloader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	youtube "github.com/lvcoi/ytdl-lib/v2"
+)
+
+func TestWritePlaylistM3UListsFilesInOrder(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "Road Trip")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	playlist := &youtube.Playlist{ID: "PL123", Title: "Road Trip"}
+	outcomes := []playlistOutcome{
+		{ok: true, index: 1, title: "Opener", output: filepath.Join(dir, "01 - Opener.m4a")},
+		{failed: true, index: 2, title: "Broken", reason: "video unavailable"},
+		{skipped: true, index: 3, title: "Already Here", reason: "exists", output: filepath.Join(dir, "03 - Already Here.m4a")},
+		{ok: true, index: 4, title: "Closer", output: filepath.Join(dir, "Bonus", "04 - Closer.m4a")},
+	}
+
+	path, err := writePlaylistM3U(playlist, outcomes, false)
+	if err != nil {
+		t.Fatalf("writePlaylistM3U: %v", err)
+	}
+	if want := filepath.Join(dir, "Road Trip.m3u8"); path != want {
+		t.Fatalf("expected m3u at %q, got %q", want, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read m3u: %v", err)
+	}
+	want := "#EXTM3U\n" +
+		"#EXTINF:-1,Opener\n01 - Opener.m4a\n" +
+		"#EXTINF:-1,Closer\nBonus/04 - Closer.m4a\n"
+	if string(data) != want {
+		t.Fatalf("unexpected m3u:\n%s\nwant:\n%s", data, want)
+	}
+
+	if _, err := writePlaylistM3U(playlist, outcomes, true); err != nil {
+		t.Fatalf("writePlaylistM3U with skipped: %v", err)
+	}
+	data, err = os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read m3u: %v", err)
+	}
+	want = "#EXTM3U\n" +
+		"#EXTINF:-1,Opener\n01 - Opener.m4a\n" +
+		"#EXTINF:-1,Already Here\n03 - Already Here.m4a\n" +
+		"#EXTINF:-1,Closer\nBonus/04 - Closer.m4a\n"
+	if string(data) != want {
+		t.Fatalf("unexpected m3u with skipped entries:\n%s\nwant:\n%s", data, want)
+	}
+}
+
+func TestWritePlaylistM3USkipsEmptyPlaylists(t *testing.T) {
+	path, err := writePlaylistM3U(&youtube.Playlist{Title: "Empty"}, []playlistOutcome{{failed: true, index: 1}}, true)
+	if err != nil || path != "" {
+		t.Fatalf("expected no m3u for a playlist without files, got %q, %v", path, err)
+	}
+}
@@ -40,6 +40,8 @@ type ItemMetadata struct {
 	Error            string       `json:"error,omitempty"`
 	Playlist         *PlaylistRef `json:"playlist,omitempty"`
 	Warnings         []string     `json:"warnings,omitempty"`
+	Loudness         *Loudness    `json:"loudness,omitempty"`
+	SHA256           string       `json:"sha256,omitempty"`
 }
 
 type PlaylistRef struct {
@@ -53,6 +55,9 @@ type PlaylistRef struct {
 func buildItemMetadata(video *youtube.Video, format *youtube.Format, ctxInfo outputContext, outputPath string, status string, err error) ItemMetadata {
 	title := stringsOrFallback(ctxInfo.EntryTitle, video.Title, video.ID)
 	artist := stringsOrFallback(ctxInfo.EntryAuthor, video.Author)
+	if ctxInfo.CleanArtist {
+		artist = cleanArtistName(artist)
+	}
 	album := ctxInfo.EntryAlbum
 
 	warnings := []string{}
@@ -145,15 +150,37 @@ func writeSidecar(outputPath, baseDir string, metadata ItemMetadata) error {
 	return nil
 }
 
-func finalizeDownloadMetadata(outputPath, baseDir string, metadata ItemMetadata, audioOnly bool, printer *Printer) error {
+func finalizeDownloadMetadata(outputPath, baseDir string, metadata ItemMetadata, checksum *outputChecksum, opts Options, printer *Printer) error {
 	if outputPath == "" {
 		return nil
 	}
-	// Only embed tags for audio-only downloads to avoid unnecessary remuxing for video files
-	if audioOnly {
+	if opts.AudioOnly && opts.AddReplayGain && metadata.Status == "ok" {
+		loudness, err := measureLoudness(outputPath)
+		if err != nil {
+			if printer != nil {
+				printer.Log(LogWarn, fmt.Sprintf("warning: replaygain analysis skipped: %v", err))
+			}
+		} else {
+			metadata.Loudness = loudness
+		}
+	}
+	// Video files are only remuxed for tags when explicitly requested.
+	if opts.AudioOnly {
 		embedAudioTags(metadata, outputPath, printer)
+	} else if opts.EmbedMetadata {
+		embedVideoTags(metadata, outputPath, printer)
+	}
+	// Checksum the file as it will stay, after any tags were embedded.
+	if metadata.Status == "ok" {
+		if err := checksum.Write(outputPath, baseDir); err != nil {
+			return err
+		}
+		metadata.SHA256 = checksum.Sum()
 	}
 
+	if opts.MetadataIndex != "" {
+		return writeIndexedMetadata(outputPath, metadata, opts)
+	}
 	if err := writeSidecar(outputPath, baseDir, metadata); err != nil {
 		return err
 	}
@@ -181,6 +208,20 @@ func extractorVersion() string {
 	return "unknown"
 }
 
+// setUploadMtime sets the finished file's modification time to the video's
+// publish date so libraries can sort by upload date. It does nothing under
+// --no-mtime or when the publish date is unknown; failures are only warned
+// about since the download itself succeeded.
+func setUploadMtime(path string, video *youtube.Video, opts Options, printer *Printer) {
+	if opts.NoMtime || video == nil || video.PublishDate.IsZero() {
+		return
+	}
+	// A zero access time leaves it unchanged.
+	if err := os.Chtimes(path, time.Time{}, video.PublishDate); err != nil && printer != nil {
+		printer.Log(LogWarn, fmt.Sprintf("warning: setting file modification time: %v", err))
+	}
+}
+
 func formatDate(value time.Time) string {
 	if value.IsZero() {
 		return ""
@@ -204,6 +245,76 @@ func stringsOrFallback(values ...string) string {
 	return ""
 }
 
+// artistChannelSuffix is a decoration YouTube appends to auto-generated or
+// label-run channel names that is not part of the artist's name.
+type artistChannelSuffix struct {
+	text     string
+	foldCase bool
+}
+
+// artistChannelSuffixes only match a distinct suffix: a separated " Vevo" in
+// any case, or an all-caps "VEVO" glued to the name as in "TaylorSwiftVEVO",
+// so names that merely end in "vevo" are kept.
+var artistChannelSuffixes = []artistChannelSuffix{
+	{text: " - topic", foldCase: true},
+	{text: " vevo", foldCase: true},
+	{text: "VEVO"},
+}
+
+func (s artistChannelSuffix) trimFrom(name string) (string, bool) {
+	if len(name) <= len(s.text) {
+		return name, false
+	}
+	tail := name[len(name)-len(s.text):]
+	if tail != s.text && !(s.foldCase && strings.EqualFold(tail, s.text)) {
+		return name, false
+	}
+	return strings.TrimSpace(name[:len(name)-len(s.text)]), true
+}
+
+// cleanArtistName strips channel decorations such as " - Topic" and "VEVO"
+// from an artist name. Names that consist only of a suffix are left as-is.
+func cleanArtistName(name string) string {
+	cleaned := strings.TrimSpace(name)
+	for {
+		stripped := false
+		for _, suffix := range artistChannelSuffixes {
+			if trimmed, ok := suffix.trimFrom(cleaned); ok {
+				cleaned = trimmed
+				stripped = true
+				break
+			}
+		}
+		if !stripped {
+			return cleaned
+		}
+	}
+}
+
+// ValidCleanArtistMode reports whether mode is an accepted --clean-artist
+// value.
+func ValidCleanArtistMode(mode string) bool {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", "auto", "always", "never":
+		return true
+	default:
+		return false
+	}
+}
+
+// shouldCleanArtist resolves the --clean-artist mode for a run. The default
+// ("auto") only cleans artists for YouTube Music URLs.
+func shouldCleanArtist(mode string, isMusicURL bool) bool {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "always":
+		return true
+	case "never":
+		return false
+	default:
+		return isMusicURL
+	}
+}
+
 func applyMetaOverrides(metadata *ItemMetadata, overrides map[string]string) {
 	if metadata == nil || len(overrides) == 0 {
 		return
@@ -0,0 +1,137 @@
+package downloader
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metadata storage strategies for --metadata-store.
+const (
+	MetadataStoreSidecar = "sidecar"
+	MetadataStoreIndex   = "index"
+)
+
+// MetadataIndexFile is the NDJSON index written under the data folder of the
+// output root in index mode.
+const MetadataIndexFile = "metadata.ndjson"
+
+// MetadataIndexPath returns where index mode keeps its NDJSON file for the
+// given output root. Paths recorded in it are relative to that root.
+func MetadataIndexPath(root string) string {
+	if root == "" {
+		root = "."
+	}
+	return filepath.Join(root, "data", MetadataIndexFile)
+}
+
+// MetadataIndexRecord is one line of the append-only metadata index. A "put"
+// replaces whatever was recorded for Path before it; a "delete" forgets it.
+type MetadataIndexRecord struct {
+	Op       string        `json:"op"`
+	Path     string        `json:"path"`
+	At       string        `json:"at"`
+	Metadata *ItemMetadata `json:"metadata,omitempty"`
+}
+
+// metadataIndexMu serializes appends from concurrent downloads in this
+// process so records never interleave.
+var metadataIndexMu sync.Mutex
+
+// AppendMetadataIndex records a put (metadata non-nil) or delete (nil) for
+// relPath, a slash-separated path relative to the index's output root.
+func AppendMetadataIndex(indexPath, relPath string, metadata *ItemMetadata) error {
+	record := MetadataIndexRecord{
+		Op:       "put",
+		Path:     filepath.ToSlash(relPath),
+		At:       time.Now().UTC().Format(time.RFC3339),
+		Metadata: metadata,
+	}
+	if metadata == nil {
+		record.Op = "delete"
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	metadataIndexMu.Lock()
+	defer metadataIndexMu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(indexPath), 0o755); err != nil {
+		return fmt.Errorf("creating metadata index directory: %w", err)
+	}
+	file, err := os.OpenFile(indexPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening metadata index: %w", err)
+	}
+	if _, err := file.Write(line); err != nil {
+		file.Close()
+		return fmt.Errorf("writing metadata index: %w", err)
+	}
+	return file.Close()
+}
+
+// LoadMetadataIndex replays the index into the current metadata per path. A
+// missing index yields an empty map; malformed lines, such as a record cut
+// short by a crash, are skipped.
+func LoadMetadataIndex(indexPath string) (map[string]ItemMetadata, error) {
+	entries := make(map[string]ItemMetadata)
+	file, err := os.Open(indexPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return entries, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var record MetadataIndexRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil || record.Path == "" {
+			continue
+		}
+		switch {
+		case record.Op == "delete":
+			delete(entries, record.Path)
+		case record.Op == "put" && record.Metadata != nil:
+			entries[record.Path] = *record.Metadata
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// writeIndexedMetadata appends metadata for outputPath to opts.MetadataIndex
+// in place of a per-file sidecar.
+func writeIndexedMetadata(outputPath string, metadata ItemMetadata, opts Options) error {
+	root := filepath.Dir(filepath.Dir(opts.MetadataIndex))
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return wrapCategory(CategoryFilesystem, fmt.Errorf("resolving metadata index root: %w", err))
+	}
+	absOutput, err := filepath.Abs(outputPath)
+	if err != nil {
+		return wrapCategory(CategoryFilesystem, fmt.Errorf("resolving output path: %w", err))
+	}
+	relPath, err := filepath.Rel(absRoot, absOutput)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		// Outside the indexed root (e.g. a -paths route elsewhere): record
+		// the absolute path so the entry is still findable.
+		relPath = absOutput
+	}
+	if err := AppendMetadataIndex(opts.MetadataIndex, relPath, &metadata); err != nil {
+		return wrapCategory(CategoryFilesystem, err)
+	}
+	return nil
+}
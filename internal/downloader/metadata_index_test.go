@@ -0,0 +1,73 @@
+package downloader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMetadataIndexReplaysUpdatesAndDeletes(t *testing.T) {
+	indexPath := MetadataIndexPath(t.TempDir())
+
+	steps := []struct {
+		path     string
+		metadata *ItemMetadata
+	}{
+		{"video/a.mp4", &ItemMetadata{ID: "a", Title: "First"}},
+		{"audio/b.m4a", &ItemMetadata{ID: "b", Title: "Second"}},
+		{"video/a.mp4", &ItemMetadata{ID: "a", Title: "First (updated)"}},
+		{"audio/b.m4a", nil},
+	}
+	for _, step := range steps {
+		if err := AppendMetadataIndex(indexPath, step.path, step.metadata); err != nil {
+			t.Fatalf("AppendMetadataIndex(%s): %v", step.path, err)
+		}
+	}
+	// A record cut short by a crash must not hide the others.
+	file, err := os.OpenFile(indexPath, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("open index: %v", err)
+	}
+	_, _ = file.WriteString(`{"op":"put","path":"video/c.mp4","metad`)
+	file.Close()
+
+	entries, err := LoadMetadataIndex(indexPath)
+	if err != nil {
+		t.Fatalf("LoadMetadataIndex: %v", err)
+	}
+	if len(entries) != 1 || entries["video/a.mp4"].Title != "First (updated)" {
+		t.Fatalf("expected only the updated entry, got %+v", entries)
+	}
+
+	missing, err := LoadMetadataIndex(filepath.Join(t.TempDir(), "none.ndjson"))
+	if err != nil || len(missing) != 0 {
+		t.Fatalf("expected an empty index for a missing file, got %v, %v", missing, err)
+	}
+}
+
+func TestFinalizeDownloadMetadataWritesIndexInsteadOfSidecar(t *testing.T) {
+	root := t.TempDir()
+	outputPath := filepath.Join(root, "video", "clip.mp4")
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(outputPath, []byte("data"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	opts := Options{OutputDir: root, MetadataIndex: MetadataIndexPath(root)}
+
+	metadata := ItemMetadata{ID: "clip", Title: "Clip", Status: "ok"}
+	if err := finalizeDownloadMetadata(outputPath, root, metadata, nil, opts, nil); err != nil {
+		t.Fatalf("finalizeDownloadMetadata: %v", err)
+	}
+	if _, err := os.Stat(outputPath + ".json"); !os.IsNotExist(err) {
+		t.Fatalf("expected no sidecar in index mode, stat err = %v", err)
+	}
+	entries, err := LoadMetadataIndex(opts.MetadataIndex)
+	if err != nil {
+		t.Fatalf("LoadMetadataIndex: %v", err)
+	}
+	if entries["video/clip.mp4"].Title != "Clip" {
+		t.Fatalf("expected the item under its root-relative path, got %+v", entries)
+	}
+}
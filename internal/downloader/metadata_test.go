@@ -5,6 +5,9 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
+
+	youtube "github.com/lvcoi/ytdl-lib/v2"
 )
 
 func TestFinalizeDownloadMetadataWritesSidecar(t *testing.T) {
@@ -39,7 +42,7 @@ func TestFinalizeDownloadMetadataWritesSidecar(t *testing.T) {
 		},
 	}
 
-	if err := finalizeDownloadMetadata(outputPath, baseDir, metadata, false, nil); err != nil {
+	if err := finalizeDownloadMetadata(outputPath, baseDir, metadata, nil, Options{}, nil); err != nil {
 		t.Fatalf("finalizeDownloadMetadata: %v", err)
 	}
 
@@ -75,3 +78,105 @@ func TestFinalizeDownloadMetadataWritesSidecar(t *testing.T) {
 		t.Fatalf("expected playlist metadata in sidecar, got %+v", parsed.Playlist)
 	}
 }
+
+func TestCleanArtistName(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Artist Name - Topic", "Artist Name"},
+		{"Artist Name - topic", "Artist Name"},
+		{"ArtistVEVO", "Artist"},
+		{"Artist Vevo", "Artist"},
+		{"Artist Name", "Artist Name"},
+		{"TaylorSwiftVEVO", "TaylorSwift"},
+		{"VEVO", "VEVO"},
+		{"Grevevo", "Grevevo"},
+		{"ArtistVevo", "ArtistVevo"},
+		{"Los Chevevo", "Los Chevevo"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := cleanArtistName(tt.in); got != tt.want {
+			t.Fatalf("cleanArtistName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestShouldCleanArtist(t *testing.T) {
+	if !shouldCleanArtist("auto", true) {
+		t.Fatalf("auto should clean artists for music URLs")
+	}
+	if shouldCleanArtist("", false) {
+		t.Fatalf("auto should not clean artists for regular URLs")
+	}
+	if !shouldCleanArtist("always", false) {
+		t.Fatalf("always should clean artists for regular URLs")
+	}
+	if shouldCleanArtist("never", true) {
+		t.Fatalf("never should not clean artists for music URLs")
+	}
+}
+
+func TestValidCleanArtistMode(t *testing.T) {
+	for _, mode := range []string{"", "auto", "always", "never", " Always "} {
+		if !ValidCleanArtistMode(mode) {
+			t.Errorf("expected %q to be accepted", mode)
+		}
+	}
+	for _, mode := range []string{"yes", "on", "true", "sometimes"} {
+		if ValidCleanArtistMode(mode) {
+			t.Errorf("expected %q to be rejected", mode)
+		}
+	}
+}
+
+func TestBuildItemMetadataCleansArtist(t *testing.T) {
+	video := &youtube.Video{ID: "vid123", Title: "Song", Author: "Artist Name - Topic"}
+
+	cleaned := buildItemMetadata(video, nil, outputContext{CleanArtist: true}, "", "ok", nil)
+	if cleaned.Artist != "Artist Name" {
+		t.Fatalf("expected cleaned artist, got %q", cleaned.Artist)
+	}
+	if cleaned.Author != "Artist Name - Topic" {
+		t.Fatalf("expected raw channel author to be preserved, got %q", cleaned.Author)
+	}
+
+	raw := buildItemMetadata(video, nil, outputContext{}, "", "ok", nil)
+	if raw.Artist != "Artist Name - Topic" {
+		t.Fatalf("expected artist untouched without clean-artist, got %q", raw.Artist)
+	}
+}
+
+func TestSetUploadMtime(t *testing.T) {
+	dir := t.TempDir()
+	published := time.Date(2019, 6, 14, 0, 0, 0, 0, time.UTC)
+	video := &youtube.Video{ID: "abc123", PublishDate: published}
+
+	path := filepath.Join(dir, "video.mp4")
+	if err := os.WriteFile(path, []byte("data"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	setUploadMtime(path, video, Options{}, nil)
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if !info.ModTime().Equal(published) {
+		t.Fatalf("expected mtime %v, got %v", published, info.ModTime())
+	}
+
+	kept := filepath.Join(dir, "kept.mp4")
+	if err := os.WriteFile(kept, []byte("data"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	setUploadMtime(kept, video, Options{NoMtime: true}, nil)
+	setUploadMtime(kept, &youtube.Video{ID: "undated"}, Options{}, nil)
+	info, err = os.Stat(kept)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if time.Since(info.ModTime()) > time.Hour {
+		t.Fatalf("expected -no-mtime and undated videos to keep the current mtime, got %v", info.ModTime())
+	}
+}
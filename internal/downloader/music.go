@@ -73,7 +73,7 @@ func fetchMusicPlaylistEntries(ctx context.Context, playlistID string, opts Opti
 
 func fetchMusicConfig(ctx context.Context, playlistID string, timeout time.Duration) (musicConfig, error) {
 	playlistURL := "https://music.youtube.com/playlist?list=" + url.QueryEscape(playlistID)
-	client := newHTTPClient(timeout)
+	client := newMetadataHTTPClient(timeout)
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, playlistURL, nil)
 	if err != nil {
 		return musicConfig{}, err
@@ -120,7 +120,7 @@ func fetchMusicConfig(ctx context.Context, playlistID string, timeout time.Durat
 func fetchMusicPlaylistTitle(ctx context.Context, playlistID string, timeout time.Duration) (string, error) {
 	// Fetch the HTML page and extract og:title meta tag
 	playlistURL := "https://music.youtube.com/playlist?list=" + url.QueryEscape(playlistID)
-	client := newHTTPClient(timeout)
+	client := newMetadataHTTPClient(timeout)
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, playlistURL, nil)
 	if err != nil {
 		return "", err
@@ -171,7 +171,7 @@ func fetchMusicBrowse(ctx context.Context, apiKey string, payload map[string]any
 		return nil, err
 	}
 
-	client := newHTTPClient(timeout)
+	client := newMetadataHTTPClient(timeout)
 	endpoint := "https://music.youtube.com/youtubei/v1/browse?key=" + apiKey
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
 	if err != nil {
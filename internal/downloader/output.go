@@ -5,9 +5,12 @@ import (
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/lvcoi/ytdl-lib/v2"
 )
@@ -23,10 +26,30 @@ type jsonResult struct {
 	Retries       bool   `json:"retried,omitempty"`
 	Skipped       bool   `json:"skipped,omitempty"`
 	Error         string `json:"error,omitempty"`
+	Category      string `json:"category,omitempty"`
+	Retryable     *bool  `json:"retryable,omitempty"`
 	PlaylistID    string `json:"playlist_id,omitempty"`
 	PlaylistTitle string `json:"playlist_title,omitempty"`
 	Index         int    `json:"index,omitempty"`
 	Total         int    `json:"total,omitempty"`
+	// SkippedFragments counts HLS/DASH fragments left out under
+	// -skip-unavailable-fragments.
+	SkippedFragments int `json:"skipped_fragments,omitempty"`
+	// SHA256 is the output's checksum under -write-checksum.
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// withError fills in the error message, category and retryable hint for a
+// failed item; a nil err leaves r unchanged.
+func (r jsonResult) withError(err error) jsonResult {
+	if err == nil {
+		return r
+	}
+	retryable := IsRetryable(err)
+	r.Error = err.Error()
+	r.Category = string(errorCategory(err))
+	r.Retryable = &retryable
+	return r
 }
 
 type formatInfo struct {
@@ -43,9 +66,12 @@ type formatInfo struct {
 	Height       int    `json:"height"`
 	Size         int64  `json:"content_length"`
 	Ext          string `json:"ext"`
+	HDR          bool   `json:"hdr"`
+	Spatial      bool   `json:"spatial"`
 }
 
-func emitJSONResult(res jsonResult) {
+func emitJSONResult(stats *RunStats, res jsonResult) {
+	stats.record(res)
 	enc := json.NewEncoder(os.Stdout)
 	enc.SetEscapeHTML(false)
 	_ = enc.Encode(res)
@@ -69,9 +95,30 @@ func renderFormatsJSON(video *youtube.Video, playlistID, playlistTitle string, i
 		Total:         total,
 		ID:            video.ID,
 		Title:         video.Title,
+		Formats:       formatInfos(video.Formats),
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(payload)
+}
+
+// renderFormatsArrayJSON prints a video's formats as a bare JSON array, for
+// -print-json-formats-only.
+func renderFormatsArrayJSON(video *youtube.Video) error {
+	formats := formatInfos(video.Formats)
+	if formats == nil {
+		formats = []formatInfo{}
 	}
-	for _, f := range video.Formats {
-		payload.Formats = append(payload.Formats, formatInfo{
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(formats)
+}
+
+func formatInfos(formats youtube.FormatList) []formatInfo {
+	var infos []formatInfo
+	for i := range formats {
+		f := &formats[i]
+		infos = append(infos, formatInfo{
 			Itag:         f.ItagNo,
 			MimeType:     f.MimeType,
 			Quality:      f.Quality,
@@ -85,14 +132,14 @@ func renderFormatsJSON(video *youtube.Video, playlistID, playlistTitle string, i
 			Height:       f.Height,
 			Size:         int64(f.ContentLength),
 			Ext:          mimeToExt(f.MimeType),
+			HDR:          formatIsHDR(f),
+			Spatial:      formatIsSpatial(f),
 		})
 	}
-	enc := json.NewEncoder(os.Stdout)
-	enc.SetEscapeHTML(false)
-	return enc.Encode(payload)
+	return infos
 }
 
-func printVideoInfo(video *youtube.Video) error {
+func printVideoInfo(video *youtube.Video, maxDescription int) error {
 	payload := struct {
 		ID          string `json:"id"`
 		Title       string `json:"title"`
@@ -102,7 +149,7 @@ func printVideoInfo(video *youtube.Video) error {
 	}{
 		ID:          video.ID,
 		Title:       video.Title,
-		Description: video.Description,
+		Description: truncateDescription(video.Description, maxDescription),
 		Author:      video.Author,
 		Duration:    int(video.Duration.Seconds()),
 	}
@@ -112,7 +159,10 @@ func printVideoInfo(video *youtube.Video) error {
 	return enc.Encode(payload)
 }
 
-func printPlaylistInfo(playlist *youtube.Playlist) error {
+// printPlaylistInfo writes the playlist and its entries as JSON using only
+// what the playlist fetch returned. Entries are never resolved into full
+// videos, so -info stays fast on very large playlists.
+func printPlaylistInfo(w io.Writer, playlist *youtube.Playlist, maxDescription int) error {
 	type entryInfo struct {
 		Index    int    `json:"index"`
 		ID       string `json:"id"`
@@ -131,7 +181,7 @@ func printPlaylistInfo(playlist *youtube.Playlist) error {
 	}{
 		ID:          playlist.ID,
 		Title:       playlist.Title,
-		Description: playlist.Description,
+		Description: truncateDescription(playlist.Description, maxDescription),
 		Author:      playlist.Author,
 		VideoCount:  len(playlist.Videos),
 	}
@@ -149,11 +199,63 @@ func printPlaylistInfo(playlist *youtube.Playlist) error {
 		})
 	}
 
-	enc := json.NewEncoder(os.Stdout)
+	enc := json.NewEncoder(w)
 	enc.SetIndent("", "  ")
 	return enc.Encode(payload)
 }
 
+// flatEntry is one line of -flat-playlist output.
+type flatEntry struct {
+	Index    int    `json:"index"`
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Author   string `json:"author"`
+	Duration int    `json:"duration_seconds"`
+	URL      string `json:"url"`
+}
+
+// printFlatPlaylist writes one JSON line per playlist entry for
+// -flat-playlist. Like printPlaylistInfo it only uses the playlist fetch, so
+// no entry is resolved into a full video.
+func printFlatPlaylist(w io.Writer, playlist *youtube.Playlist) error {
+	entries := make([]flatEntry, 0, len(playlist.Videos))
+	for i, entry := range playlist.Videos {
+		if entry == nil || entry.ID == "" {
+			continue
+		}
+		entries = append(entries, flatEntry{
+			Index:    i + 1,
+			ID:       entry.ID,
+			Title:    entry.Title,
+			Author:   entry.Author,
+			Duration: int(entry.Duration.Seconds()),
+			URL:      watchURLForID(entry.ID),
+		})
+	}
+	return printFlatEntries(w, entries)
+}
+
+func printFlatEntries(w io.Writer, entries []flatEntry) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for _, entry := range entries {
+		if err := enc.Encode(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// truncateDescription cuts a description to at most max characters followed
+// by "..." so -info output stays small. A max of 0 or less keeps it whole.
+func truncateDescription(description string, max int) string {
+	if max <= 0 || utf8.RuneCountInString(description) <= max {
+		return description
+	}
+	runes := []rune(description)
+	return strings.TrimRightFunc(string(runes[:max]), unicode.IsSpace) + "..."
+}
+
 func validateOutputFile(path string, format *youtube.Format) error {
 	info, err := os.Stat(path)
 	if err != nil {
@@ -0,0 +1,110 @@
+package downloader
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	youtube "github.com/lvcoi/ytdl-lib/v2"
+)
+
+func TestTruncateDescription(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		max  int
+		want string
+	}{
+		{name: "unlimited", in: "a long description", max: 0, want: "a long description"},
+		{name: "fits", in: "short", max: 5, want: "short"},
+		{name: "cut", in: "abcdefghij", max: 4, want: "abcd..."},
+		{name: "trailing space", in: "one two three", max: 4, want: "one..."},
+		{name: "multibyte", in: "héllo wörld", max: 7, want: "héllo w..."},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := truncateDescription(tc.in, tc.max); got != tc.want {
+				t.Fatalf("truncateDescription(%q, %d) = %q, want %q", tc.in, tc.max, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPrintPlaylistInfoTruncatesDescription(t *testing.T) {
+	playlist := &youtube.Playlist{ID: "PLxA687tYuMWhkqYjvAGtW_heiEL4Hk_Lx", Description: strings.Repeat("x", 500)}
+	var buf bytes.Buffer
+	if err := printPlaylistInfo(&buf, playlist, 10); err != nil {
+		t.Fatalf("printPlaylistInfo: %v", err)
+	}
+	var payload struct {
+		Description string `json:"description"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &payload); err != nil {
+		t.Fatalf("decoding output: %v", err)
+	}
+	if want := strings.Repeat("x", 10) + "..."; payload.Description != want {
+		t.Fatalf("description = %q, want %q", payload.Description, want)
+	}
+}
+
+func bigMixPlaylist() *youtube.Playlist {
+	return &youtube.Playlist{
+		ID:    "PLxA687tYuMWhkqYjvAGtW_heiEL4Hk_Lx",
+		Title: "Big Mix",
+		Videos: []*youtube.PlaylistEntry{
+			{ID: "aaaaaaaaaaa", Title: "First", Author: "Artist", Duration: 90 * time.Second},
+			nil,
+			{ID: "bbbbbbbbbbb", Title: "Third"},
+		},
+	}
+}
+
+func TestPrintPlaylistInfoListsEntries(t *testing.T) {
+	var buf bytes.Buffer
+	if err := printPlaylistInfo(&buf, bigMixPlaylist(), 0); err != nil {
+		t.Fatalf("printPlaylistInfo: %v", err)
+	}
+	var payload struct {
+		Title      string `json:"title"`
+		VideoCount int    `json:"video_count"`
+		Videos     []struct {
+			Index    int    `json:"index"`
+			ID       string `json:"id"`
+			Title    string `json:"title"`
+			Duration int    `json:"duration_seconds"`
+		} `json:"videos"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &payload); err != nil {
+		t.Fatalf("decoding info output %q: %v", buf.String(), err)
+	}
+	if payload.Title != "Big Mix" || payload.VideoCount != 3 || len(payload.Videos) != 2 {
+		t.Fatalf("unexpected payload: %+v", payload)
+	}
+	if got := payload.Videos[1]; got.Index != 3 || got.ID != "bbbbbbbbbbb" || got.Title != "Third" {
+		t.Fatalf("unexpected third entry: %+v", got)
+	}
+	if payload.Videos[0].Duration != 90 {
+		t.Fatalf("duration = %d, want 90", payload.Videos[0].Duration)
+	}
+}
+
+func TestPrintFlatPlaylistWritesOneLinePerEntry(t *testing.T) {
+	var buf bytes.Buffer
+	if err := printFlatPlaylist(&buf, bigMixPlaylist()); err != nil {
+		t.Fatalf("printFlatPlaylist: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", buf.String())
+	}
+	var entry flatEntry
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil {
+		t.Fatalf("decoding %q: %v", lines[1], err)
+	}
+	want := flatEntry{Index: 3, ID: "bbbbbbbbbbb", Title: "Third", URL: "https://www.youtube.com/watch?v=bbbbbbbbbbb"}
+	if entry != want {
+		t.Fatalf("entry = %+v, want %+v", entry, want)
+	}
+}
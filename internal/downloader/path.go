@@ -1,6 +1,8 @@
 package downloader
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -15,6 +17,9 @@ func resolveOutputPath(template string, video *youtube.Video, format *youtube.Fo
 	if template == "" {
 		template = "{title}.{ext}"
 	}
+	if ctxInfo.FlatPlaylist && ctxInfo.Playlist != nil {
+		template = flattenPlaylistTemplate(template)
+	}
 
 	title := sanitize(video.Title)
 	videoID := sanitize(video.ID)
@@ -38,6 +43,14 @@ func resolveOutputPath(template string, video *youtube.Video, format *youtube.Fo
 		playlistID = sanitize(ctxInfo.Playlist.ID)
 		if ctxInfo.Index > 0 {
 			index = strconv.Itoa(ctxInfo.Index)
+			if ctxInfo.FlatPlaylist {
+				// Pad so a flat folder lists entries in playlist order.
+				width := len(strconv.Itoa(ctxInfo.Total))
+				if width < 2 {
+					width = 2
+				}
+				index = fmt.Sprintf("%0*d", width, ctxInfo.Index)
+			}
 		}
 		if ctxInfo.Total > 0 {
 			total = strconv.Itoa(ctxInfo.Total)
@@ -49,6 +62,9 @@ func resolveOutputPath(template string, video *youtube.Video, format *youtube.Fo
 			artist = ctxInfo.EntryAuthor
 		}
 	}
+	if ctxInfo.CleanArtist {
+		artist = cleanArtistName(artist)
+	}
 	artist = sanitize(artist)
 	album = sanitizeOptional(album)
 
@@ -65,6 +81,7 @@ func resolveOutputPath(template string, video *youtube.Video, format *youtube.Fo
 		"{playlist-id}", playlistID,
 		"{index}", index,
 		"{count}", total,
+		"{autonumber}", ctxInfo.AutoNumber,
 	)
 	path := replacer.Replace(template)
 	path = filepath.Clean(path)
@@ -93,9 +110,150 @@ func resolveOutputPath(template string, video *youtube.Video, format *youtube.Fo
 	if filepath.Ext(path) == "" {
 		path = path + "." + ext
 	}
+	if ctxInfo.TrimFilenames > 0 {
+		path = filepath.Join(filepath.Dir(path), trimFilename(filepath.Base(path), ctxInfo.TrimFilenames))
+	}
 	return validatedOutputPath(path, baseDir)
 }
 
+// playlistPlaceholders are the template fields that nest output by playlist.
+var playlistPlaceholders = []string{"{playlist_title}", "{playlist-title}", "{playlist_id}", "{playlist-id}"}
+
+// flattenPlaylistTemplate implements --flat-playlist-layout: directory segments built
+// from playlist fields are dropped, and the filename is prefixed with the
+// entry's index unless it is already numbered.
+func flattenPlaylistTemplate(template string) string {
+	segments := strings.Split(strings.ReplaceAll(template, `\`, "/"), "/")
+	filename := segments[len(segments)-1]
+	if filename == "" {
+		filename = "{title}.{ext}"
+	}
+	kept := make([]string, 0, len(segments))
+	for _, segment := range segments[:len(segments)-1] {
+		nested := false
+		for _, placeholder := range playlistPlaceholders {
+			if strings.Contains(segment, placeholder) {
+				nested = true
+				break
+			}
+		}
+		if !nested {
+			kept = append(kept, segment)
+		}
+	}
+	if !strings.Contains(filename, "{index}") && !strings.Contains(filename, "{autonumber}") {
+		filename = "{index} - " + filename
+	}
+	return strings.Join(append(kept, filename), "/")
+}
+
+// trimFilename shortens name to at most limit characters, cutting from the
+// stem so the extension survives. At least one stem character is kept even
+// when the extension alone would exceed the limit.
+func trimFilename(name string, limit int) string {
+	runes := []rune(name)
+	if limit <= 0 || len(runes) <= limit {
+		return name
+	}
+	ext := []rune(filepath.Ext(name))
+	stem := runes[:len(runes)-len(ext)]
+	keep := limit - len(ext)
+	if keep < 1 {
+		keep = 1
+	}
+	if keep < len(stem) {
+		stem = stem[:keep]
+	}
+	trimmed := strings.TrimRight(string(stem), " .")
+	if trimmed == "" {
+		trimmed = string(stem[:1])
+	}
+	return trimmed + string(ext)
+}
+
+// Media types that can be routed to their own directory with --paths.
+const (
+	pathKeyAudio    = "audio"
+	pathKeyVideo    = "video"
+	pathKeySubtitle = "subtitle"
+)
+
+// ParsePaths parses a --paths value such as "audio:/music,video:/videos" into a
+// map from media type to directory.
+func ParsePaths(value string) (map[string]string, error) {
+	paths := map[string]string{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid path route %q (expected type:dir)", entry)
+		}
+		key := strings.ToLower(strings.TrimSpace(parts[0]))
+		dir := strings.TrimSpace(parts[1])
+		switch key {
+		case pathKeyAudio, pathKeyVideo, pathKeySubtitle:
+		default:
+			return nil, fmt.Errorf("invalid path route %q (type must be audio, video, or subtitle)", entry)
+		}
+		if dir == "" {
+			return nil, fmt.Errorf("invalid path route %q (empty directory)", entry)
+		}
+		paths[key] = dir
+	}
+	return paths, nil
+}
+
+// mediaTypeForFormat reports whether a download produces audio or video so it
+// can be matched against --paths routes.
+func mediaTypeForFormat(format *youtube.Format, audioOnly bool) string {
+	if audioOnly {
+		return pathKeyAudio
+	}
+	if format != nil && strings.HasPrefix(format.MimeType, "audio/") {
+		return pathKeyAudio
+	}
+	return pathKeyVideo
+}
+
+// routedOutputDir returns the base directory for a download of the given
+// format. A matching --paths route replaces the base directory; relative routes
+// are resolved under opts.OutputDir and absolute routes must stay inside it.
+func routedOutputDir(opts Options, format *youtube.Format) (string, error) {
+	return routedDir(opts.Paths, mediaTypeForFormat(format, opts.AudioOnly), opts.OutputDir)
+}
+
+func routedDir(paths map[string]string, mediaType, baseDir string) (string, error) {
+	dir, ok := paths[mediaType]
+	if !ok || dir == "" {
+		return baseDir, nil
+	}
+	if hasPathTraversal(dir) {
+		return "", fmt.Errorf("%s path %q cannot contain '..' segments", mediaType, dir)
+	}
+	dir = filepath.Clean(dir)
+	if !filepath.IsAbs(dir) {
+		if baseDir == "" {
+			return dir, nil
+		}
+		return filepath.Join(baseDir, dir), nil
+	}
+	if baseDir == "" {
+		return dir, nil
+	}
+	baseAbs, err := filepath.Abs(baseDir)
+	if err != nil {
+		return "", fmt.Errorf("resolve output directory %q: %w", baseDir, err)
+	}
+	rel, err := filepath.Rel(baseAbs, dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s path %q escapes output directory %q", mediaType, dir, baseDir)
+	}
+	return dir, nil
+}
+
 func validatedOutputPath(resolved string, baseDir string) (string, error) {
 	if resolved == "" {
 		return "", fmt.Errorf("output path is empty")
@@ -203,6 +361,52 @@ func artifactPath(outputPath, suffix, baseDir string) (string, error) {
 	return validated, nil
 }
 
+// cleanupFailedArtifacts removes partial download files after a failure when
+// --cleanup-on-failure is set. By default they are kept so the next run can
+// resume. An interrupted run (context cancelled) is not a failure and always
+// keeps them.
+func cleanupFailedArtifacts(ctx context.Context, opts Options, paths ...string) {
+	if !opts.CleanupOnFailure || ctx.Err() != nil {
+		return
+	}
+	for _, path := range paths {
+		if path != "" {
+			_ = os.Remove(path)
+		}
+	}
+}
+
+// adoptablePartSize returns the size of a part file left by another tool for
+// --continue-from-browser-download, or 0 when there is nothing to continue. A
+// part that is not shorter than the expected size (when known) cannot be a
+// prefix of the download, so it is reported and the download starts over.
+func adoptablePartSize(partPath string, expected int64, printer *Printer) int64 {
+	info, err := os.Stat(partPath)
+	if err != nil || !info.Mode().IsRegular() || info.Size() == 0 {
+		return 0
+	}
+	if expected > 0 && info.Size() >= expected {
+		printer.Log(LogWarn, fmt.Sprintf("warning: %s is %d bytes but the download is %d; downloading from the start", filepath.Base(partPath), info.Size(), expected))
+		return 0
+	}
+	return info.Size()
+}
+
+// discardResumeArtifacts deletes the part and resume files of an earlier
+// attempt when -no-resume is set, so a corrupt resume state cannot be picked
+// up and the download starts from the first byte.
+func discardResumeArtifacts(opts Options, partPath, resumePath string) error {
+	if !opts.NoResume {
+		return nil
+	}
+	for _, path := range []string{partPath, resumePath} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return wrapCategory(CategoryFilesystem, fmt.Errorf("removing resume artifact: %w", err))
+		}
+	}
+	return nil
+}
+
 // hasPathTraversal checks if a path contains ".." components.
 // This function is designed to work on uncleaned paths to detect path traversal
 // attempts before filepath.Clean() collapses them. For example, "a/../../../etc/passwd"
@@ -219,6 +423,12 @@ func hasPathTraversal(path string) bool {
 	return false
 }
 
+// SanitizeFilename replaces characters that are invalid in file names, as
+// done for titles in output templates.
+func SanitizeFilename(name string) string {
+	return sanitize(name)
+}
+
 func sanitize(name string) string {
 	invalid := regexp.MustCompile(`[<>:"/\\|?*\x00-\x1F]`)
 	clean := invalid.ReplaceAllString(name, "-")
@@ -0,0 +1,190 @@
+package downloader
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	youtube "github.com/lvcoi/ytdl-lib/v2"
+)
+
+func TestResolveOutputPathCleansArtist(t *testing.T) {
+	baseDir := t.TempDir()
+	video := &youtube.Video{ID: "vid123", Title: "Song", Author: "Artist Name - Topic"}
+	format := &youtube.Format{MimeType: "audio/mp4"}
+
+	path, err := resolveOutputPath("{artist}/{title}.{ext}", video, format, outputContext{CleanArtist: true}, baseDir)
+	if err != nil {
+		t.Fatalf("resolveOutputPath: %v", err)
+	}
+	if got := filepath.Base(filepath.Dir(path)); got != "Artist Name" {
+		t.Fatalf("expected artist directory %q, got %q (%s)", "Artist Name", got, path)
+	}
+}
+
+func TestParsePaths(t *testing.T) {
+	paths, err := ParsePaths("audio:/music, video:/videos,subtitle:subs")
+	if err != nil {
+		t.Fatalf("ParsePaths: %v", err)
+	}
+	if paths["audio"] != "/music" || paths["video"] != "/videos" || paths["subtitle"] != "subs" {
+		t.Fatalf("unexpected routes: %v", paths)
+	}
+	for _, bad := range []string{"audio", "thumbs:/x", "video:"} {
+		if _, err := ParsePaths(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestResolveOutputPathRoutesByMediaType(t *testing.T) {
+	baseDir := t.TempDir()
+	video := &youtube.Video{ID: "vid123", Title: "Song"}
+	paths := map[string]string{"audio": "music", "video": filepath.Join(baseDir, "videos")}
+
+	tests := []struct {
+		name    string
+		format  *youtube.Format
+		audio   bool
+		wantDir string
+	}{
+		{"audio format", &youtube.Format{MimeType: "audio/mp4"}, false, filepath.Join(baseDir, "music")},
+		{"audio only flag", &youtube.Format{MimeType: "video/mp4"}, true, filepath.Join(baseDir, "music")},
+		{"video format", &youtube.Format{MimeType: "video/mp4"}, false, filepath.Join(baseDir, "videos")},
+	}
+	for _, tt := range tests {
+		opts := Options{OutputDir: baseDir, Paths: paths, AudioOnly: tt.audio}
+		dir, err := routedOutputDir(opts, tt.format)
+		if err != nil {
+			t.Fatalf("%s: routedOutputDir: %v", tt.name, err)
+		}
+		path, err := resolveOutputPath("{title}.{ext}", video, tt.format, outputContext{}, dir)
+		if err != nil {
+			t.Fatalf("%s: resolveOutputPath: %v", tt.name, err)
+		}
+		if got := filepath.Dir(path); got != tt.wantDir {
+			t.Fatalf("%s: expected dir %q, got %q", tt.name, tt.wantDir, got)
+		}
+	}
+}
+
+func TestRoutedOutputDirRejectsEscapingRoutes(t *testing.T) {
+	baseDir := t.TempDir()
+	format := &youtube.Format{MimeType: "audio/mp4"}
+
+	if _, err := routedOutputDir(Options{OutputDir: baseDir, Paths: map[string]string{"audio": "/elsewhere"}}, format); err == nil {
+		t.Fatalf("expected absolute route outside output dir to be rejected")
+	}
+	if _, err := routedOutputDir(Options{OutputDir: baseDir, Paths: map[string]string{"audio": "../music"}}, format); err == nil {
+		t.Fatalf("expected traversal route to be rejected")
+	}
+	dir, err := routedOutputDir(Options{OutputDir: baseDir}, format)
+	if err != nil || dir != baseDir {
+		t.Fatalf("expected unrouted base dir %q, got %q (%v)", baseDir, dir, err)
+	}
+}
+
+func TestResolveOutputPathTrimsFilename(t *testing.T) {
+	baseDir := t.TempDir()
+	video := &youtube.Video{ID: "vid123", Title: strings.Repeat("Very Long Title ", 20)}
+	format := &youtube.Format{MimeType: "video/mp4"}
+
+	path, err := resolveOutputPath("Nested/{title}.{ext}", video, format, outputContext{TrimFilenames: 40}, baseDir)
+	if err != nil {
+		t.Fatalf("resolveOutputPath: %v", err)
+	}
+	name := filepath.Base(path)
+	if len(name) > 40 {
+		t.Fatalf("expected filename of at most 40 characters, got %d (%s)", len(name), name)
+	}
+	if filepath.Ext(name) != ".mp4" {
+		t.Fatalf("expected .mp4 extension to survive trimming, got %q", name)
+	}
+	if got := filepath.Base(filepath.Dir(path)); got != "Nested" {
+		t.Fatalf("expected directory to be left alone, got %q", got)
+	}
+}
+
+func TestTrimFilename(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit int
+		want  string
+	}{
+		{"short.mp4", 40, "short.mp4"},
+		{"abcdefghij.mp4", 10, "abcdef.mp4"},
+		{"abc def.mp4", 8, "abc.mp4"},
+		{"abcdef.webm", 3, "a.webm"},
+		{"日本語のタイトル.m4a", 7, "日本語.m4a"},
+	}
+	for _, tt := range tests {
+		if got := trimFilename(tt.name, tt.limit); got != tt.want {
+			t.Fatalf("trimFilename(%q, %d) = %q, want %q", tt.name, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestResolveOutputPathFlatVersusNestedPlaylist(t *testing.T) {
+	baseDir := t.TempDir()
+	playlist := &youtube.Playlist{ID: "PL123", Title: "Road Trip"}
+	format := &youtube.Format{MimeType: "audio/mp4"}
+
+	tests := []struct {
+		name     string
+		template string
+		flat     bool
+		want     []string
+	}{
+		{
+			name:     "nested by default",
+			template: "{playlist_title}/{title}.{ext}",
+			want:     []string{"Road Trip/First.mp4", "Road Trip/Second.mp4"},
+		},
+		{
+			name:     "flat drops playlist folder and numbers entries",
+			template: "{playlist_title}/{title}.{ext}",
+			flat:     true,
+			want:     []string{"01 - First.mp4", "02 - Second.mp4"},
+		},
+		{
+			name:     "flat keeps other folders and existing numbering",
+			template: "Music/{playlist_id}/{index}. {title}.{ext}",
+			flat:     true,
+			want:     []string{"Music/01. First.mp4", "Music/02. Second.mp4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, title := range []string{"First", "Second"} {
+				video := &youtube.Video{ID: "vid" + title, Title: title}
+				ctxInfo := outputContext{Playlist: playlist, Index: i + 1, Total: 12, FlatPlaylist: tt.flat}
+				path, err := resolveOutputPath(tt.template, video, format, ctxInfo, baseDir)
+				if err != nil {
+					t.Fatalf("resolveOutputPath: %v", err)
+				}
+				rel, err := filepath.Rel(baseDir, path)
+				if err != nil {
+					t.Fatalf("rel: %v", err)
+				}
+				if filepath.ToSlash(rel) != tt.want[i] {
+					t.Fatalf("entry %d: expected %q, got %q", i+1, tt.want[i], filepath.ToSlash(rel))
+				}
+			}
+		})
+	}
+}
+
+func TestResolveOutputPathFlatPlaylistIgnoredOutsidePlaylists(t *testing.T) {
+	baseDir := t.TempDir()
+	video := &youtube.Video{ID: "vid1", Title: "Single"}
+	format := &youtube.Format{MimeType: "audio/mp4"}
+
+	path, err := resolveOutputPath("{title}.{ext}", video, format, outputContext{FlatPlaylist: true}, baseDir)
+	if err != nil {
+		t.Fatalf("resolveOutputPath: %v", err)
+	}
+	if filepath.Base(path) != "Single.mp4" {
+		t.Fatalf("expected single videos to keep their name, got %q", path)
+	}
+}
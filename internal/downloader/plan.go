@@ -0,0 +1,173 @@
+package downloader
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/lvcoi/ytdl-lib/v2"
+)
+
+// Plan actions for -dump-plan entries.
+const (
+	planDownload = "download"
+	planSkip     = "skip"
+	planPrompt   = "prompt"
+	planError    = "error"
+)
+
+// downloadPlan is the -dump-plan document: what a download run would do with
+// each entry, worked out without downloading anything.
+type downloadPlan struct {
+	Type          string      `json:"type"`
+	PlaylistID    string      `json:"playlist_id,omitempty"`
+	PlaylistTitle string      `json:"playlist_title,omitempty"`
+	Entries       []planEntry `json:"entries"`
+}
+
+type planEntry struct {
+	Index   int    `json:"index,omitempty"`
+	ID      string `json:"id,omitempty"`
+	Title   string `json:"title,omitempty"`
+	Itag    int    `json:"itag,omitempty"`
+	Format  string `json:"format,omitempty"`
+	Quality string `json:"quality,omitempty"`
+	Output  string `json:"output,omitempty"`
+	// Action is download, skip, prompt (the output exists and a download
+	// would ask whether to overwrite it) or error.
+	Action string `json:"action"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// planPrompter stands in for the duplicate prompt during a dry pass: it
+// answers skip and notes that a real run would have asked.
+type planPrompter struct {
+	asked bool
+}
+
+func (p *planPrompter) PromptDuplicate(string) (DuplicateDecision, error) {
+	p.asked = true
+	return DuplicateDecisionSkip, nil
+}
+
+// planVideo works out the entry for one video the way downloadVideo would:
+// format selection, --paths routing, the output template and the existing
+// file check.
+func planVideo(video *youtube.Video, opts Options, ctxInfo outputContext) planEntry {
+	entry := planEntry{Index: ctxInfo.Index, ID: video.ID, Title: stringsOrFallback(ctxInfo.EntryTitle, video.Title)}
+	format, err := selectFormat(video, opts)
+	switch {
+	case errors.Is(err, errBelowMinFileSize):
+		entry.Action, entry.Reason = planSkip, "below min-filesize"
+		return entry
+	case err != nil && errorCategory(err) == CategoryUnsupported && (video.HLSManifestURL != "" || video.DASHManifestURL != ""):
+		if reason := opts.Archive.skipReason(video.ID, nil, opts); reason != "" {
+			entry.Action, entry.Reason = planSkip, reason
+			return entry
+		}
+		// The adaptive variant, and so the output name, is only picked
+		// once the manifest is fetched.
+		entry.Action, entry.Format = planDownload, "adaptive"
+		entry.Reason = "HLS/DASH stream; output resolved at download time"
+		return entry
+	case err != nil:
+		entry.Action, entry.Reason = planError, err.Error()
+		return entry
+	}
+	entry.Itag, entry.Format, entry.Quality = format.ItagNo, format.MimeType, format.QualityLabel
+	if entry.Quality == "" {
+		entry.Quality = format.Quality
+	}
+	if reason := opts.Archive.skipReason(video.ID, format, opts); reason != "" {
+		entry.Action, entry.Reason = planSkip, reason
+		return entry
+	}
+
+	dir, err := routedOutputDir(opts, format)
+	if err != nil {
+		entry.Action, entry.Reason = planError, err.Error()
+		return entry
+	}
+	opts.OutputDir = dir
+	path, err := resolveOutputPath(opts.OutputTemplate, video, format, ctxInfo, opts.OutputDir)
+	if err != nil {
+		entry.Action, entry.Reason = planError, err.Error()
+		return entry
+	}
+	if opts.AudioOnly && opts.AudioFormat != "" && ffmpegAvailable() {
+		path = withAudioExtension(path, opts.AudioFormat)
+	}
+	prompter := &planPrompter{}
+	opts.DuplicatePrompter = prompter
+	path, skip, err := handleExistingPath(path, opts.OutputDir, opts, nil)
+	entry.Output = path
+	switch {
+	case err != nil:
+		entry.Action, entry.Reason = planError, err.Error()
+	case prompter.asked:
+		entry.Action, entry.Reason = planPrompt, "exists"
+	case skip:
+		entry.Action, entry.Reason = planSkip, "exists"
+	default:
+		entry.Action = planDownload
+	}
+	return entry
+}
+
+// planPlaylist builds the -dump-plan document for a playlist, applying the
+// entry filters processPlaylist applies.
+func planPlaylist(ctx context.Context, client YouTubeClient, playlist *youtube.Playlist, opts Options, cleanArtist bool) (downloadPlan, error) {
+	plan := downloadPlan{Type: "plan", PlaylistID: playlist.ID, PlaylistTitle: playlist.Title, Entries: []planEntry{}}
+	total := len(playlist.Videos)
+	for i, entry := range playlist.Videos {
+		if entry == nil || entry.ID == "" {
+			plan.Entries = append(plan.Entries, planEntry{Index: i + 1, Action: planSkip, Reason: "missing playlist entry"})
+			continue
+		}
+		filtered := planEntry{Index: i + 1, ID: entry.ID, Title: entryTitle(entry), Action: planSkip}
+		if filtered.Reason = entrySkipReason(entry, opts); filtered.Reason != "" {
+			plan.Entries = append(plan.Entries, filtered)
+			continue
+		}
+		video, err := client.VideoFromPlaylistEntryContext(ctx, entry)
+		if err != nil {
+			if ctx.Err() != nil {
+				return downloadPlan{}, ctx.Err()
+			}
+			plan.Entries = append(plan.Entries, planEntry{Index: i + 1, ID: entry.ID, Title: entryTitle(entry), Action: planError, Reason: wrapFetchError(err, "fetching video metadata").Error()})
+			continue
+		}
+		if filtered.Reason = dateWindowSkipReason(video.PublishDate, opts); filtered.Reason != "" {
+			plan.Entries = append(plan.Entries, filtered)
+			continue
+		}
+		title := entry.Title
+		if applyPreferredLanguage(ctx, client, video, opts.PreferLang, nil) {
+			title = video.Title
+		}
+		plan.Entries = append(plan.Entries, planVideo(video, opts, outputContext{
+			Playlist:      playlist,
+			Index:         i + 1,
+			Total:         total,
+			EntryTitle:    title,
+			EntryAuthor:   entry.Author,
+			CleanArtist:   cleanArtist,
+			TrimFilenames: opts.TrimFilenames,
+			AutoNumber:    nextAutoNumber(opts),
+			FlatPlaylist:  opts.FlatPlaylistLayout,
+		}))
+	}
+	return plan, nil
+}
+
+func writePlan(w io.Writer, plan downloadPlan) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(plan); err != nil {
+		return fmt.Errorf("writing plan: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	youtube "github.com/lvcoi/ytdl-lib/v2"
+)
+
+func TestPlanPlaylistMatchesDownloadOutcomes(t *testing.T) {
+	payload := "\x00\x00\x00\x18ftypisom" + strings.Repeat("\x00", 12) + "moov" + strings.Repeat("x", 256)
+	videos := map[string]*youtube.Video{}
+	for _, id := range []string{"alpha1", "beta22", "gamma3"} {
+		videos[id] = &youtube.Video{ID: id, Title: id, Formats: youtube.FormatList{{
+			ItagNo: 18, URL: "https://example.com/" + id, MimeType: `video/mp4; codecs="avc1.42001E, mp4a.40.2"`,
+			Quality: "medium", QualityLabel: "360p", AudioChannels: 2, Width: 640, Height: 360,
+		}}}
+	}
+	client := &mockYouTubeClient{
+		videoFromFn: func(ctx context.Context, entry *youtube.PlaylistEntry) (*youtube.Video, error) {
+			return videos[entry.ID], nil
+		},
+		getStreamFn: func(ctx context.Context, video *youtube.Video, format *youtube.Format) (io.ReadCloser, int64, error) {
+			return io.NopCloser(strings.NewReader(payload)), int64(len(payload)), nil
+		},
+	}
+	playlist := &youtube.Playlist{ID: "PL1", Title: "Mix", Videos: []*youtube.PlaylistEntry{
+		{ID: "alpha1", Title: "Alpha"},
+		{ID: "beta22", Title: "Beta"},
+		nil,
+		{ID: "gamma3", Title: "Gamma (Live)"},
+	}}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "Beta.mp4"), []byte("already here"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{
+		OutputTemplate: "{title}.{ext}",
+		OutputDir:      dir,
+		Quiet:          true,
+		OnDuplicate:    DuplicatePolicySkip,
+		RejectTitle:    regexp.MustCompile(`\(Live\)`),
+	}
+
+	plan, err := planPlaylist(context.Background(), client, playlist, opts, false)
+	if err != nil {
+		t.Fatalf("planPlaylist: %v", err)
+	}
+	var out bytes.Buffer
+	if err := writePlan(&out, plan); err != nil {
+		t.Fatal(err)
+	}
+	var decoded downloadPlan
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("plan is not valid JSON: %v\n%s", err, out.String())
+	}
+	if decoded.Type != "plan" || decoded.PlaylistID != "PL1" || len(decoded.Entries) != 4 {
+		t.Fatalf("unexpected plan header or entry count:\n%s", out.String())
+	}
+	wantActions := []string{planDownload, planSkip, planSkip, planSkip}
+	wantReasons := []string{"", "exists", "missing playlist entry", "title matches -reject-title"}
+	for i, entry := range decoded.Entries {
+		if entry.Action != wantActions[i] || entry.Reason != wantReasons[i] {
+			t.Errorf("entry %d: action %q, reason %q; want %q, %q", i+1, entry.Action, entry.Reason, wantActions[i], wantReasons[i])
+		}
+	}
+	if entry := decoded.Entries[0]; entry.Itag != 18 || entry.Quality != "360p" || entry.Output != filepath.Join(dir, "Alpha.mp4") {
+		t.Errorf("entry 1: itag %d, quality %q, output %q", entry.Itag, entry.Quality, entry.Output)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Alpha.mp4")); !os.IsNotExist(err) {
+		t.Fatalf("planning must not create the output, stat err = %v", err)
+	}
+
+	// Downloading the planned entries must land where the plan said.
+	for i, entry := range decoded.Entries[:2] {
+		playlistEntry := playlist.Videos[i]
+		result, err := downloadVideo(context.Background(), client, videos[entry.ID], opts, outputContext{
+			Playlist:   playlist,
+			Index:      i + 1,
+			Total:      len(playlist.Videos),
+			EntryTitle: playlistEntry.Title,
+		}, newPrinter(opts, nil), "[1/1]")
+		if err != nil {
+			t.Fatalf("entry %d: downloadVideo: %v", i+1, err)
+		}
+		if result.outputPath != entry.Output || result.skipped != (entry.Action == planSkip) {
+			t.Errorf("entry %d: downloaded to %q (skipped %v), plan said %q (%s)", i+1, result.outputPath, result.skipped, entry.Output, entry.Action)
+		}
+	}
+}
+
+func TestPlanVideoReportsPrompt(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "Clip.mp4"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	video := &youtube.Video{ID: "clip01", Title: "Clip", Formats: youtube.FormatList{{
+		ItagNo: 18, MimeType: `video/mp4; codecs="avc1.42001E, mp4a.40.2"`, AudioChannels: 2, Width: 640, Height: 360,
+	}}}
+	opts := Options{OutputTemplate: "{title}.{ext}", OutputDir: dir, OnDuplicate: DuplicatePolicyPrompt}
+	entry := planVideo(video, opts, outputContext{})
+	if entry.Action != planPrompt || entry.Reason != "exists" {
+		t.Fatalf("expected a prompt for an existing output, got %q (%s)", entry.Action, entry.Reason)
+	}
+}
@@ -0,0 +1,127 @@
+package downloader
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	youtube "github.com/lvcoi/ytdl-lib/v2"
+)
+
+// relativeStartPattern matches the countdown YouTube puts in the reason of an
+// upcoming video, e.g. "Premieres in 3 hours" or "will begin in 2 days".
+var relativeStartPattern = regexp.MustCompile(`(?i)\bin (\d+) (minute|hour|day|week)s?\b`)
+
+// absoluteStartPattern matches a date and time such as "10/20/26, 6:00 PM".
+var absoluteStartPattern = regexp.MustCompile(`\d{1,2}/\d{1,2}/\d{2,4},? \d{1,2}:\d{2} ?[AaPp][Mm]`)
+
+var absoluteStartLayouts = []string{"1/2/06, 3:04 PM", "1/2/2006, 3:04 PM", "1/2/06 3:04 PM", "1/2/2006 3:04 PM"}
+
+// playabilityKind classifies a playability failure as "members-only",
+// "upcoming premiere" or "upcoming live stream", returning "" for anything
+// else, along with the reason YouTube gave.
+func playabilityKind(err error) (kind, reason string) {
+	var statusErr *youtube.ErrPlayabiltyStatus
+	if !stderrors.As(err, &statusErr) {
+		return "", ""
+	}
+	reason = strings.TrimSpace(statusErr.Reason)
+	lower := strings.ToLower(reason)
+
+	if strings.Contains(lower, "members-only") || strings.Contains(lower, "members only") ||
+		strings.Contains(lower, "join this channel") {
+		return "members-only", reason
+	}
+	upcoming := statusErr.Status == "LIVE_STREAM_OFFLINE" || strings.Contains(lower, "premiere") ||
+		strings.Contains(lower, "will begin") || strings.Contains(lower, "upcoming")
+	if !upcoming {
+		return "", reason
+	}
+	if strings.Contains(lower, "premiere") {
+		return "upcoming premiere", reason
+	}
+	return "upcoming live stream", reason
+}
+
+// isUpcomingVideo reports whether err means the video is a premiere or live
+// stream that has not started yet.
+func isUpcomingVideo(err error) bool {
+	kind, _ := playabilityKind(err)
+	return strings.HasPrefix(kind, "upcoming")
+}
+
+// describePlayability turns playability failures that the generic restricted
+// message explains poorly (upcoming premieres, scheduled live streams,
+// members-only videos) into a readable sentence. It reports false for any
+// other error.
+func describePlayability(err error, now time.Time) (string, bool) {
+	kind, reason := playabilityKind(err)
+	switch kind {
+	case "":
+		return "", false
+	case "members-only":
+		return "video is members-only and requires a channel membership", true
+	}
+	if start, ok := scheduledStart(reason, now); ok {
+		return fmt.Sprintf("video is an %s scheduled for %s", kind, start.UTC().Format("2006-01-02 15:04 MST")), true
+	}
+	if reason != "" {
+		return fmt.Sprintf("video is an %s (%s)", kind, reason), true
+	}
+	return fmt.Sprintf("video is an %s and has not started yet", kind), true
+}
+
+// scheduledStart extracts the start time from an upcoming video's reason.
+// Countdowns are resolved against now and rounded to the minute; absolute
+// dates carry no zone and are read as UTC.
+func scheduledStart(reason string, now time.Time) (time.Time, bool) {
+	if match := relativeStartPattern.FindStringSubmatch(reason); match != nil {
+		n, err := strconv.Atoi(match[1])
+		if err == nil {
+			unit := map[string]time.Duration{
+				"minute": time.Minute,
+				"hour":   time.Hour,
+				"day":    24 * time.Hour,
+				"week":   7 * 24 * time.Hour,
+			}[strings.ToLower(match[2])]
+			return now.Add(time.Duration(n) * unit).Round(time.Minute), true
+		}
+	}
+	if match := absoluteStartPattern.FindString(reason); match != "" {
+		normalized := strings.ToUpper(match)
+		for _, layout := range absoluteStartLayouts {
+			if start, err := time.Parse(layout, normalized); err == nil {
+				return start, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// fetchVideoWaiting fetches video metadata, and with --wait-for-video keeps
+// re-checking an upcoming premiere or live stream every opts.WaitForVideo
+// until it becomes available, opts.WaitForVideoMax elapses, or ctx is
+// cancelled. Other errors are returned immediately.
+func fetchVideoWaiting(ctx context.Context, client YouTubeClient, url string, opts Options, printer *Printer) (*youtube.Video, error) {
+	start := time.Now()
+	for {
+		video, err := client.GetVideoContext(ctx, url)
+		if err == nil || opts.WaitForVideo <= 0 || !isUpcomingVideo(err) {
+			return video, err
+		}
+		if opts.WaitForVideoMax > 0 && time.Since(start)+opts.WaitForVideo > opts.WaitForVideoMax {
+			return nil, fmt.Errorf("gave up waiting after %s: %w", opts.WaitForVideoMax, err)
+		}
+		if printer != nil {
+			message, _ := describePlayability(err, time.Now())
+			printer.Log(LogInfo, fmt.Sprintf("%s; checking again in %s", message, opts.WaitForVideo))
+		}
+		if sleepErr := sleepWithContext(ctx, opts.WaitForVideo); sleepErr != nil {
+			return nil, err
+		}
+	}
+}
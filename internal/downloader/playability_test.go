@@ -0,0 +1,172 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	youtube "github.com/lvcoi/ytdl-lib/v2"
+)
+
+func TestDescribePlayabilityUpcoming(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		status string
+		reason string
+		want   string
+	}{
+		{
+			name:   "premiere countdown",
+			status: "LIVE_STREAM_OFFLINE",
+			reason: "Premieres in 3 hours",
+			want:   "video is an upcoming premiere scheduled for 2026-10-15 15:00 UTC",
+		},
+		{
+			name:   "live stream countdown",
+			status: "LIVE_STREAM_OFFLINE",
+			reason: "This live event will begin in 2 days.",
+			want:   "video is an upcoming live stream scheduled for 2026-10-17 12:00 UTC",
+		},
+		{
+			name:   "premiere absolute date",
+			status: "UNPLAYABLE",
+			reason: "Premieres 10/20/26, 6:30 PM",
+			want:   "video is an upcoming premiere scheduled for 2026-10-20 18:30 UTC",
+		},
+		{
+			name:   "no schedule",
+			status: "LIVE_STREAM_OFFLINE",
+			reason: "Premiere will begin shortly",
+			want:   "video is an upcoming premiere (Premiere will begin shortly)",
+		},
+		{
+			name:   "members only",
+			status: "UNPLAYABLE",
+			reason: "Join this channel to get access to members-only content like this video, and other exclusive perks.",
+			want:   "video is members-only and requires a channel membership",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := &youtube.ErrPlayabiltyStatus{Status: tt.status, Reason: tt.reason}
+			got, ok := describePlayability(err, now)
+			if !ok {
+				t.Fatalf("expected %q to be recognized", tt.reason)
+			}
+			if got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDescribePlayabilityIgnoresOtherErrors(t *testing.T) {
+	for _, err := range []error{
+		&youtube.ErrPlayabiltyStatus{Status: "ERROR", Reason: "Video unavailable"},
+		youtube.ErrLoginRequired,
+		errors.New("connection reset"),
+	} {
+		if msg, ok := describePlayability(err, time.Now()); ok {
+			t.Fatalf("expected %v to be left alone, got %q", err, msg)
+		}
+	}
+}
+
+func TestWrapFetchErrorUpcomingVideo(t *testing.T) {
+	err := wrapFetchError(&youtube.ErrPlayabiltyStatus{Status: "LIVE_STREAM_OFFLINE", Reason: "Premieres in 45 minutes"}, "fetching video metadata")
+
+	if errorCategory(err) != CategoryRestricted {
+		t.Fatalf("expected restricted category, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "video is an upcoming premiere scheduled for") {
+		t.Fatalf("expected friendly upcoming message, got %q", err.Error())
+	}
+	var statusErr *youtube.ErrPlayabiltyStatus
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("expected original playability error to stay wrapped")
+	}
+}
+
+func TestFetchVideoWaitingPollsUntilAvailable(t *testing.T) {
+	upcoming := &youtube.ErrPlayabiltyStatus{Status: "LIVE_STREAM_OFFLINE", Reason: "Premieres in 1 minute"}
+	calls := 0
+	client := &mockYouTubeClient{getVideoFn: func(ctx context.Context, url string) (*youtube.Video, error) {
+		calls++
+		if calls <= 3 {
+			return nil, upcoming
+		}
+		return &youtube.Video{ID: "abc123"}, nil
+	}}
+
+	opts := Options{WaitForVideo: time.Millisecond, WaitForVideoMax: time.Minute}
+	video, err := fetchVideoWaiting(context.Background(), client, "https://youtu.be/abc123", opts, nil)
+	if err != nil {
+		t.Fatalf("fetchVideoWaiting: %v", err)
+	}
+	if video.ID != "abc123" || calls != 4 {
+		t.Fatalf("expected video after 4 checks, got %+v after %d", video, calls)
+	}
+}
+
+func TestFetchVideoWaitingStops(t *testing.T) {
+	upcoming := &youtube.ErrPlayabiltyStatus{Status: "LIVE_STREAM_OFFLINE", Reason: "Premieres in 1 minute"}
+
+	tests := []struct {
+		name      string
+		err       error
+		opts      Options
+		wantCalls int
+	}{
+		{name: "not waiting", err: upcoming, opts: Options{}, wantCalls: 1},
+		{name: "not upcoming", err: youtube.ErrVideoPrivate, opts: Options{WaitForVideo: time.Millisecond}, wantCalls: 1},
+		// wantCalls 0: polls more than once, then gives up.
+		{name: "max wait", err: upcoming, opts: Options{WaitForVideo: 20 * time.Millisecond, WaitForVideoMax: 50 * time.Millisecond}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			client := &mockYouTubeClient{getVideoFn: func(ctx context.Context, url string) (*youtube.Video, error) {
+				calls++
+				return nil, tt.err
+			}}
+			_, err := fetchVideoWaiting(context.Background(), client, "https://youtu.be/abc123", tt.opts, nil)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected %v, got %v", tt.err, err)
+			}
+			if tt.wantCalls == 0 {
+				if calls < 2 || !strings.Contains(err.Error(), "gave up waiting") {
+					t.Fatalf("expected several checks then a give-up error, got %d checks and %v", calls, err)
+				}
+			} else if calls != tt.wantCalls {
+				t.Fatalf("expected %d checks, got %d", tt.wantCalls, calls)
+			}
+		})
+	}
+}
+
+func TestFetchVideoWaitingHonorsCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	client := &mockYouTubeClient{getVideoFn: func(context.Context, string) (*youtube.Video, error) {
+		cancel()
+		return nil, &youtube.ErrPlayabiltyStatus{Status: "LIVE_STREAM_OFFLINE", Reason: "Premieres in 1 hour"}
+	}}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := fetchVideoWaiting(ctx, client, "https://youtu.be/abc123", Options{WaitForVideo: time.Hour}, nil)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !isUpcomingVideo(err) {
+			t.Fatalf("expected the upcoming error after cancellation, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("fetchVideoWaiting did not return after cancellation")
+	}
+}
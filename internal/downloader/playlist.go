@@ -4,15 +4,26 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/lvcoi/ytdl-lib/v2"
 )
 
 var fetchMusicPlaylistEntriesFn = fetchMusicPlaylistEntries
 
+// newPlaylistClientFn builds the client that fetches the playlist itself.
+var newPlaylistClientFn = func(opts Options) YouTubeClient {
+	return newClientForType("web", opts)
+}
+
+// playlistEntryRetryDelay is the base delay between per-entry metadata fetch
+// retries; it grows linearly with each attempt.
+var playlistEntryRetryDelay = time.Second
+
 func processPlaylist(ctx context.Context, url string, opts Options, printer *Printer, isMusicURL bool) error {
-	playlistClient := newClientForType("web", opts)
+	playlistClient := newPlaylistClientFn(opts)
 	playlist, err := playlistClient.GetPlaylistContext(ctx, url)
 	if err != nil {
 		return wrapAccessError(fmt.Errorf("fetching playlist: %w", err))
@@ -31,11 +42,27 @@ func processPlaylist(ctx context.Context, url string, opts Options, printer *Pri
 
 	// Check InfoOnly first, then ListFormats (consistent with single-video path)
 	if opts.InfoOnly {
-		return printPlaylistInfo(playlist)
+		return printPlaylistInfo(os.Stdout, playlist, opts.MaxDescriptionLength)
+	}
+	if opts.FlatPlaylist {
+		return printFlatPlaylist(os.Stdout, playlist)
 	}
 	if opts.ListFormats {
 		return listPlaylistFormats(ctx, playlist, opts, printer)
 	}
+	if opts.ListSubtitles {
+		return listPlaylistSubtitles(ctx, playlist, opts)
+	}
+	if opts.DumpPlan {
+		plan, err := planPlaylist(ctx, newClientForType("android", opts), playlist, opts, shouldCleanArtist(opts.CleanArtist, isMusicURL))
+		if err != nil {
+			return err
+		}
+		return writePlan(os.Stdout, plan)
+	}
+	if opts.GetFilename || opts.GetURL {
+		return queryPlaylist(ctx, os.Stdout, playlist, opts, shouldCleanArtist(opts.CleanArtist, isMusicURL))
+	}
 
 	// Only check for empty videos when actually downloading
 	if len(playlist.Videos) == 0 {
@@ -45,13 +72,22 @@ func processPlaylist(ctx context.Context, url string, opts Options, printer *Pri
 	albumMeta := resolveMusicPlaylistAlbumMeta(ctx, playlist.ID, opts, isMusicURL, printer)
 
 	printer.Log(LogInfo, fmt.Sprintf("playlist: %s (%d videos)", playlist.Title, len(playlist.Videos)))
+	printer.PlaylistStart(playlist.Title, len(playlist.Videos))
 
 	videoClient := newClientForType("android", opts)
-	type playlistOutcome struct {
-		ok      bool
-		failed  bool
-		skipped bool
-		bytes   int64
+	cleanArtist := shouldCleanArtist(opts.CleanArtist, isMusicURL)
+
+	var prefetch *playlistPrefetcher
+	if opts.MetadataConcurrency > 0 {
+		prefetchCtx, cancelPrefetch := context.WithCancel(ctx)
+		defer cancelPrefetch()
+		skip := func(entry *youtube.PlaylistEntry) bool {
+			return entrySkipReason(entry, opts) != ""
+		}
+		prefetch = startPlaylistPrefetch(prefetchCtx, playlist.Videos, opts.MetadataConcurrency, skip, func(i int, entry *youtube.PlaylistEntry) (*youtube.Video, error) {
+			prefix := printer.Prefix(i+1, len(playlist.Videos), entryTitle(entry))
+			return fetchPlaylistEntryVideo(prefetchCtx, videoClient, entry, opts.PlaylistEntryRetries, printer, prefix)
+		})
 	}
 
 	handleEntry := func(i int, entry *youtube.PlaylistEntry) playlistOutcome {
@@ -60,7 +96,7 @@ func processPlaylist(ctx context.Context, url string, opts Options, printer *Pri
 		if entry == nil || entry.ID == "" {
 			printer.ItemSkipped(prefix, "missing playlist entry")
 			if opts.JSON {
-				emitJSONResult(jsonResult{
+				emitJSONResult(opts.Stats, jsonResult{
 					Type:          "item",
 					Status:        "skip",
 					PlaylistID:    playlist.ID,
@@ -71,15 +107,40 @@ func processPlaylist(ctx context.Context, url string, opts Options, printer *Pri
 					Error:         "missing playlist entry",
 				})
 			}
-			return playlistOutcome{skipped: true}
+			return playlistOutcome{skipped: true, index: i + 1, reason: "missing playlist entry"}
+		}
+		skipFiltered := func(reason string) playlistOutcome {
+			printer.ItemSkipped(prefix, reason)
+			if opts.JSON {
+				emitJSONResult(opts.Stats, jsonResult{
+					Type:          "item",
+					Status:        "skip",
+					PlaylistID:    playlist.ID,
+					PlaylistTitle: playlist.Title,
+					Index:         i + 1,
+					ID:            entry.ID,
+					Title:         entryTitle(entry),
+					Error:         reason,
+				})
+			}
+			return playlistOutcome{skipped: true, index: i + 1, id: entry.ID, title: entryTitle(entry), reason: reason}
+		}
+		if reason := entrySkipReason(entry, opts); reason != "" {
+			return skipFiltered(reason)
 		}
 
-		video, err := videoClient.VideoFromPlaylistEntryContext(ctx, entry)
+		var video *youtube.Video
+		var err error
+		if prefetch != nil {
+			video, err = prefetch.get(i)
+		} else {
+			video, err = fetchPlaylistEntryVideo(ctx, videoClient, entry, opts.PlaylistEntryRetries, printer, prefix)
+		}
 		if err != nil {
 			err = wrapFetchError(err, "fetching video metadata")
 			printer.ItemResult(prefix, downloadResult{}, err)
 			if opts.JSON {
-				emitJSONResult(jsonResult{
+				emitJSONResult(opts.Stats, jsonResult{
 					Type:          "item",
 					Status:        "error",
 					PlaylistID:    playlist.ID,
@@ -87,15 +148,22 @@ func processPlaylist(ctx context.Context, url string, opts Options, printer *Pri
 					Index:         i + 1,
 					ID:            entry.ID,
 					Title:         entryTitle(entry),
-					Error:         err.Error(),
-				})
+				}.withError(err))
 			}
-			return playlistOutcome{failed: true}
+			return playlistOutcome{failed: true, index: i + 1, id: entry.ID, title: entryTitle(entry), reason: err.Error(), err: err}
+		}
+		// Playlist entries carry no date, so the window is checked once the
+		// video's metadata is in.
+		if reason := dateWindowSkipReason(video.PublishDate, opts); reason != "" {
+			return skipFiltered(reason)
 		}
 
 		meta := albumMeta[entry.ID]
 		entryTitle := entry.Title
 		entryAuthor := entry.Author
+		if applyPreferredLanguage(ctx, videoClient, video, opts.PreferLang, printer) {
+			entryTitle = video.Title
+		}
 		if meta.Title != "" {
 			entryTitle = meta.Title
 		}
@@ -113,11 +181,19 @@ func processPlaylist(ctx context.Context, url string, opts Options, printer *Pri
 			SourceURL:     watchURLForID(entry.ID),
 			PlaylistURL:   url,
 			MetaOverrides: opts.MetaOverrides,
+			CleanArtist:   cleanArtist,
+			TrimFilenames: opts.TrimFilenames,
+			AutoNumber:    nextAutoNumber(opts),
+			FlatPlaylist:  opts.FlatPlaylistLayout,
 		}, printer, prefix)
 		if result.skipped {
-			printer.ItemSkipped(prefix, "exists")
+			reason := result.skipReason
+			if reason == "" {
+				reason = "exists"
+			}
+			printer.ItemSkipped(prefix, reason)
 			if opts.JSON {
-				emitJSONResult(jsonResult{
+				emitJSONResult(opts.Stats, jsonResult{
 					Type:          "item",
 					Status:        "skip",
 					PlaylistID:    playlist.ID,
@@ -128,58 +204,64 @@ func processPlaylist(ctx context.Context, url string, opts Options, printer *Pri
 					Output:        result.outputPath,
 					Bytes:         result.bytes,
 					Retries:       result.retried,
-					Error:         "exists",
+					Error:         reason,
 				})
 			}
-			return playlistOutcome{skipped: true}
+			return playlistOutcome{skipped: true, index: i + 1, id: entry.ID, title: entryTitle, reason: reason, output: result.outputPath}
 		}
 		printer.ItemResult(prefix, result, err)
 		if opts.JSON {
 			status := "ok"
-			errMsg := ""
 			if err != nil {
 				status = "error"
-				errMsg = err.Error()
 			}
-			emitJSONResult(jsonResult{
-				Type:          "item",
-				Status:        status,
-				PlaylistID:    playlist.ID,
-				PlaylistTitle: playlist.Title,
-				Index:         i + 1,
-				ID:            entry.ID,
-				Title:         entryTitle,
-				Output:        result.outputPath,
-				Bytes:         result.bytes,
-				Retries:       result.retried,
-				Error:         errMsg,
-			})
+			emitJSONResult(opts.Stats, jsonResult{
+				Type:             "item",
+				Status:           status,
+				PlaylistID:       playlist.ID,
+				PlaylistTitle:    playlist.Title,
+				Index:            i + 1,
+				ID:               entry.ID,
+				Title:            entryTitle,
+				Output:           result.outputPath,
+				Bytes:            result.bytes,
+				Retries:          result.retried,
+				SkippedFragments: result.skippedFragments,
+				SHA256:           result.sha256,
+			}.withError(err))
 		}
 
 		if err != nil {
-			return playlistOutcome{failed: true}
+			return playlistOutcome{failed: true, index: i + 1, id: entry.ID, title: entryTitle, reason: err.Error(), err: err}
 		}
 
-		return playlistOutcome{ok: true, bytes: result.bytes}
+		return playlistOutcome{ok: true, bytes: result.bytes, index: i + 1, id: entry.ID, title: entryTitle, output: result.outputPath}
 	}
 
 	successes := 0
 	failures := 0
 	skipped := 0
 	var totalBytes int64
+	var outcomes []playlistOutcome
+	var firstFailure error
 
-	// Always download sequentially to:
+	// Always download sequentially (metadata may be prefetched ahead) to:
 	// 1. Avoid bandwidth contention between concurrent downloads
 	// 2. Properly clean up connections after each download
 	// 3. Prevent zombie processes from accumulating
 	for i, entry := range playlist.Videos {
 		outcome := handleEntry(i, entry)
+		outcomes = append(outcomes, outcome)
+		printer.PlaylistItemDone()
 		if outcome.skipped {
 			skipped++
 			continue
 		}
 		if outcome.failed {
 			failures++
+			if firstFailure == nil {
+				firstFailure = outcome.err
+			}
 			continue
 		}
 		if outcome.ok {
@@ -189,12 +271,139 @@ func processPlaylist(ctx context.Context, url string, opts Options, printer *Pri
 	}
 
 	printer.Summary(len(playlist.Videos), successes, failures, skipped, totalBytes)
+	if opts.ReportFailed != "" {
+		if err := writeFailureReport(opts.ReportFailed, buildFailureReport(playlist, url, outcomes)); err != nil {
+			printer.Log(LogWarn, fmt.Sprintf("warning: %v", err))
+		}
+	}
+	if opts.WritePlaylistM3U {
+		if path, err := writePlaylistM3U(playlist, outcomes, opts.M3UIncludeSkipped); err != nil {
+			printer.Log(LogWarn, fmt.Sprintf("warning: %v", err))
+		} else if path != "" {
+			printer.Log(LogInfo, fmt.Sprintf("playlist index: %s", path))
+		}
+	}
+	return playlistError(successes, failures, firstFailure, opts.RequireAll)
+}
+
+// playlistError decides whether a finished playlist run failed overall. By
+// default it fails only when nothing was downloaded; with --require-all any
+// failed entry fails the run, categorized like the first failure.
+func playlistError(successes, failures int, firstFailure error, requireAll bool) error {
 	if successes == 0 {
 		return markReported(wrapCategory(CategoryUnsupported, errors.New("no playlist entries downloaded successfully")))
 	}
+	if requireAll && failures > 0 {
+		return markReported(wrapCategory(errorCategory(firstFailure), fmt.Errorf("%d of %d playlist entries failed (-require-all)", failures, successes+failures)))
+	}
 	return nil
 }
 
+// fetchPlaylistEntryVideo resolves a playlist entry to a full video, retrying
+// transient (network) failures up to retries extra times. Restricted and
+// invalid entries are returned immediately since retrying cannot help.
+func fetchPlaylistEntryVideo(ctx context.Context, client YouTubeClient, entry *youtube.PlaylistEntry, retries int, printer *Printer, prefix string) (*youtube.Video, error) {
+	if retries < 0 {
+		retries = 0
+	}
+	var lastErr error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			if printer != nil {
+				printer.Log(LogWarn, fmt.Sprintf("%s retrying metadata fetch (%d/%d): %v", prefix, attempt, retries, lastErr))
+			}
+			if err := sleepWithContext(ctx, time.Duration(attempt)*playlistEntryRetryDelay); err != nil {
+				return nil, lastErr
+			}
+		}
+		video, err := client.VideoFromPlaylistEntryContext(ctx, entry)
+		if err == nil {
+			return video, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil || categoryForYouTubeError(err) != CategoryNetwork {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+type prefetchedEntry struct {
+	video *youtube.Video
+	err   error
+	// held reports whether the fetch took a prefetch slot that the consumer
+	// must give back.
+	held bool
+}
+
+// playlistPrefetcher resolves playlist entry metadata ahead of the download
+// loop so network-bound metadata calls overlap with downloads. At most ahead
+// entries are being fetched or waiting to be consumed at any time.
+type playlistPrefetcher struct {
+	results []chan prefetchedEntry
+	slots   chan struct{}
+	// next is the lowest index not yet consumed or released by get.
+	next int
+}
+
+// startPlaylistPrefetch begins resolving entries in order with fetch. Missing
+// entries, and entries skip reports the consumer will filter out, are not
+// fetched and take no slot. Once ctx is cancelled, every entry not yet
+// started resolves to ctx's error.
+func startPlaylistPrefetch(ctx context.Context, entries []*youtube.PlaylistEntry, ahead int, skip func(*youtube.PlaylistEntry) bool, fetch func(int, *youtube.PlaylistEntry) (*youtube.Video, error)) *playlistPrefetcher {
+	if ahead < 1 {
+		ahead = 1
+	}
+	p := &playlistPrefetcher{
+		results: make([]chan prefetchedEntry, len(entries)),
+		slots:   make(chan struct{}, ahead),
+	}
+	for i := range p.results {
+		p.results[i] = make(chan prefetchedEntry, 1)
+	}
+	go func() {
+		for i, entry := range entries {
+			if entry == nil || entry.ID == "" || (skip != nil && skip(entry)) {
+				p.results[i] <- prefetchedEntry{}
+				continue
+			}
+			select {
+			case p.slots <- struct{}{}:
+			case <-ctx.Done():
+				for j := i; j < len(entries); j++ {
+					p.results[j] <- prefetchedEntry{err: ctx.Err()}
+				}
+				return
+			}
+			go func(i int, entry *youtube.PlaylistEntry) {
+				video, err := fetch(i, entry)
+				p.results[i] <- prefetchedEntry{video: video, err: err, held: true}
+			}(i, entry)
+		}
+	}()
+	return p
+}
+
+// get waits for entry i's metadata and frees its slot for the next entry.
+// Indices must be requested in increasing order. Entries passed over without
+// a get are released along the way, so the consumer may skip any of them
+// without starving the prefetch.
+func (p *playlistPrefetcher) get(i int) (*youtube.Video, error) {
+	for ; p.next < i; p.next++ {
+		p.release(<-p.results[p.next])
+	}
+	result := <-p.results[i]
+	p.next = i + 1
+	p.release(result)
+	return result.video, result.err
+}
+
+func (p *playlistPrefetcher) release(result prefetchedEntry) {
+	if result.held {
+		<-p.slots
+	}
+}
+
 func resolveMusicPlaylistAlbumMeta(ctx context.Context, playlistID string, opts Options, isMusicURL bool, printer *Printer) map[string]musicEntryMeta {
 	if !isMusicURL {
 		return map[string]musicEntryMeta{}
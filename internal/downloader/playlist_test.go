@@ -3,8 +3,14 @@ package downloader
 import (
 	"context"
 	"errors"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	youtube "github.com/lvcoi/ytdl-lib/v2"
 )
 
 func TestResolveMusicPlaylistAlbumMetaSkipsWhenNotMusicURL(t *testing.T) {
@@ -60,3 +66,298 @@ func TestResolveMusicPlaylistAlbumMetaReturnsFetchedData(t *testing.T) {
 		t.Fatalf("expected album metadata passthrough, got %+v", out["abc123"])
 	}
 }
+
+func TestFetchPlaylistEntryVideoRetriesTransientErrors(t *testing.T) {
+	restore := playlistEntryRetryDelay
+	playlistEntryRetryDelay = time.Millisecond
+	defer func() { playlistEntryRetryDelay = restore }()
+
+	calls := 0
+	client := &mockYouTubeClient{
+		videoFromFn: func(context.Context, *youtube.PlaylistEntry) (*youtube.Video, error) {
+			calls++
+			if calls == 1 {
+				return nil, errors.New("connection reset by peer")
+			}
+			return &youtube.Video{ID: "abc123"}, nil
+		},
+	}
+
+	video, err := fetchPlaylistEntryVideo(context.Background(), client, &youtube.PlaylistEntry{ID: "abc123"}, 2, nil, "")
+	if err != nil {
+		t.Fatalf("expected flaky entry to succeed on retry, got %v", err)
+	}
+	if video == nil || video.ID != "abc123" {
+		t.Fatalf("unexpected video: %+v", video)
+	}
+	if calls != 2 {
+		t.Fatalf("expected 2 attempts, got %d", calls)
+	}
+}
+
+func TestFetchPlaylistEntryVideoDoesNotRetryRestricted(t *testing.T) {
+	restore := playlistEntryRetryDelay
+	playlistEntryRetryDelay = time.Millisecond
+	defer func() { playlistEntryRetryDelay = restore }()
+
+	calls := 0
+	client := &mockYouTubeClient{
+		videoFromFn: func(context.Context, *youtube.PlaylistEntry) (*youtube.Video, error) {
+			calls++
+			return nil, youtube.ErrVideoPrivate
+		},
+	}
+
+	_, err := fetchPlaylistEntryVideo(context.Background(), client, &youtube.PlaylistEntry{ID: "abc123"}, 3, nil, "")
+	if !errors.Is(err, youtube.ErrVideoPrivate) {
+		t.Fatalf("expected private video error, got %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected restricted error not to be retried, got %d attempts", calls)
+	}
+}
+
+func TestFetchPlaylistEntryVideoGivesUpAfterRetries(t *testing.T) {
+	restore := playlistEntryRetryDelay
+	playlistEntryRetryDelay = time.Millisecond
+	defer func() { playlistEntryRetryDelay = restore }()
+
+	calls := 0
+	client := &mockYouTubeClient{
+		videoFromFn: func(context.Context, *youtube.PlaylistEntry) (*youtube.Video, error) {
+			calls++
+			return nil, errors.New("timeout")
+		},
+	}
+
+	if _, err := fetchPlaylistEntryVideo(context.Background(), client, &youtube.PlaylistEntry{ID: "abc123"}, 2, nil, ""); err == nil {
+		t.Fatalf("expected error after exhausting retries")
+	}
+	if calls != 3 {
+		t.Fatalf("expected 3 attempts (1 + 2 retries), got %d", calls)
+	}
+}
+
+func TestPlaylistPrefetchOverlapsDownload(t *testing.T) {
+	entries := []*youtube.PlaylistEntry{{ID: "a"}, {ID: "b"}, nil, {ID: "c"}, {ID: "d"}}
+	started := make(chan string, len(entries))
+	prefetch := startPlaylistPrefetch(context.Background(), entries, 2, nil, func(_ int, entry *youtube.PlaylistEntry) (*youtube.Video, error) {
+		started <- entry.ID
+		return &youtube.Video{ID: entry.ID}, nil
+	})
+	// Fetches run concurrently, so they may start in any order.
+	waitStarted := func(want ...string) {
+		t.Helper()
+		pending := make(map[string]bool)
+		for _, id := range want {
+			pending[id] = true
+		}
+		for len(pending) > 0 {
+			select {
+			case got := <-started:
+				if !pending[got] {
+					t.Fatalf("prefetched %q, want one of %v", got, want)
+				}
+				delete(pending, got)
+			case <-time.After(2 * time.Second):
+				t.Fatalf("timed out waiting for %v to be prefetched", want)
+			}
+		}
+	}
+	assertIdle := func() {
+		t.Helper()
+		select {
+		case got := <-started:
+			t.Fatalf("prefetched %q beyond the lookahead limit", got)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	waitStarted("a", "b")
+	assertIdle()
+
+	video, err := prefetch.get(0)
+	if err != nil || video.ID != "a" {
+		t.Fatalf("get(0) = %+v, %v", video, err)
+	}
+	// Entry 0 is now "downloading"; the next entry's metadata is fetched
+	// meanwhile, skipping the missing one.
+	waitStarted("c")
+	assertIdle()
+
+	for i, want := range []string{"b", "", "c"} {
+		video, err := prefetch.get(i + 1)
+		if err != nil {
+			t.Fatalf("get(%d): %v", i+1, err)
+		}
+		if want == "" {
+			if video != nil {
+				t.Fatalf("missing entry resolved to %+v", video)
+			}
+			continue
+		}
+		if video.ID != want {
+			t.Fatalf("get(%d) = %q, want %q", i+1, video.ID, want)
+		}
+	}
+	waitStarted("d")
+}
+
+func TestPlaylistPrefetchStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	entries := []*youtube.PlaylistEntry{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	prefetch := startPlaylistPrefetch(ctx, entries, 1, nil, func(_ int, entry *youtube.PlaylistEntry) (*youtube.Video, error) {
+		return &youtube.Video{ID: entry.ID}, nil
+	})
+	cancel()
+	if _, err := prefetch.get(0); err != nil && !errors.Is(err, context.Canceled) {
+		t.Fatalf("get(0): unexpected error %v", err)
+	}
+	for i := 1; i < len(entries); i++ {
+		done := make(chan error, 1)
+		go func() {
+			_, err := prefetch.get(i)
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			if err != nil && !errors.Is(err, context.Canceled) {
+				t.Fatalf("get(%d): unexpected error %v", i, err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("get(%d) blocked after cancellation", i)
+		}
+	}
+}
+
+// TestPlaylistPrefetchWithEntryFilters mirrors the download loop, which never
+// calls get for entries the filters skip, and checks it does not hang.
+func TestPlaylistPrefetchWithEntryFilters(t *testing.T) {
+	entries := []*youtube.PlaylistEntry{
+		{ID: "a", Title: "Episode 1", Duration: 30 * time.Minute},
+		{ID: "b", Title: "Trailer", Duration: 2 * time.Minute},
+		{ID: "c", Title: "Episode 2 preview", Duration: time.Minute},
+		{ID: "d", Title: "Episode 3", Duration: 40 * time.Minute},
+	}
+	opts := Options{MatchTitle: regexp.MustCompile(`^Episode`), MinDuration: 10 * time.Minute}
+	var fetched sync.Map
+	skip := func(entry *youtube.PlaylistEntry) bool { return entrySkipReason(entry, opts) != "" }
+	prefetch := startPlaylistPrefetch(context.Background(), entries, 1, skip, func(_ int, entry *youtube.PlaylistEntry) (*youtube.Video, error) {
+		fetched.Store(entry.ID, true)
+		return &youtube.Video{ID: entry.ID}, nil
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i, entry := range entries {
+			if entrySkipReason(entry, opts) != "" {
+				continue
+			}
+			if video, err := prefetch.get(i); err != nil || video.ID != entry.ID {
+				t.Errorf("get(%d) = %+v, %v", i, video, err)
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("download loop hung on a filtered entry")
+	}
+	for _, id := range []string{"b", "c"} {
+		if _, ok := fetched.Load(id); ok {
+			t.Errorf("filtered entry %q was fetched", id)
+		}
+	}
+}
+
+func TestPlaylistPrefetchReleasesSkippedEntries(t *testing.T) {
+	entries := []*youtube.PlaylistEntry{{ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "d"}}
+	prefetch := startPlaylistPrefetch(context.Background(), entries, 1, nil, func(_ int, entry *youtube.PlaylistEntry) (*youtube.Video, error) {
+		return &youtube.Video{ID: entry.ID}, nil
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, i := range []int{0, 3} {
+			if video, err := prefetch.get(i); err != nil || video.ID != entries[i].ID {
+				t.Errorf("get(%d) = %+v, %v", i, video, err)
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("get hung after skipping fetched entries")
+	}
+}
+
+func TestPlaylistErrorRequireAll(t *testing.T) {
+	networkErr := wrapCategory(CategoryNetwork, errors.New("connection reset"))
+
+	// One of three entries failed: tolerated by default.
+	if err := playlistError(2, 1, networkErr, false); err != nil {
+		t.Fatalf("expected a partial playlist to succeed by default, got %v", err)
+	}
+
+	err := playlistError(2, 1, networkErr, true)
+	if err == nil {
+		t.Fatal("expected -require-all to fail a playlist with one failed entry")
+	}
+	if !IsReported(err) {
+		t.Fatalf("expected the error to be marked reported, got %v", err)
+	}
+	if errorCategory(err) != CategoryNetwork || ExitCode(err) != ExitCode(networkErr) {
+		t.Fatalf("expected the first failure's category, got %v (exit %d)", errorCategory(err), ExitCode(err))
+	}
+	if !strings.Contains(err.Error(), "1 of 3 playlist entries failed") {
+		t.Fatalf("unexpected error message %q", err)
+	}
+
+	if err := playlistError(3, 0, nil, true); err != nil {
+		t.Fatalf("expected a complete playlist to succeed under -require-all, got %v", err)
+	}
+	if err := playlistError(0, 2, networkErr, false); errorCategory(err) != CategoryUnsupported {
+		t.Fatalf("expected an empty run to keep failing as before, got %v", err)
+	}
+}
+
+func TestProcessPlaylistFlatSkipsEntryResolution(t *testing.T) {
+	prev := newPlaylistClientFn
+	t.Cleanup(func() { newPlaylistClientFn = prev })
+	newPlaylistClientFn = func(Options) YouTubeClient {
+		return &mockYouTubeClient{
+			getPlaylistFn: func(context.Context, string) (*youtube.Playlist, error) {
+				return bigMixPlaylist(), nil
+			},
+			videoFromFn: func(_ context.Context, entry *youtube.PlaylistEntry) (*youtube.Video, error) {
+				t.Fatalf("unexpected per-entry fetch for %s", entry.ID)
+				return nil, nil
+			},
+		}
+	}
+
+	out, err := os.CreateTemp(t.TempDir(), "flat-*.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	stdout := os.Stdout
+	os.Stdout = out
+	opts := Options{FlatPlaylist: true}
+	err = processPlaylist(context.Background(), "https://www.youtube.com/playlist?list=PLxA687tYuMWhkqYjvAGtW_heiEL4Hk_Lx", opts, newPrinter(opts, nil), false)
+	os.Stdout = stdout
+	if err != nil {
+		t.Fatalf("processPlaylist: %v", err)
+	}
+
+	data, err := os.ReadFile(out.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"id":"aaaaaaaaaaa"`) || !strings.Contains(lines[1], `"id":"bbbbbbbbbbb"`) {
+		t.Fatalf("unexpected flat listing:\n%s", data)
+	}
+}
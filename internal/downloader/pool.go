@@ -2,9 +2,11 @@ package downloader
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/lvcoi/ytdl-go/internal/ws"
@@ -18,8 +20,19 @@ type Task struct {
 	Jobs     int
 	Execute  func(ctx context.Context, urls []string, opts Options, jobs int) ([]any, int)
 	OnFinish func(id string, err error)
+	// MaxDuration, when positive, is an overall deadline for the task. Execute
+	// receives a context that is cancelled once it elapses.
+	MaxDuration time.Duration
+	// Context, when set, also cancels the context Execute receives, so the
+	// caller can stop a single task without stopping the pool. A task stopped
+	// this way is broadcast with status "cancelled" instead of an error.
+	Context context.Context
 }
 
+// ErrTaskTimeout is passed to OnFinish, wrapped with the limit, when a task
+// runs past its MaxDuration.
+var ErrTaskTimeout = errors.New("job timed out")
+
 // WSBroadcaster is an interface to decouple the pool from the WebSocket hub.
 type WSBroadcaster interface {
 	Broadcast(msg ws.WSMessage)
@@ -27,12 +40,22 @@ type WSBroadcaster interface {
 
 // Pool manages a fixed number of workers to process download tasks.
 type Pool struct {
-	TaskQueue   chan Task // Strict Unbuffered Channel
-	Workers     int
-	Hub         WSBroadcaster
-	wg          sync.WaitGroup
-	ctx         context.Context
-	cancel      context.CancelFunc
+	TaskQueue chan Task // Strict Unbuffered Channel
+	Workers   int
+	Hub       WSBroadcaster
+	wg        sync.WaitGroup
+	ctx       context.Context
+	cancel    context.CancelFunc
+	queued    atomic.Int64
+	running   atomic.Int64
+	started   atomic.Bool
+}
+
+// PoolStats is a point-in-time snapshot of pool occupancy.
+type PoolStats struct {
+	Queued  int `json:"queued"`
+	Running int `json:"running"`
+	Size    int `json:"pool_size"`
 }
 
 func NewPool(workers int, hub WSBroadcaster) *Pool {
@@ -48,20 +71,39 @@ func (p *Pool) Start(ctx context.Context) {
 	for i := 0; i < p.Workers; i++ {
 		go p.worker()
 	}
+	p.started.Store(true)
+}
+
+// Running reports whether Start has been called and the pool has not been
+// stopped or had its context cancelled since.
+func (p *Pool) Running() bool {
+	return p.started.Load() && p.ctx.Err() == nil
 }
 
 func (p *Pool) AddTask(t Task) {
 	p.wg.Add(1)
+	p.queued.Add(1)
 	go func() {
 		select {
 		case p.TaskQueue <- t:
 		case <-p.ctx.Done():
 			log.Printf("pool: task %q dropped (context cancelled)", t.ID)
+			p.queued.Add(-1)
 			p.wg.Done()
 		}
 	}()
 }
 
+// Stats reports how many tasks are waiting for a worker, how many are being
+// processed, and the configured number of workers.
+func (p *Pool) Stats() PoolStats {
+	return PoolStats{
+		Queued:  int(p.queued.Load()),
+		Running: int(p.running.Load()),
+		Size:    p.Workers,
+	}
+}
+
 func (p *Pool) worker() {
 	for {
 		select {
@@ -71,7 +113,10 @@ func (p *Pool) worker() {
 			if !ok {
 				return
 			}
+			p.running.Add(1)
+			p.queued.Add(-1)
 			p.processTask(task)
+			p.running.Add(-1)
 			p.wg.Done()
 		}
 	}
@@ -94,9 +139,52 @@ func (p *Pool) processTask(t Task) {
 	}
 
 	// Use provided context for cancellation
-	_, exitCode := t.Execute(p.ctx, t.URLs, t.Options, t.Jobs)
+	ctx := p.ctx
+	if t.Context != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+		stop := context.AfterFunc(t.Context, cancel)
+		defer stop()
+	}
+	if t.MaxDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.MaxDuration)
+		defer cancel()
+	}
+	_, exitCode := t.Execute(ctx, t.URLs, t.Options, t.Jobs)
 	var err error
-	if exitCode != 0 {
+	if t.Context != nil && t.Context.Err() != nil {
+		// Cancelled by the caller: report it as such, not as a failure.
+		err = t.Context.Err()
+		p.Hub.Broadcast(ws.WSMessage{
+			Type: "progress",
+			Payload: ws.ProgressPayload{
+				ID:     t.ID,
+				Status: "cancelled",
+			},
+		})
+	} else if t.MaxDuration > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("%w after %s", ErrTaskTimeout, t.MaxDuration)
+		if exitCode == 0 {
+			exitCode = 1
+		}
+		p.Hub.Broadcast(ws.WSMessage{
+			Type: "progress",
+			Payload: ws.ProgressPayload{
+				ID:     t.ID,
+				Status: "error",
+			},
+		})
+		p.Hub.Broadcast(ws.WSMessage{
+			Type: "error",
+			Payload: ws.ErrorPayload{
+				ID:      t.ID,
+				Message: err.Error(),
+				Code:    exitCode,
+			},
+		})
+	} else if exitCode != 0 {
 		err = fmt.Errorf("exit code %d", exitCode)
 		p.Hub.Broadcast(ws.WSMessage{
 			Type: "error",
@@ -2,6 +2,8 @@ package downloader
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -164,3 +166,190 @@ func TestPool_AddTaskDroppedOnCancelledContext(t *testing.T) {
 		t.Fatal("task should not have been executed after context cancellation")
 	}
 }
+
+// TestPool_StatsReportsQueuedAndRunning enqueues more tasks than workers and
+// checks that the surplus is reported as queued.
+func TestPool_StatsReportsQueuedAndRunning(t *testing.T) {
+	mockHub := &MockHub{}
+	pool := NewPool(2, mockHub)
+	pool.Start(context.Background())
+	defer pool.Stop()
+
+	release := make(chan struct{})
+	for i := 0; i < 5; i++ {
+		pool.AddTask(Task{
+			ID:   "stats_task",
+			URLs: []string{"http://example.com"},
+			Execute: func(ctx context.Context, urls []string, opts Options, jobs int) ([]any, int) {
+				<-release
+				return nil, 0
+			},
+		})
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	stats := pool.Stats()
+	for stats.Running != 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+		stats = pool.Stats()
+	}
+	if stats.Running != 2 || stats.Queued != 3 || stats.Size != 2 {
+		t.Fatalf("expected 2 running, 3 queued, size 2; got %+v", stats)
+	}
+
+	close(release)
+	pool.Wait()
+	if stats := pool.Stats(); stats.Running != 0 || stats.Queued != 0 {
+		t.Fatalf("expected empty pool after Wait, got %+v", stats)
+	}
+}
+
+// TestPool_MaxDurationCancelsSlowTask runs a task that outlives its deadline
+// and checks it is cancelled and reported as a timeout error.
+func TestPool_MaxDurationCancelsSlowTask(t *testing.T) {
+	mockHub := &MockHub{}
+	pool := NewPool(1, mockHub)
+	pool.Start(context.Background())
+	defer pool.Stop()
+
+	var finishErr error
+	pool.AddTask(Task{
+		ID:          "slow_task",
+		URLs:        []string{"http://example.com"},
+		MaxDuration: 50 * time.Millisecond,
+		Execute: func(ctx context.Context, urls []string, opts Options, jobs int) ([]any, int) {
+			select {
+			case <-ctx.Done():
+				return nil, 1
+			case <-time.After(5 * time.Second):
+				return nil, 0
+			}
+		},
+		OnFinish: func(id string, err error) {
+			finishErr = err
+		},
+	})
+
+	done := make(chan struct{})
+	go func() {
+		pool.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("slow task was not cancelled at its deadline")
+	}
+
+	if !errors.Is(finishErr, ErrTaskTimeout) || !strings.Contains(finishErr.Error(), "timed out after 50ms") {
+		t.Fatalf("expected timeout error from OnFinish, got %v", finishErr)
+	}
+
+	mockHub.mu.Lock()
+	defer mockHub.mu.Unlock()
+	var sawStatus, sawError bool
+	for _, msg := range mockHub.messages {
+		switch payload := msg.Payload.(type) {
+		case ws.ProgressPayload:
+			if payload.ID == "slow_task" && payload.Status == "error" {
+				sawStatus = true
+			}
+		case ws.ErrorPayload:
+			if payload.ID == "slow_task" && strings.Contains(payload.Message, "timed out") {
+				sawError = true
+			}
+		}
+	}
+	if !sawStatus || !sawError {
+		t.Fatalf("expected error status and timeout message, got %+v", mockHub.messages)
+	}
+}
+
+// TestPool_TaskContextCancelsTask cancels one task through its own context
+// and checks the pool keeps serving other tasks.
+func TestPool_TaskContextCancelsTask(t *testing.T) {
+	pool := NewPool(1, &MockHub{})
+	pool.Start(context.Background())
+	defer pool.Stop()
+
+	taskCtx, cancelTask := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	pool.AddTask(Task{
+		ID:      "cancelled_task",
+		Context: taskCtx,
+		Execute: func(ctx context.Context, urls []string, opts Options, jobs int) ([]any, int) {
+			close(started)
+			<-ctx.Done()
+			return nil, 130
+		},
+	})
+	<-started
+	cancelTask()
+
+	var ran atomic.Bool
+	pool.AddTask(Task{
+		ID: "next_task",
+		Execute: func(ctx context.Context, urls []string, opts Options, jobs int) ([]any, int) {
+			ran.Store(ctx.Err() == nil)
+			return nil, 0
+		},
+	})
+
+	done := make(chan struct{})
+	go func() {
+		pool.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("cancelling the task context did not stop the task")
+	}
+	if !ran.Load() {
+		t.Fatal("expected the next task to run with a live context")
+	}
+}
+
+// TestPool_CancelledTaskReportsCancelledNotError checks that a task stopped
+// through its context is broadcast as cancelled without an error message.
+func TestPool_CancelledTaskReportsCancelledNotError(t *testing.T) {
+	mockHub := &MockHub{}
+	pool := NewPool(1, mockHub)
+	pool.Start(context.Background())
+	defer pool.Stop()
+
+	taskCtx, cancelTask := context.WithCancel(context.Background())
+	cancelTask()
+	var finishErr error
+	pool.AddTask(Task{
+		ID:      "cancelled_task",
+		Context: taskCtx,
+		Execute: func(ctx context.Context, urls []string, opts Options, jobs int) ([]any, int) {
+			return nil, 130
+		},
+		OnFinish: func(id string, err error) {
+			finishErr = err
+		},
+	})
+	pool.Wait()
+
+	if !errors.Is(finishErr, context.Canceled) {
+		t.Fatalf("expected OnFinish to receive context.Canceled, got %v", finishErr)
+	}
+	mockHub.mu.Lock()
+	defer mockHub.mu.Unlock()
+	var sawCancelled bool
+	for _, msg := range mockHub.messages {
+		switch payload := msg.Payload.(type) {
+		case ws.ProgressPayload:
+			if payload.Status == "cancelled" {
+				sawCancelled = true
+			}
+		case ws.ErrorPayload:
+			t.Fatalf("expected no error message for a cancelled task, got %+v", payload)
+		}
+	}
+	if !sawCancelled {
+		t.Fatalf("expected a cancelled status, got %+v", mockHub.messages)
+	}
+}
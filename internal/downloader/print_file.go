@@ -0,0 +1,137 @@
+package downloader
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// PrintSpec is one --print-to-file target: a line template and the file each
+// rendered line is appended to.
+type PrintSpec struct {
+	Template string
+	Path     string
+}
+
+var printPlaceholderRegex = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// printPlaceholders are the substitutions available to --print-to-file
+// templates.
+var printPlaceholders = map[string]bool{
+	"output": true,
+	"title":  true,
+	"id":     true,
+	"artist": true,
+	"album":  true,
+	"url":    true,
+}
+
+// printFileMu serializes appends so concurrent jobs and playlist entries
+// never interleave partial lines in a shared file.
+var printFileMu sync.Mutex
+
+// ParsePrintSpec parses a --print-to-file value of the form "TEMPLATE:path".
+// The path follows the last colon, except that a Windows drive letter such as
+// "C:" stays part of the path.
+func ParsePrintSpec(value string) (PrintSpec, error) {
+	i := strings.LastIndex(value, ":")
+	if i > 1 && isDriveLetterPrefix(value[:i+1]) {
+		i = strings.LastIndex(value[:i-1], ":")
+	}
+	if i <= 0 {
+		return PrintSpec{}, fmt.Errorf("invalid print spec %q (expected TEMPLATE:path)", value)
+	}
+	spec := PrintSpec{Template: value[:i], Path: strings.TrimSpace(value[i+1:])}
+	if spec.Path == "" {
+		return PrintSpec{}, fmt.Errorf("invalid print spec %q (empty path)", value)
+	}
+	if err := validatePrintTemplate(spec.Template); err != nil {
+		return PrintSpec{}, err
+	}
+	return spec, nil
+}
+
+// isDriveLetterPrefix reports whether s ends in a single-letter drive such as
+// "C:" that starts a path component.
+func isDriveLetterPrefix(s string) bool {
+	n := len(s)
+	if n < 3 || s[n-1] != ':' {
+		return false
+	}
+	c := s[n-2]
+	if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z') {
+		return false
+	}
+	return s[n-3] == ':'
+}
+
+func validatePrintTemplate(template string) error {
+	if strings.TrimSpace(template) == "" {
+		return errors.New("print template is empty")
+	}
+	for _, match := range printPlaceholderRegex.FindAllStringSubmatch(template, -1) {
+		if !printPlaceholders[match[1]] {
+			return fmt.Errorf("unknown print placeholder {%s} (supported: {output}, {title}, {id}, {artist}, {album}, {url})", match[1])
+		}
+	}
+	stripped := printPlaceholderRegex.ReplaceAllString(template, "")
+	if strings.ContainsAny(stripped, "{}") {
+		return errors.New("print template has unbalanced braces")
+	}
+	return nil
+}
+
+func renderPrintTemplate(template string, values map[string]string) string {
+	return printPlaceholderRegex.ReplaceAllStringFunc(template, func(token string) string {
+		name := token[1 : len(token)-1]
+		if value, ok := values[name]; ok {
+			return value
+		}
+		return token
+	})
+}
+
+// appendPrintLine appends one rendered line to path, creating the file if
+// needed.
+func appendPrintLine(path, line string) error {
+	printFileMu.Lock()
+	defer printFileMu.Unlock()
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("print-to-file: %w", err)
+	}
+	if _, err := f.WriteString(line + "\n"); err != nil {
+		f.Close()
+		return fmt.Errorf("print-to-file: %w", err)
+	}
+	return f.Close()
+}
+
+// runPrintToFile writes every --print-to-file spec for a finished download.
+// Failures are downgraded to warnings; the download itself has already
+// succeeded.
+func runPrintToFile(opts Options, metadata ItemMetadata, printer *Printer) {
+	if len(opts.PrintToFile) == 0 || metadata.Status != "ok" || metadata.Output == "" {
+		return
+	}
+	artist := metadata.Artist
+	if artist == "" {
+		artist = metadata.Author
+	}
+	values := map[string]string{
+		"output": metadata.Output,
+		"title":  metadata.Title,
+		"id":     metadata.ID,
+		"artist": artist,
+		"album":  metadata.Album,
+		"url":    metadata.SourceURL,
+	}
+	for _, spec := range opts.PrintToFile {
+		if err := appendPrintLine(spec.Path, renderPrintTemplate(spec.Template, values)); err != nil && printer != nil {
+			printer.Log(LogWarn, fmt.Sprintf("warning: %v", err))
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package downloader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParsePrintSpec(t *testing.T) {
+	spec, err := ParsePrintSpec("{title},{id}:out/list.csv")
+	if err != nil {
+		t.Fatalf("ParsePrintSpec: %v", err)
+	}
+	if spec.Template != "{title},{id}" || spec.Path != "out/list.csv" {
+		t.Fatalf("unexpected spec: %+v", spec)
+	}
+	spec, err = ParsePrintSpec(`{output}:C:\music\all.m3u`)
+	if err != nil {
+		t.Fatalf("ParsePrintSpec drive letter: %v", err)
+	}
+	if spec.Template != "{output}" || spec.Path != `C:\music\all.m3u` {
+		t.Fatalf("unexpected drive-letter spec: %+v", spec)
+	}
+	for _, bad := range []string{"{title}", ":out.txt", "{title}:", "{size}:out.txt", "{title:out.txt"} {
+		if _, err := ParsePrintSpec(bad); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestRunPrintToFileWritesEachSpec(t *testing.T) {
+	dir := t.TempDir()
+	m3u := filepath.Join(dir, "batch.m3u")
+	csv := filepath.Join(dir, "batch.csv")
+	opts := Options{PrintToFile: []PrintSpec{
+		{Template: "{output}", Path: m3u},
+		{Template: "{id},{title}", Path: csv},
+	}}
+
+	items := []ItemMetadata{
+		{ID: "aaa", Title: "First", Output: "First.mp4", Status: "ok"},
+		{ID: "bbb", Title: "Second", Output: "Second.mp4", Status: "ok"},
+		{ID: "ccc", Title: "Broken", Status: "error"},
+	}
+	for _, item := range items {
+		runPrintToFile(opts, item, nil)
+	}
+
+	assertFile := func(path, want string) {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read %s: %v", path, err)
+		}
+		if string(data) != want {
+			t.Fatalf("%s: expected %q, got %q", filepath.Base(path), want, string(data))
+		}
+	}
+	assertFile(m3u, "First.mp4\nSecond.mp4\n")
+	assertFile(csv, "aaa,First\nbbb,Second\n")
+}
@@ -1,12 +1,16 @@
 package downloader
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
 )
 
 type LogLevel int
@@ -46,6 +50,7 @@ func levelLabel(level LogLevel) string {
 
 type Printer struct {
 	quiet           bool
+	silent          bool
 	color           bool
 	columns         int
 	titleWidth      int
@@ -78,12 +83,13 @@ func newPrinter(opts Options, manager *ProgressManager) *Printer {
 		renderer = &progressRenderer{manager: manager}
 	}
 
-	interactive := !opts.Quiet && manager != nil
-	progressEnabled := !opts.Quiet
+	interactive := !opts.Quiet && !opts.Silent && manager != nil
+	progressEnabled := !opts.Quiet && !opts.Silent
 
 	printer := &Printer{
-		quiet:           opts.Quiet,
-		color:           supportsColor(),
+		quiet:           opts.Quiet || opts.Silent,
+		silent:          opts.Silent,
+		color:           colorEnabled(opts.Color),
 		columns:         columns,
 		titleWidth:      titleWidth,
 		logLevel:        parseLogLevel(opts.LogLevel),
@@ -112,9 +118,9 @@ func (p *Printer) Prefix(index, total int, title string) string {
 	return fmt.Sprintf("[%s] %-*s", idx, titleWidth, truncateText(title, titleWidth))
 }
 
-func (p *Printer) progressLine(prefix string, current, total int64, elapsed time.Duration) string {
+func (p *Printer) progressLine(prefix, filename string, current, total int64, elapsed time.Duration) string {
 	if p.layout != "" {
-		return formatProgressLayout(p.layout, prefix, current, total, elapsed)
+		return formatProgressLayout(p.layout, prefix, filename, current, total, elapsed)
 	}
 	speed := ""
 	if elapsed > 0 {
@@ -140,7 +146,7 @@ func (p *Printer) progressLine(prefix string, current, total int64, elapsed time
 }
 
 func (p *Printer) ItemResult(prefix string, result downloadResult, err error) {
-	if err == nil && p.quiet {
+	if p.silent || (err == nil && p.quiet) {
 		return
 	}
 
@@ -151,6 +157,10 @@ func (p *Printer) ItemResult(prefix string, result downloadResult, err error) {
 		detail = fmt.Sprintf("%s (retry)", detail)
 		statusColor = colorYellow
 	}
+	if result.skippedFragments > 0 {
+		detail = fmt.Sprintf("%s (%d fragments skipped)", detail, result.skippedFragments)
+		statusColor = colorYellow
+	}
 
 	if err != nil {
 		statusText = "FAIL"
@@ -176,7 +186,7 @@ func (p *Printer) ItemResult(prefix string, result downloadResult, err error) {
 		level := LogInfo
 		if err != nil {
 			level = LogError
-		} else if result.retried {
+		} else if result.retried || result.skippedFragments > 0 {
 			level = LogWarn
 		}
 		message := fmt.Sprintf("%s %s %s", prefix, statusText, detail)
@@ -240,6 +250,41 @@ func (p *Printer) Summary(total, ok, failed, skipped int, bytes int64) {
 	fmt.Fprintln(os.Stderr, line)
 }
 
+// itemContext derives the context for one item's download so the progress
+// UI can cancel it without stopping the rest. release must be called once the
+// download ends.
+func (p *Printer) itemContext(ctx context.Context, prefix string) (context.Context, func()) {
+	if p == nil || p.manager == nil {
+		return ctx, func() {}
+	}
+	itemCtx, cancel := context.WithCancel(ctx)
+	p.manager.setItemCancel(prefix, cancel)
+	return itemCtx, func() {
+		p.manager.setItemCancel(prefix, nil)
+		cancel()
+	}
+}
+
+// PlaylistStart and PlaylistItemDone drive the overall playlist indicator of
+// renderers that have one; other renderers ignore them.
+func (p *Printer) PlaylistStart(title string, total int) {
+	if p == nil || p.quiet {
+		return
+	}
+	if pp, ok := p.renderer.(playlistProgress); ok {
+		pp.StartPlaylist(title, total)
+	}
+}
+
+func (p *Printer) PlaylistItemDone() {
+	if p == nil || p.quiet {
+		return
+	}
+	if pp, ok := p.renderer.(playlistProgress); ok {
+		pp.ItemComplete()
+	}
+}
+
 func (p *Printer) Log(level LogLevel, message string) {
 	if p.quiet {
 		return
@@ -299,14 +344,16 @@ func truncateText(text string, max int) string {
 	return text[:max-3] + "..."
 }
 
-func formatProgressLayout(layout, prefix string, current, total int64, elapsed time.Duration) string {
+func formatProgressLayout(layout, prefix, filename string, current, total int64, elapsed time.Duration) string {
 	percent := ""
 	eta := ""
 	rate := ""
+	size := ""
 	if elapsed > 0 {
 		rate = humanBytes(int64(float64(current)/elapsed.Seconds())) + "/s"
 	}
 	if total > 0 {
+		size = humanBytes(total)
 		percent = fmt.Sprintf("%.2f%%", float64(current)*100/float64(total))
 		if current > 0 {
 			remaining := time.Duration(float64(elapsed) * (float64(total-current) / float64(current)))
@@ -323,6 +370,8 @@ func formatProgressLayout(layout, prefix string, current, total int64, elapsed t
 	line = strings.ReplaceAll(line, "{rate}", rate)
 	line = strings.ReplaceAll(line, "{eta}", eta)
 	line = strings.ReplaceAll(line, "{bytes}", humanBytes(current))
+	line = strings.ReplaceAll(line, "{filename}", filename)
+	line = strings.ReplaceAll(line, "{size}", size)
 	return strings.TrimSpace(line)
 }
 
@@ -352,6 +401,40 @@ func terminalColumns() int {
 	return 0
 }
 
+// colorEnabled resolves a --color mode. "always" and "never" override terminal
+// detection; anything else (including "auto" and "") falls back to it.
+func colorEnabled(mode string) bool {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "always":
+		return true
+	case "never":
+		return false
+	default:
+		return supportsColor()
+	}
+}
+
+// ValidColorMode reports whether mode is an accepted --color value.
+func ValidColorMode(mode string) bool {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", "auto", "always", "never":
+		return true
+	default:
+		return false
+	}
+}
+
+// ApplyColorMode configures the lipgloss renderer used by the TUI components so
+// that styled output honors --color the same way the plain printer does.
+func ApplyColorMode(mode string) {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "always":
+		lipgloss.SetColorProfile(termenv.ANSI256)
+	case "never":
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
+}
+
 func supportsColor() bool {
 	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
 		return false
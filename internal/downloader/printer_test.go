@@ -0,0 +1,185 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestFormatProgressLayoutFilenameAndSize(t *testing.T) {
+	line := formatProgressLayout("{filename} {percent} of {size}", "[1/1] Song", "Song.m4a", 512, 1024, time.Second)
+	if line != "Song.m4a 50.00% of 1.0KB" {
+		t.Fatalf("unexpected progress line %q", line)
+	}
+}
+
+func TestFormatProgressLayoutUnknownSize(t *testing.T) {
+	line := formatProgressLayout("{filename} [{size}]", "[1/1] Song", "Song.m4a", 512, 0, time.Second)
+	if line != "Song.m4a []" {
+		t.Fatalf("expected empty size for unknown total, got %q", line)
+	}
+}
+
+func TestProgressWriterUsesOutputBaseName(t *testing.T) {
+	pw := newProgressWriter(10, nil, "[1/1] Song", "music/Artist/Song.m4a")
+	if pw.filename != "Song.m4a" {
+		t.Fatalf("expected base filename, got %q", pw.filename)
+	}
+}
+
+func TestProgressWriterEstimatesResumedSegmentSize(t *testing.T) {
+	pw := newProgressWriter(0, nil, "[1/1] Stream", "stream.ts")
+	pw.total.Store(600)
+	pw.EstimateFromSegments(3, 10)
+	if got := pw.size.Load(); got != 2000 {
+		t.Fatalf("expected 3 of 10 segments at 600 bytes to estimate 2000, got %d", got)
+	}
+	printer := newPrinter(Options{Quiet: true}, nil)
+	line := printer.progressLine("[1/1]", "stream.ts", pw.total.Load(), pw.size.Load(), 0)
+	if !strings.Contains(line, " 30.00%") {
+		t.Fatalf("expected a 30%% resume progress line, got %q", line)
+	}
+
+	pw.total.Store(2100)
+	pw.EstimateFromSegments(10, 10)
+	if got := pw.size.Load(); got != 2100 {
+		t.Fatalf("expected the final size to match the bytes written, got %d", got)
+	}
+
+	fresh := newProgressWriter(0, nil, "[1/1] Stream", "stream.ts")
+	fresh.EstimateFromSegments(0, 10)
+	if got := fresh.size.Load(); got != 0 {
+		t.Fatalf("expected no estimate before any segment, got %d", got)
+	}
+}
+
+func TestColorNeverBypassesStyles(t *testing.T) {
+	restore := lipgloss.ColorProfile()
+	defer lipgloss.SetColorProfile(restore)
+
+	ApplyColorMode("always")
+	printer := newPrinter(Options{Quiet: true, Color: "never"}, nil)
+	if got := printer.colorize("OK", colorGreen); got != "OK" {
+		t.Fatalf("expected plain text with color=never, got %q", got)
+	}
+
+	ApplyColorMode("never")
+	styled := lipgloss.NewStyle().Foreground(lipgloss.Color("205")).Bold(true).Render("title")
+	if strings.Contains(styled, "\x1b[") {
+		t.Fatalf("expected lipgloss output without ANSI escapes, got %q", styled)
+	}
+}
+
+func TestColorAlwaysForcesColor(t *testing.T) {
+	printer := newPrinter(Options{Quiet: true, Color: "always"}, nil)
+	if got := printer.colorize("OK", colorGreen); got != colorGreen+"OK"+colorReset {
+		t.Fatalf("expected colorized text with color=always, got %q", got)
+	}
+}
+
+func TestSilentPrinterWritesNothingToStderr(t *testing.T) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe: %v", err)
+	}
+	restore := os.Stderr
+	os.Stderr = writer
+	defer func() { os.Stderr = restore }()
+
+	printer := newPrinter(Options{Silent: true, LogLevel: "debug"}, nil)
+	failure := wrapCategory(CategoryNetwork, errors.New("connection reset"))
+	printer.ItemResult("[1/1] Song", downloadResult{}, failure)
+	printer.ItemSkipped("[1/1] Song", "exists")
+	printer.Log(LogError, "something failed")
+	printer.Summary(1, 0, 1, 0, 0)
+
+	writer.Close()
+	os.Stderr = restore
+	out, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("read stderr: %v", err)
+	}
+	if len(out) != 0 {
+		t.Fatalf("expected no stderr output in silent mode, got %q", out)
+	}
+}
+
+func TestQuietPrinterStillReportsErrors(t *testing.T) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe: %v", err)
+	}
+	restore := os.Stderr
+	os.Stderr = writer
+	defer func() { os.Stderr = restore }()
+
+	printer := newPrinter(Options{Quiet: true}, nil)
+	printer.ItemResult("[1/1] Song", downloadResult{}, errors.New("connection reset"))
+
+	writer.Close()
+	os.Stderr = restore
+	out, _ := io.ReadAll(reader)
+	if !strings.Contains(string(out), "connection reset") {
+		t.Fatalf("expected quiet mode to still print errors, got %q", out)
+	}
+}
+
+// writeSizeRecorder records the size of every Write it receives.
+type writeSizeRecorder struct {
+	sizes []int
+	total int
+}
+
+func (w *writeSizeRecorder) Write(p []byte) (int, error) {
+	w.sizes = append(w.sizes, len(p))
+	w.total += len(p)
+	return len(p), nil
+}
+
+func TestCopyWithContextHonorsBufferSize(t *testing.T) {
+	t.Cleanup(func() { _ = SetCopyBufferSize(0) })
+	data := bytes.Repeat([]byte("abcdefgh"), 128<<10) // 1 MiB
+
+	for _, size := range []int64{MinCopyBufferSize, 256 << 10} {
+		if err := SetCopyBufferSize(size); err != nil {
+			t.Fatalf("SetCopyBufferSize(%d): %v", size, err)
+		}
+		var dst writeSizeRecorder
+		start := time.Now()
+		n, err := copyWithContext(context.Background(), &dst, bytes.NewReader(data))
+		elapsed := time.Since(start)
+		if err != nil || n != int64(len(data)) || dst.total != len(data) {
+			t.Fatalf("copy with %d-byte buffer: n=%d total=%d err=%v", size, n, dst.total, err)
+		}
+		// bytes.Reader would otherwise hand over everything in one Write.
+		wantWrites := len(data) / int(size)
+		if len(dst.sizes) != wantWrites {
+			t.Fatalf("copy with %d-byte buffer made %d writes, want %d", size, len(dst.sizes), wantWrites)
+		}
+		for _, got := range dst.sizes {
+			if got != int(size) {
+				t.Fatalf("copy with %d-byte buffer wrote a %d-byte chunk", size, got)
+			}
+		}
+		t.Logf("buffer %s: %d writes in %s", humanBytes(size), len(dst.sizes), elapsed)
+	}
+}
+
+func TestSetCopyBufferSizeRejectsOutOfRange(t *testing.T) {
+	t.Cleanup(func() { _ = SetCopyBufferSize(0) })
+	for _, size := range []int64{1, MinCopyBufferSize - 1, MaxCopyBufferSize + 1} {
+		if err := SetCopyBufferSize(size); err == nil {
+			t.Fatalf("expected buffer size %d to be rejected", size)
+		}
+	}
+	if err := SetCopyBufferSize(0); err != nil {
+		t.Fatalf("expected 0 to restore the default, got %v", err)
+	}
+}
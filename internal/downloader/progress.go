@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"sync/atomic"
 	"time"
 )
@@ -16,12 +17,15 @@ type progressWriter struct {
 	lastUpdate atomic.Int64 // Last update time in Unix nanoseconds
 	finished   atomic.Bool
 	prefix     string
+	filename   string
 	printer    *Printer
 	taskID     string
 	renderer   ProgressRenderer
 }
 
-func newProgressWriter(size int64, printer *Printer, prefix string) *progressWriter {
+// newProgressWriter creates a progress tracker for a single download. outputPath
+// is the resolved destination and feeds the {filename} layout token.
+func newProgressWriter(size int64, printer *Printer, prefix, outputPath string) *progressWriter {
 	taskID := ""
 	var renderer ProgressRenderer
 	if printer != nil && printer.renderer != nil {
@@ -29,8 +33,13 @@ func newProgressWriter(size int64, printer *Printer, prefix string) *progressWri
 		taskID = renderer.Register(prefix, size)
 	}
 	now := time.Now()
+	filename := ""
+	if outputPath != "" {
+		filename = filepath.Base(outputPath)
+	}
 	pw := &progressWriter{
 		prefix:   prefix,
+		filename: filename,
 		printer:  printer,
 		taskID:   taskID,
 		renderer: renderer,
@@ -72,7 +81,7 @@ func (p *progressWriter) print() {
 	}
 	startNano := p.start.Load()
 	elapsed := time.Duration(time.Now().UnixNano() - startNano)
-	line := p.printer.progressLine(p.prefix, total, size, elapsed)
+	line := p.printer.progressLine(p.prefix, p.filename, total, size, elapsed)
 	p.printer.writeProgressLine(line)
 }
 
@@ -85,7 +94,7 @@ func (p *progressWriter) Finish() {
 	if !p.printer.progressEnabled {
 		startNano := p.start.Load()
 		elapsed := time.Duration(time.Now().UnixNano() - startNano)
-		line := p.printer.progressLine(p.prefix, total, size, elapsed)
+		line := p.printer.progressLine(p.prefix, p.filename, total, size, elapsed)
 		fmt.Fprintf(os.Stderr, "%s\n", line)
 		return
 	}
@@ -128,6 +137,25 @@ func (p *progressWriter) Reset(size int64) {
 	}
 }
 
+// EstimateFromSegments sets the expected size of a segmented download whose
+// byte total is unknown by extrapolating the bytes written so far over the
+// done of count segments. A resumed download then shows a percentage from
+// its first update instead of an open-ended byte count.
+func (p *progressWriter) EstimateFromSegments(done, count int) {
+	if p == nil || done <= 0 || count <= 0 {
+		return
+	}
+	total := p.total.Load()
+	if total <= 0 {
+		return
+	}
+	if done >= count {
+		p.size.Store(total)
+		return
+	}
+	p.size.Store(total * int64(count) / int64(done))
+}
+
 func (p *progressWriter) SetCurrent(current int64) {
 	if p == nil {
 		return
@@ -163,7 +191,37 @@ func (r *contextReader) Read(p []byte) (int, error) {
 
 func copyWithContext(ctx context.Context, dst io.Writer, src io.Reader) (int64, error) {
 	reader := &contextReader{ctx: ctx, r: src}
-	return io.Copy(dst, reader)
+	return copyBuffer(dst, reader)
+}
+
+// Bounds for --buffer-size.
+const (
+	MinCopyBufferSize = 4 << 10
+	MaxCopyBufferSize = 16 << 20
+)
+
+// copyBufferSize is the --buffer-size in bytes; 0 leaves buffering to io.Copy.
+var copyBufferSize atomic.Int64
+
+// SetCopyBufferSize sets the buffer used to copy downloaded data to disk, for
+// --buffer-size. Zero restores the default.
+func SetCopyBufferSize(size int64) error {
+	if size != 0 && (size < MinCopyBufferSize || size > MaxCopyBufferSize) {
+		return fmt.Errorf("buffer size %s is out of range (%s to %s)", humanBytes(size), humanBytes(MinCopyBufferSize), humanBytes(MaxCopyBufferSize))
+	}
+	copyBufferSize.Store(size)
+	return nil
+}
+
+// copyBuffer copies src to dst like io.Copy, through a --buffer-size buffer
+// when one is set. The reader and writer are wrapped so their
+// ReaderFrom/WriterTo fast paths, which pick their own buffer, are not used.
+func copyBuffer(dst io.Writer, src io.Reader) (int64, error) {
+	size := copyBufferSize.Load()
+	if size == 0 {
+		return io.Copy(dst, src)
+	}
+	return io.CopyBuffer(struct{ io.Writer }{dst}, struct{ io.Reader }{src}, make([]byte, size))
 }
 
 // formatDurationShort formats a duration in a compact human-readable form
@@ -25,6 +25,9 @@ type ProgressManager struct {
 	program *tea.Program
 	started bool
 	done    chan struct{}
+	// itemCancels maps a download's progress label to the function that
+	// cancels that download alone.
+	itemCancels map[string]context.CancelFunc
 }
 
 // NewProgressManager creates a new progress manager.
@@ -121,6 +124,27 @@ func (pm *ProgressManager) PromptDuplicate(path string) (promptChoice, error) {
 	}
 }
 
+// setItemCancel records (or, with a nil cancel, forgets) how to cancel the
+// download shown under label.
+func (pm *ProgressManager) setItemCancel(label string, cancel context.CancelFunc) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	if cancel == nil {
+		delete(pm.itemCancels, label)
+		return
+	}
+	if pm.itemCancels == nil {
+		pm.itemCancels = make(map[string]context.CancelFunc)
+	}
+	pm.itemCancels[label] = cancel
+}
+
+func (pm *ProgressManager) itemCancel(label string) context.CancelFunc {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	return pm.itemCancels[label]
+}
+
 func (pm *ProgressManager) send(msg tea.Msg) {
 	if pm == nil {
 		return
@@ -151,10 +175,11 @@ func (pr *progressRenderer) Register(prefix string, size int64) string {
 	}
 	id := fmt.Sprintf("%s@%d", prefix, time.Now().UnixNano())
 	pr.manager.send(registerMsg{
-		id:    id,
-		label: prefix,
-		total: size,
-		start: time.Now(),
+		id:     id,
+		label:  prefix,
+		total:  size,
+		start:  time.Now(),
+		cancel: pr.manager.itemCancel(prefix),
 	})
 	return id
 }
@@ -180,11 +205,33 @@ func (pr *progressRenderer) Log(level LogLevel, msg string) {
 	pr.manager.Log(level, msg)
 }
 
+// playlistProgress is implemented by renderers that show overall playlist
+// progress next to the per-item bars.
+type playlistProgress interface {
+	StartPlaylist(title string, total int)
+	ItemComplete()
+}
+
+func (pr *progressRenderer) StartPlaylist(title string, total int) {
+	if pr == nil || pr.manager == nil {
+		return
+	}
+	pr.manager.send(playlistStartMsg{title: title, total: total})
+}
+
+func (pr *progressRenderer) ItemComplete() {
+	if pr == nil || pr.manager == nil {
+		return
+	}
+	pr.manager.send(itemCompleteMsg{})
+}
+
 type registerMsg struct {
-	id    string
-	label string
-	total int64
-	start time.Time
+	id     string
+	label  string
+	total  int64
+	start  time.Time
+	cancel context.CancelFunc
 }
 
 type updateMsg struct {
@@ -204,6 +251,15 @@ type logMsg struct {
 
 type stopMsg struct{}
 
+// playlistStartMsg resets the overall indicator for a playlist of total items.
+type playlistStartMsg struct {
+	title string
+	total int
+}
+
+// itemCompleteMsg counts one playlist item as done, whatever its outcome.
+type itemCompleteMsg struct{}
+
 type promptMsg struct {
 	path string
 	resp chan promptChoice
@@ -299,8 +355,14 @@ type progressModel struct {
 	promptResp   chan promptChoice
 	promptQueue  []promptMsg
 	promptIndex  int
+	selected     int
 	vp           viewport.Model
 	vpReady      bool
+
+	playlistTitle string
+	playlistTotal int
+	playlistDone  int
+	playlistBar   progressbar.Model
 }
 
 type progressTask struct {
@@ -314,6 +376,9 @@ type progressTask struct {
 	bar      progressbar.Model
 	spin     spinner.Model
 	done     bool
+	// cancel stops this download alone; nil when it cannot be cancelled.
+	cancel    context.CancelFunc
+	cancelled bool
 }
 
 func newProgressModel() *progressModel {
@@ -349,6 +414,31 @@ func truncateLine(text string, width int) string {
 	return text[:width-3] + "..."
 }
 
+// cancelSelected cancels the selected download, leaving the others running.
+func (m *progressModel) cancelSelected() {
+	if m.selected < 0 || m.selected >= len(m.order) {
+		return
+	}
+	task, ok := m.tasks[m.order[m.selected]]
+	if !ok || task.done || task.cancelled || task.cancel == nil {
+		return
+	}
+	task.cancel()
+	task.cancelled = true
+	m.log = logWarnStyle.Render(truncateLine("cancelled: "+task.label, m.width))
+}
+
+// resizeViewport fits the task viewport below the log line, the playlist
+// indicator when one is shown, and the downloads header.
+func (m *progressModel) resizeViewport() {
+	headerHeight := 2
+	if m.playlistTotal > 0 {
+		headerHeight += 2
+	}
+	borderHeight := 2
+	m.vp.Height = m.height - headerHeight - borderHeight
+}
+
 func (m *progressModel) Init() tea.Cmd {
 	return nil
 }
@@ -358,15 +448,14 @@ func (m *progressModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
-		headerHeight := 2
-		borderHeight := 2
 		m.vp.Width = msg.Width - 2
-		m.vp.Height = msg.Height - headerHeight - borderHeight
+		m.resizeViewport()
 		m.vp, _ = m.vp.Update(msg)
 		m.vpReady = true
 		for _, task := range m.tasks {
 			task.bar.Width = barWidth(m.width)
 		}
+		m.playlistBar.Width = barWidth(m.width)
 	case promptMsg:
 		if m.promptActive {
 			m.promptQueue = append(m.promptQueue, msg)
@@ -397,6 +486,7 @@ func (m *progressModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			started: msg.start,
 			bar:     bar,
 			spin:    spin,
+			cancel:  msg.cancel,
 		}
 		m.tasks[msg.id] = task
 		return m, tea.Batch(task.bar.SetPercent(0), task.spin.Tick)
@@ -418,6 +508,23 @@ func (m *progressModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			task.finished = time.Now()
 			return m, task.bar.SetPercent(1)
 		}
+	case playlistStartMsg:
+		m.playlistTitle = msg.title
+		m.playlistTotal = msg.total
+		m.playlistDone = 0
+		m.resizeViewport()
+		m.playlistBar = progressbar.New(
+			progressbar.WithGradient("#FFE66D", "#00F5D4"),
+			progressbar.WithWidth(barWidth(m.width)),
+			progressbar.WithoutPercentage(),
+		)
+		return m, m.playlistBar.SetPercent(0)
+	case itemCompleteMsg:
+		if m.playlistTotal <= 0 || m.playlistDone >= m.playlistTotal {
+			return m, nil
+		}
+		m.playlistDone++
+		return m, m.playlistBar.SetPercent(float64(m.playlistDone) / float64(m.playlistTotal))
 	case logMsg:
 		// Update log message (single line)
 		var style lipgloss.Style
@@ -449,6 +556,16 @@ func (m *progressModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.vp.GotoTop()
 			case "end", "G":
 				m.vp.GotoBottom()
+			case "tab":
+				if len(m.order) > 0 {
+					m.selected = (m.selected + 1) % len(m.order)
+				}
+			case "shift+tab":
+				if len(m.order) > 0 {
+					m.selected = (m.selected - 1 + len(m.order)) % len(m.order)
+				}
+			case "x":
+				m.cancelSelected()
 			}
 			return m, nil
 		}
@@ -521,6 +638,15 @@ func (m *progressModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 	case progressbar.FrameMsg:
 		cmds := make([]tea.Cmd, 0, len(m.tasks))
+		if m.playlistTotal > 0 {
+			model, cmd := m.playlistBar.Update(msg)
+			if updated, ok := model.(progressbar.Model); ok {
+				m.playlistBar = updated
+			}
+			if cmd != nil {
+				cmds = append(cmds, cmd)
+			}
+		}
 		for _, task := range m.tasks {
 			if task == nil {
 				continue
@@ -613,10 +739,19 @@ func (m *progressModel) View() string {
 		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, box)
 	}
 
+	if m.playlistTotal > 0 {
+		b.WriteString(labelStyle.Render(truncateLine(m.playlistTitle, m.width/2)))
+		b.WriteString(" ")
+		b.WriteString(percentStyle.Render(fmt.Sprintf("%d/%d items complete", m.playlistDone, m.playlistTotal)))
+		b.WriteString("\n")
+		b.WriteString(progressBarStyle.Render(m.playlistBar.View()))
+		b.WriteString("\n")
+	}
+
 	// Render downloads through viewport for scrolling
 	if len(m.order) > 0 {
 		var taskContent strings.Builder
-		for _, id := range m.order {
+		for i, id := range m.order {
 			task, ok := m.tasks[id]
 			if !ok {
 				continue
@@ -638,9 +773,12 @@ func (m *progressModel) View() string {
 
 			percentText := percentStyle.Render(fmt.Sprintf("%5.1f%%", task.percent*100))
 			labelText := labelStyle.Render(task.label)
+			if i == m.selected {
+				labelText = promptSelectedStyle.Render(task.label)
+			}
 
 			spinText := ""
-			if !task.done {
+			if !task.done && !task.cancelled {
 				spinText = task.spin.View()
 				if spinText != "" {
 					spinText = spinnerStyle.Render(spinText)
@@ -660,7 +798,9 @@ func (m *progressModel) View() string {
 			taskContent.WriteString(fmt.Sprintf("        %s\n", etaStyle.Render(bytesLine)))
 
 			var etaText string
-			if task.done {
+			if task.cancelled && !task.done {
+				etaText = logWarnStyle.Render("cancelled")
+			} else if task.done {
 				etaText = etaStyle.Render(fmt.Sprintf("completed in %s", formatDurationShort(elapsed)))
 			} else {
 				etaText = etaStyle.Render(fmt.Sprintf("elapsed %s · eta %s",
@@ -673,7 +813,7 @@ func (m *progressModel) View() string {
 		m.vp.SetContent(taskContent.String())
 		b.WriteString(titleStyle.Render(" Downloads"))
 		b.WriteString(" ")
-		b.WriteString(etaStyle.Render(fmt.Sprintf("(↑/↓ scroll, %d items)", len(m.order))))
+		b.WriteString(etaStyle.Render(fmt.Sprintf("(↑/↓ scroll, tab select, x cancel, %d items)", len(m.order))))
 		b.WriteString("\n")
 		b.WriteString(m.vp.View())
 	}
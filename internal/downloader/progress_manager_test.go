@@ -0,0 +1,95 @@
+package downloader
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestProgressModelCountsCompletedPlaylistItems(t *testing.T) {
+	m := newProgressModel()
+	if strings.Contains(m.View(), "items complete") {
+		t.Fatal("expected no playlist indicator before a playlist starts")
+	}
+
+	m.Update(playlistStartMsg{title: "Road Trip", total: 3})
+	m.Update(itemCompleteMsg{})
+	m.Update(itemCompleteMsg{})
+	if m.playlistDone != 2 {
+		t.Fatalf("playlistDone = %d, want 2", m.playlistDone)
+	}
+	view := m.View()
+	if !strings.Contains(view, "Road Trip") || !strings.Contains(view, "2/3 items complete") {
+		t.Fatalf("expected overall playlist progress in view, got:\n%s", view)
+	}
+
+	// Extra completions never push the count past the total.
+	m.Update(itemCompleteMsg{})
+	m.Update(itemCompleteMsg{})
+	if m.playlistDone != 3 {
+		t.Fatalf("playlistDone = %d, want 3", m.playlistDone)
+	}
+
+	// A new playlist starts counting from zero.
+	m.Update(playlistStartMsg{title: "Second", total: 5})
+	if m.playlistDone != 0 || !strings.Contains(m.View(), "0/5 items complete") {
+		t.Fatalf("expected the count to reset for a new playlist, got %d", m.playlistDone)
+	}
+}
+
+func TestProgressModelCancelsSelectedTask(t *testing.T) {
+	ctxA, cancelA := context.WithCancel(context.Background())
+	defer cancelA()
+	ctxB, cancelB := context.WithCancel(context.Background())
+	defer cancelB()
+
+	m := newProgressModel()
+	m.Update(registerMsg{id: "a", label: "[1/2] first", total: 100, start: time.Now(), cancel: cancelA})
+	m.Update(registerMsg{id: "b", label: "[2/2] second", total: 100, start: time.Now(), cancel: cancelB})
+
+	m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	if m.selected != 1 {
+		t.Fatalf("selected = %d after tab, want 1", m.selected)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+
+	if ctxB.Err() == nil {
+		t.Fatal("expected the selected task's context to be cancelled")
+	}
+	if ctxA.Err() != nil {
+		t.Fatal("expected the other task to keep running")
+	}
+	if !m.tasks["b"].cancelled || !strings.Contains(m.View(), "cancelled") {
+		t.Fatalf("expected the task to be shown as cancelled, got:\n%s", m.View())
+	}
+
+	// Finished tasks are not cancelled after the fact.
+	m.Update(finishMsg{id: "a"})
+	m.Update(tea.KeyMsg{Type: tea.KeyShiftTab})
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	if ctxA.Err() != nil || m.tasks["a"].cancelled {
+		t.Fatal("expected a finished task to be left alone")
+	}
+}
+
+func TestPrinterItemContextRegistersCancel(t *testing.T) {
+	manager := NewProgressManager(Options{})
+	printer := newPrinter(Options{}, manager)
+
+	ctx, release := printer.itemContext(context.Background(), "[1/1] video")
+	cancel := manager.itemCancel("[1/1] video")
+	if cancel == nil {
+		t.Fatal("expected the item's cancel func to be registered under its label")
+	}
+	cancel()
+	if ctx.Err() == nil {
+		t.Fatal("expected the registered cancel func to cancel the item context")
+	}
+	release()
+	if manager.itemCancel("[1/1] video") != nil {
+		t.Fatal("expected release to forget the cancel func")
+	}
+}
@@ -0,0 +1,80 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/lvcoi/ytdl-lib/v2"
+)
+
+// printQuickQuery handles --get-filename and --get-url: it selects the format
+// a download would use and prints the resolved output path and/or the stream
+// URL, one per line, without reading the stream.
+func printQuickQuery(ctx context.Context, w io.Writer, client YouTubeClient, video *youtube.Video, opts Options, ctxInfo outputContext) error {
+	format, err := selectFormat(video, opts)
+	if errors.Is(err, errBelowMinFileSize) {
+		return wrapCategory(CategoryUnsupported, fmt.Errorf("no format at or above min-filesize"))
+	}
+	if err != nil {
+		return err
+	}
+	if opts.GetFilename {
+		dir, err := routedOutputDir(opts, format)
+		if err != nil {
+			return wrapCategory(CategoryFilesystem, err)
+		}
+		path, err := resolveOutputPath(opts.OutputTemplate, video, format, ctxInfo, dir)
+		if err != nil {
+			return wrapCategory(CategoryFilesystem, err)
+		}
+		if opts.AudioOnly && opts.AudioFormat != "" && ffmpegAvailable() {
+			path = withAudioExtension(path, opts.AudioFormat)
+		}
+		fmt.Fprintln(w, path)
+	}
+	if opts.GetURL {
+		streamURL, err := client.GetStreamURLContext(ctx, video, format)
+		if err != nil {
+			return wrapFetchError(err, "resolving stream URL")
+		}
+		fmt.Fprintln(w, streamURL)
+	}
+	return nil
+}
+
+// queryPlaylist runs --get-filename / --get-url for every playlist entry, with
+// the playlist placeholders filled in as a real download would.
+func queryPlaylist(ctx context.Context, w io.Writer, playlist *youtube.Playlist, opts Options, cleanArtist bool) error {
+	client := newClientForType("android", opts)
+	total := len(playlist.Videos)
+	for i, entry := range playlist.Videos {
+		if entry == nil || entry.ID == "" {
+			continue
+		}
+		video, err := client.VideoFromPlaylistEntryContext(ctx, entry)
+		if err != nil {
+			return wrapFetchError(err, "fetching video metadata")
+		}
+		title := entry.Title
+		if applyPreferredLanguage(ctx, client, video, opts.PreferLang, nil) {
+			title = video.Title
+		}
+		ctxInfo := outputContext{
+			Playlist:      playlist,
+			Index:         i + 1,
+			Total:         total,
+			EntryTitle:    title,
+			EntryAuthor:   entry.Author,
+			CleanArtist:   cleanArtist,
+			TrimFilenames: opts.TrimFilenames,
+			AutoNumber:    nextAutoNumber(opts),
+			FlatPlaylist:  opts.FlatPlaylistLayout,
+		}
+		if err := printQuickQuery(ctx, w, client, video, opts, ctxInfo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	youtube "github.com/lvcoi/ytdl-lib/v2"
+)
+
+func quickQueryVideo() *youtube.Video {
+	return &youtube.Video{
+		ID:     "vid123",
+		Title:  "Quick Query",
+		Author: "Channel",
+		Formats: []youtube.Format{
+			{ItagNo: 18, MimeType: "video/mp4", QualityLabel: "360p", Width: 640, Height: 360, AudioChannels: 2, URL: "https://example.com/18"},
+			{ItagNo: 140, MimeType: "audio/mp4", Bitrate: 128000, AudioChannels: 2, URL: "https://example.com/140"},
+		},
+	}
+}
+
+func TestPrintQuickQueryFilenameMatchesTemplate(t *testing.T) {
+	baseDir := t.TempDir()
+	video := quickQueryVideo()
+	opts := Options{OutputTemplate: "{artist}/{title} [{id}].{ext}", OutputDir: baseDir, GetFilename: true}
+
+	var out bytes.Buffer
+	if err := printQuickQuery(context.Background(), &out, &mockYouTubeClient{}, video, opts, outputContext{}); err != nil {
+		t.Fatalf("printQuickQuery: %v", err)
+	}
+
+	format, err := selectFormat(video, opts)
+	if err != nil {
+		t.Fatalf("selectFormat: %v", err)
+	}
+	want, err := resolveOutputPath(opts.OutputTemplate, video, format, outputContext{}, baseDir)
+	if err != nil {
+		t.Fatalf("resolveOutputPath: %v", err)
+	}
+	if got := strings.TrimSpace(out.String()); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	if filepath.Base(want) != "Quick Query [vid123].mp4" {
+		t.Fatalf("unexpected resolved filename %q", want)
+	}
+}
+
+func TestPrintQuickQueryFilenameAndURL(t *testing.T) {
+	// The .mp3 rename only applies when ffmpeg can do the encoding.
+	ffmpegDir, _ := useFakeFFmpeg(t)
+	if err := SetFFmpegLocation(ffmpegDir); err != nil {
+		t.Fatalf("SetFFmpegLocation: %v", err)
+	}
+	baseDir := t.TempDir()
+	opts := Options{OutputTemplate: "{title}.{ext}", OutputDir: baseDir, AudioOnly: true, AudioFormat: "mp3", GetFilename: true, GetURL: true}
+
+	var out bytes.Buffer
+	if err := printQuickQuery(context.Background(), &out, &mockYouTubeClient{}, quickQueryVideo(), opts, outputContext{}); err != nil {
+		t.Fatalf("printQuickQuery: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected filename and URL lines, got %q", out.String())
+	}
+	if want := filepath.Join(baseDir, "Quick Query.mp3"); lines[0] != want {
+		t.Fatalf("expected filename %q, got %q", want, lines[0])
+	}
+	if lines[1] != "https://example.com/140" {
+		t.Fatalf("expected audio stream URL, got %q", lines[1])
+	}
+}
@@ -0,0 +1,223 @@
+package downloader
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rateLimiter caps the combined rate of every response body read through it,
+// across all jobs and segment workers. It is a token bucket holding up to one
+// second of traffic. The rate comes from the first schedule window covering
+// the local time of day, or the base limit outside them; 0 means unlimited.
+type rateLimiter struct {
+	mu       sync.Mutex
+	limit    int64
+	schedule []rateWindow
+	now      func() time.Time
+	tokens   float64
+	last     time.Time
+}
+
+// rateWindow is one --rate-limit-schedule entry. start and end are minutes
+// past midnight; a window with end before start wraps past midnight.
+type rateWindow struct {
+	start, end int
+	limit      int64
+}
+
+// downloadLimiter is the process-wide limiter behind --rate-limit and
+// --rate-limit-schedule.
+var downloadLimiter = &rateLimiter{now: time.Now}
+
+// SetRateLimit caps the total download rate in bytes per second, for
+// --rate-limit. 0 removes the cap. It must be called before any downloads
+// start.
+func SetRateLimit(bytesPerSecond int64) {
+	downloadLimiter.mu.Lock()
+	defer downloadLimiter.mu.Unlock()
+	downloadLimiter.limit = bytesPerSecond
+}
+
+// SetRateLimitSchedule sets time-of-day rate limits, for
+// --rate-limit-schedule, e.g. "08:00-18:00=1M,18:00-23:00=4M,23:00-08:00=0".
+// Outside every window the --rate-limit value applies. It must be called
+// before any downloads start.
+func SetRateLimitSchedule(value string) error {
+	schedule, err := parseRateSchedule(value)
+	if err != nil {
+		return err
+	}
+	downloadLimiter.mu.Lock()
+	defer downloadLimiter.mu.Unlock()
+	downloadLimiter.schedule = schedule
+	return nil
+}
+
+func parseRateSchedule(value string) ([]rateWindow, error) {
+	var schedule []rateWindow
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		span, rate, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("window %q: expected HH:MM-HH:MM=RATE", part)
+		}
+		from, to, ok := strings.Cut(span, "-")
+		if !ok {
+			return nil, fmt.Errorf("window %q: expected HH:MM-HH:MM=RATE", part)
+		}
+		start, err := parseClock(from)
+		if err != nil {
+			return nil, fmt.Errorf("window %q: %w", part, err)
+		}
+		end, err := parseClock(to)
+		if err != nil {
+			return nil, fmt.Errorf("window %q: %w", part, err)
+		}
+		if start == end {
+			return nil, fmt.Errorf("window %q: start and end are the same", part)
+		}
+		limit, err := parseRate(rate)
+		if err != nil {
+			return nil, fmt.Errorf("window %q: %w", part, err)
+		}
+		schedule = append(schedule, rateWindow{start: start, end: end, limit: limit})
+	}
+	if len(schedule) == 0 {
+		return nil, fmt.Errorf("schedule has no windows")
+	}
+	return schedule, nil
+}
+
+// parseClock parses "HH:MM" into minutes past midnight; "24:00" is midnight
+// at the end of the day.
+func parseClock(value string) (int, error) {
+	hours, minutes, ok := strings.Cut(strings.TrimSpace(value), ":")
+	h, herr := strconv.Atoi(hours)
+	m, merr := strconv.Atoi(minutes)
+	if !ok || herr != nil || merr != nil || h < 0 || h > 24 || m < 0 || m > 59 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("invalid time %q (want HH:MM)", value)
+	}
+	return (h*60 + m) % (24 * 60), nil
+}
+
+// parseRate parses a rate like "500K" or "2M" (bytes per second); "0" and
+// "unlimited" mean no limit.
+func parseRate(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	if strings.EqualFold(value, "unlimited") {
+		return 0, nil
+	}
+	return ParseByteSize(value)
+}
+
+func (w rateWindow) contains(minute int) bool {
+	if w.start < w.end {
+		return minute >= w.start && minute < w.end
+	}
+	return minute >= w.start || minute < w.end
+}
+
+// limitAt returns the rate in effect at t. The caller holds l.mu.
+func (l *rateLimiter) limitAt(t time.Time) int64 {
+	minute := t.Hour()*60 + t.Minute()
+	for _, window := range l.schedule {
+		if window.contains(minute) {
+			return window.limit
+		}
+	}
+	return l.limit
+}
+
+func (l *rateLimiter) enabled() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit > 0 || len(l.schedule) > 0
+}
+
+// reserve takes n bytes from the bucket and returns how long the reader has
+// to wait for them.
+func (l *rateLimiter) reserve(n int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	limit := l.limitAt(now)
+	if limit <= 0 {
+		l.last = time.Time{}
+		return 0
+	}
+	rate := float64(limit)
+	if l.last.IsZero() {
+		l.tokens = rate
+	} else {
+		l.tokens += now.Sub(l.last).Seconds() * rate
+	}
+	if l.tokens > rate {
+		l.tokens = rate
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / rate * float64(time.Second))
+}
+
+func (l *rateLimiter) wait(ctx context.Context, n int) error {
+	delay := l.reserve(n)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// rateLimitedTransport slows response bodies from base down to the limiter's
+// rate. Without a limit configured responses pass through untouched.
+type rateLimitedTransport struct {
+	base    http.RoundTripper
+	limiter *rateLimiter
+}
+
+func newRateLimitedTransport(base http.RoundTripper, limiter *rateLimiter) http.RoundTripper {
+	return &rateLimitedTransport{base: base, limiter: limiter}
+}
+
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || !t.limiter.enabled() {
+		return resp, err
+	}
+	resp.Body = &rateLimitedBody{ReadCloser: resp.Body, ctx: req.Context(), limiter: t.limiter}
+	return resp, nil
+}
+
+type rateLimitedBody struct {
+	io.ReadCloser
+	ctx     context.Context
+	limiter *rateLimiter
+}
+
+func (b *rateLimitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		if waitErr := b.limiter.wait(b.ctx, n); waitErr != nil && err == nil {
+			err = waitErr
+		}
+	}
+	return n, err
+}
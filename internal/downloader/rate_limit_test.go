@@ -0,0 +1,132 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func clockAt(hour, minute int) time.Time {
+	return time.Date(2024, 3, 1, hour, minute, 0, 0, time.Local)
+}
+
+func TestParseRateSchedule(t *testing.T) {
+	schedule, err := parseRateSchedule("08:00-18:00=1M, 23:30-07:00=unlimited,18:00-24:00=500K")
+	if err != nil {
+		t.Fatalf("parseRateSchedule: %v", err)
+	}
+	want := []rateWindow{{480, 1080, 1 << 20}, {1410, 420, 0}, {1080, 0, 500 << 10}}
+	if len(schedule) != len(want) {
+		t.Fatalf("got %d windows, want %d", len(schedule), len(want))
+	}
+	for i := range want {
+		if schedule[i] != want[i] {
+			t.Errorf("window %d = %+v, want %+v", i, schedule[i], want[i])
+		}
+	}
+
+	for value, msg := range map[string]string{
+		"":                  "no windows",
+		"08:00-18:00":       "expected HH:MM-HH:MM=RATE",
+		"0800-1800=1M":      "invalid time",
+		"08:00-25:00=1M":    "invalid time",
+		"08:00-08:00=1M":    "same",
+		"08:00-18:00=fast":  "invalid",
+		"08:00-18:00=-1M":   "invalid size",
+		"08:00-18:00=1M,x=": "expected HH:MM-HH:MM=RATE",
+	} {
+		if _, err := parseRateSchedule(value); err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("parseRateSchedule(%q) error %v, want it to mention %q", value, err, msg)
+		}
+	}
+}
+
+func TestRateLimiterFollowsSchedule(t *testing.T) {
+	schedule, err := parseRateSchedule("09:00-17:00=100,23:00-06:00=unlimited")
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := &fakeClock{t: clockAt(12, 0)}
+	limiter := &rateLimiter{limit: 1000, schedule: schedule, now: clock.Now}
+
+	for _, tt := range []struct {
+		when time.Time
+		want int64
+	}{
+		{clockAt(9, 0), 100},
+		{clockAt(16, 59), 100},
+		{clockAt(17, 0), 1000},
+		{clockAt(23, 15), 0},
+		{clockAt(3, 0), 0},
+		{clockAt(6, 0), 1000},
+	} {
+		if got := limiter.limitAt(tt.when); got != tt.want {
+			t.Errorf("limit at %s = %d, want %d", tt.when.Format("15:04"), got, tt.want)
+		}
+	}
+
+	// Daytime: the bucket holds one second at 100 B/s.
+	if d := limiter.reserve(100); d != 0 {
+		t.Fatalf("expected the first second's bytes to pass, waited %s", d)
+	}
+	if d := limiter.reserve(100); d != time.Second {
+		t.Fatalf("expected 100 more bytes at 100 B/s to wait 1s, waited %s", d)
+	}
+
+	// Evening: the base limit applies and the refilled bucket caps at 1000.
+	clock.t = clockAt(17, 30)
+	if d := limiter.reserve(500); d != 0 {
+		t.Fatalf("expected 500 bytes at 1000 B/s to pass, waited %s", d)
+	}
+	if d := limiter.reserve(1000); d != 500*time.Millisecond {
+		t.Fatalf("expected a 500ms wait at 1000 B/s, waited %s", d)
+	}
+
+	// Night: unlimited.
+	clock.t = clockAt(23, 30)
+	if d := limiter.reserve(1 << 30); d != 0 {
+		t.Fatalf("expected no wait in an unlimited window, waited %s", d)
+	}
+}
+
+func TestRateLimitedTransportSlowsBodies(t *testing.T) {
+	payload := bytes.Repeat([]byte("r"), 30000)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(payload)
+	}))
+	defer srv.Close()
+
+	limiter := &rateLimiter{limit: 20000, now: time.Now}
+	client := &http.Client{Transport: newRateLimitedTransport(http.DefaultTransport, limiter)}
+	start := time.Now()
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil || !bytes.Equal(data, payload) {
+		t.Fatalf("read %d bytes, err %v", len(data), err)
+	}
+	// One second is in the bucket; the remaining 10000 bytes take ~500ms.
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Fatalf("expected the body to be throttled, took %s", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	cancel()
+	if _, err := io.ReadAll(resp.Body); err == nil {
+		t.Fatal("expected a cancelled request to stop waiting for the limiter")
+	}
+}
@@ -0,0 +1,61 @@
+package downloader
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+
+	youtube "github.com/lvcoi/ytdl-lib/v2"
+)
+
+// Stream and segment URLs handed out by YouTube expire a few hours after the
+// metadata that carried them was fetched, after which they answer 403. The
+// helpers below re-fetch the metadata so a long or resumed download can carry
+// on with fresh URLs for the same stream.
+
+// refreshFormat re-fetches videoID and returns the fresh video along with its
+// format for itag.
+func refreshFormat(ctx context.Context, client YouTubeClient, videoID string, itag int) (*youtube.Video, *youtube.Format, error) {
+	video, err := client.GetVideoContext(ctx, videoID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("refetching metadata: %w", err)
+	}
+	for i := range video.Formats {
+		if video.Formats[i].ItagNo == itag {
+			return video, &video.Formats[i], nil
+		}
+	}
+	return nil, nil, fmt.Errorf("itag %d no longer offered", itag)
+}
+
+// refreshDASHRepresentation re-fetches videoID and its DASH manifest and
+// returns the representation with the same ID as current (the itag on
+// YouTube). It refuses a representation whose segment layout changed, since
+// resuming into it would splice mismatched data.
+func refreshDASHRepresentation(ctx context.Context, client YouTubeClient, videoID string, current dashRepresentation) (dashRepresentation, error) {
+	video, err := client.GetVideoContext(ctx, videoID)
+	if err != nil {
+		return dashRepresentation{}, fmt.Errorf("refetching metadata: %w", err)
+	}
+	if video.DASHManifestURL == "" {
+		return dashRepresentation{}, fmt.Errorf("refreshed metadata has no DASH manifest")
+	}
+	data, err := fetchManifest(ctx, client, video.DASHManifestURL)
+	if err != nil {
+		return dashRepresentation{}, fmt.Errorf("fetching DASH manifest: %w", err)
+	}
+	var manifest mpd
+	if err := xml.Unmarshal(data, &manifest); err != nil {
+		return dashRepresentation{}, fmt.Errorf("parsing DASH manifest: %w", err)
+	}
+	for _, rep := range collectDASHRepresentations(manifest, video.DASHManifestURL) {
+		if rep.Rep.ID != current.Rep.ID {
+			continue
+		}
+		if len(rep.Segments) != len(current.Segments) {
+			return dashRepresentation{}, fmt.Errorf("representation %s now has %d segments, expected %d", rep.Rep.ID, len(rep.Segments), len(current.Segments))
+		}
+		return rep, nil
+	}
+	return dashRepresentation{}, fmt.Errorf("representation %s no longer offered", current.Rep.ID)
+}
@@ -0,0 +1,111 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	youtube "github.com/lvcoi/ytdl-lib/v2"
+)
+
+func TestDownloadVideoRefreshesExpiredStreamURL(t *testing.T) {
+	payload := "\x00\x00\x00\x18ftypisom" + strings.Repeat("\x00", 12) + "moov" + strings.Repeat("x", 256)
+	progressive := func(url string) youtube.Format {
+		return youtube.Format{ItagNo: 18, URL: url, MimeType: `video/mp4; codecs="avc1.42001E, mp4a.40.2"`, AudioChannels: 2, Width: 640, Height: 360}
+	}
+	stale := &youtube.Video{ID: "abc123", Title: "Clip", Formats: youtube.FormatList{progressive("https://example.com/stale")}}
+
+	var refetches atomic.Int32
+	client := &mockYouTubeClient{
+		getVideoFn: func(ctx context.Context, id string) (*youtube.Video, error) {
+			refetches.Add(1)
+			if id != "abc123" {
+				t.Errorf("expected a refetch of abc123, got %q", id)
+			}
+			return &youtube.Video{ID: "abc123", Title: "Clip", Formats: youtube.FormatList{progressive("https://example.com/fresh")}}, nil
+		},
+		getStreamFn: func(ctx context.Context, video *youtube.Video, format *youtube.Format) (io.ReadCloser, int64, error) {
+			if format.URL == "https://example.com/stale" {
+				return io.NopCloser(failingReader{err: youtube.ErrUnexpectedStatusCode(http.StatusForbidden)}), 0, nil
+			}
+			return io.NopCloser(strings.NewReader(payload)), int64(len(payload)), nil
+		},
+	}
+	opts := Options{OutputTemplate: "{title}.{ext}", OutputDir: t.TempDir(), Quiet: true}
+	result, err := downloadVideo(context.Background(), client, stale, opts, outputContext{}, newPrinter(opts, nil), "[1/1]")
+	if err != nil {
+		t.Fatalf("downloadVideo: %v", err)
+	}
+	if refetches.Load() != 1 {
+		t.Fatalf("expected one metadata refetch, got %d", refetches.Load())
+	}
+	if data, err := os.ReadFile(result.outputPath); err != nil || string(data) != payload {
+		t.Fatalf("expected the fresh stream in %s (err %v)", result.outputPath, err)
+	}
+}
+
+func TestDownloadDASHSegmentsResumeRefreshesExpiredURLs(t *testing.T) {
+	var srvURL string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/manifest.mpd":
+			fmt.Fprintf(w, `<MPD><BaseURL>%s/fresh/</BaseURL><Period><AdaptationSet mimeType="video/mp4">
+<Representation id="137" bandwidth="1000" width="1920" height="1080"><SegmentList>
+<SegmentURL media="seg0"/><SegmentURL media="seg1"/><SegmentURL media="seg2"/>
+</SegmentList></Representation></AdaptationSet></Period></MPD>`, srvURL)
+		case strings.HasPrefix(r.URL.Path, "/stale/"):
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			fmt.Fprintf(w, "[%s]", filepath.Base(r.URL.Path))
+		}
+	}))
+	defer srv.Close()
+	srvURL = srv.URL
+
+	var refetches atomic.Int32
+	client := &mockYouTubeClient{
+		httpDoer: srv.Client(),
+		getVideoFn: func(ctx context.Context, id string) (*youtube.Video, error) {
+			refetches.Add(1)
+			return &youtube.Video{ID: id, DASHManifestURL: srv.URL + "/manifest.mpd"}, nil
+		},
+	}
+	stale := dashRepresentation{
+		Rep:      representation{ID: "137"},
+		Segments: []string{srv.URL + "/stale/seg0", srv.URL + "/stale/seg1", srv.URL + "/stale/seg2"},
+	}
+
+	dir := t.TempDir()
+	outputPath := filepath.Join(dir, "video.bin")
+	partPath, _ := artifactPath(outputPath, partSuffix, dir)
+	resumePath, _ := artifactPath(outputPath, resumeSuffix, dir)
+	if err := os.WriteFile(partPath, []byte("[seg0]"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveDASHResume(resumePath, dashResumeState{SegmentCount: 3, NextIndex: 1, BytesWritten: 6}); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := Options{Quiet: true}
+	if _, err := downloadDASHSegments(context.Background(), client, "abc123", stale, outputPath, dir, opts, nil, "[1/1]"); err != nil {
+		t.Fatalf("downloadDASHSegments: %v", err)
+	}
+	if refetches.Load() != 1 {
+		t.Fatalf("expected one metadata refetch, got %d", refetches.Load())
+	}
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, []byte("[seg0][seg1][seg2]")) {
+		t.Fatalf("expected the resumed file to continue with fresh segments, got %q", data)
+	}
+}
@@ -0,0 +1,87 @@
+package downloader
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/lvcoi/ytdl-lib/v2"
+)
+
+// playlistOutcome is the result of processing a single playlist entry.
+type playlistOutcome struct {
+	ok      bool
+	failed  bool
+	skipped bool
+	bytes   int64
+	index   int
+	id      string
+	title   string
+	reason  string
+	output  string
+	err     error
+}
+
+// failureReport is written by --report-failed so failed entries can be retried.
+type failureReport struct {
+	PlaylistID    string               `json:"playlist_id"`
+	PlaylistTitle string               `json:"playlist_title"`
+	PlaylistURL   string               `json:"playlist_url,omitempty"`
+	Failed        []failureReportEntry `json:"failed"`
+	Skipped       []failureReportEntry `json:"skipped"`
+}
+
+type failureReportEntry struct {
+	Index  int    `json:"index"`
+	ID     string `json:"id,omitempty"`
+	URL    string `json:"url,omitempty"`
+	Title  string `json:"title,omitempty"`
+	Reason string `json:"reason"`
+}
+
+func buildFailureReport(playlist *youtube.Playlist, playlistURL string, outcomes []playlistOutcome) failureReport {
+	report := failureReport{
+		PlaylistURL: playlistURL,
+		Failed:      []failureReportEntry{},
+		Skipped:     []failureReportEntry{},
+	}
+	if playlist != nil {
+		report.PlaylistID = playlist.ID
+		report.PlaylistTitle = playlist.Title
+	}
+	for _, outcome := range outcomes {
+		if !outcome.failed && !outcome.skipped {
+			continue
+		}
+		entry := failureReportEntry{
+			Index:  outcome.index,
+			ID:     outcome.id,
+			URL:    watchURLForID(outcome.id),
+			Title:  outcome.title,
+			Reason: outcome.reason,
+		}
+		if outcome.failed {
+			report.Failed = append(report.Failed, entry)
+		} else {
+			report.Skipped = append(report.Skipped, entry)
+		}
+	}
+	return report
+}
+
+func writeFailureReport(path string, report failureReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding failure report: %w", err)
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("writing failure report: %w", err)
+		}
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("writing failure report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package downloader
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	youtube "github.com/lvcoi/ytdl-lib/v2"
+)
+
+func TestFailureReportMixedOutcomes(t *testing.T) {
+	playlist := &youtube.Playlist{ID: "PL123", Title: "Mix"}
+	outcomes := []playlistOutcome{
+		{ok: true, bytes: 1024, index: 1, id: "okvid"},
+		{failed: true, index: 2, id: "badvid", title: "Broken", reason: "video unavailable"},
+		{skipped: true, index: 3, reason: "missing playlist entry"},
+		{skipped: true, index: 4, id: "dupvid", title: "Dup", reason: "exists"},
+		{failed: true, index: 5, id: "netvid", title: "Flaky", reason: "download failed: timeout"},
+	}
+
+	path := filepath.Join(t.TempDir(), "reports", "failed.json")
+	if err := writeFailureReport(path, buildFailureReport(playlist, "https://www.youtube.com/playlist?list=PL123", outcomes)); err != nil {
+		t.Fatalf("writeFailureReport: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read report: %v", err)
+	}
+	var report failureReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("decode report: %v", err)
+	}
+
+	if report.PlaylistID != "PL123" || report.PlaylistTitle != "Mix" {
+		t.Fatalf("unexpected playlist fields: %+v", report)
+	}
+	if len(report.Failed) != 2 || len(report.Skipped) != 2 {
+		t.Fatalf("expected 2 failed and 2 skipped, got %+v", report)
+	}
+	failed := report.Failed[0]
+	if failed.Index != 2 || failed.ID != "badvid" || failed.Reason != "video unavailable" {
+		t.Fatalf("unexpected failed entry: %+v", failed)
+	}
+	if failed.URL != "https://www.youtube.com/watch?v=badvid" {
+		t.Fatalf("unexpected failed URL %q", failed.URL)
+	}
+	if report.Failed[1].ID != "netvid" {
+		t.Fatalf("expected failures in playlist order, got %+v", report.Failed)
+	}
+	if missing := report.Skipped[0]; missing.ID != "" || missing.URL != "" || missing.Reason != "missing playlist entry" {
+		t.Fatalf("unexpected skipped entry: %+v", missing)
+	}
+	if report.Skipped[1].Reason != "exists" {
+		t.Fatalf("unexpected skipped reason: %+v", report.Skipped[1])
+	}
+}
@@ -1,8 +1,10 @@
 package downloader
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"io"
 	"math"
 	"math/rand"
 	"net"
@@ -86,10 +88,12 @@ func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 
 		// Retryable status: close the previous response and keep this one
 		// in case we exhaust retries and need to return it to the caller.
+		// Its body is buffered and closed now so the connection, and any
+		// per-host slot it holds, is free for the retry.
 		if lastResp != nil {
 			lastResp.Body.Close()
 		}
-		lastResp = resp
+		lastResp = detachResponse(resp)
 		lastErr = nil
 	}
 
@@ -100,6 +104,21 @@ func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	return nil, lastErr
 }
 
+// retainedBodyLimit caps how much of a retryable response body is kept for
+// the caller in case retries run out; error pages are small.
+const retainedBodyLimit = 64 << 10
+
+// detachResponse reads up to retainedBodyLimit bytes of resp's body into
+// memory and closes the original, so the response can be held across a
+// backoff without keeping its connection busy.
+func detachResponse(resp *http.Response) *http.Response {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, retainedBodyLimit))
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	return resp
+}
+
 // backoffDelay calculates delay with exponential backoff and jitter.
 func (t *retryTransport) backoffDelay(attempt int) time.Duration {
 	base := float64(t.config.InitialDelay) * math.Pow(2, float64(attempt-1))
@@ -10,13 +10,21 @@ import (
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 type segmentDownloadPlan struct {
 	URLs        []string
 	TempDir     string
 	Prefix      string
+	OutputPath  string
 	Concurrency int
+	// AutoConcurrency lets a concurrencyTuner pick the worker count, with
+	// Concurrency (when set) as the ceiling.
+	AutoConcurrency bool
+	// SkipUnavailable leaves out segments that still fail after all retries
+	// instead of failing the download (-skip-unavailable-fragments).
+	SkipUnavailable bool
 }
 
 const (
@@ -42,20 +50,35 @@ func defaultSegmentConcurrency(value int) int {
 	return cpu * ioMultiplier
 }
 
-func downloadSegmentsParallel(ctx context.Context, client YouTubeClient, plan segmentDownloadPlan, writer io.Writer, printer *Printer) (int64, error) {
+// downloadSegmentsParallel downloads plan's segments with a worker pool and
+// writes them to writer in order. It returns the bytes downloaded and how
+// many segments were skipped as unavailable.
+func downloadSegmentsParallel(ctx context.Context, client YouTubeClient, plan segmentDownloadPlan, writer io.Writer, printer *Printer) (int64, int, error) {
+	// The segment directory never outlives the call: on success the parts
+	// have been assembled, and on failure or cancellation the parallel path
+	// restarts from scratch (resume state only tracks sequential downloads).
+	if plan.TempDir != "" {
+		defer os.RemoveAll(plan.TempDir)
+	}
 	concurrency := defaultSegmentConcurrency(plan.Concurrency)
+	var tuner *concurrencyTuner
+	if plan.AutoConcurrency {
+		tuner = newConcurrencyTuner(plan.Concurrency, nil)
+		concurrency = tuner.Max()
+		client = tunedClient{YouTubeClient: client, tuner: tuner}
+	}
 	if concurrency < 2 || len(plan.URLs) < 2 {
 		return downloadSegmentsSequential(ctx, client, plan, writer, printer)
 	}
 
 	if err := os.MkdirAll(plan.TempDir, 0o755); err != nil {
-		return 0, wrapCategory(CategoryFilesystem, fmt.Errorf("creating temp dir: %w", err))
+		return 0, 0, wrapCategory(CategoryFilesystem, fmt.Errorf("creating temp dir: %w", err))
 	}
 
 	var totalBytes int64
 	progress := (*progressWriter)(nil)
 	if printer != nil {
-		progress = newProgressWriter(0, printer, plan.Prefix)
+		progress = newProgressWriter(0, printer, plan.Prefix, plan.OutputPath)
 	}
 	var progressMu sync.Mutex
 	counter := &progressCounter{total: &totalBytes, progress: progress, mu: &progressMu}
@@ -65,15 +88,34 @@ func downloadSegmentsParallel(ctx context.Context, client YouTubeClient, plan se
 		URL   string
 	}
 	jobs := make(chan job)
+	// skippedSegments[i] is only written by the worker handling segment i
+	// and read after all workers have finished.
+	skippedSegments := make([]bool, len(plan.URLs))
 
 	workerCtx, cancelCause := context.WithCancelCause(ctx)
 	defer cancelCause(nil)
 
 	var wg sync.WaitGroup
+	// fed is closed once every job has been handed out, releasing workers
+	// that the tuner has parked.
+	fed := make(chan struct{})
 
-	worker := func() {
+	worker := func(id int) {
 		defer wg.Done()
-		for j := range jobs {
+		for {
+			for tuner != nil && id >= tuner.Limit() {
+				select {
+				case <-workerCtx.Done():
+					return
+				case <-fed:
+					return
+				case <-time.After(autoConcurrencyPoll):
+				}
+			}
+			j, ok := <-jobs
+			if !ok {
+				return
+			}
 			if workerCtx.Err() != nil {
 				return
 			}
@@ -81,20 +123,38 @@ func downloadSegmentsParallel(ctx context.Context, client YouTubeClient, plan se
 			if info, err := os.Stat(dest); err == nil && info.Size() > 0 {
 				continue
 			}
+			// Write to a sibling file and rename once complete, so dest only
+			// ever holds whole segments and can be reused if left behind.
+			partial := dest + ".tmp"
 			err := func() error {
-				file, err := os.Create(dest)
+				file, err := os.Create(partial)
 				if err != nil {
 					return wrapCategory(CategoryFilesystem, fmt.Errorf("creating segment file: %w", err))
 				}
 				defer file.Close()
 				segmentWriter := io.Writer(file)
 				if progress != nil {
-					segmentWriter = io.MultiWriter(file, counter)
+					segmentWriter = io.MultiWriter(segmentWriter, counter)
+				}
+				if tuner != nil {
+					segmentWriter = io.MultiWriter(segmentWriter, tuner)
 				}
 				err = downloadSegmentWithRetry(workerCtx, client, j.URL, segmentWriter)
 				if err != nil {
+					if skipUnavailableSegment(workerCtx, plan.SkipUnavailable, err, j.Index, printer, plan.Prefix) {
+						skippedSegments[j.Index] = true
+						file.Close()
+						os.Remove(partial)
+						return nil
+					}
 					return wrapCategory(CategoryNetwork, fmt.Errorf("segment %d failed: %w", j.Index+1, err))
 				}
+				if err := file.Close(); err != nil {
+					return wrapCategory(CategoryFilesystem, fmt.Errorf("closing segment file: %w", err))
+				}
+				if err := os.Rename(partial, dest); err != nil {
+					return wrapCategory(CategoryFilesystem, fmt.Errorf("finalizing segment file: %w", err))
+				}
 				return nil
 			}()
 			if err != nil {
@@ -106,7 +166,7 @@ func downloadSegmentsParallel(ctx context.Context, client YouTubeClient, plan se
 
 	for i := 0; i < concurrency; i++ {
 		wg.Add(1)
-		go worker()
+		go worker(i)
 	}
 
 	for i, url := range plan.URLs {
@@ -116,20 +176,26 @@ func downloadSegmentsParallel(ctx context.Context, client YouTubeClient, plan se
 		}
 	}
 	close(jobs)
+	close(fed)
 	wg.Wait()
 
 	if cause := context.Cause(workerCtx); cause != nil {
 		if progress != nil {
 			progress.NewLine()
 		}
-		return atomic.LoadInt64(&totalBytes), cause
+		return atomic.LoadInt64(&totalBytes), 0, cause
 	}
 
 	if progress != nil {
 		progress.Finish()
 	}
 
+	skipped := 0
 	for i := range plan.URLs {
+		if skippedSegments[i] {
+			skipped++
+			continue
+		}
 		err := func() error {
 			path := filepath.Join(plan.TempDir, fmt.Sprintf("segment-%06d.part", i))
 			file, err := os.Open(path)
@@ -137,22 +203,17 @@ func downloadSegmentsParallel(ctx context.Context, client YouTubeClient, plan se
 				return wrapCategory(CategoryFilesystem, fmt.Errorf("opening segment: %w", err))
 			}
 			defer file.Close()
-			if _, err := io.Copy(writer, file); err != nil {
+			if _, err := copyBuffer(writer, file); err != nil {
 				return wrapCategory(CategoryFilesystem, fmt.Errorf("assembling segments: %w", err))
 			}
 			return nil
 		}()
 		if err != nil {
-			return atomic.LoadInt64(&totalBytes), err
+			return atomic.LoadInt64(&totalBytes), skipped, err
 		}
 	}
 
-	for i := range plan.URLs {
-		path := filepath.Join(plan.TempDir, fmt.Sprintf("segment-%06d.part", i))
-		_ = os.Remove(path)
-	}
-
-	return atomic.LoadInt64(&totalBytes), nil
+	return atomic.LoadInt64(&totalBytes), skipped, nil
 }
 
 func validateSegmentTempDir(tempDir string) (string, error) {
@@ -301,30 +362,35 @@ func validateSegmentTempDir(tempDir string) (string, error) {
 	return evalTemp, nil
 }
 
-func downloadSegmentsSequential(ctx context.Context, client YouTubeClient, plan segmentDownloadPlan, writer io.Writer, printer *Printer) (int64, error) {
+func downloadSegmentsSequential(ctx context.Context, client YouTubeClient, plan segmentDownloadPlan, writer io.Writer, printer *Printer) (int64, int, error) {
 	progress := (*progressWriter)(nil)
 	if printer != nil {
-		progress = newProgressWriter(0, printer, plan.Prefix)
+		progress = newProgressWriter(0, printer, plan.Prefix, plan.OutputPath)
 	}
 	var totalBytes int64
 	var progressMu sync.Mutex
 	counter := &progressCounter{total: &totalBytes, progress: progress, mu: &progressMu}
+	skipped := 0
 	for i, url := range plan.URLs {
 		segmentWriter := writer
 		if progress != nil {
 			segmentWriter = io.MultiWriter(writer, counter)
 		}
-		if err := downloadSegmentWithRetry(ctx, client, url, segmentWriter); err != nil {
+		if err := fetchSegment(ctx, client, url, segmentWriter, plan.SkipUnavailable); err != nil {
+			if skipUnavailableSegment(ctx, plan.SkipUnavailable, err, i, printer, plan.Prefix) {
+				skipped++
+				continue
+			}
 			if progress != nil {
 				progress.NewLine()
 			}
-			return totalBytes, wrapCategory(CategoryNetwork, fmt.Errorf("segment %d failed: %w", i+1, err))
+			return totalBytes, skipped, wrapCategory(CategoryNetwork, fmt.Errorf("segment %d failed: %w", i+1, err))
 		}
 	}
 	if progress != nil {
 		progress.Finish()
 	}
-	return totalBytes, nil
+	return totalBytes, skipped, nil
 }
 
 type progressCounter struct {
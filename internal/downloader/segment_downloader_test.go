@@ -0,0 +1,161 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDownloadSegmentsParallelCancelRemovesTempDir(t *testing.T) {
+	var served atomic.Int32
+	started := make(chan struct{}, 16)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if served.Add(1) == 1 {
+			// Let the first segment finish so a completed part file exists.
+			_, _ = w.Write(bytes.Repeat([]byte("x"), 512))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("partial"))
+		w.(http.Flusher).Flush()
+		started <- struct{}{}
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	tempDir, err := validateSegmentTempDir(fmt.Sprintf("cancel-test-%d.segments", time.Now().UnixNano()))
+	if err != nil {
+		t.Fatalf("validateSegmentTempDir: %v", err)
+	}
+	urls := make([]string, 8)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("%s/seg%d.ts", srv.URL, i)
+	}
+	plan := segmentDownloadPlan{URLs: urls, TempDir: tempDir, Concurrency: 4}
+	client := &mockYouTubeClient{httpDoer: srv.Client()}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := downloadSegmentsParallel(ctx, client, plan, &bytes.Buffer{}, nil)
+		done <- err
+	}()
+	select {
+	case err = <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("downloadSegmentsParallel did not return after cancellation")
+	}
+	if err == nil {
+		t.Fatal("expected an error from a cancelled download")
+	}
+	if _, statErr := os.Stat(tempDir); !errors.Is(statErr, os.ErrNotExist) {
+		t.Fatalf("expected segment temp dir %s to be removed, stat err = %v", tempDir, statErr)
+	}
+}
+
+func TestDownloadSegmentsParallelReusesCompletedSegments(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		_, _ = w.Write([]byte(filepath.Base(r.URL.Path)))
+	}))
+	defer srv.Close()
+
+	tempDir, err := validateSegmentTempDir(fmt.Sprintf("reuse-test-%d.segments", time.Now().UnixNano()))
+	if err != nil {
+		t.Fatalf("validateSegmentTempDir: %v", err)
+	}
+	// A stale run left one finished segment and one interrupted write.
+	if err := os.WriteFile(filepath.Join(tempDir, "segment-000000.part"), []byte("a"), 0o644); err != nil {
+		t.Fatalf("seed segment: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "segment-000001.part.tmp"), []byte("garbage"), 0o644); err != nil {
+		t.Fatalf("seed partial: %v", err)
+	}
+
+	plan := segmentDownloadPlan{
+		URLs:        []string{srv.URL + "/a", srv.URL + "/b", srv.URL + "/c"},
+		TempDir:     tempDir,
+		Concurrency: 2,
+	}
+	var out bytes.Buffer
+	if _, _, err := downloadSegmentsParallel(context.Background(), &mockYouTubeClient{httpDoer: srv.Client()}, plan, &out, nil); err != nil {
+		t.Fatalf("downloadSegmentsParallel: %v", err)
+	}
+	if got := out.String(); got != "abc" {
+		t.Fatalf("expected assembled output %q, got %q", "abc", got)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Fatalf("expected the completed segment to be reused (2 requests), got %d", got)
+	}
+	if _, err := os.Stat(tempDir); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected segment temp dir to be removed after success, stat err = %v", err)
+	}
+}
+
+func TestDownloadSegmentsUnavailableFragments(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/b" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(filepath.Base(r.URL.Path)))
+	}))
+	defer srv.Close()
+	client := &mockYouTubeClient{httpDoer: srv.Client()}
+	urls := []string{srv.URL + "/a", srv.URL + "/b", srv.URL + "/c"}
+
+	cases := []struct {
+		name        string
+		concurrency int
+		skip        bool
+	}{
+		{name: "parallel abort", concurrency: 2},
+		{name: "parallel skip", concurrency: 2, skip: true},
+		{name: "sequential skip", concurrency: 1, skip: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tempDir, err := validateSegmentTempDir(fmt.Sprintf("skip-test-%d.segments", time.Now().UnixNano()))
+			if err != nil {
+				t.Fatalf("validateSegmentTempDir: %v", err)
+			}
+			plan := segmentDownloadPlan{
+				URLs:            urls,
+				TempDir:         tempDir,
+				Concurrency:     tc.concurrency,
+				SkipUnavailable: tc.skip,
+			}
+			var out bytes.Buffer
+			_, skipped, err := downloadSegmentsParallel(context.Background(), client, plan, &out, nil)
+			if !tc.skip {
+				if err == nil || !isUnexpectedStatus(err, http.StatusNotFound) {
+					t.Fatalf("expected the 404 fragment to fail the download, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("downloadSegmentsParallel: %v", err)
+			}
+			if skipped != 1 {
+				t.Fatalf("skipped = %d, want 1", skipped)
+			}
+			if got := out.String(); got != "ac" {
+				t.Fatalf("assembled output = %q, want %q", got, "ac")
+			}
+		})
+	}
+}
@@ -14,6 +14,13 @@ type HLSManifest struct {
 	Encrypted bool
 	KeyMethod string
 	KeyURI    string
+	// MediaSequence is the sequence number of the first listed segment.
+	MediaSequence int
+	// TargetDuration is the maximum segment duration in seconds.
+	TargetDuration float64
+	// EndList is set once the playlist is complete; live playlists omit it
+	// until the stream ends.
+	EndList bool
 }
 
 type HLSVariant struct {
@@ -65,6 +72,23 @@ func ParseHLSManifest(data []byte) (HLSManifest, error) {
 			continue
 		}
 
+		if strings.HasPrefix(line, "#EXT-X-MEDIA-SEQUENCE:") {
+			manifest.MediaSequence = parseInt(strings.TrimPrefix(line, "#EXT-X-MEDIA-SEQUENCE:"))
+			continue
+		}
+
+		if strings.HasPrefix(line, "#EXT-X-TARGETDURATION:") {
+			if target, err := strconv.ParseFloat(strings.TrimPrefix(line, "#EXT-X-TARGETDURATION:"), 64); err == nil {
+				manifest.TargetDuration = target
+			}
+			continue
+		}
+
+		if line == "#EXT-X-ENDLIST" {
+			manifest.EndList = true
+			continue
+		}
+
 		if strings.HasPrefix(line, "#EXT-X-KEY:") {
 			attrs := parseHLSAttributes(strings.TrimPrefix(line, "#EXT-X-KEY:"))
 			method := strings.ToUpper(attrs["METHOD"])
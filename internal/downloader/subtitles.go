@@ -0,0 +1,308 @@
+package downloader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/lvcoi/ytdl-lib/v2"
+)
+
+// maxCaptionBytes caps a single caption download; real tracks are far smaller.
+const maxCaptionBytes = 10 << 20
+
+var subtitleLangSanitizer = regexp.MustCompile(`[^A-Za-z0-9-]`)
+
+// subtitleFile is a caption track written to disk next to a download.
+type subtitleFile struct {
+	Path     string
+	Language string
+	Name     string
+}
+
+// subtitleTrackInfo describes one caption track for --list-subtitles.
+type subtitleTrackInfo struct {
+	LanguageCode  string `json:"language_code"`
+	Name          string `json:"name"`
+	AutoGenerated bool   `json:"auto_generated"`
+	Translatable  bool   `json:"translatable"`
+}
+
+func subtitleTracks(video *youtube.Video) []subtitleTrackInfo {
+	tracks := make([]subtitleTrackInfo, 0, len(video.CaptionTracks))
+	for _, track := range video.CaptionTracks {
+		tracks = append(tracks, subtitleTrackInfo{
+			LanguageCode: track.LanguageCode,
+			Name:         track.Name.SimpleText,
+			// YouTube marks speech-recognition tracks with kind "asr".
+			AutoGenerated: track.Kind == "asr",
+			Translatable:  track.IsTranslatable,
+		})
+	}
+	return tracks
+}
+
+// renderSubtitles prints the caption tracks available for a video.
+func renderSubtitles(w io.Writer, video *youtube.Video, opts Options, playlistID, playlistTitle string, index, total int) error {
+	tracks := subtitleTracks(video)
+	if opts.JSON {
+		payload := struct {
+			Type          string              `json:"type"`
+			PlaylistID    string              `json:"playlist_id,omitempty"`
+			PlaylistTitle string              `json:"playlist_title,omitempty"`
+			Index         int                 `json:"index,omitempty"`
+			Total         int                 `json:"total,omitempty"`
+			ID            string              `json:"id"`
+			Title         string              `json:"title"`
+			Subtitles     []subtitleTrackInfo `json:"subtitles"`
+		}{
+			Type:          "subtitles",
+			PlaylistID:    playlistID,
+			PlaylistTitle: playlistTitle,
+			Index:         index,
+			Total:         total,
+			ID:            video.ID,
+			Title:         video.Title,
+			Subtitles:     tracks,
+		}
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		return enc.Encode(payload)
+	}
+
+	fmt.Fprintf(w, "Subtitles: %s\n", video.Title)
+	if len(tracks) == 0 {
+		fmt.Fprintln(w, "  no subtitles available")
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  LANGUAGE\tNAME\tAUTO-GENERATED")
+	for _, track := range tracks {
+		auto := "no"
+		if track.AutoGenerated {
+			auto = "yes"
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\n", track.LanguageCode, track.Name, auto)
+	}
+	return tw.Flush()
+}
+
+func listPlaylistSubtitles(ctx context.Context, playlist *youtube.Playlist, opts Options) error {
+	client := newClientForType("android", opts)
+
+	for i, entry := range playlist.Videos {
+		if entry == nil || entry.ID == "" {
+			continue
+		}
+		video, err := client.VideoFromPlaylistEntryContext(ctx, entry)
+		if err != nil {
+			return wrapFetchError(err, "fetching video metadata")
+		}
+		if err := renderSubtitles(os.Stdout, video, opts, playlist.ID, playlist.Title, i+1, len(playlist.Videos)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// selectCaptionTracks picks the tracks requested by --subtitles, a
+// comma-separated list of language codes or "all". For each language a
+// human-authored track is preferred over YouTube's auto-generated one.
+func selectCaptionTracks(video *youtube.Video, selection string) []youtube.CaptionTrack {
+	byLang := make(map[string]youtube.CaptionTrack)
+	var order []string
+	for _, track := range video.CaptionTracks {
+		lang := strings.ToLower(track.LanguageCode)
+		existing, seen := byLang[lang]
+		if !seen {
+			order = append(order, lang)
+		}
+		if !seen || (existing.Kind == "asr" && track.Kind != "asr") {
+			byLang[lang] = track
+		}
+	}
+
+	var selected []youtube.CaptionTrack
+	for _, want := range strings.Split(selection, ",") {
+		want = strings.ToLower(strings.TrimSpace(want))
+		if want == "" {
+			continue
+		}
+		if want == "all" {
+			selected = selected[:0]
+			for _, lang := range order {
+				selected = append(selected, byLang[lang])
+			}
+			return selected
+		}
+		if track, ok := byLang[want]; ok {
+			selected = append(selected, track)
+		}
+	}
+	return selected
+}
+
+func fetchCaptionTrack(ctx context.Context, track youtube.CaptionTrack, opts Options) ([]byte, error) {
+	trackURL, err := url.Parse(track.BaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid caption URL: %w", err)
+	}
+	query := trackURL.Query()
+	query.Set("fmt", "json3")
+	trackURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, trackURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := newHTTPClient(opts.Timeout).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxCaptionBytes))
+}
+
+// downloadSubtitles writes the --subtitles tracks as SRT files named after
+// outputPath (e.g. "Title.en.srt"). They go next to the output unless a
+// "subtitle" route is configured via --paths, which resolves against
+// outputRoot. Failures are logged and skipped; subtitles never fail a download.
+func downloadSubtitles(ctx context.Context, video *youtube.Video, opts Options, outputPath, outputRoot string, printer *Printer) []subtitleFile {
+	tracks := selectCaptionTracks(video, opts.Subtitles)
+	if len(tracks) == 0 {
+		printer.Log(LogWarn, fmt.Sprintf("warning: no subtitles found for %q", opts.Subtitles))
+		return nil
+	}
+
+	dir := filepath.Dir(outputPath)
+	if _, routed := opts.Paths[pathKeySubtitle]; routed {
+		routedSubtitleDir, err := routedDir(opts.Paths, pathKeySubtitle, outputRoot)
+		if err != nil {
+			printer.Log(LogWarn, fmt.Sprintf("warning: subtitles skipped: %v", err))
+			return nil
+		}
+		dir = routedSubtitleDir
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		printer.Log(LogWarn, fmt.Sprintf("warning: subtitles skipped: %v", err))
+		return nil
+	}
+	stem := strings.TrimSuffix(filepath.Base(outputPath), filepath.Ext(outputPath))
+
+	var files []subtitleFile
+	for _, track := range tracks {
+		lang := subtitleLangSanitizer.ReplaceAllString(track.LanguageCode, "")
+		if lang == "" {
+			continue
+		}
+		data, err := fetchCaptionTrack(ctx, track, opts)
+		if err == nil {
+			var srt string
+			if srt, err = convertCaptionsToSRT(data); err == nil {
+				path := filepath.Join(dir, stem+"."+lang+".srt")
+				if err = os.WriteFile(path, []byte(srt), 0o644); err == nil {
+					files = append(files, subtitleFile{Path: path, Language: lang, Name: track.Name.SimpleText})
+					continue
+				}
+			}
+		}
+		printer.Log(LogWarn, fmt.Sprintf("warning: %s subtitles failed: %v", lang, err))
+	}
+	return files
+}
+
+// subtitleCodecForContainer reports the soft-subtitle codec a container can
+// hold, or "" when it cannot carry SRT-derived tracks.
+func subtitleCodecForContainer(outputPath string) string {
+	switch strings.ToLower(filepath.Ext(outputPath)) {
+	case ".mp4", ".m4v", ".mov":
+		return "mov_text"
+	case ".mkv":
+		return "srt"
+	default:
+		return ""
+	}
+}
+
+// iso639_2 maps common two-letter codes to the three-letter form containers
+// expect in stream language tags.
+var iso639_2 = map[string]string{
+	"ar": "ara", "de": "deu", "en": "eng", "es": "spa", "fr": "fra",
+	"hi": "hin", "it": "ita", "ja": "jpn", "ko": "kor", "nl": "nld",
+	"pl": "pol", "pt": "por", "ru": "rus", "sv": "swe", "tr": "tur",
+	"uk": "ukr", "zh": "zho",
+}
+
+func subtitleLanguageTag(lang string) string {
+	base := strings.ToLower(strings.SplitN(lang, "-", 2)[0])
+	if tag, ok := iso639_2[base]; ok {
+		return tag
+	}
+	return base
+}
+
+func embedSubtitleArgs(outputPath, tmpPath, codec string, subs []subtitleFile) []string {
+	args := []string{"-hide_banner", "-loglevel", "error", "-i", outputPath}
+	for _, sub := range subs {
+		args = append(args, "-i", sub.Path)
+	}
+	args = append(args, "-map", "0:v?", "-map", "0:a?")
+	for i := range subs {
+		args = append(args, "-map", fmt.Sprintf("%d:0", i+1))
+	}
+	args = append(args, "-c", "copy", "-c:s", codec)
+	for i, sub := range subs {
+		args = append(args, fmt.Sprintf("-metadata:s:s:%d", i), "language="+subtitleLanguageTag(sub.Language))
+		if sub.Name != "" {
+			args = append(args, fmt.Sprintf("-metadata:s:s:%d", i), "title="+sub.Name)
+		}
+	}
+	return append(args, "-y", tmpPath)
+}
+
+// embedSubtitles muxes subs into outputPath as soft subtitle tracks. Containers
+// that cannot hold them are left untouched with a warning.
+func embedSubtitles(outputPath, baseDir string, subs []subtitleFile, printer *Printer) error {
+	if len(subs) == 0 {
+		return nil
+	}
+	codec := subtitleCodecForContainer(outputPath)
+	if codec == "" {
+		printer.Log(LogWarn, fmt.Sprintf("warning: --embed-subs skipped: %s cannot hold subtitle tracks", filepath.Ext(outputPath)))
+		return nil
+	}
+	if !ffmpegAvailable() {
+		printer.Log(LogWarn, "warning: --embed-subs skipped: ffmpeg not found")
+		return nil
+	}
+	tmpPath, err := artifactPath(outputPath, ".subs"+filepath.Ext(outputPath), baseDir)
+	if err != nil {
+		return err
+	}
+	output, err := exec.Command(ffmpegPath, embedSubtitleArgs(outputPath, tmpPath, codec, subs)...).CombinedOutput()
+	if err != nil {
+		os.Remove(tmpPath)
+		stderr := strings.TrimSpace(string(output))
+		if stderr != "" {
+			return fmt.Errorf("embedding subtitles: %s: %w", stderr, err)
+		}
+		return fmt.Errorf("embedding subtitles: %w", err)
+	}
+	if err := os.Rename(tmpPath, outputPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("replacing output with subtitled version: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package downloader
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	youtube "github.com/lvcoi/ytdl-lib/v2"
+)
+
+func subtitleFixtureVideo() *youtube.Video {
+	video := &youtube.Video{ID: "sub123", Title: "Captioned"}
+	tracks := []struct {
+		lang, name, kind string
+		translatable     bool
+	}{
+		{lang: "en", name: "English", translatable: true},
+		{lang: "en", name: "English (auto-generated)", kind: "asr", translatable: true},
+		{lang: "de", name: "German"},
+	}
+	for _, tr := range tracks {
+		track := youtube.CaptionTrack{LanguageCode: tr.lang, Kind: tr.kind, IsTranslatable: tr.translatable}
+		track.Name.SimpleText = tr.name
+		video.CaptionTracks = append(video.CaptionTracks, track)
+	}
+	return video
+}
+
+func TestRenderSubtitlesJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := renderSubtitles(&buf, subtitleFixtureVideo(), Options{JSON: true}, "", "", 0, 0); err != nil {
+		t.Fatalf("renderSubtitles: %v", err)
+	}
+	var payload struct {
+		Type      string              `json:"type"`
+		ID        string              `json:"id"`
+		Subtitles []subtitleTrackInfo `json:"subtitles"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &payload); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if payload.Type != "subtitles" || payload.ID != "sub123" {
+		t.Fatalf("unexpected payload header: %+v", payload)
+	}
+	if len(payload.Subtitles) != 3 {
+		t.Fatalf("expected 3 tracks, got %+v", payload.Subtitles)
+	}
+	if payload.Subtitles[0].AutoGenerated || !payload.Subtitles[1].AutoGenerated {
+		t.Fatalf("unexpected auto-generated flags: %+v", payload.Subtitles)
+	}
+	if payload.Subtitles[2].LanguageCode != "de" || payload.Subtitles[2].Name != "German" || payload.Subtitles[2].Translatable {
+		t.Fatalf("unexpected German track: %+v", payload.Subtitles[2])
+	}
+}
+
+func TestRenderSubtitlesText(t *testing.T) {
+	var buf bytes.Buffer
+	if err := renderSubtitles(&buf, subtitleFixtureVideo(), Options{}, "", "", 0, 0); err != nil {
+		t.Fatalf("renderSubtitles: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"Subtitles: Captioned", "English (auto-generated)", "German"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in output:\n%s", want, out)
+		}
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 5 || !strings.HasSuffix(strings.TrimSpace(lines[3]), "yes") {
+		t.Fatalf("unexpected table layout:\n%s", out)
+	}
+
+	buf.Reset()
+	if err := renderSubtitles(&buf, &youtube.Video{Title: "Bare"}, Options{}, "", "", 0, 0); err != nil {
+		t.Fatalf("renderSubtitles: %v", err)
+	}
+	if !strings.Contains(buf.String(), "no subtitles available") {
+		t.Fatalf("expected empty notice, got %q", buf.String())
+	}
+}
+
+func TestSelectCaptionTracksPrefersManual(t *testing.T) {
+	video := subtitleFixtureVideo()
+
+	got := selectCaptionTracks(video, "en")
+	if len(got) != 1 || got[0].Kind == "asr" {
+		t.Fatalf("expected manual English track, got %+v", got)
+	}
+	got = selectCaptionTracks(video, "de, fr ,EN")
+	if len(got) != 2 || got[0].LanguageCode != "de" || got[1].LanguageCode != "en" {
+		t.Fatalf("unexpected selection order: %+v", got)
+	}
+	if got := selectCaptionTracks(video, "all"); len(got) != 2 {
+		t.Fatalf("expected one track per language for all, got %+v", got)
+	}
+}
+
+func TestEmbedSubtitleArgs(t *testing.T) {
+	subs := []subtitleFile{
+		{Path: "v.en.srt", Language: "en", Name: "English"},
+		{Path: "v.pt-BR.srt", Language: "pt-BR"},
+	}
+	got := strings.Join(embedSubtitleArgs("v.mp4", "v.mp4.subs.mp4", "mov_text", subs), " ")
+	want := "-hide_banner -loglevel error -i v.mp4 -i v.en.srt -i v.pt-BR.srt -map 0:v? -map 0:a? -map 1:0 -map 2:0 " +
+		"-c copy -c:s mov_text -metadata:s:s:0 language=eng -metadata:s:s:0 title=English -metadata:s:s:1 language=por -y v.mp4.subs.mp4"
+	if got != want {
+		t.Fatalf("unexpected args:\n got %q\nwant %q", got, want)
+	}
+	if codec := subtitleCodecForContainer("clip.webm"); codec != "" {
+		t.Fatalf("expected webm to be unsupported, got %q", codec)
+	}
+}
+
+func TestEmbedSubtitlesMuxesTracks(t *testing.T) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		t.Skip("ffmpeg not available")
+	}
+	if _, err := exec.LookPath("ffprobe"); err != nil {
+		t.Skip("ffprobe not available")
+	}
+
+	for _, ext := range []string{".mkv", ".mp4"} {
+		t.Run(ext, func(t *testing.T) {
+			dir := t.TempDir()
+			video := filepath.Join(dir, "clip"+ext)
+			cmd := exec.Command("ffmpeg", "-hide_banner", "-loglevel", "error",
+				"-f", "lavfi", "-i", "testsrc=size=64x64:rate=5:duration=2",
+				"-y", video)
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Skipf("ffmpeg cannot encode test video: %v: %s", err, out)
+			}
+			var subs []subtitleFile
+			for _, lang := range []string{"en", "de"} {
+				path := filepath.Join(dir, "clip."+lang+".srt")
+				if err := os.WriteFile(path, []byte("1\n00:00:00,000 --> 00:00:01,000\nhi\n\n"), 0o644); err != nil {
+					t.Fatalf("write srt: %v", err)
+				}
+				subs = append(subs, subtitleFile{Path: path, Language: lang})
+			}
+
+			if err := embedSubtitles(video, dir, subs, newPrinter(Options{Quiet: true}, nil)); err != nil {
+				t.Fatalf("embedSubtitles: %v", err)
+			}
+
+			out, err := exec.Command("ffprobe", "-v", "error", "-select_streams", "s",
+				"-show_entries", "stream_tags=language", "-of", "csv=p=0", video).Output()
+			if err != nil {
+				t.Fatalf("ffprobe: %v", err)
+			}
+			langs := strings.Fields(string(out))
+			if len(langs) != 2 || langs[0] != "eng" || langs[1] != "deu" {
+				t.Fatalf("expected eng and deu subtitle streams, got %q", out)
+			}
+		})
+	}
+}
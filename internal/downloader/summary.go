@@ -0,0 +1,116 @@
+package downloader
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"sync"
+	"time"
+)
+
+// RunStats accumulates per-item outcomes across a run so a single summary can
+// be reported at the end. It is safe for concurrent use; a nil *RunStats
+// records nothing.
+type RunStats struct {
+	mu      sync.Mutex
+	start   time.Time
+	total   int
+	ok      int
+	failed  int
+	skipped int
+	bytes   int64
+}
+
+// NewRunStats returns an empty collector; the run's duration is measured
+// from this call.
+func NewRunStats() *RunStats {
+	return &RunStats{start: time.Now()}
+}
+
+// RunSummary is the JSON line emitted after a --json run.
+type RunSummary struct {
+	Type     string  `json:"type"`
+	Total    int     `json:"total"`
+	OK       int     `json:"ok"`
+	Failed   int     `json:"failed"`
+	Skipped  int     `json:"skipped"`
+	Bytes    int64   `json:"bytes"`
+	Duration float64 `json:"duration"`
+}
+
+func (s *RunStats) record(res jsonResult) {
+	if s == nil || res.Type != "item" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.total++
+	switch res.Status {
+	case "ok":
+		s.ok++
+	case "skip":
+		s.skipped++
+	default:
+		s.failed++
+	}
+	s.bytes += res.Bytes
+}
+
+// Total reports how many items have been recorded.
+func (s *RunStats) Total() int {
+	if s == nil {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.total
+}
+
+// RecordFailure counts a failure that happened before any item was reported,
+// such as an invalid URL or a metadata fetch error.
+func (s *RunStats) RecordFailure() {
+	s.record(jsonResult{Type: "item", Status: "error"})
+}
+
+// Merge adds the counts from other into s.
+func (s *RunStats) Merge(other *RunStats) {
+	if s == nil || other == nil {
+		return
+	}
+	other.mu.Lock()
+	total, ok, failed, skipped, bytes := other.total, other.ok, other.failed, other.skipped, other.bytes
+	other.mu.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.total += total
+	s.ok += ok
+	s.failed += failed
+	s.skipped += skipped
+	s.bytes += bytes
+}
+
+// Summary snapshots the counts, with duration as seconds elapsed since
+// NewRunStats.
+func (s *RunStats) Summary() RunSummary {
+	summary := RunSummary{Type: "summary"}
+	if s == nil {
+		return summary
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	summary.Duration = math.Round(time.Since(s.start).Seconds()*1000) / 1000
+	summary.Total = s.total
+	summary.OK = s.ok
+	summary.Failed = s.failed
+	summary.Skipped = s.skipped
+	summary.Bytes = s.bytes
+	return summary
+}
+
+// EmitJSONSummary writes summary as a single JSON line.
+func EmitJSONSummary(w io.Writer, summary RunSummary) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(summary)
+}
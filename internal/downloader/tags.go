@@ -59,21 +59,87 @@ func embedID3Tags(metadata ItemMetadata, outputPath string) error {
 	if metadata.Track != 0 {
 		tag.AddTextFrame(tag.CommonID("Track number/Position in set"), tag.DefaultEncoding(), strconv.Itoa(metadata.Track))
 	}
+	if metadata.Loudness != nil {
+		tag.AddUserDefinedTextFrame(id3v2.UserDefinedTextFrame{
+			Encoding:    tag.DefaultEncoding(),
+			Description: "REPLAYGAIN_TRACK_GAIN",
+			Value:       metadata.Loudness.TrackGainTag(),
+		})
+		tag.AddUserDefinedTextFrame(id3v2.UserDefinedTextFrame{
+			Encoding:    tag.DefaultEncoding(),
+			Description: "REPLAYGAIN_TRACK_PEAK",
+			Value:       metadata.Loudness.TrackPeakTag(),
+		})
+	}
 	return tag.Save()
 }
 
-// embedFFmpegTags uses ffmpeg to embed metadata into formats that don't support ID3.
-func embedFFmpegTags(metadata ItemMetadata, outputPath string) error {
-	if _, err := exec.LookPath("ffmpeg"); err != nil {
-		return fmt.Errorf("ffmpeg not found: %w", err)
+// RetagFile rewrites the tags of an existing file from metadata, as an audio
+// (-audio) or video (-embed-metadata) download would have embedded them.
+// MP3s are tagged directly; other containers need ffmpeg and are left alone
+// without it. It reports whether the file was rewritten.
+func RetagFile(outputPath string, metadata ItemMetadata, audio bool) (bool, error) {
+	ext := strings.ToLower(filepath.Ext(outputPath))
+	if audio && ext == ".mp3" {
+		return true, embedID3Tags(metadata, outputPath)
+	}
+	if !ffmpegAvailable() {
+		return false, nil
 	}
+	if audio {
+		switch ext {
+		case ".m4a", ".mp4", ".webm", ".opus", ".ogg", ".mkv":
+			return true, embedFFmpegTags(metadata, outputPath)
+		}
+		return false, nil
+	}
+	switch ext {
+	case ".mp4", ".m4v", ".mov", ".mkv", ".webm":
+		return true, remuxWithMetadata(outputPath, videoMetadataArgs(metadata))
+	}
+	return false, nil
+}
 
-	// Build metadata arguments
-	args := []string{
-		"-i", outputPath,
-		"-y",
-		"-c", "copy",
+// embedVideoTags writes title, artist, date and source URL into video
+// containers via ffmpeg. It is a no-op when ffmpeg is not installed.
+func embedVideoTags(metadata ItemMetadata, outputPath string, printer *Printer) {
+	if outputPath == "" || metadata.Status != "ok" || !ffmpegAvailable() {
+		return
+	}
+	ext := strings.ToLower(filepath.Ext(outputPath))
+	switch ext {
+	case ".mp4", ".m4v", ".mov", ".mkv", ".webm":
+	default:
+		return
+	}
+	if err := remuxWithMetadata(outputPath, videoMetadataArgs(metadata)); err != nil && printer != nil {
+		printer.Log(LogWarn, fmt.Sprintf("warning: video metadata embedding failed for %s: %v", ext, err))
 	}
+}
+
+// videoMetadataArgs builds the ffmpeg -metadata arguments for a video file.
+func videoMetadataArgs(metadata ItemMetadata) []string {
+	var args []string
+	if metadata.Title != "" {
+		args = append(args, "-metadata", "title="+metadata.Title)
+	}
+	if metadata.Artist != "" {
+		args = append(args, "-metadata", "artist="+metadata.Artist)
+	}
+	if metadata.ReleaseDate != "" {
+		args = append(args, "-metadata", "date="+metadata.ReleaseDate)
+	} else if metadata.ReleaseYear != 0 {
+		args = append(args, "-metadata", "date="+strconv.Itoa(metadata.ReleaseYear))
+	}
+	if metadata.SourceURL != "" {
+		args = append(args, "-metadata", "comment="+metadata.SourceURL)
+	}
+	return args
+}
+
+// embedFFmpegTags uses ffmpeg to embed metadata into formats that don't support ID3.
+func embedFFmpegTags(metadata ItemMetadata, outputPath string) error {
+	var args []string
 	if metadata.Title != "" {
 		args = append(args, "-metadata", "title="+metadata.Title)
 	}
@@ -89,13 +155,40 @@ func embedFFmpegTags(metadata ItemMetadata, outputPath string) error {
 	if metadata.Track != 0 {
 		args = append(args, "-metadata", "track="+strconv.Itoa(metadata.Track))
 	}
+	if metadata.Loudness != nil {
+		args = append(args,
+			"-metadata", "REPLAYGAIN_TRACK_GAIN="+metadata.Loudness.TrackGainTag(),
+			"-metadata", "REPLAYGAIN_TRACK_PEAK="+metadata.Loudness.TrackPeakTag(),
+		)
+		// MP4 containers drop non-standard keys unless asked to keep them.
+		switch strings.ToLower(filepath.Ext(outputPath)) {
+		case ".m4a", ".mp4":
+			args = append(args, "-movflags", "use_metadata_tags")
+		}
+	}
+	return remuxWithMetadata(outputPath, args)
+}
+
+// remuxWithMetadata copies outputPath through ffmpeg with the given metadata
+// arguments and replaces the original with the tagged result.
+func remuxWithMetadata(outputPath string, metadataArgs []string) error {
+	if _, err := exec.LookPath(ffmpegPath); err != nil {
+		return fmt.Errorf("ffmpeg not found: %w", err)
+	}
+
+	args := []string{
+		"-i", outputPath,
+		"-y",
+		"-c", "copy",
+	}
+	args = append(args, metadataArgs...)
 
 	// Write to a temp file then rename
 	dir := filepath.Dir(outputPath)
 	tmpFile := filepath.Join(dir, ".tmp_tagged_"+filepath.Base(outputPath))
 	args = append(args, tmpFile)
 
-	cmd := exec.Command("ffmpeg", args...)
+	cmd := exec.Command(ffmpegPath, args...)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		// Clean up temp file on failure
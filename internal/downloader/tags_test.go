@@ -0,0 +1,72 @@
+package downloader
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVideoMetadataArgs(t *testing.T) {
+	metadata := ItemMetadata{
+		Title:       "Clip",
+		Artist:      "Channel",
+		ReleaseDate: "2024-05-01",
+		ReleaseYear: 2024,
+		SourceURL:   "https://www.youtube.com/watch?v=abc",
+	}
+	got := strings.Join(videoMetadataArgs(metadata), " ")
+	want := "-metadata title=Clip -metadata artist=Channel -metadata date=2024-05-01 -metadata comment=https://www.youtube.com/watch?v=abc"
+	if got != want {
+		t.Fatalf("unexpected args:\n got %q\nwant %q", got, want)
+	}
+
+	yearOnly := strings.Join(videoMetadataArgs(ItemMetadata{ReleaseYear: 1999}), " ")
+	if yearOnly != "-metadata date=1999" {
+		t.Fatalf("expected year fallback for date, got %q", yearOnly)
+	}
+}
+
+func TestEmbedMetadataWritesVideoTags(t *testing.T) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		t.Skip("ffmpeg not available")
+	}
+	if _, err := exec.LookPath("ffprobe"); err != nil {
+		t.Skip("ffprobe not available")
+	}
+
+	baseDir := t.TempDir()
+	outputPath := filepath.Join(baseDir, "clip.mkv")
+	cmd := exec.Command("ffmpeg", "-hide_banner", "-loglevel", "error",
+		"-f", "lavfi", "-i", "testsrc=size=64x64:rate=5:duration=1",
+		"-y", outputPath)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Skipf("ffmpeg cannot encode test video: %v: %s", err, out)
+	}
+
+	metadata := ItemMetadata{
+		ID:          "vid123",
+		Title:       "Clip Title",
+		Artist:      "Clip Artist",
+		ReleaseDate: "2024-05-01",
+		SourceURL:   "https://www.youtube.com/watch?v=vid123",
+		Status:      "ok",
+		Output:      outputPath,
+	}
+	if err := finalizeDownloadMetadata(outputPath, baseDir, metadata, nil, Options{EmbedMetadata: true}, nil); err != nil {
+		t.Fatalf("finalizeDownloadMetadata: %v", err)
+	}
+
+	out, err := exec.Command("ffprobe", "-v", "error",
+		"-show_entries", "format_tags",
+		"-of", "default=noprint_wrappers=1", outputPath).Output()
+	if err != nil {
+		t.Fatalf("ffprobe: %v", err)
+	}
+	tags := strings.ToLower(string(out))
+	for _, want := range []string{"title=clip title", "artist=clip artist", "date=2024-05-01", "comment=https://www.youtube.com/watch?v=vid123"} {
+		if !strings.Contains(tags, want) {
+			t.Fatalf("expected %q in tags, got:\n%s", want, out)
+		}
+	}
+}
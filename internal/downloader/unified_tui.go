@@ -750,8 +750,12 @@ func (spr *seamlessProgressRenderer) Log(level LogLevel, msg string) {
 // the selected itag along with the SeamlessTUI for continued progress display.
 // The caller should call tui.TransitionToProgress() after selection, then use
 // the returned printer for download progress, and finally call tui.Stop() when done.
-func RunSeamlessFormatSelector(ctx context.Context, video *youtube.Video, title string, playlistID, playlistTitle string, index, total int) (selectedItag int, tui *SeamlessTUI, err error) {
+// With sortByQuality the best formats are listed first instead of by itag.
+func RunSeamlessFormatSelector(ctx context.Context, video *youtube.Video, title string, playlistID, playlistTitle string, index, total int, sortByQuality bool) (selectedItag int, tui *SeamlessTUI, err error) {
 	tui = NewSeamlessTUI(video, title)
+	if sortByQuality {
+		tui.model.formatSelector.sortByQuality()
+	}
 
 	// Update format selector with playlist context
 	tui.model.formatSelector.playlistID = playlistID
@@ -789,8 +793,9 @@ func NewSeamlessPrinter(opts Options, tui *SeamlessTUI) *Printer {
 	renderer := &seamlessProgressRenderer{tui: tui}
 
 	return &Printer{
-		quiet:           opts.Quiet,
-		color:           supportsColor(),
+		quiet:           opts.Quiet || opts.Silent,
+		silent:          opts.Silent,
+		color:           colorEnabled(opts.Color),
 		columns:         columns,
 		titleWidth:      titleWidth,
 		logLevel:        parseLogLevel(opts.LogLevel),
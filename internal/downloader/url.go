@@ -4,12 +4,16 @@ import (
 	"fmt"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 )
 
 var (
 	playlistIDRegex  = regexp.MustCompile(`^[A-Za-z0-9_-]{13,42}$`)
 	playlistURLRegex = regexp.MustCompile(`[?&]list=([A-Za-z0-9_-]{13,42})`)
+	// timestampParamRegex matches unit-suffixed t= values like 1h2m3s.
+	timestampParamRegex = regexp.MustCompile(`^(?:(\d+)h)?(?:(\d+)m)?(?:(\d+)s)?$`)
 )
 
 func validateInputURL(raw string) (string, error) {
@@ -32,6 +36,41 @@ func looksLikePlaylist(url string) bool {
 	return playlistIDRegex.MatchString(url) || playlistURLRegex.MatchString(url)
 }
 
+// shouldProcessAsPlaylist decides whether a URL is handled as a playlist.
+// Watch URLs that carry both a video ID and a list download the whole list
+// unless NoPlaylist is set; YesPlaylist always forces playlist mode.
+func shouldProcessAsPlaylist(raw string, opts Options) bool {
+	if !looksLikePlaylist(raw) {
+		return false
+	}
+	if opts.YesPlaylist || !opts.NoPlaylist {
+		return true
+	}
+	return videoIDFromURL(raw) == ""
+}
+
+// singleVideoURL strips playlist parameters from a watch URL so only the
+// referenced video is fetched.
+func singleVideoURL(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	query := parsed.Query()
+	query.Del("list")
+	query.Del("index")
+	parsed.RawQuery = query.Encode()
+	return parsed.String()
+}
+
+func videoIDFromURL(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return parsed.Query().Get("v")
+}
+
 func isYouTubeURL(raw string) bool {
 	parsed, err := url.Parse(raw)
 	if err != nil {
@@ -74,14 +113,14 @@ func ConvertMusicURL(u string) string {
 	return parsed.String()
 }
 
-// NormalizeYouTubeURL converts alternate YouTube URL forms (live/shorts/youtu.be) to watch?v=.
+// NormalizeYouTubeURL converts alternate YouTube URL forms (live/shorts/embed/v/youtu.be) to watch?v=.
 func NormalizeYouTubeURL(u string) string {
 	parsed, err := url.Parse(u)
 	if err != nil {
 		return u
 	}
 	host := normalizeHostname(parsed)
-	if host != "youtube.com" && host != "youtu.be" {
+	if host != "youtube.com" && host != "youtu.be" && host != "youtube-nocookie.com" {
 		return u
 	}
 	query := parsed.Query()
@@ -96,7 +135,21 @@ func NormalizeYouTubeURL(u string) string {
 	}
 
 	parts := strings.Split(strings.Trim(parsed.Path, "/"), "/")
-	if len(parts) >= 2 && (parts[0] == "live" || parts[0] == "shorts") {
+	if host == "youtube-nocookie.com" {
+		// Privacy-enhanced embeds only differ from youtube.com by host.
+		if len(parts) < 2 || parts[0] != "embed" {
+			return u
+		}
+		parsed.Host = "www.youtube.com"
+	}
+	if len(parts) >= 2 && parts[0] == "embed" && parts[1] == "videoseries" {
+		// Playlist embeds carry the playlist in ?list=.
+		parsed.Path = "/playlist"
+		return parsed.String()
+	}
+	// Embed (/embed/ID) and legacy Flash (/v/ID) links keep their query, so
+	// t= and start= survive the rewrite.
+	if len(parts) >= 2 && (parts[0] == "live" || parts[0] == "shorts" || parts[0] == "embed" || parts[0] == "v") {
 		if query.Get("v") == "" && parts[1] != "" {
 			query.Set("v", parts[1])
 		}
@@ -117,6 +170,57 @@ func isMusicYouTubeURL(u string) bool {
 	return normalizeHostname(parsed) == "music.youtube.com"
 }
 
+// urlStartOffset returns the playback start carried by a URL's t= or start=
+// parameter, or 0 when there is none.
+func urlStartOffset(raw string) time.Duration {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return 0
+	}
+	query := parsed.Query()
+	for _, key := range []string{"t", "start"} {
+		if offset, ok := parseTimestampParam(query.Get(key)); ok {
+			return offset
+		}
+	}
+	return 0
+}
+
+// parseTimestampParam parses YouTube timestamp values: plain seconds ("90"),
+// or h/m/s units ("90s", "1m30s", "1h2m3s").
+func parseTimestampParam(value string) (time.Duration, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds <= 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	match := timestampParamRegex.FindStringSubmatch(value)
+	if match == nil {
+		return 0, false
+	}
+	var total time.Duration
+	units := []time.Duration{time.Hour, time.Minute, time.Second}
+	for i, unit := range units {
+		if match[i+1] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(match[i+1])
+		if err != nil {
+			return 0, false
+		}
+		total += time.Duration(n) * unit
+	}
+	if total <= 0 {
+		return 0, false
+	}
+	return total, true
+}
+
 func watchURLForID(id string) string {
 	if id == "" {
 		return ""
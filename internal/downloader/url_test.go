@@ -0,0 +1,100 @@
+package downloader
+
+import (
+	"testing"
+	"time"
+)
+
+func TestShouldProcessAsPlaylistWatchWithList(t *testing.T) {
+	const watchWithList = "https://www.youtube.com/watch?v=dQw4w9WgXcQ&list=UUuAXFkgsw1L7xaCfnd5JJOw"
+
+	if !shouldProcessAsPlaylist(watchWithList, Options{}) {
+		t.Fatalf("expected playlist mode by default for watch+list URL")
+	}
+	if shouldProcessAsPlaylist(watchWithList, Options{NoPlaylist: true}) {
+		t.Fatalf("expected single video mode with NoPlaylist")
+	}
+	if !shouldProcessAsPlaylist(watchWithList, Options{YesPlaylist: true}) {
+		t.Fatalf("expected playlist mode with YesPlaylist")
+	}
+}
+
+func TestShouldProcessAsPlaylistNoPlaylistKeepsPurePlaylists(t *testing.T) {
+	const playlistURL = "https://www.youtube.com/playlist?list=PLxA687tYuMWhkqYjvAGtW_heiEL4Hk_Lx"
+	if !shouldProcessAsPlaylist(playlistURL, Options{NoPlaylist: true}) {
+		t.Fatalf("expected playlist URL without a video ID to stay in playlist mode")
+	}
+	if shouldProcessAsPlaylist("https://www.youtube.com/watch?v=dQw4w9WgXcQ", Options{YesPlaylist: true}) {
+		t.Fatalf("expected plain watch URL to stay single video mode")
+	}
+}
+
+func TestSingleVideoURLStripsPlaylistParams(t *testing.T) {
+	got := singleVideoURL("https://www.youtube.com/watch?v=dQw4w9WgXcQ&list=UUuAXFkgsw1L7xaCfnd5JJOw&index=3")
+	if got != "https://www.youtube.com/watch?v=dQw4w9WgXcQ" {
+		t.Fatalf("unexpected single video URL %q", got)
+	}
+}
+
+func TestNormalizeYouTubeURLEmbedAndLegacyForms(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "embed", in: "https://www.youtube.com/embed/dQw4w9WgXcQ", want: "https://www.youtube.com/watch?v=dQw4w9WgXcQ"},
+		{name: "embed with start", in: "https://www.youtube.com/embed/dQw4w9WgXcQ?start=42", want: "https://www.youtube.com/watch?start=42&v=dQw4w9WgXcQ"},
+		{name: "embed with t", in: "https://youtube.com/embed/dQw4w9WgXcQ?t=30s", want: "https://youtube.com/watch?t=30s&v=dQw4w9WgXcQ"},
+		{name: "legacy v", in: "https://www.youtube.com/v/dQw4w9WgXcQ", want: "https://www.youtube.com/watch?v=dQw4w9WgXcQ"},
+		{name: "legacy v with t", in: "https://www.youtube.com/v/dQw4w9WgXcQ?t=30s", want: "https://www.youtube.com/watch?t=30s&v=dQw4w9WgXcQ"},
+		{name: "nocookie embed", in: "https://www.youtube-nocookie.com/embed/dQw4w9WgXcQ?t=30s", want: "https://www.youtube.com/watch?t=30s&v=dQw4w9WgXcQ"},
+		{name: "playlist embed", in: "https://www.youtube.com/embed/videoseries?list=PL123", want: "https://www.youtube.com/playlist?list=PL123"},
+		{name: "nocookie non-embed untouched", in: "https://www.youtube-nocookie.com/about", want: "https://www.youtube-nocookie.com/about"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeYouTubeURL(tt.in); got != tt.want {
+				t.Fatalf("NormalizeYouTubeURL(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTimestampParam(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   time.Duration
+		wantOK bool
+	}{
+		{in: "90", want: 90 * time.Second, wantOK: true},
+		{in: "90s", want: 90 * time.Second, wantOK: true},
+		{in: "1m30s", want: 90 * time.Second, wantOK: true},
+		{in: "1h2m3s", want: time.Hour + 2*time.Minute + 3*time.Second, wantOK: true},
+		{in: "2m", want: 2 * time.Minute, wantOK: true},
+		{in: "", wantOK: false},
+		{in: "0", wantOK: false},
+		{in: "abc", wantOK: false},
+		{in: "1m30", wantOK: false},
+	}
+	for _, tt := range tests {
+		got, ok := parseTimestampParam(tt.in)
+		if ok != tt.wantOK || got != tt.want {
+			t.Fatalf("parseTimestampParam(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestURLStartOffset(t *testing.T) {
+	if got := urlStartOffset("https://www.youtube.com/watch?v=dQw4w9WgXcQ&t=1m30s"); got != 90*time.Second {
+		t.Fatalf("expected 90s from t=, got %v", got)
+	}
+	if got := urlStartOffset("https://www.youtube.com/watch?v=dQw4w9WgXcQ&start=90"); got != 90*time.Second {
+		t.Fatalf("expected 90s from start=, got %v", got)
+	}
+	if got := urlStartOffset(NormalizeYouTubeURL("https://youtu.be/dQw4w9WgXcQ?t=90")); got != 90*time.Second {
+		t.Fatalf("expected t= to survive normalization, got %v", got)
+	}
+	if got := urlStartOffset("https://www.youtube.com/watch?v=dQw4w9WgXcQ"); got != 0 {
+		t.Fatalf("expected no offset, got %v", got)
+	}
+}
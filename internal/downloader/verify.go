@@ -0,0 +1,86 @@
+package downloader
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// verifyMinTolerance is the smallest allowed gap between the expected and
+// probed duration; container rounding alone can account for a second or so.
+const verifyMinTolerance = 2 * time.Second
+
+// verifyToleranceRatio is the allowed gap as a fraction of the expected duration.
+const verifyToleranceRatio = 0.02
+
+var probeDurationFn = probeDuration
+
+// ffprobeAvailable checks if ffprobe is installed and accessible
+func ffprobeAvailable() bool {
+	_, err := exec.LookPath(ffprobePath)
+	return err == nil
+}
+
+// probeDuration asks ffprobe for the container duration of a media file.
+func probeDuration(path string) (time.Duration, error) {
+	cmd := exec.Command(ffprobePath,
+		"-v", "error",
+		"-show_entries", "format=duration",
+		"-of", "default=noprint_wrappers=1:nokey=1",
+		path,
+	)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		stderr := strings.TrimSpace(string(output))
+		if stderr != "" {
+			return 0, fmt.Errorf("ffprobe failed: %s: %w", stderr, err)
+		}
+		return 0, fmt.Errorf("ffprobe failed: %w", err)
+	}
+	value := strings.TrimSpace(string(output))
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("ffprobe returned invalid duration %q", value)
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// verifyDownloadDuration compares the probed duration of path against the
+// duration YouTube reported. A short file usually means the stream was cut
+// off mid-transfer, so a mismatch is reported as a network error.
+func verifyDownloadDuration(path string, expected time.Duration) error {
+	if expected <= 0 {
+		return nil
+	}
+	actual, err := probeDurationFn(path)
+	if err != nil {
+		return wrapCategory(CategoryNetwork, fmt.Errorf("verify failed: %w", err))
+	}
+	tolerance := time.Duration(float64(expected) * verifyToleranceRatio)
+	if tolerance < verifyMinTolerance {
+		tolerance = verifyMinTolerance
+	}
+	diff := expected - actual
+	if diff < 0 {
+		diff = -diff
+	}
+	if diff > tolerance {
+		return wrapCategory(CategoryNetwork, fmt.Errorf("verify failed: duration %s does not match expected %s (likely truncated or corrupt)", actual.Round(time.Second), expected.Round(time.Second)))
+	}
+	return nil
+}
+
+// verifyIfRequested runs the --verify duration check when enabled. Without
+// ffprobe the check is skipped with a warning rather than failing the download.
+func verifyIfRequested(opts Options, path string, expected time.Duration, printer *Printer) error {
+	if !opts.Verify || path == "" {
+		return nil
+	}
+	if !ffprobeAvailable() {
+		printer.Log(LogWarn, "warning: --verify skipped: ffprobe not found")
+		return nil
+	}
+	return verifyDownloadDuration(path, expected)
+}
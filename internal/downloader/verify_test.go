@@ -0,0 +1,56 @@
+package downloader
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestVerifyDownloadDurationFlagsShortFile(t *testing.T) {
+	restore := probeDurationFn
+	probeDurationFn = func(string) (time.Duration, error) { return 30 * time.Second, nil }
+	defer func() { probeDurationFn = restore }()
+
+	err := verifyDownloadDuration("short.mp4", 3*time.Minute)
+	if err == nil {
+		t.Fatalf("expected truncated file to fail verification")
+	}
+	if errorCategory(err) != CategoryNetwork {
+		t.Fatalf("expected network category, got %s", errorCategory(err))
+	}
+}
+
+func TestVerifyDownloadDurationWithinTolerance(t *testing.T) {
+	restore := probeDurationFn
+	probeDurationFn = func(string) (time.Duration, error) { return 179 * time.Second, nil }
+	defer func() { probeDurationFn = restore }()
+
+	if err := verifyDownloadDuration("full.mp4", 3*time.Minute); err != nil {
+		t.Fatalf("expected duration within tolerance to pass, got %v", err)
+	}
+	if err := verifyDownloadDuration("live.mp4", 0); err != nil {
+		t.Fatalf("expected unknown duration to skip verification, got %v", err)
+	}
+}
+
+func TestVerifyDownloadDurationWithFFprobe(t *testing.T) {
+	if !ffprobeAvailable() || !ffmpegAvailable() {
+		t.Skip("ffmpeg/ffprobe not available")
+	}
+
+	path := filepath.Join(t.TempDir(), "short.m4a")
+	cmd := exec.Command("ffmpeg", "-hide_banner", "-loglevel", "error",
+		"-f", "lavfi", "-i", "sine=frequency=440:duration=1",
+		"-c:a", "aac", "-y", path)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Skipf("ffmpeg cannot encode aac: %v: %s", err, out)
+	}
+
+	if err := verifyDownloadDuration(path, time.Second); err != nil {
+		t.Fatalf("expected 1s file to match 1s duration, got %v", err)
+	}
+	if err := verifyDownloadDuration(path, time.Minute); err == nil {
+		t.Fatalf("expected 1s file to fail verification against 1m duration")
+	}
+}
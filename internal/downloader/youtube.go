@@ -9,6 +9,8 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 
 	youtube "github.com/lvcoi/ytdl-lib/v2"
@@ -17,7 +19,7 @@ import (
 
 const (
 	minChunkSize     int64 = 256 * 1024      // 256KB keeps progress responsive on small files
-	maxChunkSize     int64 = 2 * 1024 * 1024 // cap to avoid excessive requests on large files
+	maxChunkSize     int64 = 2 * 1024 * 1024 // starting cap; sustained success grows past it
 	targetChunkCount int64 = 64
 )
 
@@ -27,24 +29,23 @@ func adjustChunkSize(client YouTubeClient, contentLength int64) {
 	if client == nil || contentLength <= 0 {
 		return
 	}
-	chunk := contentLength / targetChunkCount
-	if chunk < minChunkSize {
-		chunk = minChunkSize
-	} else if chunk > maxChunkSize {
-		chunk = maxChunkSize
-	}
-	client.SetChunkSize(chunk)
+	client.SetChunkSize(chunkSizeFor(contentLength))
 }
 
 // ffmpegAvailable checks if ffmpeg is installed and accessible
 func ffmpegAvailable() bool {
-	_, err := exec.LookPath("ffmpeg")
+	_, err := exec.LookPath(ffmpegPath)
 	return err == nil
 }
 
-// downloadWithFFmpegFallback downloads a progressive format and extracts audio using ffmpeg
+// downloadWithFFmpegFallback downloads a progressive format and extracts audio using ffmpeg.
+// It refuses to start without ffmpeg, since the progressive download would be
+// wasted.
 func downloadWithFFmpegFallback(ctx context.Context, client YouTubeClient, video *youtube.Video, opts Options, printer *Printer, prefix string, audioOutputPath, baseDir string, progress *progressWriter) (downloadResult, error) {
 	result := downloadResult{}
+	if !ffmpegAvailable() {
+		return result, wrapCategory(CategoryUnsupported, errors.New("ffmpeg fallback requires ffmpeg (install it or set -ffmpeg-location)"))
+	}
 
 	// Find the best progressive format with high-quality audio
 	var progressiveFormat *youtube.Format
@@ -100,7 +101,7 @@ func downloadWithFFmpegFallback(ctx context.Context, client YouTubeClient, video
 		if progress != nil {
 			progress.Reset(size)
 		} else {
-			progress = newProgressWriter(size, printer, prefix)
+			progress = newProgressWriter(size, printer, prefix, audioOutputPath)
 		}
 		writer = io.MultiWriter(tempFile, progress)
 	}
@@ -115,8 +116,12 @@ func downloadWithFFmpegFallback(ctx context.Context, client YouTubeClient, video
 
 	// Extract audio using ffmpeg
 	printer.Log(LogInfo, "step 2/3: extracting audio track")
-	printer.Log(LogInfo, "step 3/3: encoding to Opus @ 160kbps")
-	if err := extractAudio(tempVideoPath, audioOutputPath); err != nil {
+	if opts.AudioFormat != "" {
+		printer.Log(LogInfo, fmt.Sprintf("step 3/3: encoding to %s", opts.AudioFormat))
+	} else {
+		printer.Log(LogInfo, "step 3/3: encoding to Opus @ 160kbps")
+	}
+	if err := extractAudio(tempVideoPath, audioOutputPath, progressiveFormat.MimeType, opts.AudioFormat, opts.AudioQuality); err != nil {
 		return result, wrapCategory(CategoryFilesystem, fmt.Errorf("ffmpeg extraction failed: %w", err))
 	}
 
@@ -125,37 +130,201 @@ func downloadWithFFmpegFallback(ctx context.Context, client YouTubeClient, video
 		result.bytes = fi.Size()
 	}
 	result.outputPath = audioOutputPath
-	printer.Log(LogInfo, fmt.Sprintf("complete: %s (Opus audio)", filepath.Base(audioOutputPath)))
+	printer.Log(LogInfo, fmt.Sprintf("complete: %s (%s audio)", filepath.Base(audioOutputPath), audioFormatLabel(opts.AudioFormat)))
 	return result, nil
 }
 
-// extractAudio extracts audio from a video file using ffmpeg
-func extractAudio(inputPath, outputPath string) error {
-	// Determine output format from extension
-	ext := strings.ToLower(filepath.Ext(outputPath))
+// audioFormats are the codecs accepted by --audio-format.
+var audioFormats = []string{"mp3", "m4a", "opus", "flac", "wav"}
+
+// ValidAudioFormat reports whether format is a supported --audio-format value.
+// An empty format means "derive the codec from the output extension".
+func ValidAudioFormat(format string) bool {
+	if format == "" {
+		return true
+	}
+	for _, candidate := range audioFormats {
+		if format == candidate {
+			return true
+		}
+	}
+	return false
+}
+
+func audioFormatLabel(format string) string {
+	if format == "" {
+		return "Opus"
+	}
+	return strings.ToUpper(format)
+}
+
+// withAudioExtension replaces the extension of path with the one implied by
+// --audio-format so the filename matches the encoded codec.
+func withAudioExtension(path, format string) string {
+	if format == "" {
+		return path
+	}
+	return strings.TrimSuffix(path, filepath.Ext(path)) + "." + format
+}
+
+// audioCodecKwargs maps an output extension (without the dot) to the ffmpeg
+// arguments used to encode it.
+func audioCodecKwargs(ext string) ffmpeg.KwArgs {
 	kwargs := ffmpeg.KwArgs{"vn": ""}
 
 	switch ext {
-	case ".mp3":
+	case "mp3":
 		kwargs["acodec"] = "libmp3lame"
 		kwargs["q:a"] = "2"
-	case ".m4a", ".aac":
+	case "m4a", "aac":
 		kwargs["acodec"] = "aac"
 		kwargs["b:a"] = "192k"
-	case ".opus":
+	case "opus":
 		kwargs["acodec"] = "libopus"
 		kwargs["b:a"] = "160k" // Match itag 251 quality
-	case ".webm":
+	case "webm":
 		kwargs["acodec"] = "libopus"
 		kwargs["b:a"] = "160k" // Match itag 251 quality
+	case "flac":
+		kwargs["acodec"] = "flac"
+	case "wav":
+		kwargs["acodec"] = "pcm_s16le"
 	default:
 		// Copy audio codec if possible
 		kwargs["acodec"] = "copy"
 	}
+	return kwargs
+}
+
+var (
+	audioBitrateRegex = regexp.MustCompile(`^(\d+)k$`)
+	audioVBRRegex     = regexp.MustCompile(`^[0-9]$`)
+)
+
+const (
+	minAudioBitrateKbps = 8
+	maxAudioBitrateKbps = 512
+)
+
+// ValidateAudioQuality checks an --audio-quality value. Bitrates like 256k
+// apply to lossy codecs; a 0-9 VBR level applies to mp3 only. When ext is
+// empty the codec is not known yet and only the syntax is checked.
+func ValidateAudioQuality(quality, ext string) error {
+	if quality == "" {
+		return nil
+	}
+	if match := audioBitrateRegex.FindStringSubmatch(quality); match != nil {
+		kbps, _ := strconv.Atoi(match[1])
+		if kbps < minAudioBitrateKbps || kbps > maxAudioBitrateKbps {
+			return fmt.Errorf("audio bitrate %s out of range (%dk-%dk)", quality, minAudioBitrateKbps, maxAudioBitrateKbps)
+		}
+		switch ext {
+		case "", "mp3", "m4a", "aac", "opus", "webm":
+			return nil
+		}
+		return fmt.Errorf("audio quality %s not supported for %s", quality, ext)
+	}
+	if audioVBRRegex.MatchString(quality) {
+		if ext == "" || ext == "mp3" {
+			return nil
+		}
+		return fmt.Errorf("VBR quality %s is only supported for mp3", quality)
+	}
+	return fmt.Errorf("invalid audio quality %q (expected a bitrate like 256k or an mp3 VBR level 0-9)", quality)
+}
+
+// applyAudioQuality replaces the default bitrate settings in kwargs with the
+// requested --audio-quality.
+func applyAudioQuality(kwargs ffmpeg.KwArgs, ext, quality string) error {
+	if quality == "" {
+		return nil
+	}
+	if err := ValidateAudioQuality(quality, ext); err != nil {
+		return err
+	}
+	delete(kwargs, "b:a")
+	delete(kwargs, "q:a")
+	if audioVBRRegex.MatchString(quality) {
+		kwargs["q:a"] = quality
+	} else {
+		kwargs["b:a"] = quality
+	}
+	return nil
+}
+
+// sourceAudioCodec returns the audio codec named in a format's MIME type
+// codecs parameter, normalized to the names used by targetAudioCodec, or ""
+// when none is recognized.
+func sourceAudioCodec(mimeType string) string {
+	_, params, ok := strings.Cut(mimeType, "codecs=")
+	if !ok {
+		return ""
+	}
+	for _, codec := range strings.Split(strings.Trim(params, `"' `), ",") {
+		codec = strings.ToLower(strings.TrimSpace(codec))
+		switch {
+		case codec == "opus":
+			return "opus"
+		case strings.HasPrefix(codec, "mp4a.40"):
+			return "aac"
+		case codec == "mp3" || codec == "mp4a.6b" || codec == "mp4a.69":
+			return "mp3"
+		case codec == "flac":
+			return "flac"
+		}
+	}
+	return ""
+}
+
+// targetAudioCodec returns the codec audioCodecKwargs encodes ext to, or ""
+// when the output is never a straight copy (wav is always converted to PCM).
+func targetAudioCodec(ext string) string {
+	switch ext {
+	case "mp3":
+		return "mp3"
+	case "m4a", "aac":
+		return "aac"
+	case "opus", "webm":
+		return "opus"
+	case "flac":
+		return "flac"
+	}
+	return ""
+}
+
+// extractAudioKwargs picks the ffmpeg output arguments for extractAudio. When
+// the source already carries the target codec and no quality was requested,
+// the audio stream is copied instead of re-encoded.
+func extractAudioKwargs(sourceMime, ext, audioQuality string) (ffmpeg.KwArgs, error) {
+	if codec := targetAudioCodec(ext); codec != "" && audioQuality == "" && codec == sourceAudioCodec(sourceMime) {
+		return ffmpeg.KwArgs{"vn": "", "acodec": "copy"}, nil
+	}
+	kwargs := audioCodecKwargs(ext)
+	if err := applyAudioQuality(kwargs, ext, audioQuality); err != nil {
+		return nil, err
+	}
+	return kwargs, nil
+}
+
+// extractAudio extracts audio from a video file using ffmpeg. sourceMime is
+// the downloaded format's MIME type, used to skip re-encoding when it already
+// matches the output codec. audioFormat, when set, overrides the codec that
+// would be derived from the extension; audioQuality overrides the codec's
+// default bitrate.
+func extractAudio(inputPath, outputPath, sourceMime, audioFormat, audioQuality string) error {
+	ext := audioFormat
+	if ext == "" {
+		ext = strings.TrimPrefix(strings.ToLower(filepath.Ext(outputPath)), ".")
+	}
+	kwargs, err := extractAudioKwargs(sourceMime, ext, audioQuality)
+	if err != nil {
+		return err
+	}
 
 	return ffmpeg.Input(inputPath).
 		Output(outputPath, kwargs).
 		OverWriteOutput().
+		SetFfmpegPath(ffmpegPath).
 		Silent(true).
 		Run()
 }
@@ -165,6 +334,17 @@ func downloadVideo(ctx context.Context, client YouTubeClient, video *youtube.Vid
 		format     *youtube.Format
 		outputPath string
 	)
+	// outputRoot is the output directory before any --paths routing.
+	outputRoot := opts.OutputDir
+	// Each download can be cancelled on its own from the progress UI.
+	ctx, release := printer.itemContext(ctx, prefix)
+	defer release()
+	checksum := newOutputChecksum(opts)
+	if printer != nil {
+		if jsonProgress, ok := printer.renderer.(*jsonProgressRenderer); ok {
+			jsonProgress.SetItem(video.ID)
+		}
+	}
 	defer func() {
 		if outputPath == "" || result.skipped {
 			return
@@ -180,28 +360,77 @@ func downloadVideo(ctx context.Context, client YouTubeClient, video *youtube.Vid
 		}
 
 		metadata := buildItemMetadata(video, effectiveFormat, ctxInfo, outputPath, status, err)
-		if metaErr := finalizeDownloadMetadata(outputPath, opts.OutputDir, metadata, opts.AudioOnly, printer); metaErr != nil && err == nil {
+		if metaErr := finalizeDownloadMetadata(outputPath, opts.OutputDir, metadata, checksum, opts, printer); metaErr != nil && err == nil {
 			err = metaErr
 		}
+		metadata.SHA256 = checksum.Sum()
+		result.sha256 = checksum.Sum()
+		if err == nil {
+			if archiveErr := opts.Archive.Record(video.ID, effectiveFormat); archiveErr != nil {
+				printer.Log(LogWarn, fmt.Sprintf("warning: %v", archiveErr))
+			}
+			setUploadMtime(outputPath, video, opts, printer)
+			runPostDownloadExec(ctx, opts, metadata, printer)
+			runPrintToFile(opts, metadata, printer)
+			if opts.OnItemComplete != nil {
+				opts.OnItemComplete(metadata, result.bytes)
+			}
+		}
 	}()
 
 	result = downloadResult{}
 	format, err = selectFormat(video, opts)
+	if errors.Is(err, errBelowMinFileSize) {
+		result.skipped = true
+		result.skipReason = "below min-filesize"
+		return result, nil
+	}
 	if err != nil {
 		if errorCategory(err) == CategoryUnsupported {
 			if video.HLSManifestURL != "" || video.DASHManifestURL != "" {
+				if reason := opts.Archive.skipReason(video.ID, nil, opts); reason != "" {
+					result.skipped = true
+					result.skipReason = reason
+					return result, nil
+				}
 				result, err = downloadAdaptive(ctx, client, video, opts, ctxInfo, printer, prefix, err)
 				outputPath = result.outputPath
+				if err == nil && !result.skipped {
+					err = finishDownload(ctx, opts, ctxInfo, outputPath, outputRoot, video, printer)
+				}
+				if result.format != nil {
+					// Keep the sidecar next to a routed adaptive output.
+					if routed, routeErr := routedOutputDir(opts, result.format); routeErr == nil {
+						opts.OutputDir = routed
+					}
+				}
 				return result, err
 			}
 		}
 		return result, err
 	}
+	if reason := opts.Archive.skipReason(video.ID, format, opts); reason != "" {
+		result.skipped = true
+		result.skipReason = reason
+		return result, nil
+	}
 
+	if opts.OutputDir, err = routedOutputDir(opts, format); err != nil {
+		return result, wrapCategory(CategoryFilesystem, err)
+	}
 	outputPath, err = resolveOutputPath(opts.OutputTemplate, video, format, ctxInfo, opts.OutputDir)
 	if err != nil {
 		return result, wrapCategory(CategoryFilesystem, err)
 	}
+	transcode := opts.AudioOnly && opts.AudioFormat != ""
+	if transcode && !ffmpegAvailable() {
+		// An explicit codec is a promise about the output file; don't
+		// silently save a different container.
+		return result, wrapCategory(CategoryUnsupported, fmt.Errorf("-audio-format %s requires ffmpeg (install it or set -ffmpeg-location)", opts.AudioFormat))
+	}
+	if transcode {
+		outputPath = withAudioExtension(outputPath, opts.AudioFormat)
+	}
 	outputPath, skip, err := handleExistingPath(outputPath, opts.OutputDir, opts, printer)
 	if err != nil {
 		return result, err
@@ -217,14 +446,49 @@ func downloadVideo(ctx context.Context, client YouTubeClient, video *youtube.Vid
 		return result, wrapCategory(CategoryFilesystem, fmt.Errorf("creating output directory: %w", err))
 	}
 
-	file, err := os.Create(outputPath)
+	// The stream lands in <output>.part and is renamed once complete, so a
+	// cancelled or failed download never leaves a truncated file under the
+	// final name. Progressive downloads keep no resume state, so the part is
+	// removed unless --continue-from-browser-download adopted it. With
+	// --audio-format it is a temp file that is transcoded into outputPath
+	// instead.
+	downloadPath, err := artifactPath(outputPath, partSuffix, opts.OutputDir)
+	if transcode {
+		downloadPath, err = artifactPath(outputPath, ".tmp."+mimeToExt(format.MimeType), opts.OutputDir)
+	}
+	if err != nil {
+		return result, wrapCategory(CategoryFilesystem, err)
+	}
+
+	// A part left by another tool is continued with ranged requests, which
+	// need the format's size; it also lets a part that is too long be
+	// recognised as not belonging to this format.
+	var offset int64
+	if opts.AdoptPartial && !opts.NoResume && !transcode && format.ContentLength > 0 {
+		offset = adoptablePartSize(downloadPath, format.ContentLength, printer)
+	}
+	if opts.AdoptPartial && !transcode {
+		defer func() {
+			if err != nil {
+				cleanupFailedArtifacts(ctx, opts, downloadPath)
+			}
+		}()
+	} else {
+		defer os.Remove(downloadPath)
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if offset > 0 {
+		flags = os.O_WRONLY | os.O_APPEND
+		printer.Log(LogInfo, fmt.Sprintf("continuing %s from %d bytes", filepath.Base(downloadPath), offset))
+	}
+	file, err := os.OpenFile(downloadPath, flags, 0o644)
 	if err != nil {
 		return result, wrapCategory(CategoryFilesystem, fmt.Errorf("opening output file: %w", err))
 	}
 	defer file.Close()
 
-	adjustChunkSize(client, format.ContentLength)
-	stream, size, err := client.GetStreamContext(ctx, video, format)
+	stream, size, err := openProgressiveStream(ctx, client, video, format, offset)
 	if err != nil {
 		return result, wrapCategory(CategoryNetwork, fmt.Errorf("starting stream: %w", err))
 	}
@@ -237,65 +501,105 @@ func downloadVideo(ctx context.Context, client YouTubeClient, video *youtube.Vid
 		}
 	}()
 
+	// A continued part is only partly copied here, so its checksum is taken
+	// from the finished file instead.
+	streamed := offset == 0
 	var writer io.Writer = file
+	if streamed {
+		writer = checksum.Writer(file)
+	}
 	var progress *progressWriter
 	if !opts.Quiet || opts.Renderer != nil {
-		progress = newProgressWriter(size, printer, prefix)
-		writer = io.MultiWriter(file, progress)
+		progress = newProgressWriter(size, printer, prefix, outputPath)
+		progress.SetCurrent(offset)
+		writer = io.MultiWriter(writer, progress)
 	}
 	result.hadProgress = progress != nil
 
+	// restartStream discards what was written so far and downloads f again
+	// from the beginning.
+	restartStream := func(v *youtube.Video, f *youtube.Format) (int64, error) {
+		if _, seekErr := file.Seek(0, 0); seekErr != nil {
+			return 0, fmt.Errorf("retry failed: %w", seekErr)
+		}
+		if truncErr := file.Truncate(0); truncErr != nil {
+			return 0, fmt.Errorf("retry failed: %w", truncErr)
+		}
+
+		stream.Close()
+		stream = nil
+		var streamErr error
+		stream, size, streamErr = openProgressiveStream(ctx, client, v, f, 0)
+		if streamErr != nil {
+			return 0, wrapCategory(CategoryNetwork, fmt.Errorf("retry failed: %w", streamErr))
+		}
+		if size <= 0 && format.ContentLength > 0 {
+			size = format.ContentLength
+		}
+
+		offset = 0
+		streamed = true
+		checksum.Reset()
+		writer = checksum.Writer(file)
+		if !opts.Quiet || opts.Renderer != nil {
+			if progress != nil {
+				progress.Reset(size)
+			} else {
+				progress = newProgressWriter(size, printer, prefix, outputPath)
+			}
+			writer = io.MultiWriter(writer, progress)
+		} else {
+			progress = nil
+		}
+		result.hadProgress = progress != nil
+		return copyWithContext(ctx, writer, stream)
+	}
+
 	written, err := copyWithContext(ctx, writer, stream)
 	if err != nil {
 		if isUnexpectedStatus(err, http.StatusForbidden) {
 			printer.Log(LogWarn, "warning: 403 from chunked download, retrying with single request")
-			if _, seekErr := file.Seek(0, 0); seekErr != nil {
-				return result, fmt.Errorf("retry failed: %w", seekErr)
-			}
-			if truncErr := file.Truncate(0); truncErr != nil {
-				return result, fmt.Errorf("retry failed: %w", truncErr)
-			}
-
 			formatSingle := *format
 			formatSingle.ContentLength = 0
+			written, err = restartStream(video, &formatSingle)
+			result.retried = true
 
-			stream.Close()
-			stream = nil
-			stream, size, err = client.GetStreamContext(ctx, video, &formatSingle)
-			if err != nil {
-				return result, wrapCategory(CategoryNetwork, fmt.Errorf("retry failed: %w", err))
-			}
-			if size <= 0 && format.ContentLength > 0 {
-				size = format.ContentLength
-			}
-
-			writer = file
-			if !opts.Quiet || opts.Renderer != nil {
-				if progress != nil {
-					progress.Reset(size)
+			// A 403 that survives the single request usually means the
+			// stream URL expired; fetch fresh metadata and try the same
+			// itag once more.
+			if isUnexpectedStatus(err, http.StatusForbidden) {
+				if freshVideo, freshFormat, refreshErr := refreshFormat(ctx, client, video.ID, format.ItagNo); refreshErr == nil {
+					printer.Log(LogWarn, "warning: 403 persisted, retrying with a freshly fetched stream URL")
+					written, err = restartStream(freshVideo, freshFormat)
 				} else {
-					progress = newProgressWriter(size, printer, prefix)
+					printer.Log(LogDebug, fmt.Sprintf("refreshing stream URL failed: %v", refreshErr))
 				}
-				writer = io.MultiWriter(file, progress)
-			} else {
-				progress = nil
 			}
-			result.hadProgress = progress != nil
-
-			written, err = copyWithContext(ctx, writer, stream)
-			result.retried = true
 		}
 		if err != nil {
+			if isUnexpectedStatus(err, http.StatusForbidden) && metadataProxyEnabled() {
+				// The stream URL was issued to the proxy's address and
+				// googlevideo may refuse it from ours.
+				err = fmt.Errorf("%w (stream URLs are bound to the IP that requested them, so the -metadata-proxy address may be refused here; try without -metadata-proxy)", err)
+			}
 			// If audio-only format fails with 403, try ffmpeg fallback
 			isAudioOnlyFormat := format.AudioChannels > 0 && format.Width == 0 && format.Height == 0
 			audioOnlyItags := map[int]bool{251: true, 140: true, 250: true, 249: true, 139: true, 171: true}
 			isAudioOnlyItag := audioOnlyItags[opts.Itag]
-			if (opts.AudioOnly || isAudioOnlyFormat || isAudioOnlyItag) && isUnexpectedStatus(err, http.StatusForbidden) && ffmpegAvailable() {
+			audioRequested := opts.AudioOnly || isAudioOnlyFormat || isAudioOnlyItag
+			if audioRequested && isUnexpectedStatus(err, http.StatusForbidden) && ffmpegAvailable() {
 				printer.Log(LogWarn, "YouTube blocked chunked audio-only download (403); switching to ffmpeg fallback to preserve Opus quality")
 				printer.Log(LogInfo, "ffmpeg fallback: download video → extract audio → encode Opus @ 160kbps")
 				file.Close()
-				os.Remove(outputPath)
-				return downloadWithFFmpegFallback(ctx, client, video, opts, printer, prefix, outputPath, opts.OutputDir, progress)
+				os.Remove(downloadPath)
+				result, err = downloadWithFFmpegFallback(ctx, client, video, opts, printer, prefix, outputPath, opts.OutputDir, progress)
+				if err == nil {
+					err = finishDownload(ctx, opts, ctxInfo, outputPath, outputRoot, video, printer)
+				}
+				return result, err
+			}
+			if audioRequested && isUnexpectedStatus(err, http.StatusForbidden) {
+				return result, wrapCategory(CategoryNetwork, fmt.Errorf("download failed: audio-only stream blocked (403) and the ffmpeg fallback is unavailable without ffmpeg (install it or set -ffmpeg-location): %w", err))
 			}
 			return result, wrapCategory(CategoryNetwork, fmt.Errorf("download failed: %w", err))
 		}
@@ -304,19 +608,77 @@ func downloadVideo(ctx context.Context, client YouTubeClient, video *youtube.Vid
 	if progress != nil {
 		progress.Finish()
 	}
+	if !transcode {
+		file.Close()
+		if err := os.Rename(downloadPath, outputPath); err != nil {
+			return result, wrapCategory(CategoryFilesystem, fmt.Errorf("renaming output: %w", err))
+		}
+		downloadPath = outputPath
+	}
+	if streamed {
+		checksum.Seal(downloadPath)
+	}
 
-	if err := validateOutputFile(outputPath, format); err != nil {
+	if err := validateOutputFile(downloadPath, format); err != nil {
 		return result, err
 	}
-
-	result.bytes = written
+	if transcode {
+		file.Close()
+		printer.Log(LogInfo, fmt.Sprintf("encoding to %s", opts.AudioFormat))
+		if err := extractAudio(downloadPath, outputPath, format.MimeType, opts.AudioFormat, opts.AudioQuality); err != nil {
+			return result, wrapCategory(CategoryFilesystem, fmt.Errorf("ffmpeg extraction failed: %w", err))
+		}
+		if fi, err := os.Stat(outputPath); err == nil {
+			written = fi.Size()
+		}
+	}
+	result.bytes = offset + written
+	if err := finishDownload(ctx, opts, ctxInfo, outputPath, outputRoot, video, printer); err != nil {
+		return result, err
+	}
+	if fi, statErr := os.Stat(outputPath); statErr == nil && ctxInfo.StartOffset > 0 {
+		result.bytes = fi.Size()
+	}
 	return result, nil
 }
 
+// finishDownload runs the post-download steps that operate on the finished
+// file: trimming to the URL's start timestamp, --verify, then subtitles.
+func finishDownload(ctx context.Context, opts Options, ctxInfo outputContext, outputPath, outputRoot string, video *youtube.Video, printer *Printer) error {
+	expected := video.Duration
+	if ctxInfo.StartOffset > 0 && video.Duration > 0 && ctxInfo.StartOffset >= video.Duration {
+		printer.Log(LogWarn, fmt.Sprintf("warning: start timestamp %s is past the end of the video; keeping full download", ctxInfo.StartOffset))
+	} else if ctxInfo.StartOffset > 0 {
+		trimmed, err := trimToStartOffset(outputPath, filepath.Dir(outputPath), ctxInfo.StartOffset, printer)
+		if err != nil {
+			return err
+		}
+		if trimmed {
+			expected -= ctxInfo.StartOffset
+		}
+	}
+	if err := verifyIfRequested(opts, outputPath, expected, printer); err != nil {
+		return err
+	}
+	if opts.Subtitles != "" {
+		subs := downloadSubtitles(ctx, video, opts, outputPath, outputRoot, printer)
+		if opts.EmbedSubs && !opts.AudioOnly {
+			if err := embedSubtitles(outputPath, filepath.Dir(outputPath), subs, printer); err != nil {
+				printer.Log(LogWarn, fmt.Sprintf("warning: %v", err))
+			}
+		}
+	}
+	return nil
+}
+
 func isUnexpectedStatus(err error, code int) bool {
 	var statusErr youtube.ErrUnexpectedStatusCode
 	if errors.As(err, &statusErr) {
 		return int(statusErr) == code
 	}
+	var segmentErr segmentStatusError
+	if errors.As(err, &segmentErr) {
+		return int(segmentErr) == code
+	}
 	return false
 }
@@ -0,0 +1,397 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/lvcoi/ytdl-lib/v2"
+)
+
+// withoutFFmpeg points the ffmpeg lookups at a binary that does not exist for
+// the duration of the test.
+func withoutFFmpeg(t *testing.T) {
+	t.Helper()
+	prev := ffmpegPath
+	ffmpegPath = filepath.Join(t.TempDir(), "missing-ffmpeg")
+	t.Cleanup(func() { ffmpegPath = prev })
+}
+
+// audioFallbackVideo has one audio-only webm format and one progressive
+// format the ffmpeg fallback would use.
+func audioFallbackVideo() *youtube.Video {
+	return &youtube.Video{
+		ID:    "abc123",
+		Title: "Song",
+		Formats: youtube.FormatList{
+			{ItagNo: 251, MimeType: `audio/webm; codecs="opus"`, AudioChannels: 2, Bitrate: 160000},
+			{ItagNo: 18, MimeType: `video/mp4; codecs="avc1.42001E, mp4a.40.2"`, AudioChannels: 2, Width: 640, Height: 360},
+		},
+	}
+}
+
+type failingReader struct{ err error }
+
+func (r failingReader) Read([]byte) (int, error) { return 0, r.err }
+
+func TestAudioCodecKwargs(t *testing.T) {
+	tests := []struct {
+		format string
+		want   map[string]string
+	}{
+		{format: "mp3", want: map[string]string{"acodec": "libmp3lame", "q:a": "2"}},
+		{format: "m4a", want: map[string]string{"acodec": "aac", "b:a": "192k"}},
+		{format: "opus", want: map[string]string{"acodec": "libopus", "b:a": "160k"}},
+		{format: "flac", want: map[string]string{"acodec": "flac"}},
+		{format: "wav", want: map[string]string{"acodec": "pcm_s16le"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			if !ValidAudioFormat(tt.format) {
+				t.Fatalf("expected %q to be a valid audio format", tt.format)
+			}
+			kwargs := audioCodecKwargs(tt.format)
+			if _, ok := kwargs["vn"]; !ok {
+				t.Fatalf("expected video stream to be dropped, got %v", kwargs)
+			}
+			if len(kwargs) != len(tt.want)+1 {
+				t.Fatalf("unexpected kwargs %v", kwargs)
+			}
+			for key, value := range tt.want {
+				if kwargs[key] != value {
+					t.Fatalf("expected %s=%s, got %v", key, value, kwargs[key])
+				}
+			}
+		})
+	}
+}
+
+func TestValidAudioFormatRejectsUnknown(t *testing.T) {
+	if !ValidAudioFormat("") {
+		t.Fatalf("expected empty audio format to be accepted")
+	}
+	for _, format := range []string{"aac", "webm", "MP3", "ogg"} {
+		if ValidAudioFormat(format) {
+			t.Fatalf("expected %q to be rejected", format)
+		}
+	}
+}
+
+func TestWithAudioExtension(t *testing.T) {
+	if got := withAudioExtension("out/Song.webm", "mp3"); got != "out/Song.mp3" {
+		t.Fatalf("unexpected path %q", got)
+	}
+	if got := withAudioExtension("out/Song.webm", ""); got != "out/Song.webm" {
+		t.Fatalf("expected path unchanged without audio format, got %q", got)
+	}
+}
+
+func TestApplyAudioQuality(t *testing.T) {
+	tests := []struct {
+		format  string
+		quality string
+		want    map[string]string
+	}{
+		{format: "mp3", quality: "256k", want: map[string]string{"acodec": "libmp3lame", "b:a": "256k"}},
+		{format: "mp3", quality: "0", want: map[string]string{"acodec": "libmp3lame", "q:a": "0"}},
+		{format: "m4a", quality: "128k", want: map[string]string{"acodec": "aac", "b:a": "128k"}},
+		{format: "opus", quality: "96k", want: map[string]string{"acodec": "libopus", "b:a": "96k"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format+"_"+tt.quality, func(t *testing.T) {
+			kwargs := audioCodecKwargs(tt.format)
+			if err := applyAudioQuality(kwargs, tt.format, tt.quality); err != nil {
+				t.Fatalf("applyAudioQuality: %v", err)
+			}
+			if len(kwargs) != len(tt.want)+1 {
+				t.Fatalf("unexpected kwargs %v", kwargs)
+			}
+			for key, value := range tt.want {
+				if kwargs[key] != value {
+					t.Fatalf("expected %s=%s, got %v", key, value, kwargs[key])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateAudioQualityRejectsMismatchedCodec(t *testing.T) {
+	cases := []struct {
+		quality string
+		format  string
+	}{
+		{quality: "5", format: "opus"},
+		{quality: "256k", format: "flac"},
+		{quality: "192k", format: "wav"},
+		{quality: "1000k", format: "mp3"},
+		{quality: "loud", format: ""},
+	}
+	for _, tc := range cases {
+		if err := ValidateAudioQuality(tc.quality, tc.format); err == nil {
+			t.Fatalf("expected %q to be rejected for %q", tc.quality, tc.format)
+		}
+	}
+	if err := ValidateAudioQuality("2", ""); err != nil {
+		t.Fatalf("expected VBR level to pass syntax check without a codec: %v", err)
+	}
+}
+
+func TestDownloadVideoAudioWithoutFFmpegKeepsNativeStream(t *testing.T) {
+	withoutFFmpeg(t)
+	webm := append([]byte{0x1A, 0x45, 0xDF, 0xA3}, bytes.Repeat([]byte("a"), 64)...)
+	client := &mockYouTubeClient{
+		getStreamFn: func(ctx context.Context, video *youtube.Video, format *youtube.Format) (io.ReadCloser, int64, error) {
+			if format.ItagNo != 251 {
+				t.Errorf("expected the audio-only format, got itag %d", format.ItagNo)
+			}
+			return io.NopCloser(bytes.NewReader(webm)), int64(len(webm)), nil
+		},
+	}
+	opts := Options{AudioOnly: true, OutputTemplate: "{title}.{ext}", OutputDir: t.TempDir(), Quiet: true}
+	result, err := downloadVideo(context.Background(), client, audioFallbackVideo(), opts, outputContext{}, newPrinter(opts, nil), "[1/1]")
+	if err != nil {
+		t.Fatalf("downloadVideo: %v", err)
+	}
+	if filepath.Ext(result.outputPath) != ".webm" {
+		t.Fatalf("expected the native .webm output, got %s", result.outputPath)
+	}
+	if data, err := os.ReadFile(result.outputPath); err != nil || !bytes.Equal(data, webm) {
+		t.Fatalf("expected the audio stream to be saved untouched (err %v)", err)
+	}
+}
+
+func TestDownloadVideoAudioFormatWithoutFFmpegFails(t *testing.T) {
+	withoutFFmpeg(t)
+	client := &mockYouTubeClient{
+		getStreamFn: func(ctx context.Context, video *youtube.Video, format *youtube.Format) (io.ReadCloser, int64, error) {
+			t.Errorf("expected no download without ffmpeg, got itag %d", format.ItagNo)
+			return nil, 0, io.EOF
+		},
+	}
+	opts := Options{AudioOnly: true, AudioFormat: "mp3", OutputTemplate: "{title}.{ext}", OutputDir: t.TempDir(), Quiet: true}
+	_, err := downloadVideo(context.Background(), client, audioFallbackVideo(), opts, outputContext{}, newPrinter(opts, nil), "[1/1]")
+	if errorCategory(err) != CategoryUnsupported || !strings.Contains(err.Error(), "requires ffmpeg") {
+		t.Fatalf("expected an unsupported error naming ffmpeg, got %v", err)
+	}
+}
+
+func TestDownloadVideoAudio403WithoutFFmpegSkipsFallback(t *testing.T) {
+	withoutFFmpeg(t)
+	client := &mockYouTubeClient{
+		getStreamFn: func(ctx context.Context, video *youtube.Video, format *youtube.Format) (io.ReadCloser, int64, error) {
+			if format.ItagNo != 251 {
+				t.Errorf("expected no progressive download without ffmpeg, got itag %d", format.ItagNo)
+			}
+			return io.NopCloser(failingReader{err: youtube.ErrUnexpectedStatusCode(http.StatusForbidden)}), 0, nil
+		},
+	}
+	opts := Options{AudioOnly: true, OutputTemplate: "{title}.{ext}", OutputDir: t.TempDir(), Quiet: true}
+	_, err := downloadVideo(context.Background(), client, audioFallbackVideo(), opts, outputContext{}, newPrinter(opts, nil), "[1/1]")
+	if err == nil || !strings.Contains(err.Error(), "ffmpeg") {
+		t.Fatalf("expected an error explaining the missing ffmpeg fallback, got %v", err)
+	}
+}
+
+func TestDownloadVideo403UnderMetadataProxyMentionsTheProxy(t *testing.T) {
+	t.Cleanup(func() {
+		metadataProxyMu.Lock()
+		metadataProxy, metadataTransport = nil, nil
+		metadataProxyMu.Unlock()
+	})
+	if err := SetMetadataProxy("http://127.0.0.1:3128"); err != nil {
+		t.Fatal(err)
+	}
+	client := &mockYouTubeClient{
+		getStreamFn: func(ctx context.Context, video *youtube.Video, format *youtube.Format) (io.ReadCloser, int64, error) {
+			return io.NopCloser(failingReader{err: youtube.ErrUnexpectedStatusCode(http.StatusForbidden)}), 0, nil
+		},
+	}
+	video := &youtube.Video{ID: "abc123", Title: "Clip", Formats: youtube.FormatList{
+		{ItagNo: 18, MimeType: `video/mp4; codecs="avc1.42001E, mp4a.40.2"`, AudioChannels: 2, Width: 640, Height: 360},
+	}}
+	opts := Options{OutputTemplate: "{title}.{ext}", OutputDir: t.TempDir(), Quiet: true}
+	_, err := downloadVideo(context.Background(), client, video, opts, outputContext{}, newPrinter(opts, nil), "[1/1]")
+	if !isUnexpectedStatus(err, http.StatusForbidden) || !strings.Contains(err.Error(), "-metadata-proxy") {
+		t.Fatalf("expected a 403 pointing at -metadata-proxy, got %v", err)
+	}
+}
+
+// cancellingReader returns data once, cancelling the download as it does, and
+// then fails with the context's error.
+type cancellingReader struct {
+	data   []byte
+	cancel context.CancelFunc
+	ctx    context.Context
+}
+
+func (r *cancellingReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, r.ctx.Err()
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	r.cancel()
+	return n, nil
+}
+
+func TestDownloadVideoCancelledLeavesNoPartialOutput(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := &mockYouTubeClient{
+		getStreamFn: func(ctx context.Context, video *youtube.Video, format *youtube.Format) (io.ReadCloser, int64, error) {
+			data := append([]byte("\x00\x00\x00\x18ftypisom"), bytes.Repeat([]byte("v"), 256)...)
+			return io.NopCloser(&cancellingReader{data: data, cancel: cancel, ctx: ctx}), 4096, nil
+		},
+	}
+	dir := t.TempDir()
+	opts := Options{OutputTemplate: "{title}.{ext}", OutputDir: dir, Quiet: true}
+	if _, err := downloadVideo(ctx, client, audioFallbackVideo(), opts, outputContext{}, newPrinter(opts, nil), "[1/1]"); err == nil {
+		t.Fatal("expected the cancelled download to fail")
+	}
+	for _, name := range []string{"Song.mp4", "Song.mp4" + partSuffix} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("expected no %s after cancelling, stat err = %v", name, err)
+		}
+	}
+}
+
+func TestDownloadWithFFmpegFallbackRequiresFFmpeg(t *testing.T) {
+	withoutFFmpeg(t)
+	client := &mockYouTubeClient{
+		getStreamFn: func(ctx context.Context, video *youtube.Video, format *youtube.Format) (io.ReadCloser, int64, error) {
+			t.Errorf("expected no download without ffmpeg, got itag %d", format.ItagNo)
+			return nil, 0, io.EOF
+		},
+	}
+	opts := Options{AudioOnly: true, Quiet: true}
+	output := filepath.Join(t.TempDir(), "Song.opus")
+	_, err := downloadWithFFmpegFallback(context.Background(), client, audioFallbackVideo(), opts, newPrinter(opts, nil), "[1/1]", output, filepath.Dir(output), nil)
+	if errorCategory(err) != CategoryUnsupported {
+		t.Fatalf("expected an unsupported error, got %v", err)
+	}
+}
+
+func TestExtractAudioKwargsCopiesMatchingCodec(t *testing.T) {
+	tests := []struct {
+		name    string
+		mime    string
+		ext     string
+		quality string
+		want    string
+	}{
+		{name: "opus to opus", mime: `audio/webm; codecs="opus"`, ext: "opus", want: "copy"},
+		{name: "opus to webm", mime: `audio/webm; codecs="opus"`, ext: "webm", want: "copy"},
+		{name: "aac to m4a", mime: `audio/mp4; codecs="mp4a.40.2"`, ext: "m4a", want: "copy"},
+		{name: "progressive aac to m4a", mime: `video/mp4; codecs="avc1.42001E, mp4a.40.2"`, ext: "m4a", want: "copy"},
+		{name: "opus to mp3", mime: `audio/webm; codecs="opus"`, ext: "mp3", want: "libmp3lame"},
+		{name: "aac to opus", mime: `audio/mp4; codecs="mp4a.40.2"`, ext: "opus", want: "libopus"},
+		{name: "opus to wav", mime: `audio/webm; codecs="opus"`, ext: "wav", want: "pcm_s16le"},
+		{name: "unknown source", mime: "audio/webm", ext: "opus", want: "libopus"},
+		{name: "quality forces encode", mime: `audio/webm; codecs="opus"`, ext: "opus", quality: "96k", want: "libopus"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kwargs, err := extractAudioKwargs(tt.mime, tt.ext, tt.quality)
+			if err != nil {
+				t.Fatalf("extractAudioKwargs: %v", err)
+			}
+			if kwargs["acodec"] != tt.want {
+				t.Fatalf("expected acodec %s, got %v", tt.want, kwargs)
+			}
+			if tt.want == "copy" && len(kwargs) != 2 {
+				t.Fatalf("expected only -vn and -acodec copy, got %v", kwargs)
+			}
+		})
+	}
+}
+
+func TestExtractAudioCopiesOrTranscodes(t *testing.T) {
+	dir, logPath := useFakeFFmpeg(t)
+	if err := SetFFmpegLocation(dir); err != nil {
+		t.Fatalf("SetFFmpegLocation: %v", err)
+	}
+	input := filepath.Join(dir, "in.webm")
+
+	if err := extractAudio(input, filepath.Join(dir, "out.opus"), `audio/webm; codecs="opus"`, "", ""); err != nil {
+		t.Fatalf("extractAudio: %v", err)
+	}
+	if err := extractAudio(input, filepath.Join(dir, "out.mp3"), `audio/webm; codecs="opus"`, "mp3", ""); err != nil {
+		t.Fatalf("extractAudio: %v", err)
+	}
+	logged, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("expected the fake ffmpeg to run: %v", err)
+	}
+	calls := strings.Split(strings.TrimSpace(string(logged)), "\n")
+	if len(calls) != 2 {
+		t.Fatalf("expected two ffmpeg calls, got %q", logged)
+	}
+	if !strings.Contains(calls[0], "-acodec copy") {
+		t.Fatalf("expected opus to .opus to copy the stream, got %q", calls[0])
+	}
+	if !strings.Contains(calls[1], "-acodec libmp3lame") {
+		t.Fatalf("expected opus to mp3 to transcode, got %q", calls[1])
+	}
+}
+
+func TestDownloadVideoAdoptsBrowserPart(t *testing.T) {
+	content := []byte("\x00\x00\x00\x18ftypisom" + strings.Repeat("\x00", 12) + "moov" + strings.Repeat("x", 4096))
+	tests := []struct {
+		name      string
+		part      []byte
+		wantStart int64
+	}{
+		{"prefix", content[:1000], 1000},
+		{"too long", append(append([]byte{}, content...), 'y'), 0},
+	}
+	for _, tt := range tests {
+		var mu sync.Mutex
+		firstStart := int64(-1)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var start, end int64
+			if _, err := fmt.Sscanf(r.URL.Query().Get("range"), "%d-%d", &start, &end); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			mu.Lock()
+			if firstStart < 0 || start < firstStart {
+				firstStart = start
+			}
+			mu.Unlock()
+			_, _ = w.Write(content[start : end+1])
+		}))
+
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "Clip.mp4"+partSuffix), tt.part, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		client := &mockYouTubeClient{httpDoer: srv.Client()}
+		format := youtube.Format{ItagNo: 18, URL: srv.URL + "/videoplayback", MimeType: `video/mp4; codecs="avc1.42001E, mp4a.40.2"`, AudioChannels: 2, Width: 640, Height: 360, ContentLength: int64(len(content))}
+		video := &youtube.Video{ID: "abc123", Title: "Clip", Formats: youtube.FormatList{format}}
+		opts := Options{OutputTemplate: "{title}.{ext}", OutputDir: dir, Quiet: true, AdoptPartial: true}
+		result, err := downloadVideo(context.Background(), client, video, opts, outputContext{}, newPrinter(opts, nil), "[1/1]")
+		srv.Close()
+		if err != nil {
+			t.Fatalf("%s: downloadVideo: %v", tt.name, err)
+		}
+		if firstStart != tt.wantStart {
+			t.Fatalf("%s: first requested byte %d, want %d", tt.name, firstStart, tt.wantStart)
+		}
+		data, err := os.ReadFile(result.outputPath)
+		if err != nil || !bytes.Equal(data, content) {
+			t.Fatalf("%s: output differs from the source (%d of %d bytes), err %v", tt.name, len(data), len(content), err)
+		}
+		if result.bytes != int64(len(content)) {
+			t.Fatalf("%s: result.bytes = %d, want %d", tt.name, result.bytes, len(content))
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func corsTestHandler(t *testing.T, raw string) http.Handler {
+	t.Helper()
+	origins, err := parseCORSOrigins(raw)
+	if err != nil {
+		t.Fatalf("parseCORSOrigins: %v", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/status", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	return withSecurityHeaders(withCORS(origins, withCompression(mux)))
+}
+
+func preflight(origin string) *http.Request {
+	req := httptest.NewRequest(http.MethodOptions, "/api/status", nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	req.Header.Set("Access-Control-Request-Headers", "content-type")
+	return req
+}
+
+func TestCORSAllowsListedOriginPreflight(t *testing.T) {
+	handler := corsTestHandler(t, "http://localhost:5173, https://ext.example.com/")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, preflight("http://localhost:5173"))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 for allowed preflight, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "http://localhost:5173" {
+		t.Fatalf("expected the origin to be echoed, got %q", got)
+	}
+	if rec.Header().Get("Access-Control-Allow-Methods") == "" || rec.Header().Get("Access-Control-Allow-Headers") == "" {
+		t.Fatalf("expected allowed methods and headers, got %v", rec.Header())
+	}
+	if got := rec.Header().Get("X-Frame-Options"); got != "DENY" {
+		t.Fatalf("expected security headers to remain, got X-Frame-Options=%q", got)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/status", nil)
+	req.Header.Set("Origin", "https://ext.example.com")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != "https://ext.example.com" {
+		t.Fatalf("expected allowed simple request, got %d with %v", rec.Code, rec.Header())
+	}
+}
+
+func TestCORSRejectsUnlistedOrigin(t *testing.T) {
+	handler := corsTestHandler(t, "http://localhost:5173")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, preflight("https://evil.example.com"))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for rejected preflight, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Fatalf("expected no CORS grant, got %q", got)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/status", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Fatalf("expected no CORS grant for unlisted origin, got %q", got)
+	}
+	if got := rec.Header().Get("Content-Security-Policy"); got == "" {
+		t.Fatal("expected security headers on rejected cross-origin requests")
+	}
+}
+
+func TestCORSDisabledByDefault(t *testing.T) {
+	handler := corsTestHandler(t, "")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, preflight("http://localhost:5173"))
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" || rec.Code == http.StatusNoContent {
+		t.Fatalf("expected no CORS handling without configured origins, got %d with %q", rec.Code, got)
+	}
+}
+
+func TestParseCORSOriginsRejectsInvalidEntries(t *testing.T) {
+	for _, raw := range []string{"localhost:5173", "ftp://example.com", "http://example.com/app", "*"} {
+		if _, err := parseCORSOrigins(raw); err == nil {
+			t.Fatalf("expected %q to be rejected", raw)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lvcoi/ytdl-go/internal/downloader"
+)
+
+const (
+	downloadStatsFileName = "download_stats.json"
+	topArtistsLimit       = 10
+)
+
+// downloadStatsState is the persisted form of the cumulative download
+// statistics.
+type downloadStatsState struct {
+	TotalDownloads int            `json:"total_downloads"`
+	TotalBytes     int64          `json:"total_bytes"`
+	Artists        map[string]int `json:"artists"`
+	UpdatedAt      string         `json:"updated_at,omitempty"`
+}
+
+type artistCount struct {
+	Artist string `json:"artist"`
+	Count  int    `json:"count"`
+}
+
+type downloadStatsResponse struct {
+	TotalDownloads int           `json:"total_downloads"`
+	TotalBytes     int64         `json:"total_bytes"`
+	TopArtists     []artistCount `json:"top_artists"`
+	UpdatedAt      string        `json:"updated_at,omitempty"`
+}
+
+// completedItem is one successful download reported by a job.
+type completedItem struct {
+	Artist string
+	Bytes  int64
+}
+
+// downloadStatsStore keeps cumulative download statistics in the media data
+// folder so they survive restarts.
+type downloadStatsStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+func newDownloadStatsStore(path string) *downloadStatsStore {
+	return &downloadStatsStore{path: path}
+}
+
+// itemArtist picks the name stats are grouped under for a finished download.
+func itemArtist(metadata downloader.ItemMetadata) string {
+	if artist := strings.TrimSpace(metadata.Artist); artist != "" {
+		return artist
+	}
+	return strings.TrimSpace(metadata.Author)
+}
+
+// RecordJob folds a finished job's items into the stored totals.
+func (s *downloadStatsStore) RecordJob(items []completedItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, err := s.loadLocked()
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		state.TotalDownloads++
+		state.TotalBytes += item.Bytes
+		if item.Artist != "" {
+			state.Artists[item.Artist]++
+		}
+	}
+	state.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	return s.saveLocked(state)
+}
+
+// Snapshot returns the stored totals with the most downloaded artists.
+func (s *downloadStatsStore) Snapshot() (downloadStatsResponse, error) {
+	s.mu.Lock()
+	state, err := s.loadLocked()
+	s.mu.Unlock()
+	if err != nil {
+		return downloadStatsResponse{TopArtists: []artistCount{}}, err
+	}
+
+	top := make([]artistCount, 0, len(state.Artists))
+	for artist, count := range state.Artists {
+		top = append(top, artistCount{Artist: artist, Count: count})
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count != top[j].Count {
+			return top[i].Count > top[j].Count
+		}
+		return top[i].Artist < top[j].Artist
+	})
+	if len(top) > topArtistsLimit {
+		top = top[:topArtistsLimit]
+	}
+	return downloadStatsResponse{
+		TotalDownloads: state.TotalDownloads,
+		TotalBytes:     state.TotalBytes,
+		TopArtists:     top,
+		UpdatedAt:      state.UpdatedAt,
+	}, nil
+}
+
+func (s *downloadStatsStore) loadLocked() (downloadStatsState, error) {
+	state := downloadStatsState{Artists: map[string]int{}}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return state, fmt.Errorf("reading download stats: %w", err)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return downloadStatsState{Artists: map[string]int{}}, fmt.Errorf("parsing download stats: %w", err)
+	}
+	if state.Artists == nil {
+		state.Artists = map[string]int{}
+	}
+	return state, nil
+}
+
+func (s *downloadStatsStore) saveLocked(state downloadStatsState) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("creating stats data directory: %w", err)
+	}
+	encoded, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding download stats: %w", err)
+	}
+
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, append(encoded, '\n'), 0o644); err != nil {
+		return fmt.Errorf("writing download stats temp file: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("committing download stats file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package web
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lvcoi/ytdl-go/internal/downloader"
+)
+
+func TestDownloadStatsAccumulateAndReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), mediaFolderData, downloadStatsFileName)
+
+	store := newDownloadStatsStore(path)
+	if err := store.RecordJob([]completedItem{
+		{Artist: itemArtist(downloader.ItemMetadata{Artist: "Alpha"}), Bytes: 100},
+		{Artist: itemArtist(downloader.ItemMetadata{Author: "Beta"}), Bytes: 50},
+	}); err != nil {
+		t.Fatalf("RecordJob: %v", err)
+	}
+	if err := store.RecordJob([]completedItem{{Artist: "Alpha", Bytes: 25}}); err != nil {
+		t.Fatalf("RecordJob: %v", err)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Fatalf("expected temp file to be renamed away, stat err = %v", err)
+	}
+
+	// A fresh store on the same path stands in for a server restart.
+	reloaded := newDownloadStatsStore(path)
+	if err := reloaded.RecordJob([]completedItem{{Artist: "Gamma", Bytes: 5}}); err != nil {
+		t.Fatalf("RecordJob after reload: %v", err)
+	}
+	stats, err := reloaded.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	if stats.TotalDownloads != 4 || stats.TotalBytes != 180 {
+		t.Fatalf("expected 4 downloads and 180 bytes, got %+v", stats)
+	}
+	want := []artistCount{{"Alpha", 2}, {"Beta", 1}, {"Gamma", 1}}
+	if len(stats.TopArtists) != len(want) {
+		t.Fatalf("expected top artists %+v, got %+v", want, stats.TopArtists)
+	}
+	for i := range want {
+		if stats.TopArtists[i] != want[i] {
+			t.Fatalf("expected top artists %+v, got %+v", want, stats.TopArtists)
+		}
+	}
+}
+
+func TestDownloadStatsSnapshotWithoutFile(t *testing.T) {
+	store := newDownloadStatsStore(filepath.Join(t.TempDir(), downloadStatsFileName))
+	stats, err := store.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	if stats.TotalDownloads != 0 || stats.TopArtists == nil || len(stats.TopArtists) != 0 {
+		t.Fatalf("expected empty stats with non-nil artists, got %+v", stats)
+	}
+}
@@ -84,6 +84,10 @@ type ProgressEvent struct {
 	ExitCode int               `json:"exitCode,omitempty"`
 	Stats    *ProgressStats    `json:"stats,omitempty"`
 	Snapshot *ProgressSnapshot `json:"snapshot,omitempty"`
+
+	// relayed marks task progress the download pool has already broadcast
+	// over the WebSocket; it is recorded on the job but not broadcast again.
+	relayed bool
 }
 
 // Job represents an async download job.
@@ -117,10 +121,21 @@ type Job struct {
 	duplicatePromptMap map[string]DuplicatePromptSnapshot
 	ctx                context.Context    `json:"-"`
 	cancel             context.CancelFunc `json:"-"`
+	userCancelled      atomic.Bool        `json:"-"`
 	brokerStop         chan struct{}      `json:"-"`
 	closeOnce          sync.Once          `json:"-"`
 }
 
+// JobSummary is the listing view of a job returned by GET /api/jobs.
+type JobSummary struct {
+	ID          string        `json:"id"`
+	Status      string        `json:"status"`
+	URLs        []string      `json:"urls"`
+	CreatedAt   time.Time     `json:"createdAt"`
+	CompletedAt *time.Time    `json:"completedAt,omitempty"`
+	Stats       ProgressStats `json:"stats"`
+}
+
 // jobTracker manages active download jobs.
 type jobTracker struct {
 	jobs    sync.Map
@@ -129,6 +144,9 @@ type jobTracker struct {
 
 var tracker = &jobTracker{}
 
+// cancelledByUserMessage is the error recorded on cancelled jobs.
+const cancelledByUserMessage = "Cancelled by user"
+
 var (
 	errDuplicatePromptNotFound = errors.New("duplicate prompt not found")
 	errDuplicatePromptClosed   = errors.New("duplicate prompt subsystem closed")
@@ -165,11 +183,13 @@ func (jt *jobTracker) Create(ctx context.Context, urls []string) *Job {
 	go job.runEventBroker()
 	go func() {
 		<-jobCtx.Done()
-		if errors.Is(jobCtx.Err(), context.Canceled) {
+		// Only Cancel marks a user cancellation; a server shutdown also
+		// cancels jobCtx and is left for SetOutcome to report.
+		if job.cancelledByUser() {
 			job.mu.Lock()
 			if job.Status == "queued" || job.Status == "running" {
-				job.setTerminalStatusLocked("error")
-				job.Error = "Cancelled by user"
+				job.setTerminalStatusLocked("cancelled")
+				job.Error = cancelledByUserMessage
 				status := job.Status
 				errMsg := job.Error
 				stats := job.Stats
@@ -204,6 +224,57 @@ func (jt *jobTracker) ActiveCount() int {
 	return count
 }
 
+// List returns one page of job summaries, newest first, optionally limited
+// to jobs with the given status. nextOffset is nil on the last page.
+func (jt *jobTracker) List(status string, offset, limit int) (page []JobSummary, nextOffset *int) {
+	var all []JobSummary
+	jt.jobs.Range(func(_, v any) bool {
+		if j, ok := v.(*Job); ok {
+			if summary := j.summary(); status == "" || summary.Status == status {
+				all = append(all, summary)
+			}
+		}
+		return true
+	})
+	sort.Slice(all, func(i, k int) bool {
+		if !all[i].CreatedAt.Equal(all[k].CreatedAt) {
+			return all[i].CreatedAt.After(all[k].CreatedAt)
+		}
+		return jobSeq(all[i].ID) > jobSeq(all[k].ID)
+	})
+
+	if offset >= len(all) {
+		return []JobSummary{}, nil
+	}
+	end := offset + limit
+	if end >= len(all) {
+		return all[offset:], nil
+	}
+	return all[offset:end], &end
+}
+
+// jobSeq extracts the counter from a "job_N" id so jobs created within the
+// same clock tick still list in creation order.
+func jobSeq(id string) int64 {
+	n, _ := strconv.ParseInt(strings.TrimPrefix(id, "job_"), 10, 64)
+	return n
+}
+
+// CancelActive cancels every queued or running job and returns how many it
+// cancelled. A job that finishes concurrently keeps its own outcome: Cancel
+// only marks jobs that are still active when the cancellation is handled.
+func (jt *jobTracker) CancelActive() int {
+	cancelled := 0
+	jt.jobs.Range(func(_, v any) bool {
+		if j, ok := v.(*Job); ok && j.isActive() {
+			j.Cancel()
+			cancelled++
+		}
+		return true
+	})
+	return cancelled
+}
+
 func (jt *jobTracker) Delete(id string) {
 	if v, ok := jt.jobs.Load(id); ok {
 		if job, jobOK := v.(*Job); jobOK {
@@ -252,10 +323,13 @@ func (jt *jobTracker) StartCleanup(ctx context.Context, interval, completedTTL,
 	}()
 }
 
+// Cancel stops the job on the user's behalf, for /api/download/cancel and
+// cancel-all.
 func (j *Job) Cancel() {
 	if j == nil || j.cancel == nil {
 		return
 	}
+	j.userCancelled.Store(true)
 	j.cancel()
 }
 
@@ -281,6 +355,23 @@ func (j *Job) isActive() bool {
 	return j.Status == "queued" || j.Status == "running"
 }
 
+func (j *Job) summary() JobSummary {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	summary := JobSummary{
+		ID:        j.ID,
+		Status:    j.Status,
+		URLs:      append([]string(nil), j.URLs...),
+		CreatedAt: j.CreatedAt,
+		Stats:     j.Stats,
+	}
+	if !j.CompletedAt.IsZero() {
+		completedAt := j.CompletedAt
+		summary.CompletedAt = &completedAt
+	}
+	return summary
+}
+
 func (j *Job) StatusValue() string {
 	j.mu.RLock()
 	defer j.mu.RUnlock()
@@ -289,7 +380,7 @@ func (j *Job) StatusValue() string {
 
 func (j *Job) setTerminalStatusLocked(status string) {
 	j.Status = status
-	if status == "complete" || status == "error" {
+	if status == "complete" || status == "error" || status == "cancelled" {
 		j.CompletedAt = time.Now()
 		return
 	}
@@ -303,6 +394,19 @@ func (j *Job) SetStatus(status string) {
 	j.emitStatusEvent(status, status)
 }
 
+// markRunning moves a queued job to running. A job cancelled while it waited
+// for a pool worker keeps its cancelled status.
+func (j *Job) markRunning() {
+	j.mu.Lock()
+	if j.Status != "queued" {
+		j.mu.Unlock()
+		return
+	}
+	j.setTerminalStatusLocked("running")
+	j.mu.Unlock()
+	j.emitStatusEvent("running", "running")
+}
+
 func (j *Job) SetOutcome(results []app.Result, exitCode int) string {
 	resultsCopy := append([]app.Result(nil), results...)
 	stats := computeProgressStats(resultsCopy)
@@ -313,6 +417,25 @@ func (j *Job) SetOutcome(results []app.Result, exitCode int) string {
 	j.Error = ""
 	j.Stats = stats
 
+	if exitCode != 0 && j.cancelledByUser() {
+		// The cancel watcher in Create may already have moved the job to
+		// cancelled and sent the terminal event; send it only once.
+		alreadyCancelled := j.Status == "cancelled"
+		if !alreadyCancelled {
+			j.setTerminalStatusLocked("cancelled")
+		}
+		j.Error = cancelledByUserMessage
+		status := j.Status
+		errMsg := j.Error
+		statsCopy := j.Stats
+		payload := j.webhookPayloadLocked()
+		j.mu.Unlock()
+		if !alreadyCancelled {
+			j.emitTerminalStatusEvent(status, errMsg, exitCode, statsCopy)
+		}
+		jobWebhook.Notify(payload)
+		return status
+	}
 	if exitCode != 0 {
 		j.setTerminalStatusLocked("error")
 		var firstErr string
@@ -334,19 +457,69 @@ func (j *Job) SetOutcome(results []app.Result, exitCode int) string {
 
 		errMsg := j.Error
 		statsCopy := j.Stats
+		payload := j.webhookPayloadLocked()
 		j.mu.Unlock()
 		j.emitTerminalStatusEvent(status, errMsg, exitCode, statsCopy)
+		jobWebhook.Notify(payload)
 		return status
 	}
 
 	j.setTerminalStatusLocked("complete")
 	status := j.Status
 	statsCopy := j.Stats
+	payload := j.webhookPayloadLocked()
 	j.mu.Unlock()
 	j.emitTerminalStatusEvent(status, "", exitCode, statsCopy)
+	jobWebhook.Notify(payload)
+	return status
+}
+
+// SetTimedOut records the outcome of a run the download pool stopped at its
+// max-job-duration deadline. Whatever the partial results say, the job is an
+// error carrying timeout's message.
+func (j *Job) SetTimedOut(results []app.Result, exitCode int, timeout error) string {
+	resultsCopy := append([]app.Result(nil), results...)
+	if exitCode == 0 {
+		exitCode = 1
+	}
+
+	j.mu.Lock()
+	j.Results = resultsCopy
+	j.ExitCode = exitCode
+	j.Stats = computeProgressStats(resultsCopy)
+	j.setTerminalStatusLocked("error")
+	j.Error = timeout.Error()
+	status := j.Status
+	errMsg := j.Error
+	statsCopy := j.Stats
+	payload := j.webhookPayloadLocked()
+	j.mu.Unlock()
+	j.emitTerminalStatusEvent(status, errMsg, exitCode, statsCopy)
+	jobWebhook.Notify(payload)
 	return status
 }
 
+// cancelledByUser reports whether the job was stopped through Cancel
+// (/api/download/cancel or cancel-all), rather than by a deadline or a server
+// shutdown. A run that fails because of the cancellation is reported as
+// cancelled, not as an error.
+func (j *Job) cancelledByUser() bool {
+	return j.userCancelled.Load()
+}
+
+func (j *Job) webhookPayloadLocked() WebhookPayload {
+	return WebhookPayload{
+		JobID:       j.ID,
+		Status:      j.Status,
+		ExitCode:    j.ExitCode,
+		Error:       j.Error,
+		URLs:        append([]string(nil), j.URLs...),
+		Results:     append([]app.Result(nil), j.Results...),
+		Stats:       j.Stats,
+		CompletedAt: j.CompletedAt,
+	}
+}
+
 func (j *Job) emitStatusEvent(status, message string) {
 	if j == nil {
 		return
@@ -392,7 +565,7 @@ func (j *Job) isExpired(now time.Time, completedTTL, erroredTTL time.Duration) b
 			return false
 		}
 		return now.Sub(completedAt) > completedTTL
-	case "error":
+	case "error", "cancelled":
 		if erroredTTL <= 0 {
 			return false
 		}
@@ -598,7 +771,9 @@ func (j *Job) recordEvent(evt ProgressEvent) {
 		j.eventHistory = append([]ProgressEvent(nil), j.eventHistory[over:]...)
 	}
 	j.applyEventLocked(evt)
-	BroadcastEvent(evt)
+	if !evt.relayed {
+		BroadcastEvent(evt)
+	}
 
 	subs := make([]chan ProgressEvent, 0, len(j.subscribers))
 
@@ -713,12 +888,7 @@ func (j *Job) Subscribe(afterSeq int64) (<-chan ProgressEvent, func()) {
 	snapshotEvt := j.snapshotEvent()
 
 	j.eventMu.Lock()
-	replay := make([]ProgressEvent, 0, len(j.eventHistory))
-	for _, evt := range j.eventHistory {
-		if evt.Seq > afterSeq {
-			replay = append(replay, evt)
-		}
-	}
+	replay := j.eventsSinceLocked(afterSeq)
 	bufferSize := baseSubscriberBufferLen + len(replay) + 1
 	if bufferSize < baseSubscriberBufferLen {
 		bufferSize = baseSubscriberBufferLen
@@ -748,6 +918,50 @@ func (j *Job) Subscribe(afterSeq int64) (<-chan ProgressEvent, func()) {
 	return ch, cancel
 }
 
+// ProgressPoll is the non-streaming view of a job returned to clients that
+// cannot hold an SSE connection open.
+type ProgressPoll struct {
+	Snapshot ProgressSnapshot `json:"snapshot"`
+	Events   []ProgressEvent  `json:"events"`
+	// LastSeq is the afterSeq to send with the next poll.
+	LastSeq int64 `json:"lastSeq"`
+}
+
+// Poll returns the job's current snapshot plus the retained events newer
+// than afterSeq, the same replay Subscribe delivers, without registering a
+// subscriber.
+func (j *Job) Poll(afterSeq int64) ProgressPoll {
+	if afterSeq < 0 {
+		afterSeq = 0
+	}
+	snapshot := j.progressSnapshot()
+
+	j.eventMu.Lock()
+	events := j.eventsSinceLocked(afterSeq)
+	j.eventMu.Unlock()
+
+	lastSeq := snapshot.LastSeq
+	if n := len(events); n > 0 && events[n-1].Seq > lastSeq {
+		lastSeq = events[n-1].Seq
+	}
+	if lastSeq < afterSeq {
+		lastSeq = afterSeq
+	}
+	return ProgressPoll{Snapshot: snapshot, Events: events, LastSeq: lastSeq}
+}
+
+// eventsSinceLocked copies the retained events with a sequence above
+// afterSeq. The caller must hold j.eventMu.
+func (j *Job) eventsSinceLocked(afterSeq int64) []ProgressEvent {
+	events := make([]ProgressEvent, 0, len(j.eventHistory))
+	for _, evt := range j.eventHistory {
+		if evt.Seq > afterSeq {
+			events = append(events, evt)
+		}
+	}
+	return events
+}
+
 func (j *Job) snapshotEvent() ProgressEvent {
 	snapshot := j.progressSnapshot()
 	return ProgressEvent{
@@ -892,6 +1106,15 @@ var _ downloader.DuplicatePrompter = (*webDuplicatePrompter)(nil)
 type webRenderer struct {
 	events chan<- ProgressEvent
 	closed atomic.Bool
+	// next, when set, receives every call as well and supplies the task IDs,
+	// so a job's snapshot and SSE stream use the same IDs as the pool's
+	// WebSocket progress.
+	next downloader.ProgressRenderer
+}
+
+// newJobRenderer records progress on job while forwarding it to next.
+func newJobRenderer(job *Job, next downloader.ProgressRenderer) *webRenderer {
+	return &webRenderer{events: job.Events, next: next}
 }
 
 func (w *webRenderer) Close() {
@@ -910,23 +1133,35 @@ func safeEnqueueEvent(w *webRenderer, evt ProgressEvent) {
 
 func (w *webRenderer) Register(prefix string, size int64) string {
 	id := fmt.Sprintf("%s@%d", prefix, time.Now().UnixNano())
-	safeEnqueueEvent(w, ProgressEvent{Type: "register", ID: id, Label: prefix, Total: size})
+	if w.next != nil {
+		id = w.next.Register(prefix, size)
+	}
+	safeEnqueueEvent(w, ProgressEvent{Type: "register", ID: id, Label: prefix, Total: size, relayed: w.next != nil})
 	return id
 }
 
 func (w *webRenderer) Update(id string, current, total int64) {
+	if w.next != nil {
+		w.next.Update(id, current, total)
+	}
 	percent := 0.0
 	if total > 0 {
 		percent = float64(current) * 100 / float64(total)
 	}
-	safeEnqueueEvent(w, ProgressEvent{Type: "progress", ID: id, Current: current, Total: total, Percent: percent})
+	safeEnqueueEvent(w, ProgressEvent{Type: "progress", ID: id, Current: current, Total: total, Percent: percent, relayed: w.next != nil})
 }
 
 func (w *webRenderer) Finish(id string) {
-	safeEnqueueEvent(w, ProgressEvent{Type: "finish", ID: id})
+	if w.next != nil {
+		w.next.Finish(id)
+	}
+	safeEnqueueEvent(w, ProgressEvent{Type: "finish", ID: id, relayed: w.next != nil})
 }
 
 func (w *webRenderer) Log(level downloader.LogLevel, msg string) {
+	if w.next != nil {
+		w.next.Log(level, msg)
+	}
 	levelStr := "info"
 	switch level {
 	case downloader.LogDebug:
@@ -2,6 +2,7 @@ package web
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"testing"
 	"time"
@@ -112,6 +113,38 @@ func TestJobTrackerRemoveExpired(t *testing.T) {
 	}
 }
 
+func TestJobTrackerCleanupHonorsConfiguredTTL(t *testing.T) {
+	jt := &jobTracker{}
+
+	longLived := createTestJob(t, jt, []string{"https://example.com/1"})
+	longLived.SetOutcome(nil, 0)
+	setCompletedAtForTest(longLived, time.Now().Add(-16*time.Minute))
+
+	shortLived := createTestJob(t, jt, []string{"https://example.com/2"})
+	shortLived.SetOutcome(nil, 1)
+	setCompletedAtForTest(shortLived, time.Now().Add(-time.Minute))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	jt.StartCleanup(ctx, 10*time.Millisecond, time.Hour, time.Second)
+
+	waitForCondition(t, 2*time.Second, func() bool {
+		_, ok := jt.Get(shortLived.ID)
+		return !ok
+	}, func() string { return "expected errored job to be removed by cleanup tick" })
+
+	if _, ok := jt.Get(longLived.ID); !ok {
+		t.Fatalf("expected completed job with 1h TTL to survive cleanup")
+	}
+}
+
+func TestListenAndServeRejectsNegativeJobTTL(t *testing.T) {
+	err := ListenAndServe(context.Background(), "127.0.0.1:0", 1, ServerOptions{JobCompletedTTL: -time.Second})
+	if err == nil {
+		t.Fatalf("expected error for negative job TTL")
+	}
+}
+
 func TestJobConcurrentStateAccess(t *testing.T) {
 	jt := &jobTracker{}
 	job := createTestJob(t, jt, []string{"https://example.com"})
@@ -354,3 +387,244 @@ func TestJobProgressSnapshotIncludesTaskDuplicateAndStats(t *testing.T) {
 		t.Fatalf("unexpected stats: %+v", snapshot.Stats)
 	}
 }
+
+func TestJobPollReturnsSnapshotAndDelta(t *testing.T) {
+	jt := &jobTracker{}
+	job := createTestJob(t, jt, []string{"https://example.com"})
+
+	startSeq := job.eventSeq.Load()
+	if !job.enqueueCriticalEvent(ProgressEvent{Type: "register", ID: "task_1", Label: "Track 1", Total: 100}, time.Second) {
+		t.Fatalf("failed to enqueue register event")
+	}
+	waitForEventSeq(t, job, startSeq+1)
+
+	first := job.Poll(0)
+	if first.Snapshot.JobID != job.ID || len(first.Snapshot.Tasks) != 1 {
+		t.Fatalf("expected snapshot with the registered task, got %+v", first.Snapshot)
+	}
+	if len(first.Events) == 0 || first.Events[len(first.Events)-1].Type != "register" {
+		t.Fatalf("expected full replay ending in register, got %+v", first.Events)
+	}
+	if want := first.Events[len(first.Events)-1].Seq; first.LastSeq != want {
+		t.Fatalf("expected lastSeq %d, got %d", want, first.LastSeq)
+	}
+
+	if !job.enqueueCriticalEvent(ProgressEvent{Type: "progress", ID: "task_1", Current: 40, Total: 100}, time.Second) {
+		t.Fatalf("failed to enqueue progress event")
+	}
+	waitForEventSeq(t, job, first.LastSeq+1)
+
+	delta := job.Poll(first.LastSeq)
+	if len(delta.Events) != 1 || delta.Events[0].Type != "progress" || delta.Events[0].Seq != first.LastSeq+1 {
+		t.Fatalf("expected only the new progress event, got %+v", delta.Events)
+	}
+	if delta.Snapshot.Tasks[0].Current != 40 {
+		t.Fatalf("expected snapshot to reflect current progress, got %+v", delta.Snapshot.Tasks)
+	}
+
+	idle := job.Poll(delta.LastSeq)
+	if len(idle.Events) != 0 || idle.LastSeq != delta.LastSeq {
+		t.Fatalf("expected empty delta with unchanged lastSeq, got %d events, lastSeq %d", len(idle.Events), idle.LastSeq)
+	}
+
+	job.eventMu.Lock()
+	subscribers := len(job.subscribers)
+	job.eventMu.Unlock()
+	if subscribers != 0 {
+		t.Fatalf("expected polling to leave no subscribers, got %d", subscribers)
+	}
+}
+
+func TestJobTrackerCancelActive(t *testing.T) {
+	jt := &jobTracker{}
+	var active []*Job
+	for i := 0; i < 3; i++ {
+		active = append(active, createTestJob(t, jt, []string{"https://example.com"}))
+	}
+	active[2].SetStatus("running")
+	finished := createTestJob(t, jt, []string{"https://example.com/done"})
+	finished.SetOutcome(nil, 0)
+
+	// A job completing while cancel-all runs must not break either side.
+	racing := createTestJob(t, jt, []string{"https://example.com/racing"})
+	done := make(chan struct{})
+	go func() {
+		racing.SetOutcome(nil, 0)
+		close(done)
+	}()
+
+	cancelled := jt.CancelActive()
+	<-done
+	if cancelled < 3 || cancelled > 4 {
+		t.Fatalf("expected the 3 active jobs (plus possibly the racing one) to be cancelled, got %d", cancelled)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for _, job := range active {
+		for job.StatusValue() != "cancelled" && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		job.mu.RLock()
+		status, errMsg := job.Status, job.Error
+		job.mu.RUnlock()
+		if status != "cancelled" || errMsg != "Cancelled by user" {
+			t.Fatalf("job %s: expected cancelled state, got %q (%q)", job.ID, status, errMsg)
+		}
+	}
+	if status := finished.StatusValue(); status != "complete" {
+		t.Fatalf("expected finished job to stay complete, got %q", status)
+	}
+	if status := racing.StatusValue(); status != "complete" {
+		t.Fatalf("expected the job that completed first to keep its outcome, got %q", status)
+	}
+	if n := jt.CancelActive(); n != 0 {
+		t.Fatalf("expected nothing left to cancel, got %d", n)
+	}
+}
+
+func TestJobCancelReportsCancelledNotError(t *testing.T) {
+	jt := &jobTracker{}
+	job := createTestJob(t, jt, []string{"https://example.com"})
+	job.SetStatus("running")
+	waitForEventSeq(t, job, 2)
+
+	job.Cancel()
+	// The download run then fails with context.Canceled.
+	status := job.SetOutcome([]app.Result{{URL: "https://example.com", Error: "context canceled"}}, 1)
+	if status != "cancelled" {
+		t.Fatalf("expected a cancelled job to report cancelled, got %q", status)
+	}
+	waitForEventSeq(t, job, 3)
+	snapshot := job.progressSnapshot()
+	if snapshot.Status != "cancelled" || snapshot.Error != "Cancelled by user" {
+		t.Fatalf("expected snapshot to show the cancellation, got %q (%q)", snapshot.Status, snapshot.Error)
+	}
+	for _, evt := range job.Poll(2).Events {
+		if evt.Type == "status" && evt.Status != "cancelled" {
+			t.Fatalf("expected only cancelled status events, got %+v", evt)
+		}
+	}
+	if page, _ := jt.List("cancelled", 0, 10); len(page) != 1 || page[0].ID != job.ID {
+		t.Fatalf("expected the job under the cancelled filter, got %+v", page)
+	}
+
+	// A deadline is a failure, not a user cancellation.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	timedOut := jt.Create(ctx, []string{"https://example.com/slow"})
+	t.Cleanup(timedOut.CloseEvents)
+	<-timedOut.Context().Done()
+	if status := timedOut.SetOutcome([]app.Result{{URL: "https://example.com/slow", Error: "context deadline exceeded"}}, 1); status != "error" {
+		t.Fatalf("expected a timed-out job to report error, got %q", status)
+	}
+}
+
+func TestJobTrackerListFiltersAndPaginates(t *testing.T) {
+	jt := &jobTracker{}
+	var jobs []*Job
+	for i := 0; i < 5; i++ {
+		jobs = append(jobs, createTestJob(t, jt, []string{"https://example.com"}))
+	}
+	jobs[0].SetOutcome([]app.Result{{URL: "https://example.com"}}, 0)
+	jobs[1].SetOutcome([]app.Result{{URL: "https://example.com", Error: "boom"}}, 1)
+	jobs[2].SetStatus("running")
+	jobs[3].SetStatus("running")
+
+	all, next := jt.List("", 0, 10)
+	if len(all) != 5 || next != nil {
+		t.Fatalf("expected all 5 jobs on one page, got %d (next %v)", len(all), next)
+	}
+	if all[0].ID != jobs[4].ID || all[4].ID != jobs[0].ID {
+		t.Fatalf("expected newest first, got %s..%s", all[0].ID, all[4].ID)
+	}
+
+	running, _ := jt.List("running", 0, 10)
+	if len(running) != 2 || running[0].ID != jobs[3].ID || running[1].ID != jobs[2].ID {
+		t.Fatalf("unexpected running jobs: %+v", running)
+	}
+	failed, _ := jt.List("error", 0, 10)
+	if len(failed) != 1 || failed[0].Stats.Failed != 1 || failed[0].CompletedAt == nil {
+		t.Fatalf("unexpected error jobs: %+v", failed)
+	}
+	complete, _ := jt.List("complete", 0, 10)
+	if len(complete) != 1 || complete[0].ID != jobs[0].ID || complete[0].Stats.Succeeded != 1 {
+		t.Fatalf("unexpected complete jobs: %+v", complete)
+	}
+
+	page, next := jt.List("", 0, 2)
+	if len(page) != 2 || next == nil || *next != 2 {
+		t.Fatalf("expected first page of 2 with next offset 2, got %d (next %v)", len(page), next)
+	}
+	page, next = jt.List("", *next, 2)
+	if len(page) != 2 || page[0].ID != jobs[2].ID || next == nil || *next != 4 {
+		t.Fatalf("unexpected second page: %+v (next %v)", page, next)
+	}
+	page, next = jt.List("", *next, 2)
+	if len(page) != 1 || next != nil {
+		t.Fatalf("expected a final page of 1, got %d (next %v)", len(page), next)
+	}
+	if page, next := jt.List("", 10, 2); len(page) != 0 || next != nil {
+		t.Fatalf("expected an empty page past the end, got %d (next %v)", len(page), next)
+	}
+}
+
+func TestJobSetTimedOutReportsTheTimeout(t *testing.T) {
+	jt := &jobTracker{}
+	job := createTestJob(t, jt, []string{"https://example.com"})
+	job.SetStatus("running")
+
+	timeout := fmt.Errorf("%w after %s", downloader.ErrTaskTimeout, time.Minute)
+	if status := job.SetTimedOut([]app.Result{{URL: "https://example.com"}}, 0, timeout); status != "error" {
+		t.Fatalf("expected a timed-out job to report error, got %q", status)
+	}
+	waitForEventSeq(t, job, 3)
+	snapshot := job.progressSnapshot()
+	if snapshot.Status != "error" || snapshot.Error != "job timed out after 1m0s" || snapshot.ExitCode != 1 {
+		t.Fatalf("expected the snapshot to carry the timeout, got %q (%q, exit %d)", snapshot.Status, snapshot.Error, snapshot.ExitCode)
+	}
+}
+
+func TestJobCancelSendsOneCancelledEvent(t *testing.T) {
+	jt := &jobTracker{}
+	job := createTestJob(t, jt, []string{"https://example.com"})
+	job.SetStatus("running")
+	waitForEventSeq(t, job, 2)
+
+	job.Cancel()
+	waitForEventSeq(t, job, 3)
+	job.SetOutcome([]app.Result{{URL: "https://example.com", Error: "context canceled"}}, 130)
+
+	cancelled := 0
+	for _, evt := range job.Poll(0).Events {
+		if evt.Type == "status" && evt.Status == "cancelled" {
+			cancelled++
+		}
+	}
+	if cancelled != 1 {
+		t.Fatalf("expected exactly one cancelled event, got %d", cancelled)
+	}
+}
+
+func TestJobShutdownIsNotAUserCancel(t *testing.T) {
+	jt := &jobTracker{}
+	serverCtx, shutdown := context.WithCancel(context.Background())
+	job := jt.Create(serverCtx, []string{"https://example.com"})
+	t.Cleanup(job.CloseEvents)
+	job.SetStatus("running")
+
+	shutdown()
+	<-job.Context().Done()
+	status := job.SetOutcome([]app.Result{{URL: "https://example.com", Error: "context canceled"}}, 130)
+	if status == "cancelled" {
+		t.Fatal("expected a server shutdown not to be reported as a user cancellation")
+	}
+	snapshot := job.progressSnapshot()
+	if snapshot.Error == cancelledByUserMessage {
+		t.Fatalf("expected the shutdown not to be labelled %q", cancelledByUserMessage)
+	}
+	for _, evt := range job.Poll(0).Events {
+		if evt.Type == "status" && evt.Status == "cancelled" {
+			t.Fatalf("expected no cancelled event on shutdown, got %+v", evt)
+		}
+	}
+}
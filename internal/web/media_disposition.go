@@ -0,0 +1,61 @@
+package web
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lvcoi/ytdl-go/internal/downloader"
+)
+
+// mediaDownloadName picks the filename a browser saves a media file under
+// for ?download=1: the stored title plus the file's extension, falling back
+// to the file's own name when there is no usable title.
+func mediaDownloadName(mediaDir, reqPath, fullPath, metadataStore string) string {
+	base := filepath.Base(fullPath)
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return base
+	}
+	relPath := filepath.ToSlash(filepath.Clean(reqPath))
+	metadata, err := storedMediaMetadata(fullPath, relPath, downloader.MetadataIndexPath(mediaDir), metadataStore == downloader.MetadataStoreIndex, info)
+	if err != nil || strings.TrimSpace(metadata.Title) == "" {
+		return base
+	}
+	return downloader.SanitizeFilename(metadata.Title) + filepath.Ext(fullPath)
+}
+
+// attachmentDisposition builds a Content-Disposition header that saves the
+// response as name. Names outside printable ASCII get an RFC 5987
+// filename* parameter, with an ASCII filename fallback for older clients.
+func attachmentDisposition(name string) string {
+	fallback := strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e || r == '"' || r == '\\' {
+			return '_'
+		}
+		return r
+	}, name)
+	header := fmt.Sprintf(`attachment; filename="%s"`, fallback)
+	if fallback != name {
+		header += "; filename*=UTF-8''" + encodeRFC5987(name)
+	}
+	return header
+}
+
+// encodeRFC5987 percent-encodes every byte of s outside RFC 5987's attr-char
+// set.
+func encodeRFC5987(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9',
+			strings.IndexByte("!#$&+-.^_`|~", c) >= 0:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
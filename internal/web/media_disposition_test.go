@@ -0,0 +1,66 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMediaServeDownloadSetsContentDisposition(t *testing.T) {
+	withTempCWD(t, func(tmpDir string) {
+		tracker = &jobTracker{}
+
+		audioDir := filepath.Join(tmpDir, "media", "audio", "Artist")
+		if err := os.MkdirAll(audioDir, 0o755); err != nil {
+			t.Fatalf("mkdir audio: %v", err)
+		}
+		mediaPath := filepath.Join(audioDir, "abc123.mp3")
+		if err := os.WriteFile(mediaPath, []byte("audio"), 0o644); err != nil {
+			t.Fatalf("write media: %v", err)
+		}
+		if err := os.WriteFile(mediaPath+".json", []byte(`{"id":"abc123","title":"Café: \"Live\"","status":"ok"}`), 0o644); err != nil {
+			t.Fatalf("write sidecar: %v", err)
+		}
+		plainPath := filepath.Join(tmpDir, "media", "clip.mp4")
+		if err := os.WriteFile(plainPath, []byte("video"), 0o644); err != nil {
+			t.Fatalf("write media: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		baseURL, wait := startWebServerForTest(t, ctx)
+		defer func() {
+			cancel()
+			wait()
+		}()
+		client := &http.Client{Timeout: 3 * time.Second}
+		get := func(path string) *http.Response {
+			t.Helper()
+			resp, err := client.Get(baseURL + path)
+			if err != nil {
+				t.Fatalf("GET %s: %v", path, err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("GET %s: status %d", path, resp.StatusCode)
+			}
+			return resp
+		}
+
+		if got := get("/api/media/audio/Artist/abc123.mp3").Header.Get("Content-Disposition"); got != "" {
+			t.Fatalf("expected inline playback without Content-Disposition, got %q", got)
+		}
+
+		want := `attachment; filename="Caf_- -Live-.mp3"; filename*=UTF-8''Caf%C3%A9-%20-Live-.mp3`
+		if got := get("/api/media/audio/Artist/abc123.mp3?download=1").Header.Get("Content-Disposition"); got != want {
+			t.Fatalf("Content-Disposition = %q, want %q", got, want)
+		}
+
+		// Without a sidecar the title falls back to the file name.
+		if got := get("/api/media/clip.mp4?download=1").Header.Get("Content-Disposition"); got != `attachment; filename="clip.mp4"` {
+			t.Fatalf("Content-Disposition = %q, want the file name", got)
+		}
+	})
+}
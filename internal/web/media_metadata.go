@@ -0,0 +1,136 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lvcoi/ytdl-go/internal/downloader"
+)
+
+// mediaMetadataSuffix marks PATCH /api/media/{path}/metadata requests.
+const mediaMetadataSuffix = "/metadata"
+
+type mediaMetadataResponse struct {
+	Status   string                  `json:"status"`
+	Metadata downloader.ItemMetadata `json:"metadata"`
+	Retagged bool                    `json:"retagged"`
+}
+
+// updateMediaMetadata merges a partial ItemMetadata body into a media file's
+// stored metadata: its sidecar, or the NDJSON index when metadataStore is
+// "index". Fields missing from the body keep their stored values, and a file
+// without metadata starts from the same defaults the listing shows. The new
+// tags are then embedded into the file where the format allows it.
+func updateMediaMetadata(w http.ResponseWriter, r *http.Request, mediaDir, reqPath, metadataStore string) {
+	reqPath = strings.TrimSuffix(reqPath, mediaMetadataSuffix)
+	if reqPath == "" {
+		writeJSONError(w, http.StatusBadRequest, "file path is required")
+		return
+	}
+	fullPath, status, err := resolveMediaPath(mediaDir, reqPath)
+	if err != nil {
+		if status == 0 {
+			status = http.StatusBadRequest
+		}
+		writeJSONError(w, status, err.Error())
+		return
+	}
+	info, err := os.Stat(fullPath)
+	if err != nil || info.IsDir() {
+		writeJSONError(w, http.StatusNotFound, "file not found")
+		return
+	}
+
+	relPath := filepath.ToSlash(filepath.Clean(reqPath))
+	indexPath := downloader.MetadataIndexPath(mediaDir)
+	useIndex := metadataStore == downloader.MetadataStoreIndex
+	metadata, err := storedMediaMetadata(fullPath, relPath, indexPath, useIndex, info)
+	if err != nil {
+		log.Printf("failed reading metadata for %q: %v", relPath, err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to read metadata")
+		return
+	}
+	if reqErr := decodeJSONBody(w, r, &metadata); reqErr != nil {
+		writeJSONError(w, reqErr.status, reqErr.message)
+		return
+	}
+
+	if useIndex {
+		err = downloader.AppendMetadataIndex(indexPath, relPath, &metadata)
+	} else {
+		err = writeMediaSidecar(fullPath+".json", metadata)
+	}
+	if err != nil {
+		log.Printf("failed saving metadata for %q: %v", relPath, err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to save metadata")
+		return
+	}
+
+	audio := mediaTypeForExtension(filepath.Ext(fullPath)) == "audio"
+	retagged, err := downloader.RetagFile(fullPath, metadata, audio)
+	if err != nil {
+		log.Printf("failed embedding tags into %q: %v", relPath, err)
+		retagged = false
+	}
+
+	broadcastLibraryUpdate("updated", relPath)
+	writeJSON(w, http.StatusOK, mediaMetadataResponse{
+		Status:   "ok",
+		Metadata: normalizeMediaMetadata(metadata, relPath, info),
+		Retagged: retagged,
+	})
+}
+
+// storedMediaMetadata returns a file's current metadata as stored, without
+// the listing's normalization, so only fields the caller patches change.
+func storedMediaMetadata(fullPath, relPath, indexPath string, useIndex bool, info os.FileInfo) (downloader.ItemMetadata, error) {
+	if useIndex {
+		index, err := downloader.LoadMetadataIndex(indexPath)
+		if err != nil {
+			return downloader.ItemMetadata{}, err
+		}
+		if metadata, ok := index[relPath]; ok {
+			return metadata, nil
+		}
+		return defaultMediaMetadata(relPath, info), nil
+	}
+	data, err := os.ReadFile(fullPath + ".json")
+	if os.IsNotExist(err) {
+		return defaultMediaMetadata(relPath, info), nil
+	}
+	if err != nil {
+		return downloader.ItemMetadata{}, err
+	}
+	var metadata downloader.ItemMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return downloader.ItemMetadata{}, fmt.Errorf("parsing sidecar: %w", err)
+	}
+	return metadata, nil
+}
+
+// writeMediaSidecar replaces a sidecar atomically so a concurrent listing
+// never reads a half-written file.
+func writeMediaSidecar(path string, metadata downloader.ItemMetadata) error {
+	var encoded bytes.Buffer
+	enc := json.NewEncoder(&encoded)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(metadata); err != nil {
+		return fmt.Errorf("encoding sidecar: %w", err)
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, encoded.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("writing sidecar temp file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("committing sidecar: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lvcoi/ytdl-go/internal/downloader"
+)
+
+func patchMediaMetadata(t *testing.T, baseURL, path, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPatch, baseURL+"/api/media/"+path+"/metadata", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("new patch request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := &http.Client{Timeout: 3 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("patch request: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func readSidecarForTest(t *testing.T, path string) downloader.ItemMetadata {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read sidecar: %v", err)
+	}
+	var metadata downloader.ItemMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		t.Fatalf("parse sidecar: %v", err)
+	}
+	return metadata
+}
+
+func TestPatchMediaMetadataMergesIntoSidecar(t *testing.T) {
+	withTempCWD(t, func(tmpDir string) {
+		tracker = &jobTracker{}
+
+		audioDir := filepath.Join(tmpDir, "media", "audio")
+		if err := os.MkdirAll(audioDir, 0o755); err != nil {
+			t.Fatalf("mkdir audio: %v", err)
+		}
+		mediaPath := filepath.Join(audioDir, "song.m4a")
+		if err := os.WriteFile(mediaPath, []byte("audio"), 0o644); err != nil {
+			t.Fatalf("write media: %v", err)
+		}
+		sidecarPath := mediaPath + ".json"
+		if err := os.WriteFile(sidecarPath, []byte(`{"id":"abc123","title":"Song","artist":"Wrong Artist","album":"Album","source_url":"https://www.youtube.com/watch?v=abc123","extractor":"youtube","status":"ok"}`), 0o644); err != nil {
+			t.Fatalf("write sidecar: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		baseURL, wait := startWebServerForTest(t, ctx)
+		defer func() {
+			cancel()
+			wait()
+		}()
+
+		resp := patchMediaMetadata(t, baseURL, "audio/song.m4a", `{"artist":"Right Artist"}`)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200, got %d", resp.StatusCode)
+		}
+		var payload mediaMetadataResponse
+		if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		if payload.Metadata.Artist != "Right Artist" || payload.Metadata.Output != "audio/song.m4a" {
+			t.Fatalf("unexpected response metadata: %+v", payload.Metadata)
+		}
+
+		got := readSidecarForTest(t, sidecarPath)
+		if got.Artist != "Right Artist" {
+			t.Fatalf("artist = %q, want patched value", got.Artist)
+		}
+		if got.ID != "abc123" || got.Title != "Song" || got.Album != "Album" || got.SourceURL == "" {
+			t.Fatalf("unpatched fields changed: %+v", got)
+		}
+
+		// A file without a sidecar gets one built from the listing defaults.
+		barePath := filepath.Join(audioDir, "bare.m4a")
+		if err := os.WriteFile(barePath, []byte("audio"), 0o644); err != nil {
+			t.Fatalf("write bare media: %v", err)
+		}
+		resp = patchMediaMetadata(t, baseURL, "audio/bare.m4a", `{"album":"New Album"}`)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200 for file without sidecar, got %d", resp.StatusCode)
+		}
+		created := readSidecarForTest(t, barePath+".json")
+		if created.Album != "New Album" || created.Title != "bare" {
+			t.Fatalf("unexpected created sidecar: %+v", created)
+		}
+
+		resp = patchMediaMetadata(t, baseURL, "audio/song.m4a", `{"not_a_field":"x"}`)
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("expected 400 for unknown field, got %d", resp.StatusCode)
+		}
+		resp = patchMediaMetadata(t, baseURL, "audio/missing.m4a", `{"album":"x"}`)
+		if resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("expected 404 for missing file, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestPatchMediaMetadataRejectsTraversal(t *testing.T) {
+	withTempCWD(t, func(tmpDir string) {
+		tracker = &jobTracker{}
+
+		if err := os.MkdirAll(filepath.Join(tmpDir, "media"), 0o755); err != nil {
+			t.Fatalf("mkdir media: %v", err)
+		}
+		outside := filepath.Join(tmpDir, "outside.mp3")
+		if err := os.WriteFile(outside, []byte("audio"), 0o644); err != nil {
+			t.Fatalf("write outside file: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		baseURL, wait := startWebServerForTest(t, ctx)
+		defer func() {
+			cancel()
+			wait()
+		}()
+
+		resp := patchMediaMetadata(t, baseURL, "%2e%2e%2foutside.mp3", `{"artist":"x"}`)
+		if resp.StatusCode != http.StatusBadRequest && resp.StatusCode != http.StatusForbidden {
+			t.Fatalf("expected 400/403 for traversal, got %d", resp.StatusCode)
+		}
+		if _, err := os.Stat(outside + ".json"); !os.IsNotExist(err) {
+			t.Fatalf("expected no sidecar written outside the media directory")
+		}
+	})
+}
@@ -0,0 +1,131 @@
+package web
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/lvcoi/ytdl-go/internal/app"
+	"github.com/lvcoi/ytdl-go/internal/downloader"
+)
+
+// metricsContentType is the Prometheus text exposition format.
+const metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// metricsErrorCategories are always exposed, so a category that has not
+// failed yet reads 0 rather than being absent.
+var metricsErrorCategories = []downloader.ErrorCategory{
+	downloader.CategoryUnknown,
+	downloader.CategoryInvalidURL,
+	downloader.CategoryUnsupported,
+	downloader.CategoryRestricted,
+	downloader.CategoryNetwork,
+	downloader.CategoryFilesystem,
+}
+
+// serverMetrics counts finished download tasks for GET /metrics. Unlike the
+// download stats store it lives in memory only: Prometheus counters restart
+// from zero with the process.
+type serverMetrics struct {
+	mu            sync.Mutex
+	jobsCompleted int64
+	jobsFailed    int64
+	bytes         int64
+	errors        map[string]int64
+}
+
+func newServerMetrics() *serverMetrics {
+	counts := make(map[string]int64, len(metricsErrorCategories))
+	for _, category := range metricsErrorCategories {
+		counts[string(category)] = 0
+	}
+	return &serverMetrics{errors: counts}
+}
+
+// RecordTask folds one finished task into the counters.
+func (m *serverMetrics) RecordTask(results []app.Result, exitCode int, items []completedItem) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if exitCode == 0 {
+		m.jobsCompleted++
+	} else {
+		m.jobsFailed++
+	}
+	for _, item := range items {
+		m.bytes += item.Bytes
+	}
+	for _, result := range results {
+		if result.Error == "" {
+			continue
+		}
+		category := result.Category
+		if category == "" {
+			category = string(downloader.CategoryUnknown)
+		}
+		m.errors[category]++
+	}
+}
+
+// WritePrometheus writes the counters and the pool gauges in the Prometheus
+// text format.
+func (m *serverMetrics) WritePrometheus(w io.Writer, pool downloader.PoolStats) error {
+	m.mu.Lock()
+	completed, failed, bytes := m.jobsCompleted, m.jobsFailed, m.bytes
+	categories := make([]string, 0, len(m.errors))
+	for category := range m.errors {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	counts := make([]int64, len(categories))
+	for i, category := range categories {
+		counts[i] = m.errors[category]
+	}
+	m.mu.Unlock()
+
+	metrics := []struct {
+		name, kind, help string
+		value            int64
+	}{
+		{"ytdl_jobs_active", "gauge", "Download tasks currently running.", int64(pool.Running)},
+		{"ytdl_queue_depth", "gauge", "Download tasks waiting for a worker.", int64(pool.Queued)},
+		{"ytdl_workers", "gauge", "Configured download workers.", int64(pool.Size)},
+		{"ytdl_jobs_completed_total", "counter", "Download tasks that finished without errors.", completed},
+		{"ytdl_jobs_failed_total", "counter", "Download tasks that finished with errors.", failed},
+		{"ytdl_downloaded_bytes_total", "counter", "Bytes written by completed downloads.", bytes},
+	}
+	for _, metric := range metrics {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %d\n", metric.name, metric.help, metric.name, metric.kind, metric.name, metric.value); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprint(w, "# HELP ytdl_download_errors_total Failed downloads by error category.\n# TYPE ytdl_download_errors_total counter\n"); err != nil {
+		return err
+	}
+	for i, category := range categories {
+		if _, err := fmt.Fprintf(w, "ytdl_download_errors_total{category=%q} %d\n", category, counts[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// serveMetrics handles GET /metrics. It lives outside /api/ so scrapers use
+// the conventional path.
+func serveMetrics(w http.ResponseWriter, r *http.Request, metrics *serverMetrics, pool *downloader.Pool) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	var stats downloader.PoolStats
+	if pool != nil {
+		stats = pool.Stats()
+	}
+	w.Header().Set("Content-Type", metricsContentType)
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodHead {
+		return
+	}
+	_ = metrics.WritePrometheus(w, stats)
+}
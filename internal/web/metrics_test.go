@@ -0,0 +1,82 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/lvcoi/ytdl-go/internal/app"
+)
+
+// metricsSampleLine matches one sample in the Prometheus text format.
+var metricsSampleLine = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*(\{[a-zA-Z_][a-zA-Z0-9_]*="[^"]*"(,[a-zA-Z_][a-zA-Z0-9_]*="[^"]*")*\})? -?[0-9]+$`)
+
+func TestServeMetricsExpositionFormat(t *testing.T) {
+	metrics := newServerMetrics()
+	metrics.RecordTask([]app.Result{{URL: "a"}}, 0, []completedItem{{Bytes: 100}, {Bytes: 50}})
+	metrics.RecordTask([]app.Result{
+		{URL: "b", Error: "timeout", Category: "network"},
+		{URL: "c", Error: "boom"},
+	}, 1, nil)
+
+	rec := httptest.NewRecorder()
+	serveMetrics(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil), metrics, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/plain; version=0.0.4") {
+		t.Fatalf("Content-Type = %q, want the Prometheus text format", got)
+	}
+
+	body := rec.Body.String()
+	typed := map[string]string{}
+	for _, line := range strings.Split(strings.TrimSuffix(body, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "# HELP "):
+		case strings.HasPrefix(line, "# TYPE "):
+			fields := strings.Fields(line)
+			if len(fields) != 4 {
+				t.Fatalf("malformed TYPE line %q", line)
+			}
+			typed[fields[2]] = fields[3]
+		default:
+			if !metricsSampleLine.MatchString(line) {
+				t.Fatalf("malformed sample line %q in:\n%s", line, body)
+			}
+		}
+	}
+	for name, kind := range map[string]string{
+		"ytdl_jobs_active":            "gauge",
+		"ytdl_queue_depth":            "gauge",
+		"ytdl_jobs_completed_total":   "counter",
+		"ytdl_jobs_failed_total":      "counter",
+		"ytdl_downloaded_bytes_total": "counter",
+		"ytdl_download_errors_total":  "counter",
+	} {
+		if typed[name] != kind {
+			t.Errorf("metric %s has type %q, want %q", name, typed[name], kind)
+		}
+	}
+	for _, sample := range []string{
+		"ytdl_jobs_completed_total 1\n",
+		"ytdl_jobs_failed_total 1\n",
+		"ytdl_downloaded_bytes_total 150\n",
+		`ytdl_download_errors_total{category="network"} 1` + "\n",
+		`ytdl_download_errors_total{category="unknown"} 1` + "\n",
+		`ytdl_download_errors_total{category="filesystem"} 0` + "\n",
+	} {
+		if !strings.Contains(body, sample) {
+			t.Errorf("expected sample %q in:\n%s", sample, body)
+		}
+	}
+}
+
+func TestServeMetricsRejectsPost(t *testing.T) {
+	rec := httptest.NewRecorder()
+	serveMetrics(rec, httptest.NewRequest(http.MethodPost, "/metrics", nil), newServerMetrics(), nil)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("status = %d, want 405", rec.Code)
+	}
+}
@@ -0,0 +1,108 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultSSEHeartbeatInterval is the -sse-heartbeat default.
+const DefaultSSEHeartbeatInterval = 15 * time.Second
+
+// progressStreamPath serves a job's progress events as SSE. It is excluded
+// from response compression, which would buffer the stream.
+const progressStreamPath = "/api/download/progress"
+
+// serveProgressStream streams a job's snapshot, replayed events and live
+// events as server-sent events. Whenever heartbeat passes without an event, a
+// ": ping" comment is written so proxies do not drop the idle connection; a
+// heartbeat of 0 disables it. The resume point comes from the since query
+// parameter or, on an EventSource reconnect, the Last-Event-ID header.
+func serveProgressStream(w http.ResponseWriter, r *http.Request, heartbeat time.Duration) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	jobID := strings.TrimSpace(r.URL.Query().Get("id"))
+	if jobID == "" {
+		writeJSONError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+	var since int64
+	if raw := r.URL.Query().Get("since"); raw != "" {
+		seq, ok := parseProgressSeq(raw)
+		if !ok {
+			writeJSONError(w, http.StatusBadRequest, "since must be a non-negative integer")
+			return
+		}
+		since = seq
+	} else if seq, ok := parseProgressSeq(r.Header.Get("Last-Event-ID")); ok {
+		since = seq
+	}
+	job, ok := tracker.Get(jobID)
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, "job not found")
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSONError(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+
+	// Streams outlive the server's write timeout; heartbeats and the
+	// request context bound them instead.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	stream, cancel := job.Subscribe(since)
+	defer cancel()
+
+	var (
+		ticker *time.Ticker
+		tick   <-chan time.Time
+	)
+	if heartbeat > 0 {
+		ticker = time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case evt, ok := <-stream:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(evt)
+			if err != nil {
+				continue
+			}
+			if evt.Seq > 0 {
+				fmt.Fprintf(w, "id: %d\n", evt.Seq)
+			}
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+				return
+			}
+			flusher.Flush()
+			if ticker != nil {
+				ticker.Reset(heartbeat)
+			}
+		case <-tick:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
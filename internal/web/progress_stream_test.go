@@ -0,0 +1,137 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgressStreamSendsHeartbeatsWhileIdle(t *testing.T) {
+	origTracker := tracker
+	tracker = &jobTracker{}
+	t.Cleanup(func() { tracker = origTracker })
+
+	job := tracker.Create(context.Background(), []string{"https://example.com"})
+	t.Cleanup(job.CloseEvents)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveProgressStream(w, r, 20*time.Millisecond)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"?id="+job.ID, nil)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("stream request: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("expected text/event-stream, got %q", ct)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	sawSnapshot := false
+	pings := 0
+	for pings < 3 && scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == ": ping":
+			pings++
+		case strings.HasPrefix(line, "data: "):
+			var evt ProgressEvent
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &evt); err != nil {
+				t.Fatalf("invalid event %q: %v", line, err)
+			}
+			if evt.Type == "snapshot" {
+				sawSnapshot = true
+			}
+		}
+	}
+	if !sawSnapshot {
+		t.Fatal("expected the stream to open with a snapshot event")
+	}
+	if pings < 3 {
+		t.Fatalf("expected heartbeats on an idle job, got %d (scan err %v)", pings, scanner.Err())
+	}
+}
+
+func TestProgressStreamValidatesRequest(t *testing.T) {
+	origTracker := tracker
+	tracker = &jobTracker{}
+	t.Cleanup(func() { tracker = origTracker })
+
+	for target, want := range map[string]int{
+		"/?since=1":           http.StatusBadRequest,
+		"/?id=job_1&since=-1": http.StatusBadRequest,
+		"/?id=job_missing":    http.StatusNotFound,
+	} {
+		rec := httptest.NewRecorder()
+		serveProgressStream(rec, httptest.NewRequest(http.MethodGet, target, nil), time.Second)
+		if rec.Code != want {
+			t.Fatalf("%s: expected %d, got %d", target, want, rec.Code)
+		}
+	}
+}
+
+func TestProgressStreamFollowsPoolDownload(t *testing.T) {
+	withTempCWD(t, func(_ string) {
+		tracker = &jobTracker{}
+		mediaURL, _ := newStallingMediaServer(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		baseURL, wait := startWebServerForTest(t, ctx)
+		defer func() {
+			cancel()
+			wait()
+		}()
+
+		client := &http.Client{Timeout: 5 * time.Second}
+		jobID := postDownload(t, client, baseURL, mediaURL)
+		resp, err := client.Get(baseURL + progressStreamPath + "?id=" + jobID + "&since=0")
+		if err != nil {
+			t.Fatalf("stream request: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected the stream for %s, got %d", jobID, resp.StatusCode)
+		}
+
+		scanner := bufio.NewScanner(resp.Body)
+		var sawProgress, cancelled bool
+		for !cancelled && scanner.Scan() {
+			line := scanner.Text()
+			if !strings.HasPrefix(line, "data: ") {
+				continue
+			}
+			var evt ProgressEvent
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &evt); err != nil {
+				t.Fatalf("invalid event %q: %v", line, err)
+			}
+			switch {
+			case evt.Type == "register" && evt.ID == jobID && !sawProgress:
+				sawProgress = true
+				body := strings.NewReader(`{"jobId":"` + jobID + `"}`)
+				cancelResp, err := client.Post(baseURL+"/api/download/cancel", "application/json", body)
+				if err != nil {
+					t.Fatalf("cancel request: %v", err)
+				}
+				cancelResp.Body.Close()
+			case evt.Type == "status" && evt.Status == "cancelled":
+				cancelled = true
+			}
+		}
+		if !sawProgress || !cancelled {
+			t.Fatalf("expected task progress then a cancelled status, progress=%v cancelled=%v (scan err %v)", sawProgress, cancelled, scanner.Err())
+		}
+	})
+}
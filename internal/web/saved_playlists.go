@@ -2,11 +2,15 @@ package web
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
+
+	"github.com/lvcoi/ytdl-go/internal/downloader"
 )
 
 const (
@@ -24,6 +28,9 @@ type savedPlaylist struct {
 type savedPlaylistState struct {
 	Playlists   []savedPlaylist   `json:"playlists"`
 	Assignments map[string]string `json:"assignments"`
+	// Order lists each playlist's media keys in play order. Keys assigned to
+	// a playlist but missing from its order follow the ordered ones.
+	Order map[string][]string `json:"order,omitempty"`
 }
 
 type savedPlaylistMigrationResponse struct {
@@ -32,6 +39,32 @@ type savedPlaylistMigrationResponse struct {
 	Migrated    bool              `json:"migrated"`
 }
 
+// errUnknownSavedPlaylist is returned by Assign for a playlist ID that is not
+// in the stored state.
+var errUnknownSavedPlaylist = errors.New("unknown saved playlist")
+
+type savedPlaylistAssignRequest struct {
+	Paths []string `json:"paths"`
+}
+
+type savedPlaylistOrderRequest struct {
+	Order []string `json:"order"`
+}
+
+type savedPlaylistAssignResponse struct {
+	Playlists   []savedPlaylist     `json:"playlists"`
+	Assignments map[string]string   `json:"assignments"`
+	Order       map[string][]string `json:"order,omitempty"`
+	Assigned    []string            `json:"assigned"`
+	Skipped     []string            `json:"skipped"`
+}
+
+type savedPlaylistExportResponse struct {
+	Path    string   `json:"path"`
+	Entries []string `json:"entries"`
+	Skipped []string `json:"skipped"`
+}
+
 type savedPlaylistStore struct {
 	path string
 	mu   sync.Mutex
@@ -48,12 +81,21 @@ func (s *savedPlaylistStore) Load() (savedPlaylistState, error) {
 	return s.loadLocked()
 }
 
+// Replace stores next as the whole state. A state without an order keeps
+// the stored one, minus entries the new assignments no longer support, so
+// clients that only know about assignments do not wipe it.
 func (s *savedPlaylistStore) Replace(next savedPlaylistState) (savedPlaylistState, error) {
-	normalized := normalizeSavedPlaylistState(next)
-
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if next.Order == nil {
+		current, err := s.loadLocked()
+		if err != nil {
+			return emptySavedPlaylistState(), err
+		}
+		next.Order = current.Order
+	}
+	normalized := normalizeSavedPlaylistState(next)
 	if err := s.saveLocked(normalized); err != nil {
 		return emptySavedPlaylistState(), err
 	}
@@ -79,6 +121,62 @@ func (s *savedPlaylistStore) MigrateFromLegacy(next savedPlaylistState) (savedPl
 	return cloneSavedPlaylistState(normalizedIncoming), true, nil
 }
 
+// Assign points every media key at playlistID in one locked
+// read-modify-write, so concurrent clients cannot drop each other's updates.
+func (s *savedPlaylistStore) Assign(playlistID string, mediaKeys []string) (savedPlaylistState, error) {
+	playlistID = strings.TrimSpace(playlistID)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, err := s.loadLocked()
+	if err != nil {
+		return emptySavedPlaylistState(), err
+	}
+	if !hasSavedPlaylist(current, playlistID) {
+		return emptySavedPlaylistState(), errUnknownSavedPlaylist
+	}
+	for _, mediaKey := range mediaKeys {
+		current.Assignments[mediaKey] = playlistID
+	}
+	if err := s.saveLocked(current); err != nil {
+		return emptySavedPlaylistState(), err
+	}
+	return cloneSavedPlaylistState(current), nil
+}
+
+// SetOrder replaces the play order of playlistID. Keys not assigned to the
+// playlist are dropped.
+func (s *savedPlaylistStore) SetOrder(playlistID string, mediaKeys []string) (savedPlaylistState, error) {
+	playlistID = strings.TrimSpace(playlistID)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, err := s.loadLocked()
+	if err != nil {
+		return emptySavedPlaylistState(), err
+	}
+	if !hasSavedPlaylist(current, playlistID) {
+		return emptySavedPlaylistState(), errUnknownSavedPlaylist
+	}
+	current.Order[playlistID] = mediaKeys
+	current = normalizeSavedPlaylistState(current)
+	if err := s.saveLocked(current); err != nil {
+		return emptySavedPlaylistState(), err
+	}
+	return cloneSavedPlaylistState(current), nil
+}
+
+func hasSavedPlaylist(state savedPlaylistState, playlistID string) bool {
+	for _, playlist := range state.Playlists {
+		if playlist.ID == playlistID {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *savedPlaylistStore) loadLocked() (savedPlaylistState, error) {
 	data, err := os.ReadFile(s.path)
 	if err != nil {
@@ -118,10 +216,116 @@ func (s *savedPlaylistStore) saveLocked(next savedPlaylistState) error {
 	return nil
 }
 
+// existingMediaKeys splits requested media paths into normalized relative
+// keys for files that exist under mediaDir and the entries that do not
+// (missing, directories, or outside the media directory).
+func existingMediaKeys(mediaDir string, paths []string) (valid []string, skipped []string) {
+	valid, skipped = []string{}, []string{}
+	seen := make(map[string]struct{}, len(paths))
+	for _, raw := range paths {
+		fullPath, _, err := resolveMediaPath(mediaDir, strings.TrimSpace(raw))
+		if err != nil {
+			skipped = append(skipped, raw)
+			continue
+		}
+		if info, statErr := os.Stat(fullPath); statErr != nil || info.IsDir() {
+			skipped = append(skipped, raw)
+			continue
+		}
+		key := filepath.ToSlash(filepath.Clean(strings.TrimSpace(raw)))
+		if _, dup := seen[key]; dup {
+			continue
+		}
+		seen[key] = struct{}{}
+		valid = append(valid, key)
+	}
+	return valid, skipped
+}
+
+// savedPlaylistTracks returns the media keys assigned to playlistID in play
+// order: the stored order first, then any remaining keys by path.
+func savedPlaylistTracks(state savedPlaylistState, playlistID string) []string {
+	tracks := []string{}
+	listed := make(map[string]struct{})
+	for _, mediaKey := range state.Order[playlistID] {
+		tracks = append(tracks, mediaKey)
+		listed[mediaKey] = struct{}{}
+	}
+	var rest []string
+	for mediaKey, assigned := range state.Assignments {
+		if _, ok := listed[mediaKey]; !ok && assigned == playlistID {
+			rest = append(rest, mediaKey)
+		}
+	}
+	sort.Strings(rest)
+	return append(tracks, rest...)
+}
+
+// exportSavedPlaylistM3U writes playlistID as an extended M3U to the media
+// playlist/ folder, named after the playlist, so external players can use
+// it. Entries are relative to that folder; assigned files that no longer
+// exist are left out and reported as skipped. It returns the written path
+// relative to mediaDir.
+func exportSavedPlaylistM3U(mediaDir string, state savedPlaylistState, playlistID string) (string, []string, []string, error) {
+	var playlist *savedPlaylist
+	for i := range state.Playlists {
+		if state.Playlists[i].ID == playlistID {
+			playlist = &state.Playlists[i]
+			break
+		}
+	}
+	if playlist == nil {
+		return "", nil, nil, errUnknownSavedPlaylist
+	}
+	index, err := downloader.LoadMetadataIndex(downloader.MetadataIndexPath(mediaDir))
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("reading metadata index: %w", err)
+	}
+
+	playlistDir := filepath.Join(mediaDir, mediaFolderPlaylist)
+	entries, skipped := []string{}, []string{}
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n")
+	for _, mediaKey := range savedPlaylistTracks(state, playlistID) {
+		fullPath, _, resolveErr := resolveMediaPath(mediaDir, mediaKey)
+		if resolveErr != nil {
+			skipped = append(skipped, mediaKey)
+			continue
+		}
+		info, statErr := os.Stat(fullPath)
+		if statErr != nil || info.IsDir() {
+			skipped = append(skipped, mediaKey)
+			continue
+		}
+		rel, relErr := filepath.Rel(playlistDir, fullPath)
+		if relErr != nil {
+			skipped = append(skipped, mediaKey)
+			continue
+		}
+		metadata, _ := loadMediaMetadata(fullPath, mediaKey, info, index)
+		duration := metadata.DurationSeconds
+		if duration <= 0 {
+			duration = -1
+		}
+		fmt.Fprintf(&b, "#EXTINF:%d,%s\n%s\n", duration, metadata.Title, filepath.ToSlash(rel))
+		entries = append(entries, mediaKey)
+	}
+
+	name := downloader.SanitizeFilename(playlist.Name) + ".m3u8"
+	if err := os.MkdirAll(playlistDir, 0o755); err != nil {
+		return "", nil, nil, fmt.Errorf("creating playlist directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(playlistDir, name), []byte(b.String()), 0o644); err != nil {
+		return "", nil, nil, fmt.Errorf("writing playlist m3u: %w", err)
+	}
+	return mediaFolderPlaylist + "/" + name, entries, skipped, nil
+}
+
 func emptySavedPlaylistState() savedPlaylistState {
 	return savedPlaylistState{
 		Playlists:   []savedPlaylist{},
 		Assignments: map[string]string{},
+		Order:       map[string][]string{},
 	}
 }
 
@@ -174,6 +378,29 @@ func normalizeSavedPlaylistState(raw savedPlaylistState) savedPlaylistState {
 		out.Assignments[normalizedMediaKey] = normalizedPlaylistID
 	}
 
+	for playlistID, mediaKeys := range raw.Order {
+		normalizedPlaylistID := strings.TrimSpace(playlistID)
+		if _, ok := validIDs[normalizedPlaylistID]; !ok {
+			continue
+		}
+		seen := make(map[string]struct{}, len(mediaKeys))
+		ordered := []string{}
+		for _, mediaKey := range mediaKeys {
+			normalizedMediaKey := strings.TrimSpace(mediaKey)
+			if out.Assignments[normalizedMediaKey] != normalizedPlaylistID {
+				continue
+			}
+			if _, exists := seen[normalizedMediaKey]; exists {
+				continue
+			}
+			seen[normalizedMediaKey] = struct{}{}
+			ordered = append(ordered, normalizedMediaKey)
+		}
+		if len(ordered) > 0 {
+			out.Order[normalizedPlaylistID] = ordered
+		}
+	}
+
 	return out
 }
 
@@ -189,9 +416,13 @@ func cloneSavedPlaylistState(source savedPlaylistState) savedPlaylistState {
 	out := savedPlaylistState{
 		Playlists:   append([]savedPlaylist(nil), source.Playlists...),
 		Assignments: make(map[string]string, len(source.Assignments)),
+		Order:       make(map[string][]string, len(source.Order)),
 	}
 	for mediaKey, playlistID := range source.Assignments {
 		out.Assignments[mediaKey] = playlistID
 	}
+	for playlistID, mediaKeys := range source.Order {
+		out.Order[playlistID] = append([]string(nil), mediaKeys...)
+	}
 	return out
 }
@@ -1,6 +1,8 @@
 package web
 
 import (
+	"compress/flate"
+	"compress/gzip"
 	"context"
 	"embed"
 	"encoding/json"
@@ -15,10 +17,12 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -39,12 +43,29 @@ var embeddedAssets embed.FS
 
 const maxRequestBodyBytes = 1 << 20 // 1 MiB
 
+// maxJobDurationUnit scales the max-job-duration download option; tests
+// shorten it.
+var maxJobDurationUnit = time.Minute
+
+// Default job retention used by the -job-* flags.
+const (
+	DefaultJobCompletedTTL    = 15 * time.Minute
+	DefaultJobErroredTTL      = 30 * time.Minute
+	DefaultJobCleanupInterval = time.Minute
+)
+
+// Media listing orders accepted by -media-sort and the sort query parameter.
+const (
+	MediaSortNewest = "newest"
+	MediaSortOldest = "oldest"
+	MediaSortTitle  = "title"
+)
+
 const (
 	defaultMediaListLimit = 200
 	maxMediaListLimit     = 500
-	jobCompletedTTL       = 15 * time.Minute
-	jobErroredTTL         = 30 * time.Minute
-	jobCleanupInterval    = time.Minute
+	defaultJobListLimit   = 50
+	maxJobListLimit       = 200
 	maxPortFallbacks      = 20
 	maxTCPPort            = 65535
 	defaultMediaDirName   = "media"
@@ -116,6 +137,9 @@ type WebOption struct {
 	OnDuplicate         string            `json:"on-duplicate"`
 	UseCookies          bool              `json:"use-cookies"`
 	PoToken             string            `json:"po-token"`
+	MaxJobDuration      int               `json:"max-job-duration"`
+	Collection          string            `json:"collection"`
+	FlatPlaylistLayout  bool              `json:"flat-playlist-layout"`
 }
 
 type DuplicateResponseRequest struct {
@@ -150,6 +174,11 @@ type mediaListResponse struct {
 	NextOffset *int        `json:"next_offset"`
 }
 
+type jobListResponse struct {
+	Items      []JobSummary `json:"items"`
+	NextOffset *int         `json:"next_offset"`
+}
+
 // formatBytes formats a byte size into a human-readable string
 func formatBytes(b int64) string {
 	const unit = 1024
@@ -173,11 +202,24 @@ type DownloadResponse struct {
 	Options  downloader.Options `json:"options,omitempty"`
 }
 
+// webCollectionPattern is the accepted shape of a collection name: a single
+// path segment of letters, digits, spaces, dots, dashes and underscores that
+// starts with a letter or digit.
+var webCollectionPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9 ._-]{0,63}$`)
+
 // validateWebOutputTemplate ensures that the output template provided via the web API
 // cannot be used to write files outside the intended output area. It is intentionally
 // conservative and rejects absolute paths, parent directory references, and directory
-// components in the literal prefix before the first placeholder.
-func validateWebOutputTemplate(tmpl string) error {
+// components in the literal prefix before the first placeholder. The optional
+// collection must be a single safe folder name.
+func validateWebOutputTemplate(tmpl, collection string) error {
+	if collection != "" {
+		if !webCollectionPattern.MatchString(collection) || strings.Contains(collection, "..") ||
+			strings.HasSuffix(collection, ".") || strings.HasSuffix(collection, " ") {
+			return fmt.Errorf("invalid collection: use a single folder name of letters, digits, spaces, '.', '-' or '_'")
+		}
+	}
+
 	// Empty template is allowed; it will be defaulted later.
 	if strings.TrimSpace(tmpl) == "" {
 		return nil
@@ -263,6 +305,9 @@ func parseDownloadRequest(w http.ResponseWriter, r *http.Request) (*DownloadRequ
 	if err != nil {
 		return nil, downloader.Options{}, 0, &requestError{http.StatusBadRequest, err.Error()}
 	}
+	if req.Options.MaxJobDuration < 0 {
+		return nil, downloader.Options{}, 0, &requestError{http.StatusBadRequest, "max-job-duration must be zero or a positive number of minutes"}
+	}
 
 	opts := downloader.Options{
 		OutputTemplate:      req.Options.Output,
@@ -283,12 +328,17 @@ func parseDownloadRequest(w http.ResponseWriter, r *http.Request) (*DownloadRequ
 		OnDuplicate:         onDuplicate,
 		UseCookies:          req.Options.UseCookies,
 		PoToken:             req.Options.PoToken,
+		FlatPlaylistLayout:  req.Options.FlatPlaylistLayout,
 	}
+	// The library lists newest files first by modification time, so keep it
+	// as the download time rather than the upload date.
+	opts.NoMtime = true
 
-	if err := validateWebOutputTemplate(opts.OutputTemplate); err != nil {
+	collection := strings.TrimSpace(req.Options.Collection)
+	if err := validateWebOutputTemplate(opts.OutputTemplate, collection); err != nil {
 		return nil, downloader.Options{}, 0, &requestError{http.StatusBadRequest, err.Error()}
 	}
-	opts.OutputTemplate = normalizeWebOutputTemplate(opts.OutputTemplate, opts.AudioOnly)
+	opts.OutputTemplate = normalizeWebOutputTemplate(opts.OutputTemplate, collection, opts.AudioOnly)
 	if opts.Timeout == 0 {
 		opts.Timeout = 3 * time.Minute
 	}
@@ -296,7 +346,10 @@ func parseDownloadRequest(w http.ResponseWriter, r *http.Request) (*DownloadRequ
 	return &req, opts, req.Options.Jobs, nil
 }
 
-func normalizeWebOutputTemplate(template string, audioOnly bool) string {
+// normalizeWebOutputTemplate roots the template in a media folder and, when a
+// collection is given, places it right below that folder so the library's
+// audio/video layout is kept (e.g. video/<collection>/{title}.{ext}).
+func normalizeWebOutputTemplate(template, collection string, audioOnly bool) string {
 	baseFolder := mediaFolderVideo
 	if audioOnly {
 		baseFolder = mediaFolderAudio
@@ -304,13 +357,17 @@ func normalizeWebOutputTemplate(template string, audioOnly bool) string {
 
 	normalized := strings.TrimSpace(strings.ReplaceAll(template, `\`, "/"))
 	if normalized == "" {
-		return baseFolder + "/{title}.{ext}"
+		normalized = "{title}.{ext}"
 	}
 	normalized = strings.TrimPrefix(normalized, "./")
-	if hasKnownMediaRootPrefix(normalized) {
+	if !hasKnownMediaRootPrefix(normalized) {
+		normalized = baseFolder + "/" + normalized
+	}
+	if collection == "" {
 		return normalized
 	}
-	return baseFolder + "/" + normalized
+	root, rest, _ := strings.Cut(normalized, "/")
+	return root + "/" + collection + "/" + rest
 }
 
 func hasKnownMediaRootPrefix(template string) bool {
@@ -325,9 +382,74 @@ func hasKnownMediaRootPrefix(template string) bool {
 	return ok
 }
 
-func ListenAndServe(ctx context.Context, addr string, jobs int) error {
+// ServerOptions configures optional web server behavior.
+type ServerOptions struct {
+	// WebhookURL receives a JSON POST when a job finishes. Empty disables it.
+	WebhookURL string
+	// JobCompletedTTL and JobErroredTTL control how long finished jobs are
+	// kept; 0 keeps them forever.
+	JobCompletedTTL time.Duration
+	JobErroredTTL   time.Duration
+	// JobCleanupInterval is how often expired jobs are swept; 0 disables
+	// cleanup entirely.
+	JobCleanupInterval time.Duration
+	// MetadataStore selects where downloads record item metadata:
+	// "sidecar" (default) or "index" for a single NDJSON file in data/.
+	MetadataStore string
+	// CORSOrigins is a comma-separated list of origins allowed to call the
+	// API cross-origin. Empty keeps the API same-origin only.
+	CORSOrigins string
+	// SSEHeartbeatInterval is how long a progress stream may sit idle before
+	// a keep-alive comment is sent; 0 disables heartbeats.
+	SSEHeartbeatInterval time.Duration
+	// MediaSort is the default order of the media listing: "newest"
+	// (default), "oldest" or "title".
+	MediaSort string
+}
+
+func (o ServerOptions) validate() error {
+	if o.JobCompletedTTL < 0 {
+		return fmt.Errorf("job completed TTL must not be negative (got %s)", o.JobCompletedTTL)
+	}
+	if o.JobErroredTTL < 0 {
+		return fmt.Errorf("job errored TTL must not be negative (got %s)", o.JobErroredTTL)
+	}
+	if o.JobCleanupInterval < 0 {
+		return fmt.Errorf("job cleanup interval must not be negative (got %s)", o.JobCleanupInterval)
+	}
+	switch o.MetadataStore {
+	case "", downloader.MetadataStoreSidecar, downloader.MetadataStoreIndex:
+	default:
+		return fmt.Errorf("metadata store must be %q or %q (got %q)", downloader.MetadataStoreSidecar, downloader.MetadataStoreIndex, o.MetadataStore)
+	}
+	if o.SSEHeartbeatInterval < 0 {
+		return fmt.Errorf("SSE heartbeat interval must not be negative (got %s)", o.SSEHeartbeatInterval)
+	}
+	if o.MediaSort != "" && !validMediaSort(o.MediaSort) {
+		return fmt.Errorf("media sort must be %q, %q or %q (got %q)", MediaSortNewest, MediaSortOldest, MediaSortTitle, o.MediaSort)
+	}
+	return nil
+}
+
+func ListenAndServe(ctx context.Context, addr string, jobs int, serverOpts ServerOptions) error {
 	startedAt := time.Now()
 
+	if err := serverOpts.validate(); err != nil {
+		return err
+	}
+	corsOrigins, err := parseCORSOrigins(serverOpts.CORSOrigins)
+	if err != nil {
+		return err
+	}
+	if serverOpts.WebhookURL != "" {
+		notifier, err := newWebhookNotifier(serverOpts.WebhookURL)
+		if err != nil {
+			return err
+		}
+		jobWebhook = notifier
+		log.Printf("Job webhook: %s", notifier.url)
+	}
+
 	// Resolve media directory for downloads and library data.
 
 	mediaDir, err := resolveWebMediaDir()
@@ -347,6 +469,8 @@ func ListenAndServe(ctx context.Context, addr string, jobs int) error {
 		return err
 	}
 	playlistStore := newSavedPlaylistStore(filepath.Join(mediaDir, mediaFolderData, savedPlaylistsFileName))
+	statsStore := newDownloadStatsStore(filepath.Join(mediaDir, mediaFolderData, downloadStatsFileName))
+	metrics := newServerMetrics()
 	log.Printf("Media directory: %s", mediaDir)
 
 	// Initialize SQLite master catalog
@@ -389,22 +513,63 @@ func ListenAndServe(ctx context.Context, addr string, jobs int) error {
 			return
 		}
 		opts.OutputDir = mediaDir
+		if serverOpts.MetadataStore == downloader.MetadataStoreIndex {
+			opts.MetadataIndex = downloader.MetadataIndexPath(mediaDir)
+		}
 		opts.Quiet = true
 		// Force progress reporting for the WebSocket renderer even in quiet mode
 		// We rely on the downloader checking opts.Renderer != nil as well
 
-		// Enqueue each URL as a separate task to the pool
+		// Enqueue each URL as a separate task to the pool. Each task is also
+		// a tracked job, so the job, snapshot, progress and cancel endpoints
+		// all work with the IDs returned here.
+		maxDuration := time.Duration(req.Options.MaxJobDuration) * maxJobDurationUnit
+		jobIDs := make([]string, 0, len(req.URLs))
 		for _, u := range req.URLs {
 			url := u
-			taskID := fmt.Sprintf("dl_%d", time.Now().UnixNano()) // Simple ID
+			job := tracker.Create(ctx, []string{url})
+			jobIDs = append(jobIDs, job.ID)
+			var (
+				results  []app.Result
+				exitCode int
+			)
 
 			globalPool.AddTask(downloader.Task{
-				ID:      taskID,
-				URLs:    []string{url},
-				Options: opts,
-				Jobs:    jobs,
+				ID:          job.ID,
+				URLs:        []string{url},
+				Options:     opts,
+				Jobs:        jobs,
+				MaxDuration: maxDuration,
+				Context:     job.Context(),
+				// The pool decides whether the task timed out only after
+				// Execute returns, so the job's outcome is recorded in
+				// OnFinish. It is the single place job webhooks are sent from.
+				OnFinish: func(_ string, err error) {
+					if errors.Is(err, downloader.ErrTaskTimeout) {
+						job.SetTimedOut(results, exitCode, err)
+						return
+					}
+					job.SetOutcome(results, exitCode)
+				},
 				Execute: func(ctx context.Context, urls []string, opts downloader.Options, jobs int) ([]any, int) {
-					results, exitCode := app.Run(ctx, urls, opts, jobs)
+					job.markRunning()
+					// Record progress on the job for the snapshot and SSE
+					// endpoints while the pool's renderer feeds the WebSocket.
+					opts.Renderer = newJobRenderer(job, opts.Renderer)
+					var (
+						completedMu sync.Mutex
+						completed   []completedItem
+					)
+					opts.OnItemComplete = func(metadata downloader.ItemMetadata, bytes int64) {
+						completedMu.Lock()
+						completed = append(completed, completedItem{Artist: itemArtist(metadata), Bytes: bytes})
+						completedMu.Unlock()
+					}
+					results, exitCode = app.Run(ctx, urls, opts, jobs)
+					if err := statsStore.RecordJob(completed); err != nil {
+						log.Printf("recording download stats for %s: %v", job.ID, err)
+					}
+					metrics.RecordTask(results, exitCode, completed)
 					anyResults := make([]any, len(results))
 					for i, res := range results {
 						anyResults[i] = res
@@ -414,8 +579,10 @@ func ListenAndServe(ctx context.Context, addr string, jobs int) error {
 			})
 		}
 
-		writeJSON(w, http.StatusOK, map[string]string{
+		writeJSON(w, http.StatusOK, map[string]any{
 			"status":  "queued",
+			"jobId":   jobIDs[0],
+			"jobIds":  jobIDs,
 			"message": fmt.Sprintf("Enqueued %d item(s) to the download pool.", len(req.URLs)),
 		})
 	})
@@ -459,6 +626,38 @@ func ListenAndServe(ctx context.Context, addr string, jobs int) error {
 		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
 	})
 
+	mux.HandleFunc(progressStreamPath, func(w http.ResponseWriter, r *http.Request) {
+		serveProgressStream(w, r, serverOpts.SSEHeartbeatInterval)
+	})
+
+	// Polling fallback for clients behind proxies that buffer SSE.
+	mux.HandleFunc("/api/download/snapshot", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		jobID := strings.TrimSpace(r.URL.Query().Get("id"))
+		if jobID == "" {
+			writeJSONError(w, http.StatusBadRequest, "id is required")
+			return
+		}
+		var afterSeq int64
+		if raw := r.URL.Query().Get("afterSeq"); raw != "" {
+			seq, ok := parseProgressSeq(raw)
+			if !ok {
+				writeJSONError(w, http.StatusBadRequest, "afterSeq must be a non-negative integer")
+				return
+			}
+			afterSeq = seq
+		}
+		job, ok := tracker.Get(jobID)
+		if !ok {
+			writeJSONError(w, http.StatusNotFound, "job not found")
+			return
+		}
+		writeJSON(w, http.StatusOK, job.Poll(afterSeq))
+	})
+
 	mux.HandleFunc("/api/download/cancel", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
@@ -484,6 +683,62 @@ func ListenAndServe(ctx context.Context, addr string, jobs int) error {
 		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
 	})
 
+	mux.HandleFunc("/api/jobs", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		status := strings.TrimSpace(r.URL.Query().Get("status"))
+		switch status {
+		case "", "queued", "running", "complete", "error", "cancelled":
+		default:
+			writeJSONError(w, http.StatusBadRequest, "status must be one of queued, running, complete, error, cancelled")
+			return
+		}
+		offset, limit, err := parseListPagination(r, defaultJobListLimit, maxJobListLimit)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		items, nextOffset := tracker.List(status, offset, limit)
+		writeJSON(w, http.StatusOK, jobListResponse{Items: items, NextOffset: nextOffset})
+	})
+
+	mux.HandleFunc("/api/download/cancel-all", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"status": "ok", "cancelled": tracker.CancelActive()})
+	})
+
+	// Probes for container orchestration. They live outside /api/ so the
+	// catch-all never shadows them, and /healthz deliberately touches nothing.
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		checks, ready := readinessChecks(mediaDir, globalPool)
+		status, code := "ready", http.StatusOK
+		if !ready {
+			status, code = "not ready", http.StatusServiceUnavailable
+		}
+		writeJSON(w, code, map[string]any{"status": status, "checks": checks})
+	})
+
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		serveMetrics(w, r, metrics, globalPool)
+	})
+
 	mux.HandleFunc("/api/status", func(w http.ResponseWriter, r *http.Request) {
 
 		if r.Method != http.MethodGet {
@@ -497,6 +752,27 @@ func ListenAndServe(ctx context.Context, addr string, jobs int) error {
 		})
 	})
 
+	mux.HandleFunc("/api/queue", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		writeJSON(w, http.StatusOK, globalPool.Stats())
+	})
+
+	mux.HandleFunc("/api/stats", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		stats, err := statsStore.Snapshot()
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "failed to read download stats")
+			return
+		}
+		writeJSON(w, http.StatusOK, stats)
+	})
+
 	mux.HandleFunc("/api/system/info", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
 			writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
@@ -555,6 +831,94 @@ func ListenAndServe(ctx context.Context, addr string, jobs int) error {
 		})
 	})
 
+	mux.HandleFunc("/api/library/playlists/", func(w http.ResponseWriter, r *http.Request) {
+		playlistID, action, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/library/playlists/"), "/")
+		if !ok || playlistID == "" {
+			writeJSONError(w, http.StatusNotFound, "not found")
+			return
+		}
+		switch action {
+		case "assign":
+			if r.Method != http.MethodPost {
+				writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+				return
+			}
+			var req savedPlaylistAssignRequest
+			if err := decodeJSONBody(w, r, &req); err != nil {
+				writeJSONError(w, err.status, err.message)
+				return
+			}
+			if len(req.Paths) == 0 {
+				writeJSONError(w, http.StatusBadRequest, "paths is required")
+				return
+			}
+			keys, skipped := existingMediaKeys(mediaDir, req.Paths)
+			state, assignErr := playlistStore.Assign(playlistID, keys)
+			if errors.Is(assignErr, errUnknownSavedPlaylist) {
+				writeJSONError(w, http.StatusNotFound, "playlist not found")
+				return
+			}
+			if assignErr != nil {
+				writeJSONError(w, http.StatusInternalServerError, "failed to persist saved playlists")
+				return
+			}
+			writeJSON(w, http.StatusOK, savedPlaylistAssignResponse{
+				Playlists:   state.Playlists,
+				Assignments: state.Assignments,
+				Order:       state.Order,
+				Assigned:    keys,
+				Skipped:     skipped,
+			})
+		case "order":
+			if r.Method != http.MethodPut {
+				writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+				return
+			}
+			var req savedPlaylistOrderRequest
+			if err := decodeJSONBody(w, r, &req); err != nil {
+				writeJSONError(w, err.status, err.message)
+				return
+			}
+			state, orderErr := playlistStore.SetOrder(playlistID, req.Order)
+			if errors.Is(orderErr, errUnknownSavedPlaylist) {
+				writeJSONError(w, http.StatusNotFound, "playlist not found")
+				return
+			}
+			if orderErr != nil {
+				writeJSONError(w, http.StatusInternalServerError, "failed to persist saved playlists")
+				return
+			}
+			writeJSON(w, http.StatusOK, state)
+		case "export":
+			if r.Method != http.MethodPost {
+				writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+				return
+			}
+			state, loadErr := playlistStore.Load()
+			if loadErr != nil {
+				writeJSONError(w, http.StatusInternalServerError, "failed to read saved playlists")
+				return
+			}
+			path, entries, skipped, exportErr := exportSavedPlaylistM3U(mediaDir, state, playlistID)
+			if errors.Is(exportErr, errUnknownSavedPlaylist) {
+				writeJSONError(w, http.StatusNotFound, "playlist not found")
+				return
+			}
+			if exportErr != nil {
+				log.Printf("failed to export saved playlist %q: %v", playlistID, exportErr)
+				writeJSONError(w, http.StatusInternalServerError, "failed to export playlist")
+				return
+			}
+			writeJSON(w, http.StatusOK, savedPlaylistExportResponse{
+				Path:    path,
+				Entries: entries,
+				Skipped: skipped,
+			})
+		default:
+			writeJSONError(w, http.StatusNotFound, "not found")
+		}
+	})
+
 	mux.HandleFunc("/api/media/", func(w http.ResponseWriter, r *http.Request) {
 		reqPath := strings.TrimPrefix(r.URL.Path, "/api/media/")
 
@@ -567,8 +931,16 @@ func ListenAndServe(ctx context.Context, addr string, jobs int) error {
 					writeJSONError(w, http.StatusBadRequest, err.Error())
 					return
 				}
+				sortOrder := serverOpts.MediaSort
+				if raw := r.URL.Query().Get("sort"); raw != "" {
+					if !validMediaSort(raw) {
+						writeJSONError(w, http.StatusBadRequest, "invalid sort parameter")
+						return
+					}
+					sortOrder = raw
+				}
 
-				allItems, err := listMediaFiles(mediaDir)
+				allItems, err := listMediaFiles(mediaDir, sortOrder)
 				if err != nil {
 					writeJSONError(w, http.StatusInternalServerError, "failed to read media directory")
 					return
@@ -590,8 +962,20 @@ func ListenAndServe(ctx context.Context, addr string, jobs int) error {
 				writeJSONError(w, status, err.Error())
 				return
 			}
+			// Playback streams inline; ?download=1 asks the browser to save
+			// the file under its title instead of the last path segment.
+			if r.URL.Query().Get("download") == "1" {
+				w.Header().Set("Content-Disposition", attachmentDisposition(mediaDownloadName(mediaDir, reqPath, fullPath, serverOpts.MetadataStore)))
+			}
 			http.ServeFile(w, r, fullPath)
 
+		case http.MethodPatch:
+			if !strings.HasSuffix(reqPath, mediaMetadataSuffix) {
+				writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+				return
+			}
+			updateMediaMetadata(w, r, mediaDir, reqPath, serverOpts.MetadataStore)
+
 		case http.MethodDelete:
 			if reqPath == "" {
 				writeJSONError(w, http.StatusBadRequest, "file path is required")
@@ -630,6 +1014,12 @@ func ListenAndServe(ctx context.Context, addr string, jobs int) error {
 
 			// Remove the database record.
 			relPath := filepath.ToSlash(reqPath)
+			indexPath := downloader.MetadataIndexPath(mediaDir)
+			if _, statErr := os.Stat(indexPath); statErr == nil {
+				if err := downloader.AppendMetadataIndex(indexPath, relPath, nil); err != nil {
+					log.Printf("failed to record deletion of %q in metadata index: %v", relPath, err)
+				}
+			}
 			if globalDB != nil {
 				if _, err := globalDB.DeleteMediaByPath(relPath); err != nil {
 					log.Printf("failed to delete media DB record for %q: %v", relPath, err)
@@ -667,7 +1057,7 @@ func ListenAndServe(ctx context.Context, addr string, jobs int) error {
 
 	server := &http.Server{
 		Addr:              addr,
-		Handler:           withSecurityHeaders(mux),
+		Handler:           withSecurityHeaders(withCORS(corsOrigins, withCompression(mux))),
 		ReadHeaderTimeout: 5 * time.Second,
 		ReadTimeout:       15 * time.Second,
 		WriteTimeout:      10 * time.Minute,
@@ -679,7 +1069,9 @@ func ListenAndServe(ctx context.Context, addr string, jobs int) error {
 		return err
 	}
 	go globalHub.Run()
-	tracker.StartCleanup(ctx, jobCleanupInterval, jobCompletedTTL, jobErroredTTL)
+	if serverOpts.JobCleanupInterval > 0 {
+		tracker.StartCleanup(ctx, serverOpts.JobCleanupInterval, serverOpts.JobCompletedTTL, serverOpts.JobErroredTTL)
+	}
 
 	// Start filesystem watcher for real-time external change detection.
 	mw, watchErr := newMediaWatcher(mediaDir)
@@ -736,7 +1128,7 @@ func BroadcastEvent(evt ProgressEvent) {
 			Percent: evt.Percent,
 			Status:  firstNonEmpty(evt.Status, evt.Type),
 		}
-	} else if evt.Type == "error" || evt.Error != "" {
+	} else if evt.Type == "error" || (evt.Error != "" && evt.Status != "cancelled") {
 		msg.Type = "error"
 		msg.Payload = ws.ErrorPayload{
 			ID:      firstNonEmpty(evt.ID, evt.JobID),
@@ -894,6 +1286,26 @@ func ensureMediaLayout(mediaDir string) error {
 	return nil
 }
 
+// readinessChecks backs /readyz: the media directory must accept new files
+// and the download pool must be running. Each check maps to "ok" or a reason.
+func readinessChecks(mediaDir string, pool *downloader.Pool) (map[string]string, bool) {
+	checks := map[string]string{"media_dir": "ok", "pool": "ok"}
+	ready := true
+	if probe, err := os.CreateTemp(mediaDir, ".readyz-*"); err != nil {
+		checks["media_dir"] = fmt.Sprintf("not writable: %v", err)
+		ready = false
+	} else {
+		name := probe.Name()
+		_ = probe.Close()
+		_ = os.Remove(name)
+	}
+	if pool == nil || !pool.Running() {
+		checks["pool"] = "not started"
+		ready = false
+	}
+	return checks, ready
+}
+
 func writeJSON(w http.ResponseWriter, status int, payload any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -947,9 +1359,83 @@ func withSecurityHeaders(next http.Handler) http.Handler {
 	})
 }
 
+// withCompression gzip- or deflate-encodes /api/ responses for clients that
+// accept it. Media file downloads under /api/media/<path> are passed through
+// untouched since they are already compressed and rely on range requests; the
+// media listing itself (/api/media/) is compressed. The progress stream is
+// also left alone so events are not held back in the encoder.
+func withCompression(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mediaFile := strings.HasPrefix(r.URL.Path, "/api/media/") && r.URL.Path != "/api/media/"
+		if !strings.HasPrefix(r.URL.Path, "/api/") || mediaFile || r.URL.Path == progressStreamPath {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Accept-Encoding")
+		accept := r.Header.Get("Accept-Encoding")
+		var encoder io.WriteCloser
+		switch {
+		case acceptsEncoding(accept, "gzip"):
+			w.Header().Set("Content-Encoding", "gzip")
+			encoder = gzip.NewWriter(w)
+		case acceptsEncoding(accept, "deflate"):
+			w.Header().Set("Content-Encoding", "deflate")
+			encoder, _ = flate.NewWriter(w, flate.DefaultCompression)
+		default:
+			next.ServeHTTP(w, r)
+			return
+		}
+		defer encoder.Close()
+		next.ServeHTTP(&compressedResponseWriter{ResponseWriter: w, encoder: encoder}, r)
+	})
+}
+
+// acceptsEncoding reports whether an Accept-Encoding header lists coding with
+// a non-zero quality.
+func acceptsEncoding(header, coding string) bool {
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(name), coding) {
+			continue
+		}
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+type compressedResponseWriter struct {
+	http.ResponseWriter
+	encoder io.Writer
+}
+
+func (w *compressedResponseWriter) WriteHeader(status int) {
+	// The handler's length (if any) describes the uncompressed body.
+	w.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *compressedResponseWriter) Write(p []byte) (int, error) {
+	// Sniff from the plain bytes; net/http would otherwise see compressed ones.
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", http.DetectContentType(p))
+	}
+	return w.encoder.Write(p)
+}
+
 func parseMediaListPagination(r *http.Request) (offset int, limit int, err error) {
+	return parseListPagination(r, defaultMediaListLimit, maxMediaListLimit)
+}
+
+// parseListPagination reads the offset and limit query parameters shared by
+// the paginated listing endpoints, clamping limit to maxLimit.
+func parseListPagination(r *http.Request, defaultLimit, maxLimit int) (offset int, limit int, err error) {
 	offset = 0
-	limit = defaultMediaListLimit
+	limit = defaultLimit
 
 	q := r.URL.Query()
 	if rawOffset := q.Get("offset"); rawOffset != "" {
@@ -964,23 +1450,61 @@ func parseMediaListPagination(r *http.Request) (offset int, limit int, err error
 		if parseErr != nil || parsed <= 0 {
 			return 0, 0, fmt.Errorf("invalid limit parameter")
 		}
-		if parsed > maxMediaListLimit {
-			parsed = maxMediaListLimit
+		if parsed > maxLimit {
+			parsed = maxLimit
 		}
 		limit = parsed
 	}
 	return offset, limit, nil
 }
 
-func listMediaFiles(mediaDir string) ([]mediaItem, error) {
-	type enrichedMediaItem struct {
-		item    mediaItem
-		modTime time.Time
+// validMediaSort reports whether order is a known media listing order.
+func validMediaSort(order string) bool {
+	switch order {
+	case MediaSortNewest, MediaSortOldest, MediaSortTitle:
+		return true
 	}
+	return false
+}
+
+type enrichedMediaItem struct {
+	item    mediaItem
+	modTime time.Time
+}
+
+// sortMediaItems orders items by sortOrder, defaulting to newest first. Ties
+// are broken by filename so the order is stable across requests and pages.
+func sortMediaItems(items []enrichedMediaItem, sortOrder string) {
+	sort.Slice(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		switch sortOrder {
+		case MediaSortTitle:
+			if at, bt := strings.ToLower(a.item.Title), strings.ToLower(b.item.Title); at != bt {
+				return at < bt
+			}
+		case MediaSortOldest:
+			if !a.modTime.Equal(b.modTime) {
+				return a.modTime.Before(b.modTime)
+			}
+		default:
+			if !a.modTime.Equal(b.modTime) {
+				return a.modTime.After(b.modTime)
+			}
+		}
+		return a.item.Filename < b.item.Filename
+	})
+}
+
+func listMediaFiles(mediaDir, sortOrder string) ([]mediaItem, error) {
 
 	items := make([]enrichedMediaItem, 0, 128)
 
-	err := filepath.WalkDir(mediaDir, func(path string, entry fs.DirEntry, walkErr error) error {
+	index, err := downloader.LoadMetadataIndex(downloader.MetadataIndexPath(mediaDir))
+	if err != nil {
+		log.Printf("failed reading metadata index: %v", err)
+	}
+
+	err = filepath.WalkDir(mediaDir, func(path string, entry fs.DirEntry, walkErr error) error {
 		if walkErr != nil {
 			log.Printf("skipping media entry %q: %v", path, walkErr)
 			return nil
@@ -993,8 +1517,17 @@ func listMediaFiles(mediaDir string) ([]mediaItem, error) {
 		}
 
 		ext := strings.ToLower(filepath.Ext(entry.Name()))
-		// Media sidecars/manifests are metadata artifacts and should not be listed as playable media.
-		if ext == ".json" {
+		// Media sidecars/manifests and the metadata index are metadata artifacts and should not be listed as playable media.
+		if ext == ".json" || ext == ".ndjson" {
+			return nil
+		}
+		// Playlist indexes (-write-playlist-m3u, saved playlist exports) point at media rather than being media.
+		if ext == ".m3u" || ext == ".m3u8" {
+			return nil
+		}
+		// Downloads in progress, or interrupted ones, are written to .part files
+		// and only renamed to their media name once complete.
+		if ext == ".part" {
 			return nil
 		}
 		// SQLite database files are internal data and should not be listed.
@@ -1015,7 +1548,7 @@ func listMediaFiles(mediaDir string) ([]mediaItem, error) {
 		}
 		relPath = filepath.ToSlash(relPath)
 
-		metadata, hasSidecar := loadMediaMetadata(path, relPath, info)
+		metadata, hasSidecar := loadMediaMetadata(path, relPath, info, index)
 		title := firstNonEmpty(strings.TrimSpace(metadata.Title), strings.TrimSuffix(info.Name(), filepath.Ext(info.Name())))
 		artist := firstNonEmpty(strings.TrimSpace(metadata.Artist), strings.TrimSpace(metadata.Author), "Unknown Artist")
 		date := firstNonEmpty(strings.TrimSpace(metadata.ReleaseDate), info.ModTime().Format("2006-01-02"))
@@ -1054,12 +1587,7 @@ func listMediaFiles(mediaDir string) ([]mediaItem, error) {
 		return nil, err
 	}
 
-	sort.Slice(items, func(i, j int) bool {
-		if items[i].modTime.Equal(items[j].modTime) {
-			return items[i].item.Filename < items[j].item.Filename
-		}
-		return items[i].modTime.After(items[j].modTime)
-	})
+	sortMediaItems(items, sortOrder)
 
 	out := make([]mediaItem, 0, len(items))
 	livePaths := make(map[string]struct{}, len(items))
@@ -1082,7 +1610,12 @@ func listMediaFiles(mediaDir string) ([]mediaItem, error) {
 	return out, nil
 }
 
-func loadMediaMetadata(mediaPath, relativePath string, info fs.FileInfo) (downloader.ItemMetadata, bool) {
+// loadMediaMetadata looks up a file's metadata in the NDJSON index first and
+// falls back to its per-file sidecar. The bool reports whether either had it.
+func loadMediaMetadata(mediaPath, relativePath string, info fs.FileInfo, index map[string]downloader.ItemMetadata) (downloader.ItemMetadata, bool) {
+	if metadata, ok := index[relativePath]; ok {
+		return normalizeMediaMetadata(metadata, relativePath, info), true
+	}
 	fallback := defaultMediaMetadata(relativePath, info)
 	sidecarPath := mediaPath + ".json"
 	data, err := os.ReadFile(sidecarPath)
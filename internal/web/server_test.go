@@ -2,10 +2,12 @@ package web
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/http/httptest"
@@ -14,8 +16,12 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/lvcoi/ytdl-go/internal/app"
+	"github.com/lvcoi/ytdl-go/internal/downloader"
 )
 
 var cwdMu sync.Mutex
@@ -56,7 +62,7 @@ func startWebServerForTest(t *testing.T, ctx context.Context) (baseURL string, w
 
 	errCh := make(chan error, 1)
 	go func() {
-		errCh <- ListenAndServe(ctx, addr, 1)
+		errCh <- ListenAndServe(ctx, addr, 1, ServerOptions{})
 	}()
 
 	client := &http.Client{Timeout: 500 * time.Millisecond}
@@ -556,6 +562,271 @@ func TestSavedPlaylistsMigrationEndpointSeedsOnlyWhenEmpty(t *testing.T) {
 	})
 }
 
+func TestSavedPlaylistsBulkAssignFiltersInvalidPaths(t *testing.T) {
+	withTempCWD(t, func(tmpDir string) {
+		tracker = &jobTracker{}
+
+		videoDir := filepath.Join(tmpDir, "media", "video")
+		if err := os.MkdirAll(videoDir, 0o755); err != nil {
+			t.Fatalf("mkdir video: %v", err)
+		}
+		for _, name := range []string{"one.mp4", "two.mp4"} {
+			if err := os.WriteFile(filepath.Join(videoDir, name), []byte("video"), 0o644); err != nil {
+				t.Fatalf("write %s: %v", name, err)
+			}
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		baseURL, wait := startWebServerForTest(t, ctx)
+		defer func() {
+			cancel()
+			wait()
+		}()
+
+		client := &http.Client{Timeout: 3 * time.Second}
+		post := func(url, body string) *http.Response {
+			t.Helper()
+			req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+			if err != nil {
+				t.Fatalf("new request: %v", err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("request %s: %v", url, err)
+			}
+			t.Cleanup(func() { resp.Body.Close() })
+			return resp
+		}
+
+		putReq, err := http.NewRequest(http.MethodPut, baseURL+"/api/library/playlists",
+			strings.NewReader(`{"playlists":[{"id":"pl-1","name":"Road Trip"}],"assignments":{"video/old.mp4":"pl-1"}}`))
+		if err != nil {
+			t.Fatalf("new put request: %v", err)
+		}
+		putReq.Header.Set("Content-Type", "application/json")
+		putResp, err := client.Do(putReq)
+		if err != nil {
+			t.Fatalf("put playlists: %v", err)
+		}
+		putResp.Body.Close()
+		if putResp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200 for put playlists, got %d", putResp.StatusCode)
+		}
+
+		resp := post(baseURL+"/api/library/playlists/pl-1/assign", `{"paths":["video/one.mp4","video/missing.mp4","video/two.mp4"]}`)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200 for bulk assign, got %d", resp.StatusCode)
+		}
+		var assigned savedPlaylistAssignResponse
+		if err := json.NewDecoder(resp.Body).Decode(&assigned); err != nil {
+			t.Fatalf("decode assign response: %v", err)
+		}
+		if len(assigned.Assigned) != 2 || len(assigned.Skipped) != 1 || assigned.Skipped[0] != "video/missing.mp4" {
+			t.Fatalf("unexpected assigned/skipped: %+v / %+v", assigned.Assigned, assigned.Skipped)
+		}
+		want := map[string]string{"video/old.mp4": "pl-1", "video/one.mp4": "pl-1", "video/two.mp4": "pl-1"}
+		if len(assigned.Assignments) != len(want) {
+			t.Fatalf("assignments = %+v, want %+v", assigned.Assignments, want)
+		}
+		for key, id := range want {
+			if assigned.Assignments[key] != id {
+				t.Fatalf("assignments = %+v, want %+v", assigned.Assignments, want)
+			}
+		}
+
+		reloadResp, err := client.Get(baseURL + "/api/library/playlists")
+		if err != nil {
+			t.Fatalf("reload playlists: %v", err)
+		}
+		defer reloadResp.Body.Close()
+		var reloaded savedPlaylistState
+		if err := json.NewDecoder(reloadResp.Body).Decode(&reloaded); err != nil {
+			t.Fatalf("decode reloaded playlists: %v", err)
+		}
+		if reloaded.Assignments["video/two.mp4"] != "pl-1" || reloaded.Assignments["video/missing.mp4"] != "" {
+			t.Fatalf("bulk assignment not persisted correctly: %+v", reloaded.Assignments)
+		}
+
+		if resp := post(baseURL+"/api/library/playlists/unknown/assign", `{"paths":["video/one.mp4"]}`); resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("expected 404 for unknown playlist, got %d", resp.StatusCode)
+		}
+		if resp := post(baseURL+"/api/library/playlists/pl-1/assign", `{"paths":["../outside.mp4"]}`); resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200 with traversal path skipped, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestSavedPlaylistsOrderSetAndReload(t *testing.T) {
+	withTempCWD(t, func(tmpDir string) {
+		tracker = &jobTracker{}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		baseURL, wait := startWebServerForTest(t, ctx)
+		defer func() {
+			cancel()
+			wait()
+		}()
+
+		client := &http.Client{Timeout: 3 * time.Second}
+		put := func(url, body string) *http.Response {
+			t.Helper()
+			req, err := http.NewRequest(http.MethodPut, url, strings.NewReader(body))
+			if err != nil {
+				t.Fatalf("new request: %v", err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("request %s: %v", url, err)
+			}
+			t.Cleanup(func() { resp.Body.Close() })
+			return resp
+		}
+		load := func() savedPlaylistState {
+			t.Helper()
+			resp, err := client.Get(baseURL + "/api/library/playlists")
+			if err != nil {
+				t.Fatalf("load playlists: %v", err)
+			}
+			defer resp.Body.Close()
+			var state savedPlaylistState
+			if err := json.NewDecoder(resp.Body).Decode(&state); err != nil {
+				t.Fatalf("decode playlists: %v", err)
+			}
+			return state
+		}
+
+		statePayload := `{
+			"playlists": [{"id": "pl-1", "name": "Road Trip"}, {"id": "pl-2", "name": "Chill"}],
+			"assignments": {"video/a.mp4": "pl-1", "video/b.mp4": "pl-1", "video/c.mp4": "pl-1", "video/d.mp4": "pl-2"}
+		}`
+		if resp := put(baseURL+"/api/library/playlists", statePayload); resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200 for put playlists, got %d", resp.StatusCode)
+		}
+
+		resp := put(baseURL+"/api/library/playlists/pl-1/order", `{"order":["video/c.mp4","video/d.mp4","video/a.mp4","video/c.mp4","video/b.mp4"]}`)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200 for set order, got %d", resp.StatusCode)
+		}
+		want := []string{"video/c.mp4", "video/a.mp4", "video/b.mp4"}
+		var ordered savedPlaylistState
+		if err := json.NewDecoder(resp.Body).Decode(&ordered); err != nil {
+			t.Fatalf("decode order response: %v", err)
+		}
+		if got := ordered.Order["pl-1"]; strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Fatalf("order = %v, want %v (unassigned and duplicate keys dropped)", got, want)
+		}
+
+		if got := load().Order["pl-1"]; strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Fatalf("reloaded order = %v, want %v", got, want)
+		}
+
+		// A full replace without an order keeps the stored one, minus keys
+		// that left the playlist.
+		replacePayload := `{
+			"playlists": [{"id": "pl-1", "name": "Road Trip"}],
+			"assignments": {"video/a.mp4": "pl-1", "video/b.mp4": "pl-1"}
+		}`
+		if resp := put(baseURL+"/api/library/playlists", replacePayload); resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200 for replace, got %d", resp.StatusCode)
+		}
+		if got := load().Order["pl-1"]; strings.Join(got, ",") != "video/a.mp4,video/b.mp4" {
+			t.Fatalf("order after replace = %v, want [video/a.mp4 video/b.mp4]", got)
+		}
+
+		if resp := put(baseURL+"/api/library/playlists/missing/order", `{"order":[]}`); resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("expected 404 for unknown playlist, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestSavedPlaylistsExportWritesM3U(t *testing.T) {
+	withTempCWD(t, func(tmpDir string) {
+		tracker = &jobTracker{}
+
+		mediaDir := filepath.Join(tmpDir, "media")
+		audioDir := filepath.Join(mediaDir, "audio")
+		if err := os.MkdirAll(audioDir, 0o755); err != nil {
+			t.Fatalf("mkdir audio: %v", err)
+		}
+		for _, name := range []string{"first.mp3", "second.mp3"} {
+			if err := os.WriteFile(filepath.Join(audioDir, name), []byte("audio"), 0o644); err != nil {
+				t.Fatalf("write %s: %v", name, err)
+			}
+		}
+		if err := os.WriteFile(filepath.Join(audioDir, "second.mp3.json"), []byte(`{"title":"Second Song","duration_seconds":215}`), 0o644); err != nil {
+			t.Fatalf("write sidecar: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		baseURL, wait := startWebServerForTest(t, ctx)
+		defer func() {
+			cancel()
+			wait()
+		}()
+
+		client := &http.Client{Timeout: 3 * time.Second}
+		statePayload := `{
+			"playlists": [{"id": "pl-1", "name": "Road: Trip"}],
+			"assignments": {"audio/first.mp3": "pl-1", "audio/second.mp3": "pl-1", "audio/gone.mp3": "pl-1"},
+			"order": {"pl-1": ["audio/second.mp3", "audio/gone.mp3", "audio/first.mp3"]}
+		}`
+		putReq, err := http.NewRequest(http.MethodPut, baseURL+"/api/library/playlists", strings.NewReader(statePayload))
+		if err != nil {
+			t.Fatalf("new put request: %v", err)
+		}
+		putReq.Header.Set("Content-Type", "application/json")
+		putResp, err := client.Do(putReq)
+		if err != nil {
+			t.Fatalf("put playlists: %v", err)
+		}
+		putResp.Body.Close()
+		if putResp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200 for put playlists, got %d", putResp.StatusCode)
+		}
+
+		resp, err := client.Post(baseURL+"/api/library/playlists/pl-1/export", "application/json", nil)
+		if err != nil {
+			t.Fatalf("export playlist: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200 for export, got %d", resp.StatusCode)
+		}
+		var exported savedPlaylistExportResponse
+		if err := json.NewDecoder(resp.Body).Decode(&exported); err != nil {
+			t.Fatalf("decode export response: %v", err)
+		}
+		if exported.Path != "playlist/Road- Trip.m3u8" {
+			t.Fatalf("path = %q, want playlist/Road- Trip.m3u8", exported.Path)
+		}
+		if len(exported.Skipped) != 1 || exported.Skipped[0] != "audio/gone.mp3" {
+			t.Fatalf("skipped = %v, want [audio/gone.mp3]", exported.Skipped)
+		}
+
+		data, err := os.ReadFile(filepath.Join(mediaDir, filepath.FromSlash(exported.Path)))
+		if err != nil {
+			t.Fatalf("read exported m3u: %v", err)
+		}
+		want := "#EXTM3U\n" +
+			"#EXTINF:215,Second Song\n../audio/second.mp3\n" +
+			"#EXTINF:-1,first\n../audio/first.mp3\n"
+		if string(data) != want {
+			t.Fatalf("m3u content = %q, want %q", data, want)
+		}
+
+		missingResp, err := client.Post(baseURL+"/api/library/playlists/missing/export", "application/json", nil)
+		if err != nil {
+			t.Fatalf("export unknown playlist: %v", err)
+		}
+		defer missingResp.Body.Close()
+		if missingResp.StatusCode != http.StatusNotFound {
+			t.Fatalf("expected 404 for unknown playlist, got %d", missingResp.StatusCode)
+		}
+	})
+}
+
 func TestSavedPlaylistsEndpointMethodAndPayloadValidation(t *testing.T) {
 	withTempCWD(t, func(_ string) {
 		tracker = &jobTracker{}
@@ -665,6 +936,16 @@ func TestParseDownloadRequestNormalizesOutputTemplateForMediaLayout(t *testing.T
 			body:       `{"urls":["https://example.com/watch?v=abc"],"options":{"audio":false,"output":"audio/{title}.{ext}"}}`,
 			wantOutput: "audio/{title}.{ext}",
 		},
+		{
+			name:       "collection below default media root",
+			body:       `{"urls":["https://example.com/watch?v=abc"],"options":{"audio":true,"collection":"Road Trip 2026"}}`,
+			wantOutput: "audio/Road Trip 2026/{title}.{ext}",
+		},
+		{
+			name:       "collection below explicit media root",
+			body:       `{"urls":["https://example.com/watch?v=abc"],"options":{"output":"playlist/{playlist_title}/{title}.{ext}","collection":"mixes"}}`,
+			wantOutput: "playlist/mixes/{playlist_title}/{title}.{ext}",
+		},
 	}
 
 	for _, tt := range tests {
@@ -1177,3 +1458,684 @@ func TestMediaTypeForExtension(t *testing.T) {
 		}
 	}
 }
+
+func TestHealthAndReadinessEndpoints(t *testing.T) {
+	withTempCWD(t, func(tmpDir string) {
+		tracker = &jobTracker{}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		baseURL, wait := startWebServerForTest(t, ctx)
+		defer func() {
+			cancel()
+			wait()
+		}()
+
+		client := &http.Client{Timeout: 3 * time.Second}
+		for _, tc := range []struct {
+			path   string
+			status string
+		}{
+			{"/healthz", "ok"},
+			{"/readyz", "ready"},
+		} {
+			resp, err := client.Get(baseURL + tc.path)
+			if err != nil {
+				t.Fatalf("request %s: %v", tc.path, err)
+			}
+			var body map[string]any
+			decodeErr := json.NewDecoder(resp.Body).Decode(&body)
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("%s: expected 200, got %d", tc.path, resp.StatusCode)
+			}
+			if decodeErr != nil {
+				t.Fatalf("%s: decode body: %v", tc.path, decodeErr)
+			}
+			if body["status"] != tc.status {
+				t.Fatalf("%s: expected status %q, got %v", tc.path, tc.status, body)
+			}
+		}
+
+		resp, err := client.Post(baseURL+"/healthz", "application/json", nil)
+		if err != nil {
+			t.Fatalf("post /healthz: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusMethodNotAllowed {
+			t.Fatalf("expected 405 for POST /healthz, got %d", resp.StatusCode)
+		}
+
+		entries, err := os.ReadDir(filepath.Join(tmpDir, defaultMediaDirName))
+		if err != nil {
+			t.Fatalf("read media dir: %v", err)
+		}
+		for _, entry := range entries {
+			if strings.HasPrefix(entry.Name(), ".readyz-") {
+				t.Fatalf("readiness probe left %s behind", entry.Name())
+			}
+		}
+	})
+}
+
+func TestReadinessChecksReportFailures(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+	checks, ready := readinessChecks(missing, downloader.NewPool(1, nil))
+	if ready {
+		t.Fatalf("expected not ready with a missing media dir and unstarted pool")
+	}
+	if checks["media_dir"] == "ok" || checks["pool"] != "not started" {
+		t.Fatalf("unexpected checks: %v", checks)
+	}
+
+	pool := downloader.NewPool(1, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	pool.Start(ctx)
+	if checks, ready := readinessChecks(t.TempDir(), pool); !ready {
+		t.Fatalf("expected ready with a writable dir and running pool, got %v", checks)
+	}
+	cancel()
+	if _, ready := readinessChecks(t.TempDir(), pool); ready {
+		t.Fatalf("expected not ready after the pool context is cancelled")
+	}
+}
+
+func TestAPIResponsesAreGzipped(t *testing.T) {
+	withTempCWD(t, func(tmpDir string) {
+		tracker = &jobTracker{}
+
+		mediaDir := filepath.Join(tmpDir, "media")
+		if err := os.MkdirAll(mediaDir, 0o755); err != nil {
+			t.Fatalf("mkdir media: %v", err)
+		}
+		for _, name := range []string{"one.mp4", "two.mp3"} {
+			if err := os.WriteFile(filepath.Join(mediaDir, name), []byte(name), 0o644); err != nil {
+				t.Fatalf("write media %s: %v", name, err)
+			}
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		baseURL, wait := startWebServerForTest(t, ctx)
+		defer func() {
+			cancel()
+			wait()
+		}()
+
+		client := &http.Client{Timeout: 3 * time.Second}
+		get := func(path string) *http.Response {
+			t.Helper()
+			req, err := http.NewRequest(http.MethodGet, baseURL+path, nil)
+			if err != nil {
+				t.Fatalf("new request %s: %v", path, err)
+			}
+			// Setting the header explicitly disables the transport's own
+			// transparent decompression, so the raw encoding is visible.
+			req.Header.Set("Accept-Encoding", "gzip")
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("request %s: %v", path, err)
+			}
+			return resp
+		}
+
+		resp := get("/api/media/")
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200 for media list, got %d", resp.StatusCode)
+		}
+		if got := resp.Header.Get("Content-Encoding"); got != "gzip" {
+			t.Fatalf("expected Content-Encoding gzip, got %q", got)
+		}
+		if got := resp.Header.Get("Content-Type"); got != "application/json" {
+			t.Fatalf("expected JSON content type, got %q", got)
+		}
+		zr, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			t.Fatalf("gzip reader: %v", err)
+		}
+		var list mediaListResponse
+		if err := json.NewDecoder(zr).Decode(&list); err != nil {
+			t.Fatalf("decode gzipped media list: %v", err)
+		}
+		if len(list.Items) != 2 {
+			t.Fatalf("expected 2 media items, got %d", len(list.Items))
+		}
+
+		fileResp := get("/api/media/one.mp4")
+		body, _ := io.ReadAll(fileResp.Body)
+		fileResp.Body.Close()
+		if got := fileResp.Header.Get("Content-Encoding"); got != "" {
+			t.Fatalf("expected media file to be served uncompressed, got Content-Encoding %q", got)
+		}
+		if string(body) != "one.mp4" {
+			t.Fatalf("unexpected media file body %q", body)
+		}
+	})
+}
+
+func TestAcceptsEncoding(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"gzip", true},
+		{"br, gzip;q=0.8", true},
+		{"GZIP", true},
+		{"gzip;q=0", false},
+		{"deflate", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := acceptsEncoding(tt.header, "gzip"); got != tt.want {
+			t.Fatalf("acceptsEncoding(%q, gzip) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestDownloadSnapshotEndpoint(t *testing.T) {
+	withTempCWD(t, func(tmpDir string) {
+		tracker = &jobTracker{}
+		job := tracker.Create(context.Background(), []string{"https://example.com"})
+		defer job.CloseEvents()
+		waitForEventSeq(t, job, 1)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		baseURL, wait := startWebServerForTest(t, ctx)
+		defer func() {
+			cancel()
+			wait()
+		}()
+
+		client := &http.Client{Timeout: 3 * time.Second}
+		resp, err := client.Get(baseURL + "/api/download/snapshot?id=" + job.ID + "&afterSeq=0")
+		if err != nil {
+			t.Fatalf("snapshot request: %v", err)
+		}
+		var poll ProgressPoll
+		err = json.NewDecoder(resp.Body).Decode(&poll)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("decode snapshot: %v", err)
+		}
+		if resp.StatusCode != http.StatusOK || poll.Snapshot.JobID != job.ID || poll.Snapshot.Status != "queued" {
+			t.Fatalf("unexpected response %d: %+v", resp.StatusCode, poll)
+		}
+		if len(poll.Events) == 0 || poll.LastSeq < 1 {
+			t.Fatalf("expected the queued status event, got %+v", poll)
+		}
+
+		for path, want := range map[string]int{
+			"/api/download/snapshot":                              http.StatusBadRequest,
+			"/api/download/snapshot?id=" + job.ID + "&afterSeq=x": http.StatusBadRequest,
+			"/api/download/snapshot?id=job_missing":               http.StatusNotFound,
+		} {
+			resp, err := client.Get(baseURL + path)
+			if err != nil {
+				t.Fatalf("request %s: %v", path, err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != want {
+				t.Fatalf("%s: expected %d, got %d", path, want, resp.StatusCode)
+			}
+		}
+	})
+}
+
+func TestJobsEndpoint(t *testing.T) {
+	withTempCWD(t, func(tmpDir string) {
+		tracker = &jobTracker{}
+		running := tracker.Create(context.Background(), []string{"https://example.com/a"})
+		defer running.CloseEvents()
+		running.SetStatus("running")
+		queued := tracker.Create(context.Background(), []string{"https://example.com/b"})
+		defer queued.CloseEvents()
+		waitForEventSeq(t, running, 2)
+		waitForEventSeq(t, queued, 1)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		baseURL, wait := startWebServerForTest(t, ctx)
+		defer func() {
+			cancel()
+			wait()
+		}()
+
+		client := &http.Client{Timeout: 3 * time.Second}
+		resp, err := client.Get(baseURL + "/api/jobs?status=running")
+		if err != nil {
+			t.Fatalf("jobs request: %v", err)
+		}
+		var list jobListResponse
+		err = json.NewDecoder(resp.Body).Decode(&list)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("decode jobs: %v", err)
+		}
+		if resp.StatusCode != http.StatusOK || len(list.Items) != 1 || list.NextOffset != nil {
+			t.Fatalf("unexpected response %d: %+v", resp.StatusCode, list)
+		}
+		if got := list.Items[0]; got.ID != running.ID || got.Status != "running" || len(got.URLs) != 1 || got.CreatedAt.IsZero() {
+			t.Fatalf("unexpected job summary: %+v", got)
+		}
+
+		resp, err = client.Get(baseURL + "/api/jobs?limit=1")
+		if err != nil {
+			t.Fatalf("jobs request: %v", err)
+		}
+		list = jobListResponse{}
+		err = json.NewDecoder(resp.Body).Decode(&list)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("decode jobs: %v", err)
+		}
+		if len(list.Items) != 1 || list.Items[0].ID != queued.ID || list.NextOffset == nil || *list.NextOffset != 1 {
+			t.Fatalf("expected the newest job with a next offset, got %+v", list)
+		}
+
+		for _, path := range []string{"/api/jobs?status=paused", "/api/jobs?offset=-1"} {
+			resp, err := client.Get(baseURL + path)
+			if err != nil {
+				t.Fatalf("request %s: %v", path, err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Fatalf("%s: expected 400, got %d", path, resp.StatusCode)
+			}
+		}
+	})
+}
+
+func TestParseDownloadRequestRejectsUnsafeCollection(t *testing.T) {
+	for _, collection := range []string{"../outside", "..", "a/b", `a\\b`, "/abs", ".hidden", "trailing.", "{title}", "C:"} {
+		t.Run(collection, func(t *testing.T) {
+			payload, err := json.Marshal(map[string]any{
+				"urls":    []string{"https://example.com/watch?v=abc"},
+				"options": map[string]any{"collection": collection},
+			})
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/api/download", bytes.NewReader(payload))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			_, _, _, reqErr := parseDownloadRequest(rec, req)
+			if reqErr == nil || reqErr.status != http.StatusBadRequest {
+				t.Fatalf("expected collection %q to be rejected with 400, got %v", collection, reqErr)
+			}
+		})
+	}
+}
+
+func TestListMediaFilesReadsMetadataIndex(t *testing.T) {
+	mediaDir := t.TempDir()
+	for _, rel := range []string{"video/indexed.mp4", "audio/sidecar.m4a", "audio/removed.m4a"} {
+		path := filepath.Join(mediaDir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(rel), 0o644); err != nil {
+			t.Fatalf("write %s: %v", rel, err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(mediaDir, "audio", "sidecar.m4a.json"), []byte(`{"id":"s1","title":"From Sidecar","artist":"Sidecar Artist"}`), 0o644); err != nil {
+		t.Fatalf("write sidecar: %v", err)
+	}
+	indexPath := downloader.MetadataIndexPath(mediaDir)
+	records := []struct {
+		path     string
+		metadata *downloader.ItemMetadata
+	}{
+		{"video/indexed.mp4", &downloader.ItemMetadata{ID: "v1", Title: "Old Title"}},
+		{"video/indexed.mp4", &downloader.ItemMetadata{ID: "v1", Title: "From Index", Artist: "Index Artist", Album: "Index Album"}},
+		{"audio/removed.m4a", &downloader.ItemMetadata{ID: "r1", Title: "Removed"}},
+		{"audio/removed.m4a", nil},
+	}
+	for _, record := range records {
+		if err := downloader.AppendMetadataIndex(indexPath, record.path, record.metadata); err != nil {
+			t.Fatalf("append index: %v", err)
+		}
+	}
+
+	items, err := listMediaFiles(mediaDir, "")
+	if err != nil {
+		t.Fatalf("listMediaFiles: %v", err)
+	}
+	byPath := make(map[string]mediaItem, len(items))
+	for _, item := range items {
+		byPath[item.RelativePath] = item
+	}
+	if len(byPath) != 3 {
+		t.Fatalf("expected the 3 media files and not the index itself, got %v", byPath)
+	}
+
+	indexed := byPath["video/indexed.mp4"]
+	if indexed.Title != "From Index" || indexed.Artist != "Index Artist" || indexed.Album != "Index Album" || !indexed.HasSidecar {
+		t.Fatalf("expected latest index metadata, got %+v", indexed)
+	}
+	if indexed.Metadata.Output != "video/indexed.mp4" {
+		t.Fatalf("expected normalized output path, got %q", indexed.Metadata.Output)
+	}
+	if sidecar := byPath["audio/sidecar.m4a"]; sidecar.Title != "From Sidecar" || !sidecar.HasSidecar {
+		t.Fatalf("expected sidecar fallback for unindexed file, got %+v", sidecar)
+	}
+	if removed := byPath["audio/removed.m4a"]; removed.Title != "removed" || removed.HasSidecar {
+		t.Fatalf("expected deleted index entry to fall back to defaults, got %+v", removed)
+	}
+}
+
+func TestListMediaFilesSortOrders(t *testing.T) {
+	mediaDir := t.TempDir()
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	files := []struct {
+		rel     string
+		modTime time.Time
+	}{
+		{"video/charlie.mp4", base.Add(2 * time.Hour)},
+		{"audio/Alpha.m4a", base},
+		{"video/bravo.mp4", base.Add(time.Hour)},
+		{"audio/delta.m4a", base.Add(time.Hour)},
+	}
+	for _, f := range files {
+		path := filepath.Join(mediaDir, filepath.FromSlash(f.rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(f.rel), 0o644); err != nil {
+			t.Fatalf("write %s: %v", f.rel, err)
+		}
+		if err := os.Chtimes(path, f.modTime, f.modTime); err != nil {
+			t.Fatalf("chtimes %s: %v", f.rel, err)
+		}
+	}
+
+	tests := []struct {
+		order string
+		want  []string
+	}{
+		// bravo and delta share a modification time; the filename breaks the tie.
+		{"", []string{"video/charlie.mp4", "audio/delta.m4a", "video/bravo.mp4", "audio/Alpha.m4a"}},
+		{MediaSortNewest, []string{"video/charlie.mp4", "audio/delta.m4a", "video/bravo.mp4", "audio/Alpha.m4a"}},
+		{MediaSortOldest, []string{"audio/Alpha.m4a", "audio/delta.m4a", "video/bravo.mp4", "video/charlie.mp4"}},
+		{MediaSortTitle, []string{"audio/Alpha.m4a", "video/bravo.mp4", "video/charlie.mp4", "audio/delta.m4a"}},
+	}
+	for _, tt := range tests {
+		t.Run("sort="+tt.order, func(t *testing.T) {
+			for run := 0; run < 3; run++ {
+				items, err := listMediaFiles(mediaDir, tt.order)
+				if err != nil {
+					t.Fatalf("listMediaFiles: %v", err)
+				}
+				got := make([]string, len(items))
+				for i, item := range items {
+					got[i] = item.RelativePath
+				}
+				if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+					t.Fatalf("run %d: expected %v, got %v", run, tt.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestCancelledDownloadLeavesNoPartialInMediaList(t *testing.T) {
+	mediaDir := t.TempDir()
+	mediaURL, requests := newStallingMediaServer(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		app.Run(ctx, []string{mediaURL}, downloader.Options{
+			OutputTemplate: "{title}.{ext}",
+			OutputDir:      mediaDir,
+			Quiet:          true,
+		}, 1)
+	}()
+
+	partPath := filepath.Join(mediaDir, "clip.mp4.part")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(partPath); err == nil && requests.Load() == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the download to start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	items, err := listMediaFiles(mediaDir, "")
+	if err != nil {
+		t.Fatalf("listMediaFiles: %v", err)
+	}
+	if len(items) != 0 {
+		t.Fatalf("expected the cancelled download not to be listed, got %+v", items)
+	}
+	if _, err := os.Stat(filepath.Join(mediaDir, "clip.mp4")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected no file under the final name, stat err = %v", err)
+	}
+}
+
+func TestServerOptionsValidateMediaSort(t *testing.T) {
+	for _, order := range []string{"", MediaSortNewest, MediaSortOldest, MediaSortTitle} {
+		if err := (ServerOptions{MediaSort: order}).validate(); err != nil {
+			t.Errorf("expected %q to be accepted: %v", order, err)
+		}
+	}
+	if err := (ServerOptions{MediaSort: "size"}).validate(); err == nil {
+		t.Error("expected an unknown media sort to be rejected")
+	}
+}
+
+// newStallingMediaServer serves a direct media URL whose download stalls
+// mid-file until the request is cancelled. The first request is the page
+// metadata probe and gets an empty response.
+func newStallingMediaServer(t *testing.T) (mediaURL string, requests *atomic.Int32) {
+	t.Helper()
+	requests = &atomic.Int32{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			return
+		}
+		w.Header().Set("Content-Type", "video/mp4")
+		w.Header().Set("Content-Length", strconv.Itoa(1<<20))
+		_, _ = w.Write([]byte("\x00\x00\x00\x18ftypisom"))
+		_, _ = w.Write(bytes.Repeat([]byte("x"), 64<<10))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL + "/clip.mp4", requests
+}
+
+// postDownload starts a download through /api/download and returns the job
+// ID from the response.
+func postDownload(t *testing.T, client *http.Client, baseURL, mediaURL string) string {
+	t.Helper()
+	payload := fmt.Sprintf(`{"urls":[%q],"options":{"output":"{title}.{ext}","on-duplicate":"overwrite"}}`, mediaURL)
+	resp, err := client.Post(baseURL+"/api/download", "application/json", strings.NewReader(payload))
+	if err != nil {
+		t.Fatalf("download request: %v", err)
+	}
+	defer resp.Body.Close()
+	var body struct {
+		Status string   `json:"status"`
+		JobID  string   `json:"jobId"`
+		JobIDs []string `json:"jobIds"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode download response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || body.JobID == "" || len(body.JobIDs) != 1 || body.JobIDs[0] != body.JobID {
+		t.Fatalf("unexpected download response %d: %+v", resp.StatusCode, body)
+	}
+	return body.JobID
+}
+
+func waitForJobStatus(t *testing.T, jobID, want string) *Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if job, ok := tracker.Get(jobID); ok && job.StatusValue() == want {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for job %s to reach %q", jobID, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestDownloadEndpointRegistersTrackedJob(t *testing.T) {
+	withTempCWD(t, func(_ string) {
+		tracker = &jobTracker{}
+		mediaURL, _ := newStallingMediaServer(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		baseURL, wait := startWebServerForTest(t, ctx)
+		defer func() {
+			cancel()
+			wait()
+		}()
+
+		client := &http.Client{Timeout: 3 * time.Second}
+		jobID := postDownload(t, client, baseURL, mediaURL)
+		waitForJobStatus(t, jobID, "running")
+
+		resp, err := client.Get(baseURL + "/api/jobs?status=running")
+		if err != nil {
+			t.Fatalf("jobs request: %v", err)
+		}
+		var list jobListResponse
+		err = json.NewDecoder(resp.Body).Decode(&list)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("decode jobs: %v", err)
+		}
+		if len(list.Items) != 1 || list.Items[0].ID != jobID || len(list.Items[0].URLs) != 1 || list.Items[0].URLs[0] != mediaURL {
+			t.Fatalf("expected the running download to be listed, got %+v", list)
+		}
+	})
+}
+
+func TestCancelAllStopsPoolDownloads(t *testing.T) {
+	withTempCWD(t, func(_ string) {
+		tracker = &jobTracker{}
+		mediaURL, requests := newStallingMediaServer(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		baseURL, wait := startWebServerForTest(t, ctx)
+		defer func() {
+			cancel()
+			wait()
+		}()
+
+		client := &http.Client{Timeout: 3 * time.Second}
+		jobID := postDownload(t, client, baseURL, mediaURL)
+		deadline := time.Now().Add(5 * time.Second)
+		for requests.Load() < 2 {
+			if time.Now().After(deadline) {
+				t.Fatal("timed out waiting for the download to start")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		resp, err := client.Post(baseURL+"/api/download/cancel-all", "application/json", nil)
+		if err != nil {
+			t.Fatalf("cancel-all request: %v", err)
+		}
+		var body struct {
+			Cancelled int `json:"cancelled"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&body)
+		resp.Body.Close()
+		if err != nil || body.Cancelled != 1 {
+			t.Fatalf("expected cancel-all to cancel the download, got %+v (err %v)", body, err)
+		}
+
+		waitForJobStatus(t, jobID, "cancelled")
+		for globalPool.Stats().Running != 0 {
+			if time.Now().After(deadline) {
+				t.Fatal("cancel-all did not stop the running download")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+}
+
+func TestDownloadSnapshotEndpointServesPoolDownloads(t *testing.T) {
+	withTempCWD(t, func(_ string) {
+		tracker = &jobTracker{}
+		mediaURL, _ := newStallingMediaServer(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		baseURL, wait := startWebServerForTest(t, ctx)
+		defer func() {
+			cancel()
+			wait()
+		}()
+
+		client := &http.Client{Timeout: 3 * time.Second}
+		jobID := postDownload(t, client, baseURL, mediaURL)
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			resp, err := client.Get(baseURL + "/api/download/snapshot?id=" + jobID)
+			if err != nil {
+				t.Fatalf("snapshot request: %v", err)
+			}
+			var poll ProgressPoll
+			err = json.NewDecoder(resp.Body).Decode(&poll)
+			resp.Body.Close()
+			if err != nil || resp.StatusCode != http.StatusOK {
+				t.Fatalf("expected a snapshot for %s, got %d (err %v)", jobID, resp.StatusCode, err)
+			}
+			if poll.Snapshot.Status == "running" && len(poll.Snapshot.Tasks) == 1 && poll.Snapshot.Tasks[0].ID == jobID {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("expected the running download's task in the snapshot, got %+v", poll.Snapshot)
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	})
+}
+
+func TestDownloadEndpointReportsJobTimeout(t *testing.T) {
+	withTempCWD(t, func(_ string) {
+		tracker = &jobTracker{}
+		prevUnit := maxJobDurationUnit
+		maxJobDurationUnit = 200 * time.Millisecond
+		defer func() { maxJobDurationUnit = prevUnit }()
+		mediaURL, _ := newStallingMediaServer(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		baseURL, wait := startWebServerForTest(t, ctx)
+		defer func() {
+			cancel()
+			wait()
+		}()
+
+		client := &http.Client{Timeout: 3 * time.Second}
+		payload := fmt.Sprintf(`{"urls":[%q],"options":{"output":"{title}.{ext}","on-duplicate":"overwrite","max-job-duration":1}}`, mediaURL)
+		resp, err := client.Post(baseURL+"/api/download", "application/json", strings.NewReader(payload))
+		if err != nil {
+			t.Fatalf("download request: %v", err)
+		}
+		var body struct {
+			JobID string `json:"jobId"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&body)
+		resp.Body.Close()
+		if err != nil || body.JobID == "" {
+			t.Fatalf("unexpected download response: %+v (err %v)", body, err)
+		}
+
+		job := waitForJobStatus(t, body.JobID, "error")
+		snapshot := job.progressSnapshot()
+		if snapshot.Error != "job timed out after 200ms" || snapshot.ExitCode == 0 {
+			t.Fatalf("expected the job to report the timeout, got %q (exit %d)", snapshot.Error, snapshot.ExitCode)
+		}
+	})
+}
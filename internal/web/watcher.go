@@ -92,9 +92,10 @@ func (mw *mediaWatcher) Run(ctx context.Context) {
 }
 
 func (mw *mediaWatcher) handleEvent(event fsnotify.Event) {
-	// Ignore sidecar JSON, DB files, and the data folder internals.
+	// Ignore sidecar JSON, in-progress .part files, DB files, and the data
+	// folder internals.
 	ext := strings.ToLower(filepath.Ext(event.Name))
-	if ext == ".json" || ext == ".db" || ext == ".db-shm" || ext == ".db-wal" || ext == ".db-journal" {
+	if ext == ".json" || ext == ".ndjson" || ext == ".part" || ext == ".db" || ext == ".db-shm" || ext == ".db-wal" || ext == ".db-journal" {
 		return
 	}
 
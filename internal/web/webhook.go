@@ -0,0 +1,119 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/lvcoi/ytdl-go/internal/app"
+)
+
+const (
+	webhookTimeout        = 5 * time.Second
+	webhookMaxAttempts    = 3
+	webhookInitialBackoff = time.Second
+)
+
+// WebhookPayload is POSTed to the configured webhook URL when a job reaches a
+// terminal state.
+type WebhookPayload struct {
+	JobID       string        `json:"jobId"`
+	Status      string        `json:"status"`
+	ExitCode    int           `json:"exitCode"`
+	Error       string        `json:"error,omitempty"`
+	URLs        []string      `json:"urls,omitempty"`
+	Results     []app.Result  `json:"results,omitempty"`
+	Stats       ProgressStats `json:"stats"`
+	CompletedAt time.Time     `json:"completedAt"`
+}
+
+// webhookNotifier delivers job completion payloads. Deliveries run in the
+// background so a slow or dead endpoint never blocks job bookkeeping.
+type webhookNotifier struct {
+	url            string
+	client         *http.Client
+	maxAttempts    int
+	initialBackoff time.Duration
+}
+
+// jobWebhook is the notifier used by SetOutcome; nil disables webhooks.
+var jobWebhook *webhookNotifier
+
+func newWebhookNotifier(rawURL string) (*webhookNotifier, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook URL: %w", err)
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid webhook URL %q: must be an absolute http(s) URL", rawURL)
+	}
+	return &webhookNotifier{
+		url:            parsed.String(),
+		client:         &http.Client{Timeout: webhookTimeout},
+		maxAttempts:    webhookMaxAttempts,
+		initialBackoff: webhookInitialBackoff,
+	}, nil
+}
+
+// Notify sends payload asynchronously, retrying failed deliveries with
+// exponential backoff.
+func (n *webhookNotifier) Notify(payload WebhookPayload) {
+	if n == nil {
+		return
+	}
+	go func() {
+		if err := n.deliver(context.Background(), payload); err != nil {
+			log.Printf("webhook delivery for %s failed: %v", payload.JobID, err)
+		}
+	}()
+}
+
+func (n *webhookNotifier) deliver(ctx context.Context, payload WebhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	attempts := n.maxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	backoff := n.initialBackoff
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+		lastErr = n.post(ctx, body)
+		if lastErr == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("after %d attempt(s): %w", attempts, lastErr)
+}
+
+func (n *webhookNotifier) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lvcoi/ytdl-go/internal/app"
+)
+
+func TestSetOutcomePostsWebhookPayload(t *testing.T) {
+	received := make(chan WebhookPayload, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("expected POST, got %s", r.Method)
+		}
+		var payload WebhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("decode payload: %v", err)
+		}
+		received <- payload
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	notifier, err := newWebhookNotifier(srv.URL)
+	if err != nil {
+		t.Fatalf("newWebhookNotifier: %v", err)
+	}
+	origWebhook := jobWebhook
+	jobWebhook = notifier
+	defer func() { jobWebhook = origWebhook }()
+
+	job := createTestJob(t, &jobTracker{}, []string{"https://example.com/a", "https://example.com/b"})
+	job.SetOutcome([]app.Result{
+		{URL: "https://example.com/a"},
+		{URL: "https://example.com/b", Error: "boom"},
+	}, 1)
+
+	select {
+	case payload := <-received:
+		if payload.JobID != job.ID {
+			t.Fatalf("expected job id %q, got %q", job.ID, payload.JobID)
+		}
+		if payload.Status != "error" || payload.ExitCode != 1 {
+			t.Fatalf("unexpected status %q exit %d", payload.Status, payload.ExitCode)
+		}
+		if len(payload.Results) != 2 || payload.Stats.Total != 2 || payload.Stats.Failed != 1 {
+			t.Fatalf("unexpected results/stats: %+v %+v", payload.Results, payload.Stats)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for webhook delivery")
+	}
+}
+
+func TestWebhookRetriesWithBackoff(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	notifier, err := newWebhookNotifier(srv.URL)
+	if err != nil {
+		t.Fatalf("newWebhookNotifier: %v", err)
+	}
+	notifier.initialBackoff = 10 * time.Millisecond
+
+	if err := notifier.deliver(t.Context(), WebhookPayload{JobID: "job_1", Status: "complete"}); err != nil {
+		t.Fatalf("expected delivery to succeed on retry, got %v", err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("expected 2 attempts, got %d", got)
+	}
+}
+
+func TestNewWebhookNotifierRejectsInvalidURL(t *testing.T) {
+	for _, raw := range []string{"ftp://example.com", "/relative", "http://"} {
+		if _, err := newWebhookNotifier(raw); err == nil {
+			t.Fatalf("expected %q to be rejected", raw)
+		}
+	}
+}
+
+func TestDownloadEndpointSendsOneWebhookPerJob(t *testing.T) {
+	withTempCWD(t, func(_ string) {
+		tracker = &jobTracker{}
+		received := make(chan WebhookPayload, 4)
+		hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var payload WebhookPayload
+			if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+				t.Errorf("decode payload: %v", err)
+			}
+			received <- payload
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer hook.Close()
+		notifier, err := newWebhookNotifier(hook.URL)
+		if err != nil {
+			t.Fatalf("newWebhookNotifier: %v", err)
+		}
+		origWebhook := jobWebhook
+		jobWebhook = notifier
+		defer func() { jobWebhook = origWebhook }()
+
+		var requests atomic.Int32
+		media := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// The first request is the page metadata probe.
+			if requests.Add(1) == 1 {
+				return
+			}
+			w.Header().Set("Content-Type", "video/mp4")
+			_, _ = w.Write([]byte("\x00\x00\x00\x18ftypisom" + strings.Repeat("\x00", 12) + "moov"))
+		}))
+		defer media.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		baseURL, wait := startWebServerForTest(t, ctx)
+		defer func() {
+			cancel()
+			wait()
+		}()
+
+		jobID := postDownload(t, &http.Client{Timeout: 3 * time.Second}, baseURL, media.URL+"/clip.mp4")
+		select {
+		case payload := <-received:
+			if payload.JobID != jobID || payload.Status != "complete" {
+				t.Fatalf("unexpected webhook payload: %+v", payload)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for webhook delivery")
+		}
+		select {
+		case payload := <-received:
+			t.Fatalf("expected a single webhook, got a second: %+v", payload)
+		case <-time.After(300 * time.Millisecond):
+		}
+	})
+}
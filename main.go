@@ -1,13 +1,16 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
+	"regexp"
 	"strings"
 	"syscall"
 	"time"
@@ -23,70 +26,414 @@ func main() {
 
 	var opts downloader.Options
 	var meta metaFlags
+	var paths pathFlags
+	var printToFile printFlags
 	var jobs int
 	var web bool
 	var webAddr string
 	var serverHost string
 	var serverPort int
+	var serverOpts webserver.ServerOptions
+	var minFileSize string
+	var dateAfter string
+	var dateBefore string
+	var matchTitle string
+	var rejectTitle string
+	var downloadArchive string
+	var batchFile string
+	var sourceAddress string
+	var metadataProxy string
+	var ffmpegLocation string
+	var maxConnsPerHost int
+	var bufferSize string
+	var rateLimit string
+	var rateLimitSchedule string
+	var noCheckCertificate bool
+	var forceHTTP1 bool
+	var idleConnTimeout time.Duration
+	var noKeepAlive bool
+	var abortOnUnavailableFragment bool
+	var mtime bool
+	var metadataStore string
 
-	flag.StringVar(&opts.OutputTemplate, "o", "{title}.{ext}", "output path or template (supports {title}, {artist}, {album}, {id}, {ext}, {quality}, {playlist_title}, {playlist_id}, {index}, {count})")
+	flag.StringVar(&opts.OutputTemplate, "o", "{title}.{ext}", "output path or template (supports {title}, {artist}, {album}, {id}, {ext}, {quality}, {playlist_title}, {playlist_id}, {index}, {count}, {autonumber})")
+	flag.IntVar(&opts.AutonumberStart, "autonumber-start", 1, "first number used by the {autonumber} template placeholder")
+	flag.IntVar(&opts.AutonumberWidth, "autonumber-width", 3, "zero-pad {autonumber} to this many digits")
+	flag.BoolVar(&opts.FlatPlaylistLayout, "flat-playlist-layout", false, "save playlist entries into one folder with zero-padded index prefixes, dropping {playlist_title}/{playlist_id} directories")
+	flag.StringVar(&dateAfter, "dateafter", "", "only download playlist entries published on or after this date (YYYYMMDD)")
+	flag.StringVar(&dateBefore, "datebefore", "", "only download playlist entries published on or before this date (YYYYMMDD)")
+	flag.StringVar(&matchTitle, "match-title", "", "only download playlist entries whose title matches this regular expression")
+	flag.StringVar(&rejectTitle, "reject-title", "", "skip playlist entries whose title matches this regular expression")
+	flag.DurationVar(&opts.MinDuration, "min-duration", 0, "skip playlist entries shorter than this (e.g. 60s; 0 = no limit)")
+	flag.DurationVar(&opts.MaxDuration, "max-duration", 0, "skip playlist entries longer than this (e.g. 1h; 0 = no limit)")
+	flag.StringVar(&opts.PreferLang, "prefer-lang", "", "prefer the localized title/description in this language (e.g. en, pt-BR) when available")
+	flag.IntVar(&opts.MaxDescriptionLength, "max-description-length", 0, "truncate descriptions in -info output to this many characters, adding \"...\" (0 = no limit)")
+	flag.IntVar(&opts.TrimFilenames, "trim-filenames", 0, "truncate output filenames to this many characters, keeping the extension (0 = no limit)")
 	flag.StringVar(&opts.OutputDir, "output-dir", "", "base output directory for security enforcement (prevents directory traversal)")
 	flag.BoolVar(&opts.AudioOnly, "audio", false, "download best available audio only")
+	flag.StringVar(&opts.AudioFormat, "audio-format", "", "encode audio to mp3, m4a, opus, flac, or wav (implies -audio, requires ffmpeg)")
+	flag.StringVar(&opts.AudioQuality, "audio-quality", "", "audio encoding quality: bitrate like 256k, or mp3 VBR level 0-9")
+	flag.BoolVar(&opts.EmbedMetadata, "embed-metadata", false, "embed title, artist, date, and source URL into video files (requires ffmpeg)")
+	flag.BoolVar(&opts.WritePlaylistM3U, "write-playlist-m3u", false, "after a playlist, write an .m3u8 index of the downloaded files named after the playlist")
+	flag.BoolVar(&opts.M3UIncludeSkipped, "m3u-include-skipped", false, "list entries skipped because the file already exists in the -write-playlist-m3u index")
+	flag.StringVar(&opts.ReportFailed, "report-failed", "", "write failed and skipped playlist entries to this JSON file")
+	flag.BoolVar(&opts.RequireAll, "require-all", false, "exit non-zero if any playlist entry fails (every entry is still attempted)")
+	flag.BoolVar(&opts.RespectTimestamps, "respect-timestamps", false, "start the download at the URL's t= or start= timestamp (requires ffmpeg)")
 	flag.BoolVar(&opts.InfoOnly, "info", false, "print video metadata as JSON without downloading")
+	flag.BoolVar(&opts.FlatPlaylist, "flat-playlist", false, "print one JSON line per playlist entry without resolving each video, then exit")
+	flag.BoolVar(&opts.GetFilename, "get-filename", false, "print the output path a download would use and exit")
+	flag.BoolVar(&opts.GetURL, "get-url", false, "print the selected format's stream URL and exit")
+	flag.BoolVar(&opts.DumpPlan, "dump-plan", false, "print a JSON plan of each entry's format, output path and skip status, then exit without downloading")
 	flag.BoolVar(&opts.ListFormats, "list-formats", false, "list available formats and exit")
+	flag.BoolVar(&opts.SortFormats, "sort-formats", false, "with -list-formats, list the best formats first (by height, then bitrate); also applies to JSON output")
+	flag.BoolVar(&opts.FormatsArrayJSON, "print-json-formats-only", false, "with -list-formats -json, print each video's formats as a bare JSON array")
+	flag.BoolVar(&opts.ListSubtitles, "list-subtitles", false, "list available subtitle tracks and exit")
+	flag.StringVar(&opts.Subtitles, "subtitles", "", "download subtitles as SRT: comma-separated language codes or \"all\"")
+	flag.BoolVar(&opts.EmbedSubs, "embed-subs", false, "mux downloaded subtitles into mp4/mkv outputs (requires -subtitles and ffmpeg)")
 	flag.StringVar(&opts.Quality, "quality", "", "preferred quality (e.g. 1080p, 720p, 128k, best, worst)")
 	flag.StringVar(&opts.Format, "format", "", "preferred container/extension (e.g. mp4, webm, m4a)")
 	flag.IntVar(&opts.Itag, "itag", 0, "download specific format by itag number (use --list-formats to see available itags)")
+	flag.BoolVar(&opts.PreferFreeFormats, "prefer-free-formats", false, "prefer webm/vp9/opus over mp4/aac when quality is otherwise equal")
+	flag.StringVar(&minFileSize, "min-filesize", "", "skip formats smaller than this size (e.g. 500K, 1M); unknown sizes are not checked")
+	flag.StringVar(&batchFile, "a", "", "read URLs from a file, one per line (\"-\" for stdin; blank lines and # comments are ignored)")
+	flag.StringVar(&batchFile, "batch-file", "", "alias for -a")
 	flag.Var(&meta, "meta", "metadata override key=value (repeatable)")
-	flag.StringVar(&opts.ProgressLayout, "progress-layout", "", "progress layout template (e.g. \"{label} {percent} {current}/{total} {rate} {eta}\")")
+	flag.Var(&paths, "paths", "per-type base directories, e.g. audio:/music,video:/videos,subtitle:/subs (repeatable)")
+	flag.StringVar(&opts.ProgressLayout, "progress-layout", "", "progress layout template (e.g. \"{label} {percent} {current}/{total} {rate} {eta}\"; also {filename}, {size})")
 	flag.IntVar(&opts.SegmentConcurrency, "segment-concurrency", 0, "parallel segment downloads (0=auto)")
+	flag.DurationVar(&opts.WaitForVideo, "wait-for-video", 0, "poll an upcoming premiere or live stream at this interval until it starts, then download it (0 = don't wait)")
+	flag.DurationVar(&opts.WaitForVideoMax, "wait-for-video-max", 24*time.Hour, "give up on -wait-for-video after this long (0 = no limit)")
+	flag.BoolVar(&mtime, "mtime", true, "set the downloaded file's modification time to the video's upload date")
+	flag.StringVar(&metadataStore, "metadata-store", downloader.MetadataStoreSidecar, "where item metadata is written: sidecar (a .json next to each file) or index (one data/metadata.ndjson under the output root)")
+	flag.BoolVar(&opts.NoContentCheck, "no-content-check", false, "save direct downloads even when the server responds with HTML or text instead of media")
+	flag.BoolVar(&opts.NoMtime, "no-mtime", false, "keep the current time as the downloaded file's modification time (same as -mtime=false)")
+	flag.BoolVar(&opts.HLSUseFFmpeg, "hls-use-ffmpeg", false, "download HLS streams with ffmpeg instead of the native segment downloader (falls back to native without ffmpeg)")
+	flag.BoolVar(&opts.LiveFromStart, "live-from-start", false, "record an ongoing HLS live stream from the earliest available segment until it ends")
+	flag.BoolVar(&opts.SkipUnavailableFragments, "skip-unavailable-fragments", false, "skip HLS/DASH fragments that still fail after retries instead of failing the download")
+	flag.BoolVar(&abortOnUnavailableFragment, "abort-on-unavailable-fragment", false, "fail the download when an HLS/DASH fragment cannot be fetched after retries (default)")
+	flag.BoolVar(&opts.AutoConcurrency, "auto-concurrency", false, "tune segment concurrency from measured throughput, backing off on 429/403 (-segment-concurrency sets the ceiling)")
+	flag.StringVar(&bufferSize, "buffer-size", "", "buffer size for copying downloaded data to disk (e.g. 256K, 1M; 4K to 16M)")
+	flag.IntVar(&maxConnsPerHost, "max-connections-per-host", 0, "cap concurrent connections to any one host across all jobs and segment workers (0 = no cap)")
+	flag.StringVar(&rateLimit, "rate-limit", "", "cap the total download rate in bytes per second (e.g. 500K, 2M; default no limit)")
+	flag.StringVar(&rateLimitSchedule, "rate-limit-schedule", "", "time-of-day rate limits overriding -rate-limit, e.g. 08:00-18:00=1M,23:00-07:00=unlimited")
 	flag.IntVar(&opts.PlaylistConcurrency, "playlist-concurrency", 0, "parallel playlist entry downloads (0=auto)")
+	flag.IntVar(&opts.MetadataConcurrency, "metadata-concurrency", 0, "resolve this many playlist entries' metadata ahead of the download in progress (0 = fetch each entry just before downloading it)")
+	flag.IntVar(&opts.PlaylistEntryRetries, "playlist-entry-retries", 0, "retry transient per-entry playlist metadata fetch errors this many times before marking the entry failed")
+	flag.BoolVar(&opts.NoPlaylist, "no-playlist", false, "download only the video when a URL references both a video and a playlist")
+	flag.BoolVar(&opts.YesPlaylist, "yes-playlist", false, "download the playlist when a URL references both a video and a playlist")
 	flag.IntVar(&jobs, "jobs", 1, "number of concurrent downloads")
+	flag.StringVar(&opts.CleanArtist, "clean-artist", "auto", "strip \" - Topic\"/VEVO channel suffixes from artist names: auto (music URLs only), always, never")
+	flag.BoolVar(&opts.AddReplayGain, "add-replaygain", false, "measure loudness with ffmpeg and write ReplayGain tags for audio downloads")
 	flag.BoolVar(&opts.JSON, "json", false, "emit JSON output (suppresses human-readable progress)")
+	flag.BoolVar(&opts.JSONProgress, "json-progress", false, "with -json, also emit periodic progress lines (implies -json)")
+	flag.BoolVar(&opts.CleanupOnFailure, "cleanup-on-failure", false, "delete .part and .resume.json files when a download fails (default keeps them for resume)")
+	flag.StringVar(&downloadArchive, "download-archive", "", "skip videos listed in this file and record each download's ID and format in it")
+	flag.BoolVar(&opts.RedownloadOnBetterQuality, "redownload-on-better-quality", false, "with -download-archive, download archived videos again when a better format than the recorded one is available")
+	flag.BoolVar(&opts.NoResume, "no-resume", false, "delete existing .part and .resume.json files and start downloads from scratch")
+	flag.BoolVar(&opts.AdoptPartial, "continue-from-browser-download", false, "continue a direct or progressive YouTube download from an existing <output>.part written by another tool; a part not shorter than the known size starts over")
+	flag.BoolVar(&opts.Verify, "verify", false, "check the downloaded duration with ffprobe and fail truncated downloads")
+	flag.BoolVar(&opts.WriteChecksum, "write-checksum", false, "write the SHA-256 of each download to <output>.sha256")
+	flag.StringVar(&ffmpegLocation, "ffmpeg-location", "", "path to the ffmpeg binary, or the directory holding ffmpeg and ffprobe (default: search PATH)")
 	flag.DurationVar(&opts.Timeout, "timeout", 3*time.Minute, "per-request timeout")
+	flag.BoolVar(&noCheckCertificate, "no-check-certificate", false, "skip TLS certificate verification for downloads (insecure; only for broken proxies or TLS interception)")
+	flag.StringVar(&sourceAddress, "source-address", "", "bind outgoing connections to this local IP address")
+	flag.StringVar(&metadataProxy, "metadata-proxy", "", "send YouTube metadata requests through this proxy (http, https or socks5 URL); streams download directly")
+	flag.BoolVar(&forceHTTP1, "force-http1", false, "use HTTP/1.1 only for downloads (some CDNs throttle HTTP/2)")
+	flag.DurationVar(&idleConnTimeout, "idle-conn-timeout", 90*time.Second, "how long idle connections are kept for reuse (0 keeps them until the server closes them)")
+	flag.BoolVar(&noKeepAlive, "no-keep-alive", false, "open a new connection for every request instead of reusing idle ones")
+	flag.StringVar(&opts.Color, "color", "auto", "colorize output: auto, always, never")
 	flag.BoolVar(&opts.Quiet, "quiet", false, "suppress progress output (errors still shown)")
+	flag.BoolVar(&opts.Silent, "silent", false, "suppress all human-readable output, including errors (rely on the exit code)")
 	flag.StringVar(&opts.LogLevel, "log-level", "info", "log level: debug, info, warn, error")
+	flag.Var(&printToFile, "print-to-file", "append a line per download to a file, as \"TEMPLATE:path\" (supports {output}, {title}, {id}, {artist}, {album}, {url}; repeatable)")
+	flag.StringVar(&opts.Exec, "exec", "", "shell command to run after each successful download (supports {output}, {title}, {id}; also exported as $YTDL_OUTPUT, $YTDL_TITLE, $YTDL_ID)")
 	flag.BoolVar(&web, "web", false, "launch the web UI server")
 	flag.StringVar(&webAddr, "web-addr", "", "web server address (overrides host/port)")
 	flag.StringVar(&serverHost, "host", "0.0.0.0", "web server host")
 	flag.IntVar(&serverPort, "port", 8888, "web server port")
-	flag.Parse()
+	flag.StringVar(&serverOpts.WebhookURL, "webhook-url", "", "web server: POST a JSON summary to this URL when a job finishes")
+	flag.DurationVar(&serverOpts.JobCompletedTTL, "job-completed-ttl", webserver.DefaultJobCompletedTTL, "web server: how long completed jobs are kept (0 = forever)")
+	flag.DurationVar(&serverOpts.JobErroredTTL, "job-errored-ttl", webserver.DefaultJobErroredTTL, "web server: how long failed jobs are kept (0 = forever)")
+	flag.DurationVar(&serverOpts.JobCleanupInterval, "job-cleanup-interval", webserver.DefaultJobCleanupInterval, "web server: how often expired jobs are removed (0 = never)")
+	flag.StringVar(&serverOpts.CORSOrigins, "web-cors-origins", "", "web server: comma-separated origins allowed to call the API cross-origin (default same-origin only)")
+	flag.StringVar(&serverOpts.MediaSort, "media-sort", webserver.MediaSortNewest, "web server: default media library order: newest, oldest, or title")
+	flag.DurationVar(&serverOpts.SSEHeartbeatInterval, "sse-heartbeat", webserver.DefaultSSEHeartbeatInterval, "web server: keep-alive interval for idle progress streams (0 = off)")
+	flag.String(configFlag, "", "read default options from this YAML file (default ~/.config/ytdl-go/config.yaml); flags override it")
+	flag.String(profileFlag, "", "apply this profile from the config file on top of its top-level defaults")
+	configPath, explicitConfig := configPathFromArgs(flag.CommandLine, os.Args[1:])
+	profile, _ := flagFromArgs(flag.CommandLine, os.Args[1:], profileFlag)
+	if err := applyConfigFile(flag.CommandLine, configPath, explicitConfig, profile); err != nil {
+		fmt.Fprintf(os.Stderr, "-config: %v\n", err)
+		os.Exit(2)
+	}
+	// flag.CommandLine exits on parse errors, so err is always nil here.
+	urls, _ := parseArgs(flag.CommandLine, os.Args[1:])
 
 	if webAddr == "" {
 		webAddr = fmt.Sprintf("%s:%d", serverHost, serverPort)
 	}
 
+	if !downloader.ValidColorMode(opts.Color) {
+		fmt.Fprintf(os.Stderr, "invalid -color value %q (expected auto, always, or never)\n", opts.Color)
+		os.Exit(2)
+	}
+	downloader.ApplyColorMode(opts.Color)
+	if !downloader.ValidCleanArtistMode(opts.CleanArtist) {
+		fmt.Fprintf(os.Stderr, "invalid -clean-artist value %q (expected auto, always, or never)\n", opts.CleanArtist)
+		os.Exit(2)
+	}
+	if opts.Exec != "" {
+		if err := downloader.ValidateExecTemplate(opts.Exec); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -exec value: %v\n", err)
+			os.Exit(2)
+		}
+	}
+	opts.AudioFormat = strings.ToLower(strings.TrimSpace(opts.AudioFormat))
+	if !downloader.ValidAudioFormat(opts.AudioFormat) {
+		fmt.Fprintf(os.Stderr, "invalid -audio-format value %q (expected mp3, m4a, opus, flac, or wav)\n", opts.AudioFormat)
+		os.Exit(2)
+	}
+	if opts.AudioFormat != "" {
+		opts.AudioOnly = true
+	}
+	if minFileSize != "" {
+		size, err := downloader.ParseByteSize(minFileSize)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -min-filesize value: %v\n", err)
+			os.Exit(2)
+		}
+		opts.MinFileSize = size
+	}
+	if dateAfter != "" {
+		date, err := downloader.ParseDate(dateAfter)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -dateafter value: %v\n", err)
+			os.Exit(2)
+		}
+		opts.DateAfter = date
+	}
+	if dateBefore != "" {
+		date, err := downloader.ParseDate(dateBefore)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -datebefore value: %v\n", err)
+			os.Exit(2)
+		}
+		opts.DateBefore = date
+	}
+	if !opts.DateAfter.IsZero() && !opts.DateBefore.IsZero() && opts.DateAfter.After(opts.DateBefore) {
+		fmt.Fprintln(os.Stderr, "-dateafter must not be later than -datebefore")
+		os.Exit(2)
+	}
+	if matchTitle != "" {
+		re, err := regexp.Compile(matchTitle)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -match-title value: %v\n", err)
+			os.Exit(2)
+		}
+		opts.MatchTitle = re
+	}
+	if rejectTitle != "" {
+		re, err := regexp.Compile(rejectTitle)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -reject-title value: %v\n", err)
+			os.Exit(2)
+		}
+		opts.RejectTitle = re
+	}
+	if downloadArchive != "" {
+		archive, err := downloader.OpenDownloadArchive(downloadArchive)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -download-archive value: %v\n", err)
+			os.Exit(2)
+		}
+		opts.Archive = archive
+	} else if opts.RedownloadOnBetterQuality {
+		fmt.Fprintln(os.Stderr, "-redownload-on-better-quality requires -download-archive")
+		os.Exit(2)
+	}
+	if opts.MinDuration < 0 || opts.MaxDuration < 0 {
+		fmt.Fprintln(os.Stderr, "-min-duration and -max-duration must not be negative")
+		os.Exit(2)
+	}
+	if opts.MinDuration > 0 && opts.MaxDuration > 0 && opts.MinDuration > opts.MaxDuration {
+		fmt.Fprintln(os.Stderr, "-min-duration must not be greater than -max-duration")
+		os.Exit(2)
+	}
+	if metadataProxy != "" {
+		if err := downloader.SetMetadataProxy(metadataProxy); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -metadata-proxy value: %v\n", err)
+			os.Exit(2)
+		}
+	}
+	if sourceAddress != "" {
+		if err := downloader.SetSourceAddress(sourceAddress); err != nil {
+			fmt.Fprintf(os.Stderr, "-source-address: %v\n", err)
+			os.Exit(2)
+		}
+	}
+	if bufferSize != "" {
+		size, err := downloader.ParseByteSize(bufferSize)
+		if err == nil {
+			err = downloader.SetCopyBufferSize(size)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -buffer-size value: %v\n", err)
+			os.Exit(2)
+		}
+	}
+	if maxConnsPerHost < 0 {
+		fmt.Fprintf(os.Stderr, "invalid -max-connections-per-host value %d (must be 0 or greater)\n", maxConnsPerHost)
+		os.Exit(2)
+	}
+	downloader.SetMaxConnectionsPerHost(maxConnsPerHost)
+	if rateLimit != "" {
+		rate, err := downloader.ParseByteSize(rateLimit)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -rate-limit value: %v\n", err)
+			os.Exit(2)
+		}
+		downloader.SetRateLimit(rate)
+	}
+	if rateLimitSchedule != "" {
+		if err := downloader.SetRateLimitSchedule(rateLimitSchedule); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -rate-limit-schedule value: %v\n", err)
+			os.Exit(2)
+		}
+	}
+	if idleConnTimeout < 0 {
+		fmt.Fprintf(os.Stderr, "invalid -idle-conn-timeout value %s (must be 0 or greater)\n", idleConnTimeout)
+		os.Exit(2)
+	}
+	downloader.SetIdleConnTimeout(idleConnTimeout)
+	if forceHTTP1 {
+		downloader.ForceHTTP1()
+	}
+	if noKeepAlive {
+		downloader.DisableKeepAlives()
+	}
+	if noCheckCertificate {
+		downloader.DisableCertificateCheck()
+		if !opts.Silent {
+			fmt.Fprintln(os.Stderr, "WARNING: -no-check-certificate is set: TLS certificates are NOT verified for downloads; traffic can be intercepted or altered")
+		}
+	}
+	if ffmpegLocation != "" {
+		if err := downloader.SetFFmpegLocation(ffmpegLocation); err != nil {
+			fmt.Fprintf(os.Stderr, "-ffmpeg-location: %v\n", err)
+			os.Exit(2)
+		}
+	}
+	switch metadataStore {
+	case downloader.MetadataStoreSidecar:
+	case downloader.MetadataStoreIndex:
+		opts.MetadataIndex = downloader.MetadataIndexPath(opts.OutputDir)
+		serverOpts.MetadataStore = metadataStore
+	default:
+		fmt.Fprintf(os.Stderr, "invalid -metadata-store value %q (use sidecar or index)\n", metadataStore)
+		os.Exit(2)
+	}
+	if !mtime {
+		opts.NoMtime = true
+	}
+	if opts.WaitForVideo < 0 || opts.WaitForVideoMax < 0 {
+		fmt.Fprintln(os.Stderr, "-wait-for-video and -wait-for-video-max must not be negative")
+		os.Exit(2)
+	}
+	if opts.AutonumberWidth < 1 {
+		fmt.Fprintf(os.Stderr, "invalid -autonumber-width value %d (must be 1 or greater)\n", opts.AutonumberWidth)
+		os.Exit(2)
+	}
+	if opts.TrimFilenames < 0 {
+		fmt.Fprintf(os.Stderr, "invalid -trim-filenames value %d (must be 0 or greater)\n", opts.TrimFilenames)
+		os.Exit(2)
+	}
+	if opts.MetadataConcurrency < 0 {
+		fmt.Fprintf(os.Stderr, "invalid -metadata-concurrency value %d (must be 0 or greater)\n", opts.MetadataConcurrency)
+		os.Exit(2)
+	}
+	if opts.MaxDescriptionLength < 0 {
+		fmt.Fprintf(os.Stderr, "invalid -max-description-length value %d (must be 0 or greater)\n", opts.MaxDescriptionLength)
+		os.Exit(2)
+	}
+	if opts.EmbedSubs && strings.TrimSpace(opts.Subtitles) == "" {
+		fmt.Fprintln(os.Stderr, "-embed-subs requires -subtitles (e.g. -subtitles en)")
+		os.Exit(2)
+	}
+	if opts.FormatsArrayJSON && (!opts.ListFormats || !opts.JSON) {
+		fmt.Fprintln(os.Stderr, "-print-json-formats-only requires -list-formats and -json")
+		os.Exit(2)
+	}
+	if opts.SkipUnavailableFragments && abortOnUnavailableFragment {
+		fmt.Fprintln(os.Stderr, "-skip-unavailable-fragments and -abort-on-unavailable-fragment are mutually exclusive")
+		os.Exit(2)
+	}
+	opts.AudioQuality = strings.ToLower(strings.TrimSpace(opts.AudioQuality))
+	if err := downloader.ValidateAudioQuality(opts.AudioQuality, opts.AudioFormat); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -audio-quality value: %v\n", err)
+		os.Exit(2)
+	}
+
 	opts.MetaOverrides = meta.Values()
+	opts.Paths = paths.Values()
+	opts.PrintToFile = printToFile.Values()
 
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
 	if web {
-		if err := webserver.ListenAndServe(ctx, webAddr, jobs); err != nil && !errors.Is(err, context.Canceled) {
+		if err := webserver.ListenAndServe(ctx, webAddr, jobs, serverOpts); err != nil && !errors.Is(err, context.Canceled) {
 			fmt.Fprintf(os.Stderr, "web server error: %v\n", err)
 			os.Exit(1)
 		}
 		return
 	}
 
-	urls := flag.Args()
+	if batchFile != "" {
+		batchURLs, err := readBatchFile(batchFile)
+		if err != nil {
+			err = downloader.CategorizedError{Category: downloader.CategoryInvalidURL, Err: err}
+			if opts.JSON {
+				writeJSONError("", err)
+			} else if !opts.Silent {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			}
+			os.Exit(downloader.ExitCode(err))
+		}
+		urls = append(urls, batchURLs...)
+	}
 	if len(urls) == 0 {
 		err := downloader.CategorizedError{Category: downloader.CategoryInvalidURL, Err: errors.New("no url provided")}
 		if opts.JSON {
 			writeJSONError("", err)
-		} else {
-			fmt.Fprintf(os.Stderr, "usage: %s [options] <url> [url...]\n", os.Args[0])
+		} else if !opts.Silent {
+			fmt.Fprintf(os.Stderr, "usage: %s [options] [--] <url> [url...]\n", os.Args[0])
 			flag.PrintDefaults()
 		}
 		os.Exit(downloader.ExitCode(err))
 	}
 
+	if opts.NoPlaylist && opts.YesPlaylist {
+		err := downloader.CategorizedError{Category: downloader.CategoryInvalidURL, Err: errors.New("-no-playlist and -yes-playlist are mutually exclusive")}
+		if opts.JSON {
+			writeJSONError("", err)
+		} else if !opts.Silent {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		}
+		os.Exit(downloader.ExitCode(err))
+	}
+
 	if jobs < 1 {
 		jobs = 1
 	}
-	if opts.JSON {
+	if opts.JSONProgress {
+		opts.JSON = true
+	}
+	if opts.JSON || opts.Silent {
 		opts.Quiet = true
 	}
+	if opts.JSON {
+		opts.Stats = downloader.NewRunStats()
+	}
 
 	resultsList, exitCode := app.Run(ctx, urls, opts, jobs)
 	for _, res := range resultsList {
@@ -96,11 +443,14 @@ func main() {
 					continue
 				}
 				writeJSONError(res.URL, res.Err)
-			} else if !downloader.IsReported(res.Err) {
+			} else if !opts.Silent && !downloader.IsReported(res.Err) {
 				fmt.Fprintf(os.Stderr, "error: %v\n", res.Err)
 			}
 		}
 	}
+	if opts.JSON {
+		_ = downloader.EmitJSONSummary(os.Stdout, opts.Stats.Summary())
+	}
 
 	if exitCode != 0 {
 		os.Exit(exitCode)
@@ -109,21 +459,75 @@ func main() {
 
 func writeJSONError(url string, err error) {
 	payload := struct {
-		Type     string `json:"type"`
-		URL      string `json:"url,omitempty"`
-		Category string `json:"category"`
-		Error    string `json:"error"`
+		Type      string `json:"type"`
+		URL       string `json:"url,omitempty"`
+		Category  string `json:"category"`
+		Retryable bool   `json:"retryable"`
+		Error     string `json:"error"`
 	}{
-		Type:     "error",
-		URL:      url,
-		Category: string(downloader.CategoryOf(err)),
-		Error:    err.Error(),
+		Type:      "error",
+		URL:       url,
+		Category:  string(downloader.CategoryOf(err)),
+		Retryable: downloader.IsRetryable(err),
+		Error:     err.Error(),
 	}
 	enc := json.NewEncoder(os.Stdout)
 	enc.SetEscapeHTML(false)
 	_ = enc.Encode(payload)
 }
 
+// parseArgs parses flags from args and returns the remaining positional
+// arguments as URLs. flag.Parse only honors a "--" that directly follows the
+// flags and stops at the first URL, so in "ytdl-go URL -- -odd-url" the
+// separator would otherwise be taken as a URL. A separator the flag package
+// did not consume is dropped here so "--" works in either position.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	rest := fs.Args()
+	if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+		return rest, nil
+	}
+	for i, arg := range rest {
+		if arg == "--" {
+			return append(append([]string(nil), rest[:i]...), rest[i+1:]...), nil
+		}
+	}
+	return rest, nil
+}
+
+// readBatchFile loads URLs for -a/--batch-file. A path of "-" reads stdin.
+func readBatchFile(path string) ([]string, error) {
+	if path == "-" {
+		return parseBatchURLs(os.Stdin)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading batch file: %w", err)
+	}
+	defer f.Close()
+	return parseBatchURLs(f)
+}
+
+// parseBatchURLs returns one URL per non-blank line, skipping lines whose
+// first non-space character is '#'.
+func parseBatchURLs(r io.Reader) ([]string, error) {
+	var urls []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		urls = append(urls, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading batch file: %w", err)
+	}
+	return urls, nil
+}
+
 type metaFlags struct {
 	values map[string]string
 }
@@ -166,3 +570,74 @@ func (m *metaFlags) Values() map[string]string {
 	}
 	return out
 }
+
+type pathFlags struct {
+	values map[string]string
+}
+
+func (p *pathFlags) String() string {
+	if p == nil || len(p.values) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(p.values))
+	for key, value := range p.values {
+		parts = append(parts, fmt.Sprintf("%s:%s", key, value))
+	}
+	return strings.Join(parts, ",")
+}
+
+func (p *pathFlags) Set(value string) error {
+	parsed, err := downloader.ParsePaths(value)
+	if err != nil {
+		return err
+	}
+	if p.values == nil {
+		p.values = map[string]string{}
+	}
+	for key, dir := range parsed {
+		p.values[key] = dir
+	}
+	return nil
+}
+
+func (p *pathFlags) Values() map[string]string {
+	if p == nil || len(p.values) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(p.values))
+	for k, v := range p.values {
+		out[k] = v
+	}
+	return out
+}
+
+type printFlags struct {
+	specs []downloader.PrintSpec
+}
+
+func (p *printFlags) String() string {
+	if p == nil || len(p.specs) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(p.specs))
+	for _, spec := range p.specs {
+		parts = append(parts, spec.Template+":"+spec.Path)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (p *printFlags) Set(value string) error {
+	spec, err := downloader.ParsePrintSpec(value)
+	if err != nil {
+		return err
+	}
+	p.specs = append(p.specs, spec)
+	return nil
+}
+
+func (p *printFlags) Values() []downloader.PrintSpec {
+	if p == nil || len(p.specs) == 0 {
+		return nil
+	}
+	return append([]downloader.PrintSpec(nil), p.specs...)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadBatchFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "urls.txt")
+	content := "# favourites\nhttps://www.youtube.com/watch?v=aaa\n\n   \n  https://youtu.be/bbb  \n  # indented comment\nhttps://www.youtube.com/playlist?list=ccc\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write batch file: %v", err)
+	}
+
+	urls, err := readBatchFile(path)
+	if err != nil {
+		t.Fatalf("readBatchFile: %v", err)
+	}
+	want := []string{
+		"https://www.youtube.com/watch?v=aaa",
+		"https://youtu.be/bbb",
+		"https://www.youtube.com/playlist?list=ccc",
+	}
+	if !reflect.DeepEqual(urls, want) {
+		t.Fatalf("unexpected urls: got %q, want %q", urls, want)
+	}
+
+	if _, err := readBatchFile(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Fatalf("expected error for a missing batch file")
+	}
+}
+
+func TestParseArgsSeparator(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantURLs  []string
+		wantAudio bool
+	}{
+		{"separator after flags", []string{"-audio", "--", "-weird-looking-url"}, []string{"-weird-looking-url"}, true},
+		{"separator after a url", []string{"https://youtu.be/aaa", "--", "-weird-looking-url"}, []string{"https://youtu.be/aaa", "-weird-looking-url"}, false},
+		{"no separator", []string{"https://youtu.be/aaa"}, []string{"https://youtu.be/aaa"}, false},
+		{"literal double dash after separator", []string{"--", "--"}, []string{"--"}, false},
+	}
+	for _, tt := range tests {
+		fs := flag.NewFlagSet("ytdl-go", flag.ContinueOnError)
+		audio := fs.Bool("audio", false, "")
+		got, err := parseArgs(fs, tt.args)
+		if err != nil {
+			t.Fatalf("%s: parse: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(got, tt.wantURLs) {
+			t.Fatalf("%s: expected urls %q, got %q", tt.name, tt.wantURLs, got)
+		}
+		if *audio != tt.wantAudio {
+			t.Fatalf("%s: expected -audio=%v, got %v", tt.name, tt.wantAudio, *audio)
+		}
+	}
+}